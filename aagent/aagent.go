@@ -20,6 +20,7 @@ import (
 	"github.com/choria-io/go-choria/aagent/machine"
 	"github.com/choria-io/go-choria/aagent/model"
 	notifier "github.com/choria-io/go-choria/aagent/notifiers/choria"
+	"github.com/choria-io/go-choria/aagent/notifiers/webhook"
 	"github.com/choria-io/go-choria/aagent/watchers"
 	"github.com/choria-io/go-choria/internal/util"
 	"github.com/sirupsen/logrus"
@@ -30,6 +31,8 @@ type AAgent struct {
 	logger      *logrus.Entry
 	machines    []*managedMachine
 	notifier    *notifier.Notifier
+	webhooks    *webhook.Dispatcher
+	hooks       *webhook.Notifier
 	httpManager model.HttpManager
 
 	source string
@@ -58,13 +61,35 @@ func New(dir string, fw model.ChoriaProvider) (aa *AAgent, err error) {
 		return nil, fmt.Errorf("could not create notifier: %s", err)
 	}
 
-	return &AAgent{
+	aa = &AAgent{
 		fw:       fw,
 		logger:   fw.Logger("aagent"),
 		source:   dir,
 		machines: []*managedMachine{},
 		notifier: n,
-	}, nil
+	}
+
+	var webhookSpool string
+	spool, spoolSize := fw.SubmissionSpool()
+	if spool != "" {
+		webhookSpool = filepath.Join(spool, "webhooks")
+	}
+
+	aa.webhooks, err = webhook.NewDispatcher(webhookSpool, spoolSize, fw.Identity(), aa.logger)
+	if err != nil {
+		return nil, fmt.Errorf("could not create webhook dispatcher: %s", err)
+	}
+
+	if file := fw.MachineWebhooksFile(); file != "" {
+		hooks, err := machine.LoadWebhooks(file)
+		if err != nil {
+			aa.logger.Errorf("Could not load Autonomous Agent webhooks: %s", err)
+		} else {
+			aa.hooks = aa.webhooks.Notifier("", hooks)
+		}
+	}
+
+	return aa, nil
 }
 
 func (a *AAgent) startHTTPListeners(ctx context.Context, wg *sync.WaitGroup) {
@@ -108,6 +133,9 @@ func (a *AAgent) ManageMachines(ctx context.Context, wg *sync.WaitGroup) error {
 	go a.watchSource(ctx, wg)
 	go a.startHTTPListeners(ctx, wg)
 
+	wg.Add(1)
+	go a.webhooks.Run(ctx, wg)
+
 	return nil
 }
 
@@ -135,6 +163,12 @@ func (a *AAgent) configureMachine(aa *machine.Machine) {
 	aa.SetIdentity(a.fw.Identity())
 	aa.SetMainCollective(a.fw.MainCollective())
 	aa.RegisterNotifier(a.notifier)
+	if a.hooks != nil {
+		aa.RegisterNotifier(a.hooks)
+	}
+	if len(aa.Webhooks) > 0 {
+		aa.RegisterNotifier(a.webhooks.Notifier(aa.Name(), aa.Webhooks))
+	}
 	aa.SetTextFileDirectory(a.fw.PrometheusTextFileDir())
 	aa.SetOverridesFile(a.fw.ScoutOverridesPath())
 	aa.SetConnection(a.fw.Connector())
@@ -0,0 +1,289 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/choria-io/go-choria/providers/kv"
+)
+
+// DataStore configures persisting machine data to a Choria Key-Value bucket in addition to the local data file
+//
+// All data of the machine is stored in the key MACHINE.data.IDENTITY and keys listed in Shared in MACHINE.shared.IDENTITY,
+// the shared data of other nodes running the same machine is available to templates using the peer_lookup,
+// peer_data and peers functions
+type DataStore struct {
+	// Bucket is the name of an existing Key-Value bucket to store data in
+	Bucket string `json:"bucket" yaml:"bucket"`
+
+	// Shared lists data keys that other nodes running this machine may read
+	Shared []string `json:"shared" yaml:"shared"`
+}
+
+const dataStoreRetryInterval = 10 * time.Second
+
+// Validate validates the data store settings
+func (d *DataStore) Validate() error {
+	if d.Bucket == "" {
+		return fmt.Errorf("data_store requires a bucket")
+	}
+
+	return nil
+}
+
+func (d *DataStore) dataKey(machine string, identity string) string {
+	return fmt.Sprintf("%s.data.%s", machine, identity)
+}
+
+func (d *DataStore) sharedPrefix(machine string) string {
+	return fmt.Sprintf("%s.shared.", machine)
+}
+
+// PeerData retrieves a copy of the shared data published by other nodes running this machine, keyed by identity
+func (m *Machine) PeerData() map[string]map[string]any {
+	if m.parent != nil {
+		return m.parent.PeerData()
+	}
+
+	m.dataMu.Lock()
+	defer m.dataMu.Unlock()
+
+	res := make(map[string]map[string]any, len(m.peerData))
+	for identity, data := range m.peerData {
+		res[identity] = maps.Clone(data)
+	}
+
+	return res
+}
+
+// signals the data store to publish the latest data, lock should be held by caller
+func (m *Machine) signalDataSync() {
+	if m.dataSyncC == nil {
+		return
+	}
+
+	select {
+	case m.dataSyncC <- struct{}{}:
+	default:
+	}
+}
+
+// startDataStore connects to the data store and keeps it in sync with the machine data in the background,
+// a connection attempt is made before returning so that data can be restored before watchers start
+func (m *Machine) startDataStore(ctx context.Context, wg *sync.WaitGroup) {
+	if m.DataStore == nil || m.scenario {
+		return
+	}
+
+	m.dataMu.Lock()
+	m.dataSyncC = make(chan struct{}, 1)
+	m.dataMu.Unlock()
+
+	store, err := m.connectDataStore()
+	if err != nil {
+		m.Warnf("data_store", "Could not connect to data store bucket %s, retrying in the background: %s", m.DataStore.Bucket, err)
+	}
+
+	wg.Add(1)
+	go m.dataStoreSync(ctx, wg, store)
+}
+
+func (m *Machine) connectDataStore() (nats.KeyValue, error) {
+	mgr, err := m.JetStreamConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := kv.NewKV(mgr.NatsConn(), m.DataStore.Bucket, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.restoreData(store)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// restoreData loads data from the store when the machine has no local data, typically after a node was rebuilt
+func (m *Machine) restoreData(store nats.KeyValue) error {
+	m.dataMu.Lock()
+	defer m.dataMu.Unlock()
+
+	if len(m.data) > 0 {
+		return nil
+	}
+
+	entry, err := store.Get(m.DataStore.dataKey(m.MachineName, m.Identity()))
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	data := map[string]any{}
+	err = json.Unmarshal(entry.Value(), &data)
+	if err != nil {
+		return fmt.Errorf("invalid stored data: %s", err)
+	}
+
+	m.data = data
+	err = m.saveData()
+	if err != nil {
+		return fmt.Errorf("could not save restored data to %s: %s", dataFileName, err)
+	}
+
+	m.Infof("data_store", "Restored %d data items from data store bucket %s", len(data), m.DataStore.Bucket)
+
+	return nil
+}
+
+func (m *Machine) dataStoreSync(ctx context.Context, wg *sync.WaitGroup, store nats.KeyValue) {
+	defer wg.Done()
+
+	var err error
+
+	for store == nil {
+		select {
+		case <-time.After(dataStoreRetryInterval):
+			store, err = m.connectDataStore()
+			if err != nil {
+				m.Warnf("data_store", "Could not connect to data store bucket %s: %s", m.DataStore.Bucket, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	watch, err := store.Watch(m.DataStore.sharedPrefix(m.MachineName)+">", nats.Context(ctx))
+	if err != nil {
+		m.Errorf("data_store", "Could not watch shared data in bucket %s: %s", m.DataStore.Bucket, err)
+	}
+
+	var updates <-chan nats.KeyValueEntry
+	if watch != nil {
+		defer watch.Stop()
+		updates = watch.Updates()
+	}
+
+	published := map[string][]byte{}
+
+	err = m.publishData(store, published)
+	if err != nil {
+		m.Errorf("data_store", "Could not publish data to bucket %s: %s", m.DataStore.Bucket, err)
+	}
+
+	for {
+		select {
+		case entry, ok := <-updates:
+			if !ok {
+				updates = nil
+				continue
+			}
+
+			// a nil entry marks the end of the initial values
+			if entry != nil {
+				m.handlePeerData(entry)
+			}
+
+		case <-m.dataSyncC:
+			err = m.publishData(store, published)
+			if err != nil {
+				m.Errorf("data_store", "Could not publish data to bucket %s: %s", m.DataStore.Bucket, err)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publishData stores the data and shared data documents, documents that did not change since last published are skipped
+func (m *Machine) publishData(store nats.KeyValue, published map[string][]byte) error {
+	m.dataMu.Lock()
+	data := maps.Clone(m.data)
+	m.dataMu.Unlock()
+
+	shared := map[string]any{}
+	for _, k := range m.DataStore.Shared {
+		v, ok := data[k]
+		if ok {
+			shared[k] = v
+		}
+	}
+
+	docs := map[string]map[string]any{
+		m.DataStore.dataKey(m.MachineName, m.Identity()):       data,
+		m.DataStore.sharedPrefix(m.MachineName) + m.Identity(): shared,
+	}
+
+	for key, doc := range docs {
+		j, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(published[key], j) {
+			continue
+		}
+
+		_, err = store.Put(key, j)
+		if err != nil {
+			return err
+		}
+
+		published[key] = j
+	}
+
+	return nil
+}
+
+func (m *Machine) handlePeerData(entry nats.KeyValueEntry) {
+	identity := strings.TrimPrefix(entry.Key(), m.DataStore.sharedPrefix(m.MachineName))
+	if identity == "" || identity == m.Identity() {
+		return
+	}
+
+	m.dataMu.Lock()
+	defer m.dataMu.Unlock()
+
+	if m.peerData == nil {
+		m.peerData = map[string]map[string]any{}
+	}
+
+	if entry.Operation() != nats.KeyValuePut {
+		delete(m.peerData, identity)
+		return
+	}
+
+	data := map[string]any{}
+	err := json.Unmarshal(entry.Value(), &data)
+	if err != nil {
+		m.Warnf("data_store", "Discarding invalid shared data from %s: %s", identity, err)
+		return
+	}
+
+	// only keys this machine shares are exposed even if a peer published more
+	maps.DeleteFunc(data, func(k string, _ any) bool {
+		return !slices.Contains(m.DataStore.Shared, k)
+	})
+
+	m.peerData[identity] = data
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/nats-io/jsm.go"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DataStore", func() {
+	var (
+		store  nats.KeyValue
+		mgr    *jsm.Manager
+		ctx    context.Context
+		cancel context.CancelFunc
+		wg     *sync.WaitGroup
+	)
+
+	newMachine := func(identity string) *Machine {
+		return &Machine{
+			MachineName: "ginkgo",
+			DataStore:   &DataStore{Bucket: "MACHINE_DATA", Shared: []string{"leader"}},
+			identity:    identity,
+			directory:   GinkgoT().TempDir(),
+			data:        map[string]any{},
+			jsm:         mgr,
+		}
+	}
+
+	BeforeEach(func() {
+		srv, err := server.NewServer(&server.Options{
+			JetStream: true,
+			StoreDir:  GinkgoT().TempDir(),
+			Port:      -1,
+			Host:      "localhost",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		go srv.Start()
+		Expect(srv.ReadyForConnections(10 * time.Second)).To(BeTrue())
+		DeferCleanup(srv.Shutdown)
+
+		nc, err := nats.Connect(srv.ClientURL())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(nc.Close)
+
+		mgr, err = jsm.New(nc)
+		Expect(err).ToNot(HaveOccurred())
+
+		js, err := nc.JetStream()
+		Expect(err).ToNot(HaveOccurred())
+		store, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: "MACHINE_DATA"})
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel = context.WithCancel(context.Background())
+		wg = &sync.WaitGroup{}
+		DeferCleanup(func() {
+			cancel()
+			wg.Wait()
+		})
+	})
+
+	It("Should validate the settings", func() {
+		Expect((&DataStore{}).Validate()).To(MatchError("data_store requires a bucket"))
+		Expect((&DataStore{Bucket: "X"}).Validate()).To(Succeed())
+	})
+
+	It("Should publish data and share selected keys with peers", func() {
+		n1 := newMachine("n1.example.net")
+		n2 := newMachine("n2.example.net")
+
+		n1.startDataStore(ctx, wg)
+		n2.startDataStore(ctx, wg)
+
+		Expect(n1.DataPut("leader", "n1.example.net")).To(Succeed())
+		Expect(n1.DataPut("secret", "s3cret")).To(Succeed())
+
+		Eventually(n2.PeerData).Should(Equal(map[string]map[string]any{"n1.example.net": {"leader": "n1.example.net"}}))
+		Eventually(n1.PeerData).Should(Equal(map[string]map[string]any{"n2.example.net": {}}))
+
+		Eventually(func() map[string]any {
+			entry, err := store.Get("ginkgo.data.n1.example.net")
+			if err != nil {
+				return nil
+			}
+
+			data := map[string]any{}
+			Expect(json.Unmarshal(entry.Value(), &data)).To(Succeed())
+
+			return data
+		}).Should(Equal(map[string]any{"leader": "n1.example.net", "secret": "s3cret"}))
+
+		Expect(n1.DataDelete("leader")).To(Succeed())
+		Eventually(n2.PeerData).Should(Equal(map[string]map[string]any{"n1.example.net": {}}))
+	})
+
+	It("Should restore data when there is no local data", func() {
+		_, err := store.Put("ginkgo.data.n1.example.net", []byte(`{"leader":"n2.example.net"}`))
+		Expect(err).ToNot(HaveOccurred())
+
+		n1 := newMachine("n1.example.net")
+		n1.startDataStore(ctx, wg)
+
+		Expect(n1.Data()).To(Equal(map[string]any{"leader": "n2.example.net"}))
+
+		local := newMachine("n1.example.net")
+		Expect(local.SetDirectory(n1.Directory(), "")).To(Succeed())
+		Expect(local.Data()).To(Equal(map[string]any{"leader": "n2.example.net"}))
+	})
+
+	It("Should prefer local data", func() {
+		_, err := store.Put("ginkgo.data.n1.example.net", []byte(`{"leader":"n2.example.net"}`))
+		Expect(err).ToNot(HaveOccurred())
+
+		n1 := newMachine("n1.example.net")
+		n1.data["leader"] = "n1.example.net"
+		n1.startDataStore(ctx, wg)
+
+		Expect(n1.Data()).To(Equal(map[string]any{"leader": "n1.example.net"}))
+		Eventually(func() string {
+			entry, err := store.Get("ginkgo.data.n1.example.net")
+			if err != nil {
+				return ""
+			}
+			return string(entry.Value())
+		}).Should(Equal(`{"leader":"n1.example.net"}`))
+	})
+})
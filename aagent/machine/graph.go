@@ -0,0 +1,655 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"slices"
+	"strings"
+)
+
+// GraphFormat is a format machine graphs can be rendered in
+type GraphFormat string
+
+const (
+	// GraphDOT renders a Graphviz dot graph
+	GraphDOT GraphFormat = "dot"
+	// GraphMermaid renders a Mermaid state diagram
+	GraphMermaid GraphFormat = "mermaid"
+	// GraphSVG renders a self-contained SVG image
+	GraphSVG GraphFormat = "svg"
+	// GraphHTML renders a self-contained interactive HTML page holding a SVG image
+	GraphHTML GraphFormat = "html"
+)
+
+// GraphFormats are all the supported graph formats
+var GraphFormats = []GraphFormat{GraphDOT, GraphMermaid, GraphSVG, GraphHTML}
+
+// GraphOptions adjusts how graphs are rendered
+type GraphOptions struct {
+	// StateCounts annotates all states with the number of nodes currently in that state when set
+	StateCounts map[string]int
+}
+
+type graphState struct {
+	name     string
+	initial  bool
+	watchers []string
+	actions  []string
+	count    int
+	counted  bool
+	child    *graphModel
+	terminal map[string]string
+
+	layer  int
+	x, y   float64
+	width  float64
+	height float64
+}
+
+type graphEdge struct {
+	from          string
+	to            string
+	name          string
+	guard         string
+	subscriptions []string
+}
+
+type graphModel struct {
+	name    string
+	version string
+	initial string
+	states  []*graphState
+	edges   []*graphEdge
+}
+
+const (
+	svgCharWidth   = 7.2
+	svgLineHeight  = 16.0
+	svgPadding     = 12.0
+	svgColumnGap   = 160.0
+	svgRowGap      = 48.0
+	svgMargin      = 40.0
+	svgEdgeOverrun = 40.0
+)
+
+// Graph produce a dot graph of the fsm, guarded transitions are shown as dashed lines labeled with their guard
+func (m *Machine) Graph() string {
+	graph, _ := m.RenderGraph(GraphDOT, nil)
+	return graph
+}
+
+// RenderGraph renders the machine in format, showing watchers active in each state, state actions, child machines,
+// guards and subscriptions
+func (m *Machine) RenderGraph(format GraphFormat, opts *GraphOptions) (string, error) {
+	if opts == nil {
+		opts = &GraphOptions{}
+	}
+
+	g := m.graphModel(opts)
+
+	switch format {
+	case GraphDOT:
+		return g.dot(), nil
+	case GraphMermaid:
+		return g.mermaid(), nil
+	case GraphSVG:
+		return g.svg(), nil
+	case GraphHTML:
+		return g.html(), nil
+	default:
+		return "", fmt.Errorf("unsupported graph format %q", format)
+	}
+}
+
+func (m *Machine) graphModel(opts *GraphOptions) *graphModel {
+	g := &graphModel{name: m.MachineName, version: m.MachineVersion, initial: m.InitialState}
+
+	names := m.KnownStates()
+	slices.Sort(names)
+
+	states := map[string]*graphState{}
+	for _, name := range names {
+		s := &graphState{name: name, initial: name == m.InitialState, layer: -1}
+		if opts.StateCounts != nil {
+			s.count, s.counted = opts.StateCounts[name], true
+		}
+		states[name] = s
+		g.states = append(g.states, s)
+	}
+
+	for _, w := range m.WatcherDefs {
+		desc := fmt.Sprintf("%s (%s)", w.Name, w.Type)
+		for _, s := range g.states {
+			if len(w.StateMatch) == 0 || slices.Contains(w.StateMatch, s.name) {
+				s.watchers = append(s.watchers, desc)
+			}
+		}
+	}
+
+	for _, def := range m.States {
+		s, ok := states[def.Name]
+		if !ok {
+			continue
+		}
+
+		for _, a := range def.OnEnter {
+			s.actions = append(s.actions, fmt.Sprintf("on_enter: %s", a))
+		}
+		for _, a := range def.OnExit {
+			s.actions = append(s.actions, fmt.Sprintf("on_exit: %s", a))
+		}
+
+		if def.Machine != nil {
+			s.child = m.newChildMachine(def).graphModel(&GraphOptions{})
+			s.terminal = def.Terminal
+		}
+	}
+
+	for _, t := range m.Transitions {
+		var subs []string
+		for _, s := range t.Subscriptions {
+			subs = append(subs, fmt.Sprintf("%s/%s", s.MachineName, s.Event))
+		}
+
+		for _, from := range t.From {
+			g.edges = append(g.edges, &graphEdge{from: from, to: t.Destination, name: t.Name, guard: t.Guard, subscriptions: subs})
+		}
+	}
+
+	slices.SortFunc(g.edges, func(a, b *graphEdge) int {
+		if c := strings.Compare(a.from, b.from); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.to, b.to)
+	})
+
+	g.layout(states, m.InitialState)
+
+	return g
+}
+
+func (e *graphEdge) labelLines() []string {
+	lines := []string{e.name}
+	if e.guard != "" {
+		lines = append(lines, fmt.Sprintf("[%s]", e.guard))
+	}
+	for _, s := range e.subscriptions {
+		lines = append(lines, fmt.Sprintf("<= %s", s))
+	}
+
+	return lines
+}
+
+// details are the watchers, actions and child machine shown in the state
+func (s *graphState) details() []string {
+	details := slices.Concat(s.watchers, s.actions)
+	if s.child != nil {
+		details = append(details, fmt.Sprintf("machine: %s", s.child.name))
+	}
+
+	return details
+}
+
+// terminalStates are the sorted terminal states of the child machine
+func (s *graphState) terminalStates() []string {
+	states := make([]string, 0, len(s.terminal))
+	for cs := range s.terminal {
+		states = append(states, cs)
+	}
+	slices.Sort(states)
+
+	return states
+}
+
+func (s *graphState) title() string {
+	if !s.counted {
+		return s.name
+	}
+
+	if s.count == 1 {
+		return fmt.Sprintf("%s (1 node)", s.name)
+	}
+
+	return fmt.Sprintf("%s (%d nodes)", s.name, s.count)
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+func (g *graphModel) dot() string {
+	var buf bytes.Buffer
+
+	buf.WriteString("digraph fsm {\n")
+	g.writeDot(&buf, "", "    ")
+	buf.WriteString("}\n")
+
+	return buf.String()
+}
+
+// writeDot writes the nodes and edges of the graph, child machines are drawn as clusters with node names prefixed by
+// the path to their parent state
+func (g *graphModel) writeDot(buf *bytes.Buffer, prefix string, indent string) {
+	id := func(name string) string { return dotEscape(prefix + name) }
+
+	for _, e := range g.edges {
+		lines := e.labelLines()
+		for i, l := range lines {
+			lines[i] = dotEscape(l)
+		}
+
+		attrs := fmt.Sprintf(`label = "%s"`, strings.Join(lines, `\n`))
+		if e.guard != "" {
+			attrs += `, style = "dashed"`
+		}
+
+		fmt.Fprintf(buf, "%s\"%s\" -> \"%s\" [ %s ];\n", indent, id(e.from), id(e.to), attrs)
+	}
+
+	buf.WriteString("\n")
+
+	for _, s := range g.states {
+		label := []string{dotEscape(s.title())}
+		if details := s.details(); len(details) > 0 {
+			label = append(label, "")
+			for _, d := range details {
+				label = append(label, dotEscape(d))
+			}
+		}
+
+		attrs := fmt.Sprintf(`label = "%s", shape = "box", style = "rounded"`, strings.Join(label, `\n`))
+		if s.initial {
+			attrs += `, penwidth = 2`
+		}
+		if s.counted && s.count > 0 {
+			attrs = strings.Replace(attrs, `style = "rounded"`, `style = "rounded,filled", fillcolor = "lightblue"`, 1)
+		}
+
+		fmt.Fprintf(buf, "%s\"%s\" [ %s ];\n", indent, id(s.name), attrs)
+	}
+
+	for _, s := range g.states {
+		if s.child == nil {
+			continue
+		}
+
+		childPrefix := prefix + s.name + "/"
+
+		fmt.Fprintf(buf, "\n%ssubgraph \"cluster_%s\" {\n", indent, id(s.name))
+		fmt.Fprintf(buf, "%s    label = \"%s\";\n%s    style = \"dashed\";\n", indent, dotEscape(s.child.name), indent)
+		s.child.writeDot(buf, childPrefix, indent+"    ")
+		fmt.Fprintf(buf, "%s}\n", indent)
+
+		fmt.Fprintf(buf, "%s\"%s\" -> \"%s\" [ label = \"starts\", style = \"dotted\" ];\n", indent, id(s.name), dotEscape(childPrefix+s.child.initial))
+		for _, cs := range s.terminalStates() {
+			fmt.Fprintf(buf, "%s\"%s\" -> \"%s\" [ label = \"%s\", style = \"dotted\" ];\n", indent, dotEscape(childPrefix+cs), id(s.name), dotEscape(s.terminal[cs]))
+		}
+	}
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", ";", "#59;", ":", "#58;", "<", "#lt;", ">", "#gt;", "\n", " ").Replace(s)
+}
+
+func (g *graphModel) mermaid() string {
+	var buf bytes.Buffer
+
+	buf.WriteString("stateDiagram-v2\n")
+	g.writeMermaid(&buf, "s", "    ", nil)
+
+	return buf.String()
+}
+
+// writeMermaid writes the states and edges of the graph, child machines are drawn as composite states, terminal
+// lists states that are drawn as final states
+func (g *graphModel) writeMermaid(buf *bytes.Buffer, prefix string, indent string, terminal map[string]string) {
+	ids := map[string]string{}
+	for i, s := range g.states {
+		ids[s.name] = fmt.Sprintf("%s%d", prefix, i)
+	}
+
+	for _, s := range g.states {
+		fmt.Fprintf(buf, "%sstate \"%s\" as %s\n", indent, mermaidEscape(s.title()), ids[s.name])
+	}
+
+	for _, s := range g.states {
+		if s.child == nil {
+			continue
+		}
+
+		fmt.Fprintf(buf, "%sstate %s {\n", indent, ids[s.name])
+		s.child.writeMermaid(buf, ids[s.name]+"_", indent+"    ", s.terminal)
+		fmt.Fprintf(buf, "%s}\n", indent)
+	}
+
+	for _, s := range g.states {
+		if s.initial {
+			fmt.Fprintf(buf, "%s[*] --> %s\n", indent, ids[s.name])
+		}
+	}
+
+	for _, e := range g.edges {
+		lines := e.labelLines()
+		for i, l := range lines {
+			lines[i] = mermaidEscape(l)
+		}
+
+		fmt.Fprintf(buf, "%s%s --> %s : %s\n", indent, ids[e.from], ids[e.to], strings.Join(lines, "<br/>"))
+	}
+
+	for _, s := range g.states {
+		if event, ok := terminal[s.name]; ok {
+			fmt.Fprintf(buf, "%s%s --> [*] : %s\n", indent, ids[s.name], mermaidEscape(event))
+		}
+	}
+
+	for _, s := range g.states {
+		details := s.details()
+		if len(details) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(buf, "%snote right of %s\n", indent, ids[s.name])
+		for _, d := range details {
+			fmt.Fprintf(buf, "%s    %s\n", indent, mermaidEscape(d))
+		}
+		fmt.Fprintf(buf, "%send note\n", indent)
+	}
+}
+
+// layout places states in columns by their distance from the initial state
+func (g *graphModel) layout(states map[string]*graphState, initial string) {
+	if s, ok := states[initial]; ok {
+		s.layer = 0
+		queue := []*graphState{s}
+
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+
+			for _, e := range g.edges {
+				next, ok := states[e.to]
+				if e.from != cur.name || !ok || next.layer != -1 {
+					continue
+				}
+
+				next.layer = cur.layer + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	maxLayer := 0
+	for _, s := range g.states {
+		maxLayer = max(maxLayer, s.layer)
+	}
+	for _, s := range g.states {
+		if s.layer == -1 {
+			s.layer = maxLayer + 1
+		}
+	}
+
+	columns := map[int][]*graphState{}
+	layers := 0
+	for _, s := range g.states {
+		details := s.details()
+		longest := len(s.title())
+		for _, d := range details {
+			longest = max(longest, len(d))
+		}
+
+		s.width = float64(longest)*svgCharWidth + 2*svgPadding
+		s.height = svgLineHeight*float64(1+len(details)) + 2*svgPadding
+		if len(details) > 0 {
+			s.height += svgLineHeight / 2
+		}
+
+		columns[s.layer] = append(columns[s.layer], s)
+		layers = max(layers, s.layer+1)
+	}
+
+	tallest := 0.0
+	heights := make([]float64, layers)
+	for l := 0; l < layers; l++ {
+		for i, s := range columns[l] {
+			if i > 0 {
+				heights[l] += svgRowGap
+			}
+			heights[l] += s.height
+		}
+		tallest = max(tallest, heights[l])
+	}
+
+	x := svgMargin + svgEdgeOverrun
+	for l := 0; l < layers; l++ {
+		width := 0.0
+		y := svgMargin + svgEdgeOverrun + (tallest-heights[l])/2
+		for _, s := range columns[l] {
+			s.x = x
+			s.y = y
+			y += s.height + svgRowGap
+			width = max(width, s.width)
+		}
+		x += width + svgColumnGap
+	}
+}
+
+func (g *graphModel) state(name string) *graphState {
+	for _, s := range g.states {
+		if s.name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func (g *graphModel) svgSize() (float64, float64) {
+	w, h := 0.0, 0.0
+	for _, s := range g.states {
+		w = max(w, s.x+s.width)
+		h = max(h, s.y+s.height)
+	}
+
+	return w + svgMargin + svgEdgeOverrun, h + svgMargin + 2*svgEdgeOverrun
+}
+
+func svgText(buf *bytes.Buffer, x float64, y float64, class string, lines []string) {
+	fmt.Fprintf(buf, `<text x="%.1f" y="%.1f" class="%s">`, x, y, class)
+	for i, l := range lines {
+		dy := 0.0
+		if i > 0 {
+			dy = svgLineHeight
+		}
+		fmt.Fprintf(buf, `<tspan x="%.1f" dy="%.1f">%s</tspan>`, x, dy, html.EscapeString(l))
+	}
+	buf.WriteString("</text>\n")
+}
+
+func (g *graphModel) svg() string {
+	var buf bytes.Buffer
+
+	width, height := g.svgSize()
+
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" class="machine-graph">`+"\n", width, height, width, height)
+	buf.WriteString(`<style>
+  .machine-graph { font-family: monospace; font-size: 12px; }
+  .machine-graph .state rect { fill: #ffffff; stroke: #333333; stroke-width: 1.5; }
+  .machine-graph .state.initial rect { stroke-width: 3; }
+  .machine-graph .state.occupied rect { fill: #d6eaf8; }
+  .machine-graph .state .name { font-weight: bold; }
+  .machine-graph .state .detail { fill: #555555; }
+  .machine-graph .state .count { fill: #ffffff; font-weight: bold; text-anchor: middle; }
+  .machine-graph .state circle.count { fill: #2874a6; }
+  .machine-graph .edge path { fill: none; stroke: #555555; stroke-width: 1.2; }
+  .machine-graph .edge.guarded path { stroke-dasharray: 5 3; }
+  .machine-graph .edge text { fill: #333333; text-anchor: middle; paint-order: stroke; stroke: #ffffff; stroke-width: 4px; }
+  .machine-graph .start { fill: #333333; }
+</style>
+<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="#555555"/></marker></defs>
+`)
+
+	for _, e := range g.edges {
+		from, to := g.state(e.from), g.state(e.to)
+		if from == nil || to == nil {
+			continue
+		}
+
+		var path string
+		var lx, ly float64
+
+		switch {
+		case from == to:
+			x1 := from.x + from.width*0.35
+			x2 := from.x + from.width*0.65
+			y := from.y
+			path = fmt.Sprintf("M %.1f %.1f C %.1f %.1f, %.1f %.1f, %.1f %.1f", x1, y, x1, y-svgEdgeOverrun, x2, y-svgEdgeOverrun, x2, y)
+			lx, ly = from.x+from.width/2, y-svgEdgeOverrun-4
+
+		case to.layer > from.layer:
+			x1, y1 := from.x+from.width, from.y+from.height/2
+			x2, y2 := to.x, to.y+to.height/2
+			dx := (x2 - x1) / 2
+			path = fmt.Sprintf("M %.1f %.1f C %.1f %.1f, %.1f %.1f, %.1f %.1f", x1, y1, x1+dx, y1, x2-dx, y2, x2, y2)
+			lx, ly = (x1+x2)/2, (y1+y2)/2-4
+
+		default:
+			// edges back to earlier columns are routed below the states
+			x1, y1 := from.x+from.width/2, from.y+from.height
+			x2, y2 := to.x+to.width/2, to.y+to.height
+			bottom := math.Max(y1, y2) + svgEdgeOverrun
+			path = fmt.Sprintf("M %.1f %.1f C %.1f %.1f, %.1f %.1f, %.1f %.1f", x1, y1, x1, bottom, x2, bottom, x2, y2)
+			lx, ly = (x1+x2)/2, bottom-4
+		}
+
+		class := "edge"
+		if e.guard != "" {
+			class += " guarded"
+		}
+
+		lines := e.labelLines()
+
+		fmt.Fprintf(&buf, `<g class="%s" data-from="%s" data-to="%s"><title>%s</title>`, class, html.EscapeString(e.from), html.EscapeString(e.to), html.EscapeString(fmt.Sprintf("%s: %s -> %s", strings.Join(lines, " "), e.from, e.to)))
+		fmt.Fprintf(&buf, `<path d="%s" marker-end="url(#arrow)"/>`, path)
+		svgText(&buf, lx, ly-float64(len(lines)-1)*svgLineHeight, "label", lines)
+		buf.WriteString("</g>\n")
+	}
+
+	for _, s := range g.states {
+		class := "state"
+		if s.initial {
+			class += " initial"
+		}
+		if s.counted && s.count > 0 {
+			class += " occupied"
+		}
+
+		tip := s.title()
+		if len(s.watchers) > 0 {
+			tip += "\nwatchers: " + strings.Join(s.watchers, ", ")
+		}
+		if len(s.actions) > 0 {
+			tip += "\nactions: " + strings.Join(s.actions, ", ")
+		}
+		if s.child != nil {
+			tip += fmt.Sprintf("\nmachine: %s starting in %s", s.child.name, s.child.initial)
+			for _, cs := range s.terminalStates() {
+				tip += fmt.Sprintf("\n  %s fires %s", cs, s.terminal[cs])
+			}
+		}
+
+		fmt.Fprintf(&buf, `<g class="%s" data-state="%s"><title>%s</title>`, class, html.EscapeString(s.name), html.EscapeString(tip))
+		fmt.Fprintf(&buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="8" ry="8"/>`, s.x, s.y, s.width, s.height)
+		svgText(&buf, s.x+svgPadding, s.y+svgPadding+svgLineHeight*0.75, "name", []string{s.name})
+
+		if details := s.details(); len(details) > 0 {
+			svgText(&buf, s.x+svgPadding, s.y+svgPadding+svgLineHeight*2.25, "detail", details)
+		}
+
+		if s.counted {
+			cx, cy := s.x+s.width, s.y
+			fmt.Fprintf(&buf, `<circle class="count" cx="%.1f" cy="%.1f" r="12"/>`, cx, cy)
+			fmt.Fprintf(&buf, `<text class="count" x="%.1f" y="%.1f">%d</text>`, cx, cy+4, s.count)
+		}
+
+		if s.initial {
+			fmt.Fprintf(&buf, `<circle class="start" cx="%.1f" cy="%.1f" r="6"/>`, s.x-svgEdgeOverrun, s.y+s.height/2)
+			fmt.Fprintf(&buf, `<path class="start" d="M %.1f %.1f L %.1f %.1f" stroke="#333333" marker-end="url(#arrow)"/>`, s.x-svgEdgeOverrun+6, s.y+s.height/2, s.x, s.y+s.height/2)
+		}
+
+		buf.WriteString("</g>\n")
+	}
+
+	buf.WriteString("</svg>\n")
+
+	return buf.String()
+}
+
+func (g *graphModel) html() string {
+	var buf bytes.Buffer
+
+	title := html.EscapeString(fmt.Sprintf("%s %s", g.name, g.version))
+
+	fmt.Fprintf(&buf, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+  body { font-family: sans-serif; margin: 2em; }
+  .machine-graph .state, .machine-graph .edge { cursor: pointer; transition: opacity 0.2s; }
+  .machine-graph.focused .state, .machine-graph.focused .edge { opacity: 0.2; }
+  .machine-graph.focused .active { opacity: 1; }
+  .machine-graph .edge.active path { stroke: #c0392b; stroke-width: 2.5; }
+  .machine-graph .state.active rect { stroke: #c0392b; }
+  p.help { color: #555555; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<p class="help">Click a state to highlight its transitions, click the background to reset. Hover for details.</p>
+`, title, title)
+
+	buf.WriteString(g.svg())
+
+	buf.WriteString(`<script>
+(function () {
+  var svg = document.querySelector("svg.machine-graph");
+  function reset() {
+    svg.classList.remove("focused");
+    svg.querySelectorAll(".active").forEach(function (e) { e.classList.remove("active"); });
+  }
+  svg.addEventListener("click", function (ev) {
+    var state = ev.target.closest(".state");
+    reset();
+    if (!state) { return; }
+    var name = state.getAttribute("data-state");
+    svg.classList.add("focused");
+    state.classList.add("active");
+    svg.querySelectorAll(".edge").forEach(function (e) {
+      if (e.getAttribute("data-from") === name || e.getAttribute("data-to") === name) {
+        e.classList.add("active");
+        svg.querySelectorAll(".state").forEach(function (s) {
+          var n = s.getAttribute("data-state");
+          if (n === e.getAttribute("data-from") || n === e.getAttribute("data-to")) { s.classList.add("active"); }
+        });
+      }
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`)
+
+	return buf.String()
+}
@@ -14,12 +14,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/expr-lang/expr"
 	"github.com/goccy/go-yaml"
 	"github.com/looplab/fsm"
 	"github.com/nats-io/jsm.go"
 	"github.com/sirupsen/logrus"
 
 	"github.com/choria-io/go-choria/aagent/model"
+	aautil "github.com/choria-io/go-choria/aagent/util"
 	"github.com/choria-io/go-choria/aagent/watchers"
 	"github.com/choria-io/go-choria/backoff"
 	"github.com/choria-io/go-choria/inter"
@@ -52,6 +54,15 @@ type Machine struct {
 	// SplayStart causes a random sleep of maximum this many seconds before the machine starts
 	SplayStart int `json:"splay_start" yaml:"splay_start"`
 
+	// Webhooks receive notifications about transitions and watcher states of this machine
+	Webhooks []*Webhook `json:"webhooks" yaml:"webhooks"`
+
+	// States describes actions and child machines for specific states
+	States []*State `json:"states" yaml:"states"`
+
+	// DataStore optionally persists machine data to a Key-Value bucket and shares selected keys with other nodes
+	DataStore *DataStore `json:"data_store" yaml:"data_store"`
+
 	// ActivationCheck when set this can be called to avoid activating a plugin
 	// typically this would be used when compiling machines into the binary
 	ActivationCheck ActivationChecker `json:"-" yaml:"-"`
@@ -70,6 +81,8 @@ type Machine struct {
 
 	embedded     bool
 	data         map[string]any
+	peerData     map[string]map[string]any
+	dataSyncC    chan struct{}
 	facts        func() json.RawMessage
 	jsm          *jsm.Manager
 	conn         inter.Connector
@@ -79,6 +92,20 @@ type Machine struct {
 	knownStates  map[string]bool
 	haHttpServer model.HttpManager
 
+	// parent is set on child machines started by a state of another machine, data is stored in the parent
+	parent   *Machine
+	children map[string]*Machine
+	wg       *sync.WaitGroup
+
+	// stateChanges are state changes awaiting their entry and exit actions, they are
+	// performed in order by one goroutine at a time while stateChangesRunning is set
+	stateChanges        []stateChange
+	stateChangesRunning bool
+
+	// scenario is set when the machine is being tested using RunScenario, transitions
+	// are not rate limited and data is not persisted to disk
+	scenario bool
+
 	// we use a 5 second backoff to limit fast transitions
 	// this when this timer fires it will reset the try counter
 	// to 0, but we reset this timer on every transition meaning
@@ -94,6 +121,9 @@ type Machine struct {
 	externalMachineNotifier func(*TransitionNotification)
 	externalMachineQuery    func(string) (string, error)
 
+	// transitionMu serializes transitions so guards are evaluated against the state the transition fires from
+	transitionMu sync.Mutex
+
 	ctx    context.Context
 	cancel context.CancelFunc
 	dataMu sync.Mutex
@@ -116,6 +146,10 @@ type Transition struct {
 
 	// Description is a human friendly description of the purpose of this transition
 	Description string `json:"description" yaml:"description"`
+
+	// Guard is an optional expression that must be true for the transition to be performed, it is
+	// evaluated with the same environment as the expression watcher
+	Guard string `json:"guard" yaml:"guard"`
 }
 
 // MachineSubscription describes a remote machine event that might trigger this machine to transition
@@ -497,11 +531,6 @@ func (m *Machine) Watchers() []*watchers.WatcherDef {
 	return m.WatcherDefs
 }
 
-// Graph produce a dot graph of the fsm
-func (m *Machine) Graph() string {
-	return fsm.Visualize(m.fsm)
-}
-
 func (m *Machine) backoffFunc() {
 	m.Lock()
 	defer m.Unlock()
@@ -589,6 +618,36 @@ func (m *Machine) Validate() error {
 		return fmt.Errorf("no watchers defined")
 	}
 
+	for _, t := range m.Transitions {
+		if t.Guard == "" {
+			continue
+		}
+
+		_, err := expr.Compile(t.Guard, expr.AsBool())
+		if err != nil {
+			return fmt.Errorf("invalid guard on transition %s: %s", t.Name, err)
+		}
+	}
+
+	for _, hook := range m.Webhooks {
+		err := hook.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.DataStore != nil {
+		err := m.DataStore.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := m.validateStates()
+	if err != nil {
+		return err
+	}
+
 	for _, w := range m.Watchers() {
 		err := w.ParseAnnounceInterval()
 		if err != nil {
@@ -622,6 +681,7 @@ func (m *Machine) Setup() error {
 // Start runs the machine in the background
 func (m *Machine) Start(ctx context.Context, wg *sync.WaitGroup) (started chan struct{}) {
 	m.ctx, m.cancel = context.WithCancel(ctx)
+	m.wg = wg
 
 	started = make(chan struct{}, 1)
 
@@ -644,11 +704,19 @@ func (m *Machine) Start(ctx context.Context, wg *sync.WaitGroup) (started chan s
 
 		m.Infof(m.MachineName, "Starting Choria Machine %s version %s from %s in state %s", m.MachineName, m.MachineVersion, m.directory, m.InitialState)
 
+		m.startDataStore(m.ctx, wg)
+
 		err := m.manager.Run(m.ctx, wg)
 		if err != nil {
 			m.Errorf(m.MachineName, "Could not start manager: %s", err)
 		} else {
 			m.startTime = time.Now().UTC()
+
+			m.Lock()
+			start := m.queueStateChange("", "", m.fsm.Current())
+			m.Unlock()
+
+			m.performStateChanges(start)
 		}
 
 		started <- struct{}{}
@@ -675,6 +743,11 @@ func (m *Machine) Delete() {
 
 	m.manager.Delete()
 
+	for _, child := range m.children {
+		child.Delete()
+	}
+	m.children = nil
+
 	if m.backoffTimer != nil {
 		m.backoffTimer.Stop()
 	}
@@ -692,6 +765,11 @@ func (m *Machine) Stop() {
 	m.Lock()
 	defer m.Unlock()
 
+	for _, child := range m.children {
+		child.Stop()
+	}
+	m.children = nil
+
 	if m.backoffTimer != nil {
 		m.backoffTimer.Stop()
 	}
@@ -726,27 +804,110 @@ func (m *Machine) backoffTransition(t string) error {
 
 // Transition performs the machine transition as defined by event t
 func (m *Machine) Transition(t string, args ...any) error {
-	m.Lock()
-	defer m.Unlock()
-
 	if t == "" {
 		return nil
 	}
 
+	// guards are evaluated before taking the lock as the expression environment accesses facts and other locked
+	// state, holding transitionMu ensures no other transition can change the state before the event fires
+	m.transitionMu.Lock()
+	defer m.transitionMu.Unlock()
+
 	if m.Can(t) {
+		reason, vetoed := m.guardVeto(t)
+		if vetoed {
+			m.Infof("machine", "Transition %s vetoed while in %s: %s", t, m.State(), reason)
+			m.notifyVeto(t, reason)
+			return nil
+		}
+	}
+
+	m.Lock()
+
+	if !m.Can(t) {
+		m.Warnf("machine", "Could not fire '%s' event while in %s", t, m.fsm.Current())
+		m.Unlock()
+		return nil
+	}
+
+	if !m.scenario {
 		err := m.backoffTransition(t)
 		if err != nil {
+			m.Unlock()
 			return err
 		}
+	}
 
-		m.fsm.Event(m.ctx, t, args...)
-	} else {
-		m.Warnf("machine", "Could not fire '%s' event while in %s", t, m.fsm.Current())
+	from := m.fsm.Current()
+	m.fsm.Event(m.ctx, t, args...)
+	to := m.fsm.Current()
+
+	var start bool
+	if from != to {
+		start = m.queueStateChange(t, from, to)
+	}
+
+	m.Unlock()
+
+	// state actions are performed without holding the lock as they can access facts, connections and child machines
+	m.performStateChanges(start)
+
+	return nil
+}
+
+// transition finds the transition with name t
+func (m *Machine) transition(t string) *Transition {
+	for _, transition := range m.Transitions {
+		if transition.Name == t {
+			return transition
+		}
 	}
 
 	return nil
 }
 
+// guardVeto evaluates the guard on transition t, a guard that fails to evaluate vetoes the transition
+func (m *Machine) guardVeto(t string) (reason string, vetoed bool) {
+	transition := m.transition(t)
+	if transition == nil || transition.Guard == "" {
+		return "", false
+	}
+
+	ok, err := aautil.EvaluateBoolExpression(transition.Guard, aautil.ExpressionEnv(m))
+	if err != nil {
+		return fmt.Sprintf("guard %q failed: %s", transition.Guard, err), true
+	}
+
+	if !ok {
+		return fmt.Sprintf("guard %q is false", transition.Guard), true
+	}
+
+	return "", false
+}
+
+func (m *Machine) notifyVeto(t string, reason string) {
+	notification := &TransitionNotification{
+		Protocol:   TransitionVetoProtocol,
+		Identity:   m.Identity(),
+		ID:         m.InstanceID(),
+		Version:    m.Version(),
+		Timestamp:  m.TimeStampSeconds(),
+		Machine:    m.MachineName,
+		Transition: t,
+		FromState:  m.State(),
+		ToState:    m.transition(t).Destination,
+		Reason:     reason,
+		Info:       m,
+	}
+
+	for _, notifier := range m.notifiers {
+		err := notifier.NotifyPostTransition(notification)
+		if err != nil {
+			m.Errorf("machine", "Could not publish veto notification for %s: %s", t, err)
+		}
+	}
+}
+
 // Can determines if a transition could be performed
 func (m *Machine) Can(t string) bool {
 	return m.fsm.Can(t)
@@ -799,6 +960,10 @@ func (m *Machine) KnownStates() []string {
 
 // DataGet gets the value for a key, empty string and false when no value is stored
 func (m *Machine) DataGet(key string) (any, bool) {
+	if m.parent != nil {
+		return m.parent.DataGet(key)
+	}
+
 	m.dataMu.Lock()
 	defer m.dataMu.Unlock()
 
@@ -809,11 +974,17 @@ func (m *Machine) DataGet(key string) (any, bool) {
 
 // DataPut stores a value in a key
 func (m *Machine) DataPut(key string, val any) error {
+	if m.parent != nil {
+		return m.parent.DataPut(key, val)
+	}
+
 	m.dataMu.Lock()
 	defer m.dataMu.Unlock()
 
 	m.data[key] = val
 
+	m.signalDataSync()
+
 	err := m.saveData()
 	if err != nil {
 		m.Errorf("machine", "Could not save data to %s: %s", dataFileName, err)
@@ -825,6 +996,10 @@ func (m *Machine) DataPut(key string, val any) error {
 
 // DataDelete deletes a value from the store
 func (m *Machine) DataDelete(key string) error {
+	if m.parent != nil {
+		return m.parent.DataDelete(key)
+	}
+
 	m.dataMu.Lock()
 	defer m.dataMu.Unlock()
 
@@ -835,6 +1010,8 @@ func (m *Machine) DataDelete(key string) error {
 
 	delete(m.data, key)
 
+	m.signalDataSync()
+
 	err := m.saveData()
 	if err != nil {
 		m.Errorf("machine", "Could not save data to %s: %s", dataFileName, err)
@@ -863,6 +1040,10 @@ func (m *Machine) loadData() error {
 
 // lock should be held by caller
 func (m *Machine) saveData() error {
+	if m.scenario {
+		return nil
+	}
+
 	j, err := json.Marshal(m.data)
 	if err != nil {
 		return err
@@ -885,6 +1066,10 @@ func (m *Machine) saveData() error {
 
 // Data retrieves a copy of the current data stored by the machine, changes will not be reflected in the machine
 func (m *Machine) Data() map[string]any {
+	if m.parent != nil {
+		return m.parent.Data()
+	}
+
 	m.dataMu.Lock()
 	defer m.dataMu.Unlock()
 
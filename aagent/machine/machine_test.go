@@ -159,6 +159,61 @@ var _ = Describe("Aagent/Machine", func() {
 
 			machine.WatcherDefs = []*watchers.WatcherDef{{}}
 			Expect(machine.Validate()).ToNot(HaveOccurred())
+
+			machine.Transitions = []*Transition{{Name: "fire_1", Guard: "data.x =="}}
+			Expect(machine.Validate()).To(MatchError(ContainSubstring("invalid guard on transition fire_1")))
+		})
+	})
+
+	Describe("Graph", func() {
+		It("Should show guards", func() {
+			manager.EXPECT().SetMachine(gomock.AssignableToTypeOf(&Machine{}))
+			machine, err = FromYAML("testdata/machine.yaml", manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.Transitions[0].Guard = `data.ready == "yes"`
+
+			graph := machine.Graph()
+			Expect(graph).To(ContainSubstring(`"unknown" -> "one" [ label = "fire_1\n[data.ready == \"yes\"]", style = "dashed" ];`))
+			Expect(graph).To(ContainSubstring(`"one" -> "two" [ label = "fire_2" ];`))
+		})
+
+		It("Should show watchers, subscriptions and live state counts", func() {
+			manager.EXPECT().SetMachine(gomock.AssignableToTypeOf(&Machine{}))
+			machine, err = FromYAML("testdata/machine.yaml", manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.Transitions[1].Subscriptions = []MachineSubscription{{MachineName: "other", Event: "ready"}}
+
+			opts := &GraphOptions{StateCounts: map[string]int{"one": 3, "two": 1}}
+
+			graph, err := machine.RenderGraph(GraphDOT, opts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(graph).To(ContainSubstring(`"one" -> "two" [ label = "fire_2\n<= other/ready" ];`))
+			Expect(graph).To(ContainSubstring(`"one" [ label = "one (3 nodes)\n\ntrue_2 (exec)", shape = "box", style = "rounded,filled", fillcolor = "lightblue" ];`))
+			Expect(graph).To(ContainSubstring(`"unknown" [ label = "unknown (0 nodes)\n\ntrue_1 (exec)", shape = "box", style = "rounded", penwidth = 2 ];`))
+
+			graph, err = machine.RenderGraph(GraphMermaid, opts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(graph).To(HavePrefix("stateDiagram-v2\n"))
+			Expect(graph).To(ContainSubstring(`state "two (1 node)" as s1`))
+			Expect(graph).To(ContainSubstring("[*] --> s2\n"))
+			Expect(graph).To(ContainSubstring("s0 --> s1 : fire_2<br/>#lt;= other/ready\n"))
+			Expect(graph).To(ContainSubstring("note right of s0\n        true_2 (exec)\n    end note"))
+
+			graph, err = machine.RenderGraph(GraphSVG, opts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(graph).To(HavePrefix(`<svg xmlns="http://www.w3.org/2000/svg"`))
+			Expect(graph).To(ContainSubstring(`<g class="state occupied" data-state="one"><title>one (3 nodes)`))
+			Expect(graph).To(ContainSubstring(`<g class="edge" data-from="one" data-to="two">`))
+			Expect(graph).To(ContainSubstring(`&lt;= other/ready`))
+
+			graph, err = machine.RenderGraph(GraphHTML, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(graph).To(HavePrefix("<!DOCTYPE html>"))
+			Expect(graph).To(ContainSubstring("<title>TestMachine 0.0.1</title>"))
+			Expect(graph).To(ContainSubstring(`<g class="state initial" data-state="unknown">`))
+
+			_, err = machine.RenderGraph("png", nil)
+			Expect(err).To(MatchError(`unsupported graph format "png"`))
 		})
 	})
 
@@ -217,4 +272,44 @@ var _ = Describe("Aagent/Machine", func() {
 			Expect(machine.State()).To(Equal("one"))
 		})
 	})
+
+	Describe("Guards", func() {
+		BeforeEach(func() {
+			manager.EXPECT().SetMachine(gomock.AssignableToTypeOf(&Machine{}))
+			machine, err = FromYAML("testdata/machine.yaml", manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.ctx = context.Background()
+			machine.scenario = true
+			machine.Transitions[0].Guard = "data.ready == true && state == 'unknown'"
+			machine.RegisterNotifier(service)
+		})
+
+		It("Should veto transitions when the guard is false", func() {
+			service.EXPECT().Infof(machine, "machine", "Transition %s vetoed while in %s: %s", "fire_1", "unknown", `guard "data.ready == true && state == 'unknown'" is false`)
+			service.EXPECT().NotifyPostTransition(gomock.Any()).DoAndReturn(func(n *TransitionNotification) error {
+				Expect(n.IsVeto()).To(BeTrue())
+				Expect(n.Transition).To(Equal("fire_1"))
+				Expect(n.FromState).To(Equal("unknown"))
+				Expect(n.ToState).To(Equal("one"))
+				Expect(n.String()).To(ContainSubstring("vetoed event fire_1"))
+				return nil
+			})
+
+			Expect(machine.Transition("fire_1")).To(Succeed())
+			Expect(machine.State()).To(Equal("unknown"))
+		})
+
+		It("Should perform transitions when the guard is true", func() {
+			machine.data["ready"] = true
+
+			manager.EXPECT().NotifyStateChance()
+			service.EXPECT().NotifyPostTransition(gomock.Any()).DoAndReturn(func(n *TransitionNotification) error {
+				Expect(n.IsVeto()).To(BeFalse())
+				return nil
+			})
+
+			Expect(machine.Transition("fire_1")).To(Succeed())
+			Expect(machine.State()).To(Equal("one"))
+		})
+	})
 })
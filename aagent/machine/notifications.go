@@ -13,7 +13,11 @@ import (
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 )
 
-// TransitionNotification is a notification when a transition completes
+// TransitionVetoProtocol is the protocol of notifications sent when a transition guard vetoed a transition, the
+// Choria notifier publishes these to choria.machine.transition.veto rather than with the transitions
+const TransitionVetoProtocol = "io.choria.machine.v1.transition_veto"
+
+// TransitionNotification is a notification when a transition completes or was vetoed by a guard
 type TransitionNotification struct {
 	Protocol   string `json:"protocol"`
 	Identity   string `json:"identity"`
@@ -24,15 +28,25 @@ type TransitionNotification struct {
 	Transition string `json:"transition"`
 	FromState  string `json:"from_state"`
 	ToState    string `json:"to_state"`
+	Reason     string `json:"reason,omitempty"`
 
 	Info InfoSource `json:"-"`
 }
 
 // String returns a string representation of the event
 func (t *TransitionNotification) String() string {
+	if t.IsVeto() {
+		return fmt.Sprintf("%s %s vetoed event %s: %s => %s: %s", t.Identity, t.Machine, t.Transition, t.FromState, t.ToState, t.Reason)
+	}
+
 	return fmt.Sprintf("%s %s transitioned via event %s: %s => %s", t.Identity, t.Machine, t.Transition, t.FromState, t.ToState)
 }
 
+// IsVeto determines if the notification is about a transition vetoed by a guard
+func (t *TransitionNotification) IsVeto() bool {
+	return t.Protocol == TransitionVetoProtocol
+}
+
 // CloudEvent creates a cloud event from the transition
 func (t *TransitionNotification) CloudEvent() cloudevents.Event {
 	event := cloudevents.NewEvent("1.0")
@@ -0,0 +1,377 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/choria-io/go-choria/aagent/watchers"
+)
+
+// Scenario is a sequence of watcher outcomes and external events replayed against a machine
+// without running any watchers, used to test the logic of a machine offline
+type Scenario struct {
+	// Name is a short name for the scenario shown in reports
+	Name string `json:"name" yaml:"name"`
+
+	// Description is a human friendly description of what the scenario tests
+	Description string `json:"description" yaml:"description"`
+
+	// InitialState overrides the initial state of the machine
+	InitialState string `json:"initial_state" yaml:"initial_state"`
+
+	// Data is the initial contents of the machine data store
+	Data map[string]any `json:"data" yaml:"data"`
+
+	// MachineStates are the initial states of other machines on the node
+	MachineStates map[string]string `json:"machine_states" yaml:"machine_states"`
+
+	// Facts are the facts of the node the machine runs on
+	Facts map[string]any `json:"facts" yaml:"facts"`
+
+	// Steps are the events to replay in order
+	Steps []*ScenarioStep `json:"steps" yaml:"steps"`
+
+	// ExpectStates is the full sequence of states the machine should pass through, including the initial state
+	ExpectStates []string `json:"expect_states" yaml:"expect_states"`
+
+	// ExpectData are values the data store should hold once all steps completed
+	ExpectData map[string]any `json:"expect_data" yaml:"expect_data"`
+}
+
+// ScenarioStep is a single event in a scenario, only one of Watcher, Transition or Subscription can be set
+type ScenarioStep struct {
+	// Watcher is the name of a watcher that completed with Outcome
+	Watcher string `json:"watcher" yaml:"watcher"`
+
+	// Outcome is the result of the watcher, success and fail fire the matching watcher transition while any other value is fired as a transition
+	Outcome string `json:"outcome" yaml:"outcome"`
+
+	// Transition is an externally requested transition like those made using choria machine transition
+	Transition string `json:"transition" yaml:"transition"`
+
+	// Subscription is a transition in another machine delivered to subscribers of that event
+	Subscription *MachineSubscription `json:"subscription" yaml:"subscription"`
+
+	// MachineStates updates the states of other machines on the node before the step is performed
+	MachineStates map[string]string `json:"machine_states" yaml:"machine_states"`
+
+	// Data stores values in the data store before the step is performed, as a watcher gathering data would
+	Data map[string]any `json:"data" yaml:"data"`
+
+	// ExpectState is the state the machine should be in after the step
+	ExpectState string `json:"expect_state" yaml:"expect_state"`
+
+	// ExpectData are values the data store should hold after the step
+	ExpectData map[string]any `json:"expect_data" yaml:"expect_data"`
+}
+
+// ScenarioResult is the outcome of running a scenario
+type ScenarioResult struct {
+	Name     string         `json:"name"`
+	States   []string       `json:"states"`
+	Data     map[string]any `json:"data"`
+	Log      []string       `json:"log"`
+	Failures []string       `json:"failures"`
+}
+
+// Passed determines if all expectations were met
+func (r *ScenarioResult) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// scenarioManager is a WatcherManager that does not run any watchers, watcher outcomes are supplied by scenarios
+type scenarioManager struct{}
+
+func (s *scenarioManager) Run(context.Context, *sync.WaitGroup) error { return nil }
+func (s *scenarioManager) NotifyStateChance()                         {}
+func (s *scenarioManager) SetMachine(any) error                       { return nil }
+func (s *scenarioManager) WatcherState(string) (any, bool)            { return nil, false }
+func (s *scenarioManager) Delete()                                    {}
+
+// LoadScenario reads a scenario from a YAML file
+func LoadScenario(file string) (*Scenario, error) {
+	f, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scenario{}
+	err = yaml.Unmarshal(f, s)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse scenario %s: %w", file, err)
+	}
+
+	if s.Name == "" {
+		s.Name = file
+	}
+
+	return s, nil
+}
+
+// Validate checks the scenario is well formed
+func (s *Scenario) Validate() error {
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("no steps defined")
+	}
+
+	for i, step := range s.Steps {
+		actions := 0
+		if step.Watcher != "" {
+			actions++
+			if step.Outcome == "" {
+				return fmt.Errorf("step %d: an outcome is required for watcher %s", i+1, step.Watcher)
+			}
+		}
+		if step.Transition != "" {
+			actions++
+		}
+		if step.Subscription != nil {
+			actions++
+			if step.Subscription.MachineName == "" || step.Subscription.Event == "" {
+				return fmt.Errorf("step %d: subscriptions require a machine_name and event", i+1)
+			}
+		}
+
+		if actions > 1 {
+			return fmt.Errorf("step %d: only one of watcher, transition or subscription can be set", i+1)
+		}
+	}
+
+	return nil
+}
+
+// RunScenario loads the machine in dir and replays the scenario against it, no watchers are run and the
+// machine data is not persisted to disk
+func RunScenario(ctx context.Context, dir string, s *Scenario) (*ScenarioResult, error) {
+	err := s.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := FromDir(dir, &scenarioManager{})
+	if err != nil {
+		return nil, err
+	}
+
+	m.scenario = true
+	m.data = make(map[string]any)
+	m.ctx, m.cancel = context.WithCancel(ctx)
+	defer m.cancel()
+
+	if s.InitialState != "" {
+		if !slices.Contains(m.KnownStates(), s.InitialState) {
+			return nil, fmt.Errorf("unknown initial state %s", s.InitialState)
+		}
+
+		m.fsm.SetState(s.InitialState)
+	}
+
+	res := &ScenarioResult{
+		Name:     s.Name,
+		States:   []string{m.State()},
+		Log:      []string{},
+		Failures: []string{},
+	}
+
+	machineStates := make(map[string]string)
+	for k, v := range s.MachineStates {
+		machineStates[k] = v
+	}
+
+	m.SetExternalMachineStateQuery(func(machine string) (string, error) {
+		state, ok := machineStates[machine]
+		if !ok {
+			return "", fmt.Errorf("unknown machine %s", machine)
+		}
+
+		return state, nil
+	})
+
+	m.SetExternalMachineNotifier(func(n *TransitionNotification) {
+		res.Log = append(res.Log, fmt.Sprintf("%s: %s => %s", n.Transition, n.FromState, n.ToState))
+		res.States = append(res.States, n.ToState)
+	})
+
+	facts, err := json.Marshal(s.Facts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid facts: %w", err)
+	}
+	m.SetFactSource(func() json.RawMessage { return facts })
+
+	for k, v := range s.Data {
+		m.DataPut(k, v)
+	}
+
+	for i, step := range s.Steps {
+		for k, v := range step.MachineStates {
+			machineStates[k] = v
+		}
+
+		for k, v := range step.Data {
+			m.DataPut(k, v)
+		}
+
+		err = m.scenarioStep(step, res)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+
+		if step.ExpectState != "" && m.State() != step.ExpectState {
+			res.Failures = append(res.Failures, fmt.Sprintf("step %d: expected state %s but machine is in %s", i+1, step.ExpectState, m.State()))
+		}
+
+		res.Failures = append(res.Failures, scenarioDataFailures(fmt.Sprintf("step %d: ", i+1), step.ExpectData, m.Data())...)
+	}
+
+	if len(s.ExpectStates) > 0 && !slices.Equal(s.ExpectStates, res.States) {
+		res.Failures = append(res.Failures, fmt.Sprintf("expected states %v but machine passed through %v", s.ExpectStates, res.States))
+	}
+
+	res.Data = m.Data()
+	res.Failures = append(res.Failures, scenarioDataFailures("", s.ExpectData, res.Data)...)
+
+	return res, nil
+}
+
+func (m *Machine) scenarioStep(step *ScenarioStep, res *ScenarioResult) error {
+	switch {
+	case step.Watcher != "":
+		var watcher *watchers.WatcherDef
+		for _, w := range m.Watchers() {
+			if w.Name == step.Watcher {
+				watcher = w
+				break
+			}
+		}
+		if watcher == nil {
+			return fmt.Errorf("unknown watcher %s", step.Watcher)
+		}
+
+		active, err := m.scenarioWatcherActive(watcher)
+		if err != nil {
+			return err
+		}
+		if !active {
+			res.Log = append(res.Log, fmt.Sprintf("watcher %s is not active in state %s, ignoring %s outcome", watcher.Name, m.State(), step.Outcome))
+			return nil
+		}
+
+		event := step.Outcome
+		switch step.Outcome {
+		case "success":
+			event = watcher.SuccessTransition
+		case "fail":
+			event = watcher.FailTransition
+		default:
+			if !slices.Contains(m.KnownTransitions(), event) {
+				return fmt.Errorf("unknown transition %s in outcome for watcher %s", event, watcher.Name)
+			}
+		}
+
+		if event == "" {
+			res.Log = append(res.Log, fmt.Sprintf("watcher %s has no transition for %s outcome", watcher.Name, step.Outcome))
+			return nil
+		}
+
+		return m.scenarioTransition(event, res)
+
+	case step.Transition != "":
+		if !slices.Contains(m.KnownTransitions(), step.Transition) {
+			return fmt.Errorf("unknown transition %s", step.Transition)
+		}
+
+		return m.scenarioTransition(step.Transition, res)
+
+	case step.Subscription != nil:
+		m.ExternalEventNotify(&TransitionNotification{Machine: step.Subscription.MachineName, Transition: step.Subscription.Event})
+	}
+
+	return nil
+}
+
+func (m *Machine) scenarioTransition(event string, res *ScenarioResult) error {
+	if !m.Can(event) {
+		res.Log = append(res.Log, fmt.Sprintf("%s: not valid in state %s", event, m.State()))
+		return nil
+	}
+
+	reason, vetoed := m.guardVeto(event)
+	if vetoed {
+		res.Log = append(res.Log, fmt.Sprintf("%s: vetoed in state %s: %s", event, m.State(), reason))
+		return nil
+	}
+
+	return m.Transition(event)
+}
+
+// scenarioWatcherActive determines if a watcher would run in the current machine state, see watcher.ShouldWatch
+func (m *Machine) scenarioWatcherActive(w *watchers.WatcherDef) (bool, error) {
+	if len(w.StateMatch) > 0 && !slices.Contains(w.StateMatch, m.State()) {
+		return false, nil
+	}
+
+	for _, required := range w.ForeignStateRequired {
+		state, err := m.LookupExternalMachineState(required.MachineName)
+		if err != nil || state != required.MachineState {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func scenarioDataFailures(prefix string, expect map[string]any, data map[string]any) []string {
+	var failures []string
+
+	keys := make([]string, 0, len(expect))
+	for k := range expect {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		val, ok := data[k]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("%sexpected data item %s was not set", prefix, k))
+			continue
+		}
+
+		if !scenarioValuesEqual(expect[k], val) {
+			failures = append(failures, fmt.Sprintf("%sexpected data item %s to be %v but got %v", prefix, k, expect[k], val))
+		}
+	}
+
+	return failures
+}
+
+// scenarioValuesEqual compares values via their JSON representation so that numbers parsed from YAML match those stored by the machine
+func scenarioValuesEqual(a any, b any) bool {
+	norm := func(v any) any {
+		j, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+
+		var res any
+		err = json.Unmarshal(j, &res)
+		if err != nil {
+			return v
+		}
+
+		return res
+	}
+
+	return reflect.DeepEqual(norm(a), norm(b))
+}
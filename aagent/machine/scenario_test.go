@@ -0,0 +1,133 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Aagent/Machine/Scenario", func() {
+	var scenario *Scenario
+
+	BeforeEach(func() {
+		var err error
+		scenario, err = LoadScenario("testdata/scenario/recovery.yaml")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("Validate", func() {
+		It("Should require steps", func() {
+			Expect((&Scenario{}).Validate()).To(MatchError("no steps defined"))
+		})
+
+		It("Should require a single action per step", func() {
+			scenario.Steps[0].Transition = "running"
+			Expect(scenario.Validate()).To(MatchError("step 1: only one of watcher, transition or subscription can be set"))
+		})
+
+		It("Should require watcher outcomes", func() {
+			scenario.Steps[0].Outcome = ""
+			Expect(scenario.Validate()).To(MatchError("step 1: an outcome is required for watcher check"))
+		})
+	})
+
+	Describe("RunScenario", func() {
+		It("Should pass valid scenarios", func() {
+			res, err := RunScenario(context.Background(), "testdata/scenario", scenario)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Failures).To(BeEmpty())
+			Expect(res.Passed()).To(BeTrue())
+			Expect(res.States).To(Equal([]string{"unknown", "stopped", "running", "maintenance", "unknown"}))
+			Expect(res.Data).To(HaveKey("restarts"))
+			Expect(res.Log).To(ContainElement("watcher start is not active in state stopped, ignoring success outcome"))
+			Expect(res.Log).To(ContainElement("watcher check is not active in state maintenance, ignoring success outcome"))
+		})
+
+		It("Should not persist data", func() {
+			_, err := RunScenario(context.Background(), "testdata/scenario", scenario)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filepath.Join("testdata/scenario", dataFileName)).ToNot(BeAnExistingFile())
+		})
+
+		It("Should report failed expectations", func() {
+			scenario.Steps[0].ExpectState = "running"
+			scenario.ExpectData["restarts"] = 2
+			scenario.ExpectStates = []string{"unknown", "running"}
+
+			res, err := RunScenario(context.Background(), "testdata/scenario", scenario)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Passed()).To(BeFalse())
+			Expect(res.Failures).To(Equal([]string{
+				"step 1: expected state running but machine is in stopped",
+				"expected states [unknown running] but machine passed through [unknown stopped running maintenance unknown]",
+				"expected data item restarts to be 2 but got 1",
+			}))
+		})
+
+		It("Should support setting the initial state", func() {
+			scenario.InitialState = "invalid"
+			_, err := RunScenario(context.Background(), "testdata/scenario", scenario)
+			Expect(err).To(MatchError("unknown initial state invalid"))
+
+			scenario.InitialState = "running"
+			scenario.ExpectStates = nil
+			res, err := RunScenario(context.Background(), "testdata/scenario", scenario)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.States[0]).To(Equal("running"))
+		})
+
+		It("Should detect unknown watchers and transitions", func() {
+			scenario.Steps[0].Watcher = "unknown"
+			_, err := RunScenario(context.Background(), "testdata/scenario", scenario)
+			Expect(err).To(MatchError("step 1: unknown watcher unknown"))
+
+			scenario.Steps[0] = &ScenarioStep{Transition: "unknown_transition"}
+			_, err = RunScenario(context.Background(), "testdata/scenario", scenario)
+			Expect(err).To(MatchError("step 1: unknown transition unknown_transition"))
+		})
+	})
+
+	Describe("Guards", func() {
+		It("Should record vetoed transitions", func() {
+			dir := GinkgoT().TempDir()
+			myaml, err := os.ReadFile("testdata/scenario/machine.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			myaml = []byte(strings.Replace(string(myaml), "    destination: running\n", "    destination: running\n    guard: get_fact('role') == 'primary'\n", 1))
+			Expect(os.WriteFile(filepath.Join(dir, "machine.yaml"), myaml, 0600)).To(Succeed())
+
+			scenario := &Scenario{
+				Facts: map[string]any{"role": "secondary"},
+				Steps: []*ScenarioStep{{Watcher: "check", Outcome: "success", ExpectState: "unknown"}},
+			}
+
+			res, err := RunScenario(context.Background(), dir, scenario)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Passed()).To(BeTrue())
+			Expect(res.Log).To(Equal([]string{`running: vetoed in state unknown: guard "get_fact('role') == 'primary'" is false`}))
+
+			scenario.Facts["role"] = "primary"
+			scenario.Steps[0].ExpectState = "running"
+			res, err = RunScenario(context.Background(), dir, scenario)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Passed()).To(BeTrue())
+		})
+	})
+
+	Describe("LoadScenario", func() {
+		It("Should default the name", func() {
+			f := filepath.Join(GinkgoT().TempDir(), "s.yaml")
+			Expect(os.WriteFile(f, []byte("steps: [{transition: running}]"), 0600)).To(Succeed())
+			s, err := LoadScenario(f)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.Name).To(Equal(f))
+		})
+	})
+})
@@ -0,0 +1,517 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/shlex"
+
+	aautil "github.com/choria-io/go-choria/aagent/util"
+	"github.com/choria-io/go-choria/aagent/watchers"
+)
+
+// State describes actions taken when the machine enters or leaves a state and an optional child machine that runs while in it.
+//
+// Actions are performed in the background, without delaying the transition, in the order the machine changed states
+type State struct {
+	// Name is the name of the state, it must be a state used in transitions
+	Name string `json:"name" yaml:"name"`
+
+	// Description is a human friendly description of the purpose of this state
+	Description string `json:"description" yaml:"description"`
+
+	// OnEnter are actions performed in order after the machine entered the state
+	OnEnter []*StateAction `json:"on_enter" yaml:"on_enter"`
+
+	// OnExit are actions performed in order after the machine left the state
+	OnExit []*StateAction `json:"on_exit" yaml:"on_exit"`
+
+	// Machine is a child machine that is started when entering the state and stopped when leaving it, the name
+	// defaults to PARENT_STATE and the version to that of the parent machine
+	Machine *Machine `json:"machine" yaml:"machine"`
+
+	// Terminal maps states of the child machine to events fired in this machine when the child machine enters them
+	Terminal map[string]string `json:"terminal" yaml:"terminal"`
+}
+
+// StateAction is an action performed when entering or leaving a state, only one of Data, Publish or Command may be set
+type StateAction struct {
+	// Data sets data items, string values are processed as templates
+	Data map[string]any `json:"data" yaml:"data"`
+
+	// Publish is a NATS subject the transition event is published to
+	Publish string `json:"publish" yaml:"publish"`
+
+	// Command is a command that runs in the machine directory, it is processed as a template
+	Command string `json:"command" yaml:"command"`
+
+	// Timeout is the maximum time Command may run for, defaults to 10s
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+const defaultStateActionTimeout = 10 * time.Second
+
+// Validate validates the action settings
+func (a *StateAction) Validate() error {
+	set := 0
+	if len(a.Data) > 0 {
+		set++
+	}
+	if a.Publish != "" {
+		set++
+	}
+	if a.Command != "" {
+		set++
+	}
+
+	if set != 1 {
+		return fmt.Errorf("exactly one of data, publish or command is required")
+	}
+
+	if a.Timeout != "" {
+		if a.Command == "" {
+			return fmt.Errorf("timeout can only be set for commands")
+		}
+
+		_, err := time.ParseDuration(a.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (a *StateAction) timeout() time.Duration {
+	if a.Timeout == "" {
+		return defaultStateActionTimeout
+	}
+
+	d, _ := time.ParseDuration(a.Timeout)
+
+	return d
+}
+
+// String describes the action for logs and graphs
+func (a *StateAction) String() string {
+	switch {
+	case len(a.Data) > 0:
+		keys := make([]string, 0, len(a.Data))
+		for k := range a.Data {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		return fmt.Sprintf("set %s", strings.Join(keys, ", "))
+
+	case a.Publish != "":
+		return fmt.Sprintf("publish %s", a.Publish)
+
+	default:
+		return fmt.Sprintf("run %s", a.Command)
+	}
+}
+
+// stateDef finds the definition for state name, nil when the state has none
+func (m *Machine) stateDef(name string) *State {
+	for _, s := range m.States {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func (m *Machine) validateStates() error {
+	known := m.KnownStates()
+	seen := map[string]bool{}
+
+	for _, s := range m.States {
+		if s.Name == "" {
+			return fmt.Errorf("states require a name")
+		}
+
+		if seen[s.Name] {
+			return fmt.Errorf("state %s is defined more than once", s.Name)
+		}
+		seen[s.Name] = true
+
+		if !slices.Contains(known, s.Name) {
+			return fmt.Errorf("state %s is not used in any transition", s.Name)
+		}
+
+		for i, a := range s.OnEnter {
+			err := a.Validate()
+			if err != nil {
+				return fmt.Errorf("invalid on_enter action %d in state %s: %s", i+1, s.Name, err)
+			}
+		}
+
+		for i, a := range s.OnExit {
+			err := a.Validate()
+			if err != nil {
+				return fmt.Errorf("invalid on_exit action %d in state %s: %s", i+1, s.Name, err)
+			}
+		}
+
+		err := m.validateChildMachine(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Machine) validateChildMachine(s *State) error {
+	if s.Machine == nil {
+		if len(s.Terminal) > 0 {
+			return fmt.Errorf("state %s has terminal states but no machine", s.Name)
+		}
+
+		return nil
+	}
+
+	if len(s.Terminal) == 0 {
+		return fmt.Errorf("the machine in state %s requires terminal states", s.Name)
+	}
+
+	child := m.newChildMachine(s)
+
+	err := child.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid machine in state %s: %s", s.Name, err)
+	}
+
+	childStates := child.KnownStates()
+	transitions := m.KnownTransitions()
+
+	for cs, event := range s.Terminal {
+		if !slices.Contains(childStates, cs) {
+			return fmt.Errorf("terminal state %s is not a state of the machine in state %s", cs, s.Name)
+		}
+
+		if !slices.Contains(transitions, event) {
+			return fmt.Errorf("terminal state %s in state %s fires unknown event %s", cs, s.Name, event)
+		}
+	}
+
+	return nil
+}
+
+// newChildMachine creates an unstarted machine from the definition in state s
+func (m *Machine) newChildMachine(s *State) *Machine {
+	def := s.Machine
+
+	child := &Machine{
+		MachineName:    def.MachineName,
+		MachineVersion: def.MachineVersion,
+		InitialState:   def.InitialState,
+		Transitions:    def.Transitions,
+		WatcherDefs:    def.WatcherDefs,
+		States:         def.States,
+		SplayStart:     def.SplayStart,
+		parent:         m,
+		scenario:       m.scenario,
+	}
+
+	if child.MachineName == "" {
+		child.MachineName = fmt.Sprintf("%s_%s", m.MachineName, s.Name)
+	}
+
+	if child.MachineVersion == "" {
+		child.MachineVersion = m.MachineVersion
+	}
+
+	return child
+}
+
+// startChildMachine starts the child machine of state s, it shares the directory, connections and notifiers of this machine
+func (m *Machine) startChildMachine(s *State) error {
+	child := m.newChildMachine(s)
+
+	err := initializeMachine(child, "", "", watchers.New(m.ctx))
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	// the machine might have left the state or stopped while earlier actions ran
+	if m.fsm.Current() != s.Name || m.ctx.Err() != nil {
+		m.Unlock()
+		m.Debugf("machine", "Not starting child machine %s as the machine is no longer in state %s", child.MachineName, s.Name)
+		return nil
+	}
+
+	child.directory = m.directory
+	child.manifest = m.manifest
+	child.identity = m.identity
+	child.facts = m.facts
+	child.mainCollective = m.mainCollective
+	child.signerKey = m.signerKey
+	child.txtfileDir = m.txtfileDir
+	child.overridesFile = m.overridesFile
+	child.choriaStatusFile = m.choriaStatusFile
+	child.choriaStatusFreq = m.choriaStatusFreq
+	child.conn = m.conn
+	child.jsm = m.jsm
+	child.notifiers = m.notifiers
+	child.externalMachineQuery = m.externalMachineQuery
+
+	external := m.externalMachineNotifier
+	child.externalMachineNotifier = func(n *TransitionNotification) {
+		if external != nil {
+			external(n)
+		}
+
+		event, ok := s.Terminal[n.ToState]
+		if ok {
+			m.Infof("machine", "Child machine %s reached terminal state %s, firing %s", n.Machine, n.ToState, event)
+
+			// transitioning stops the child machine so must not block its transition
+			go m.Transition(event)
+		}
+	}
+
+	if m.children == nil {
+		m.children = map[string]*Machine{}
+	}
+	m.children[s.Name] = child
+	m.Unlock()
+
+	child.Start(m.ctx, m.wg)
+
+	return nil
+}
+
+// stopChildMachine stops the child machine running in state, if any
+func (m *Machine) stopChildMachine(state string) {
+	m.Lock()
+	child, ok := m.children[state]
+	delete(m.children, state)
+	m.Unlock()
+
+	if ok {
+		m.Infof("machine", "Stopping child machine %s", child.MachineName)
+		child.Delete()
+	}
+}
+
+// ChildMachine retrieves the child machine running in state, nil when none is running
+func (m *Machine) ChildMachine(state string) *Machine {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.children[state]
+}
+
+// stateChange is a state change awaiting its entry and exit actions
+type stateChange struct {
+	event string
+	from  string
+	to    string
+}
+
+// queueStateChange records a state change for performStateChanges, it must be called while holding the
+// lock that guarded the transition so changes are queued in the order they happened. Returns true when
+// the caller should start performing the queued changes
+func (m *Machine) queueStateChange(event string, from string, to string) bool {
+	if m.stateDef(from) == nil && m.stateDef(to) == nil {
+		return false
+	}
+
+	m.stateChanges = append(m.stateChanges, stateChange{event: event, from: from, to: to})
+	if m.stateChangesRunning {
+		return false
+	}
+
+	m.stateChangesRunning = true
+
+	return true
+}
+
+// performStateChanges performs the actions of queued state changes in order, it does not block callers
+// other than while testing scenarios where actions have to complete before the next transition
+func (m *Machine) performStateChanges(start bool) {
+	if !start {
+		return
+	}
+
+	if m.scenario {
+		m.drainStateChanges()
+	} else {
+		go m.drainStateChanges()
+	}
+}
+
+func (m *Machine) drainStateChanges() {
+	for {
+		m.Lock()
+		if len(m.stateChanges) == 0 {
+			m.stateChangesRunning = false
+			m.Unlock()
+			return
+		}
+		change := m.stateChanges[0]
+		m.stateChanges = m.stateChanges[1:]
+		m.Unlock()
+
+		m.stateChanged(change.event, change.from, change.to)
+	}
+}
+
+// stateChanged performs the exit actions of the previous state and the entry actions of the new state, from is
+// empty when the machine starts
+func (m *Machine) stateChanged(event string, from string, to string) {
+	notification := &TransitionNotification{
+		Protocol:   "io.choria.machine.v1.transition",
+		Identity:   m.Identity(),
+		ID:         m.InstanceID(),
+		Version:    m.Version(),
+		Timestamp:  m.TimeStampSeconds(),
+		Machine:    m.MachineName,
+		Transition: event,
+		FromState:  from,
+		ToState:    to,
+	}
+
+	if s := m.stateDef(from); s != nil {
+		if s.Machine != nil {
+			m.stopChildMachine(s.Name)
+		}
+
+		m.runStateActions("on_exit", s.Name, s.OnExit, notification)
+	}
+
+	if s := m.stateDef(to); s != nil {
+		m.runStateActions("on_enter", s.Name, s.OnEnter, notification)
+
+		// child machines are not run while testing scenarios as no watchers are run
+		if s.Machine != nil && m.wg != nil && !m.scenario {
+			err := m.startChildMachine(s)
+			if err != nil {
+				m.Errorf("machine", "Could not start child machine in state %s: %s", s.Name, err)
+			}
+		}
+	}
+}
+
+func (m *Machine) runStateActions(hook string, state string, actions []*StateAction, notification *TransitionNotification) {
+	for i, action := range actions {
+		m.Debugf("machine", "Performing %s action %d in state %s: %s", hook, i+1, state, action)
+
+		err := m.runStateAction(action, notification)
+		if err != nil {
+			m.Errorf("machine", "%s action %d in state %s failed: %s", hook, i+1, state, err)
+		}
+	}
+}
+
+// processTemplate renders s using the same functions available to watcher templates
+func (m *Machine) processTemplate(s string) (string, error) {
+	return aautil.ProcessTemplate(m, s, func(format string, args ...any) {
+		m.Infof("machine", format, args...)
+	})
+}
+
+func (m *Machine) runStateAction(action *StateAction, notification *TransitionNotification) error {
+	var err error
+
+	switch {
+	case len(action.Data) > 0:
+		for k, v := range action.Data {
+			if s, ok := v.(string); ok {
+				v, err = m.processTemplate(s)
+				if err != nil {
+					return fmt.Errorf("could not process template for data item %s: %s", k, err)
+				}
+			}
+
+			err = m.DataPut(k, v)
+			if err != nil {
+				return err
+			}
+		}
+
+	case action.Publish != "":
+		if m.scenario {
+			return nil
+		}
+
+		m.Lock()
+		conn := m.conn
+		m.Unlock()
+
+		if conn == nil {
+			return fmt.Errorf("no network connection")
+		}
+
+		j, err := json.Marshal(notification)
+		if err != nil {
+			return err
+		}
+
+		return conn.PublishRaw(action.Publish, j)
+
+	case action.Command != "":
+		if m.scenario {
+			return nil
+		}
+
+		return m.runStateCommand(action, notification)
+	}
+
+	return nil
+}
+
+func (m *Machine) runStateCommand(action *StateAction, notification *TransitionNotification) error {
+	parsed, err := m.processTemplate(action.Command)
+	if err != nil {
+		return fmt.Errorf("could not process command template: %s", err)
+	}
+
+	splitcmd, err := shlex.Split(parsed)
+	if err != nil {
+		return err
+	}
+
+	if len(splitcmd) == 0 {
+		return fmt.Errorf("invalid command %q", action.Command)
+	}
+
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, action.timeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(timeoutCtx, splitcmd[0], splitcmd[1:]...)
+	cmd.Dir = m.Directory()
+	cmd.Env = append(cmd.Env, fmt.Sprintf("MACHINE_NAME=%s", m.MachineName))
+	cmd.Env = append(cmd.Env, fmt.Sprintf("MACHINE_TRANSITION=%s", notification.Transition))
+	cmd.Env = append(cmd.Env, fmt.Sprintf("MACHINE_FROM_STATE=%s", notification.FromState))
+	cmd.Env = append(cmd.Env, fmt.Sprintf("MACHINE_TO_STATE=%s", notification.ToState))
+	cmd.Env = append(cmd.Env, fmt.Sprintf("PATH=%s%s%s", os.Getenv("PATH"), string(os.PathListSeparator), m.Directory()))
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %s: %s", action.Command, err, strings.TrimSpace(string(output)))
+	}
+
+	m.Debugf("machine", "Output from %s: %s", action.Command, output)
+
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("States", func() {
+	var (
+		mockctl *gomock.Controller
+		manager *MockWatcherManager
+		machine *Machine
+		td      string
+	)
+
+	BeforeEach(func() {
+		mockctl = gomock.NewController(GinkgoT())
+		manager = NewMockWatcherManager(mockctl)
+		manager.EXPECT().SetMachine(gomock.Any()).AnyTimes()
+		manager.EXPECT().NotifyStateChance().AnyTimes()
+
+		td = GinkgoT().TempDir()
+		yml, err := os.ReadFile("testdata/states/machine.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(td, "machine.yaml"), yml, 0600)).To(Succeed())
+
+		machine, err = FromDir(td, manager)
+		Expect(err).ToNot(HaveOccurred())
+		machine.ctx, machine.cancel = context.WithCancel(context.Background())
+		DeferCleanup(machine.Stop)
+	})
+
+	It("Should validate against the schema", func() {
+		errs, err := ValidateDir("testdata/states")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(errs).To(BeEmpty())
+	})
+
+	It("Should validate states", func() {
+		state := machine.States[0]
+
+		state.Terminal["done"] = "unknown"
+		Expect(machine.Validate()).To(MatchError("terminal state done in state deploying fires unknown event unknown"))
+
+		delete(state.Terminal, "done")
+		state.Terminal["missing"] = "deployed"
+		Expect(machine.Validate()).To(MatchError("terminal state missing is not a state of the machine in state deploying"))
+
+		state.Terminal = nil
+		Expect(machine.Validate()).To(MatchError("the machine in state deploying requires terminal states"))
+
+		state.Machine = nil
+		Expect(machine.Validate()).To(Succeed())
+
+		state.OnExit = append(state.OnExit, &StateAction{Publish: "x", Command: "y"})
+		Expect(machine.Validate()).To(MatchError("invalid on_exit action 2 in state deploying: exactly one of data, publish or command is required"))
+
+		state.OnExit = []*StateAction{{Publish: "x", Timeout: "1s"}}
+		Expect(machine.Validate()).To(MatchError("invalid on_exit action 1 in state deploying: timeout can only be set for commands"))
+
+		state.OnExit = nil
+		machine.States = append(machine.States, &State{Name: "other"})
+		Expect(machine.Validate()).To(MatchError("state other is not used in any transition"))
+	})
+
+	It("Should perform entry and exit actions", func() {
+		Expect(machine.DataPut("release", "1.2.3")).To(Succeed())
+
+		Expect(machine.Transition("deploy")).To(Succeed())
+		Expect(machine.State()).To(Equal("deploying"))
+		Eventually(machine.Data).Should(HaveKeyWithValue("status", "deploying 1.2.3"))
+		Eventually(filepath.Join(td, "entered")).Should(BeAnExistingFile())
+
+		Expect(machine.Transition("deployed")).To(Succeed())
+		Expect(machine.State()).To(Equal("idle"))
+		Eventually(machine.Data).Should(HaveKeyWithValue("status", "idle"))
+	})
+
+	It("Should perform actions in the order states changed", func() {
+		Expect(machine.DataPut("release", "1.2.3")).To(Succeed())
+
+		// the exit actions of deploying must not run before its entry actions
+		Expect(machine.Transition("deploy")).To(Succeed())
+		Expect(machine.Transition("deployed")).To(Succeed())
+		Expect(machine.State()).To(Equal("idle"))
+
+		Eventually(func() bool {
+			machine.Lock()
+			defer machine.Unlock()
+			return machine.stateChangesRunning
+		}).Should(BeFalse())
+		Expect(machine.Data()).To(HaveKeyWithValue("status", "idle"))
+		Expect(filepath.Join(td, "entered")).To(BeAnExistingFile())
+	})
+
+	It("Should not start child machines for states that were left", func() {
+		wg := &sync.WaitGroup{}
+		machine.wg = wg
+		DeferCleanup(wg.Wait)
+		DeferCleanup(machine.cancel)
+
+		Expect(machine.Transition("deploy")).To(Succeed())
+		Expect(machine.Transition("deployed")).To(Succeed())
+
+		Eventually(func() bool {
+			machine.Lock()
+			defer machine.Unlock()
+			return machine.stateChangesRunning
+		}).Should(BeFalse())
+		Expect(machine.ChildMachine("deploying")).To(BeNil())
+	})
+
+	It("Should run child machines that fire events in the parent", func() {
+		wg := &sync.WaitGroup{}
+		machine.wg = wg
+		DeferCleanup(wg.Wait)
+		DeferCleanup(machine.cancel)
+
+		Expect(machine.Transition("deploy")).To(Succeed())
+
+		Eventually(func() *Machine { return machine.ChildMachine("deploying") }).ShouldNot(BeNil())
+		child := machine.ChildMachine("deploying")
+		Expect(child.Name()).To(Equal("deployer_deploying"))
+		Expect(child.Version()).To(Equal("1.0.0"))
+		Expect(child.Directory()).To(Equal(machine.Directory()))
+		Expect(child.State()).To(Equal("fetch"))
+
+		Expect(child.DataPut("fetched", true)).To(Succeed())
+		Expect(machine.Data()).To(HaveKeyWithValue("fetched", true))
+
+		Expect(child.Transition("fetch_failed")).To(Succeed())
+		Eventually(machine.State).Should(Equal("broken"))
+		Expect(machine.ChildMachine("deploying")).To(BeNil())
+	})
+
+	It("Should render states in graphs", func() {
+		graph := machine.Graph()
+		Expect(graph).To(ContainSubstring(`"deploying" [ label = "deploying\n\non_enter: set status\non_enter: run touch entered\non_exit: set status\nmachine: deployer_deploying", shape = "box", style = "rounded" ];`))
+		Expect(graph).To(ContainSubstring(`subgraph "cluster_deploying" {`))
+		Expect(graph).To(ContainSubstring(`        "deploying/fetch" -> "deploying/done" [ label = "fetched" ];`))
+		Expect(graph).To(ContainSubstring(`    "deploying" -> "deploying/fetch" [ label = "starts", style = "dotted" ];`))
+		Expect(graph).To(ContainSubstring(`    "deploying/error" -> "deploying" [ label = "failed", style = "dotted" ];`))
+
+		mermaid, err := machine.RenderGraph(GraphMermaid, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mermaid).To(ContainSubstring("    state s1 {\n        state \"done\" as s1_0\n"))
+		Expect(mermaid).To(ContainSubstring("        s1_0 --> [*] : deployed\n"))
+	})
+})
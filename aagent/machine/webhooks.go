@@ -0,0 +1,175 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/choria-io/go-choria/internal/util"
+)
+
+const (
+	// WebhookTransitionEvent selects transition notifications to be sent to a webhook
+	WebhookTransitionEvent = "transition"
+	// WebhookWatcherStateEvent selects watcher state notifications to be sent to a webhook
+	WebhookWatcherStateEvent = "watcher_state"
+)
+
+var webhookFuncMap = util.FuncMap(map[string]any{
+	"ToJSON": func(v any) (string, error) {
+		j, err := json.Marshal(v)
+		return string(j), err
+	},
+})
+
+// Webhook describes a URL that receives notifications about machines
+type Webhook struct {
+	// Name is a unique name for the webhook
+	Name string `json:"name" yaml:"name"`
+
+	// URL is the http or https URL notifications are POSTed to
+	URL string `json:"url" yaml:"url"`
+
+	// Headers are additional HTTP headers to send with each request
+	Headers map[string]string `json:"headers" yaml:"headers"`
+
+	// Body is a Go template used to construct the request body, by default the notification is sent as a CloudEvent
+	Body string `json:"body" yaml:"body"`
+
+	// Timeout is how long a request may take, defaults to 10 seconds
+	Timeout string `json:"timeout" yaml:"timeout"`
+
+	// TTL is how long undelivered notifications are retried for when spooling, defaults to 1 hour
+	TTL string `json:"ttl" yaml:"ttl"`
+
+	// Events limits the kinds of notifications sent, either transition or watcher_state, defaults to both
+	Events []string `json:"events" yaml:"events"`
+
+	// Machines limits notifications to those about these machines
+	Machines []string `json:"machines" yaml:"machines"`
+
+	// States limits transition notifications to those entering these states
+	States []string `json:"states" yaml:"states"`
+
+	// WatcherTypes limits watcher state notifications to those from these types of watcher
+	WatcherTypes []string `json:"watcher_types" yaml:"watcher_types"`
+
+	timeout time.Duration
+	ttl     time.Duration
+	body    *template.Template
+}
+
+// LoadWebhooks loads a YAML or JSON file holding a list of webhooks
+func LoadWebhooks(file string) ([]*Webhook, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var hooks []*Webhook
+	err = yaml.Unmarshal(b, &hooks)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse webhooks in %s: %v", file, err)
+	}
+
+	for _, hook := range hooks {
+		err = hook.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return hooks, nil
+}
+
+// Validate checks the webhook is valid and prepares it for use
+func (w *Webhook) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("webhooks require a name")
+	}
+
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook %s requires a valid http or https url", w.Name)
+	}
+
+	for _, e := range w.Events {
+		if e != WebhookTransitionEvent && e != WebhookWatcherStateEvent {
+			return fmt.Errorf("webhook %s has an invalid event %q, valid events are %s and %s", w.Name, e, WebhookTransitionEvent, WebhookWatcherStateEvent)
+		}
+	}
+
+	w.timeout = 10 * time.Second
+	if w.Timeout != "" {
+		w.timeout, err = time.ParseDuration(w.Timeout)
+		if err != nil {
+			return fmt.Errorf("webhook %s has an invalid timeout: %v", w.Name, err)
+		}
+	}
+
+	w.ttl = time.Hour
+	if w.TTL != "" {
+		w.ttl, err = time.ParseDuration(w.TTL)
+		if err != nil {
+			return fmt.Errorf("webhook %s has an invalid ttl: %v", w.Name, err)
+		}
+	}
+
+	if w.ttl < time.Second || w.ttl > 31*24*time.Hour {
+		return fmt.Errorf("webhook %s ttl should be between 1s and 744h", w.Name)
+	}
+
+	if w.Body != "" {
+		w.body, err = template.New(w.Name).Funcs(webhookFuncMap).Parse(w.Body)
+		if err != nil {
+			return fmt.Errorf("webhook %s has an invalid body template: %v", w.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// RequestTimeout is the parsed Timeout
+func (w *Webhook) RequestTimeout() time.Duration { return w.timeout }
+
+// SpoolTTL is the parsed TTL
+func (w *Webhook) SpoolTTL() time.Duration { return w.ttl }
+
+// BodyTemplate is the parsed Body, nil when not set
+func (w *Webhook) BodyTemplate() *template.Template { return w.body }
+
+// MatchesTransition determines if a transition notification should be sent to this webhook, vetoed transitions are not sent
+func (w *Webhook) MatchesTransition(t *TransitionNotification) bool {
+	if t.IsVeto() || !w.matchesEvent(WebhookTransitionEvent) || !w.matchesMachine(t.Machine) {
+		return false
+	}
+
+	return len(w.States) == 0 || slices.Contains(w.States, t.ToState)
+}
+
+// MatchesWatcherState determines if a watcher state notification should be sent to this webhook
+func (w *Webhook) MatchesWatcherState(machine string, watcherType string) bool {
+	if !w.matchesEvent(WebhookWatcherStateEvent) || !w.matchesMachine(machine) {
+		return false
+	}
+
+	return len(w.WatcherTypes) == 0 || slices.Contains(w.WatcherTypes, watcherType)
+}
+
+func (w *Webhook) matchesEvent(e string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, e)
+}
+
+func (w *Webhook) matchesMachine(m string) bool {
+	return len(w.Machines) == 0 || slices.Contains(w.Machines, m)
+}
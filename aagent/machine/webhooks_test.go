@@ -0,0 +1,72 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook", func() {
+	Describe("Validate", func() {
+		It("Should validate the webhook", func() {
+			Expect((&Webhook{}).Validate()).To(MatchError("webhooks require a name"))
+			Expect((&Webhook{Name: "x", URL: "ftp://example.net"}).Validate()).To(MatchError("webhook x requires a valid http or https url"))
+			Expect((&Webhook{Name: "x", URL: "https://example.net", Events: []string{"other"}}).Validate()).To(MatchError(`webhook x has an invalid event "other", valid events are transition and watcher_state`))
+			Expect((&Webhook{Name: "x", URL: "https://example.net", Timeout: "x"}).Validate()).To(MatchError(ContainSubstring("invalid timeout")))
+			Expect((&Webhook{Name: "x", URL: "https://example.net", TTL: "10000h"}).Validate()).To(MatchError("webhook x ttl should be between 1s and 744h"))
+			Expect((&Webhook{Name: "x", URL: "https://example.net", Body: "{{ .Foo"}).Validate()).To(MatchError(ContainSubstring("invalid body template")))
+
+			hook := &Webhook{Name: "x", URL: "https://example.net", Timeout: "1s", Body: "{{ .Machine | ToJSON }}"}
+			Expect(hook.Validate()).To(Succeed())
+			Expect(hook.RequestTimeout()).To(Equal(time.Second))
+			Expect(hook.SpoolTTL()).To(Equal(time.Hour))
+			Expect(hook.BodyTemplate()).ToNot(BeNil())
+		})
+	})
+
+	Describe("LoadWebhooks", func() {
+		It("Should load and validate the file", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "webhooks.yaml")
+			Expect(os.WriteFile(file, []byte("- name: ops\n  url: https://example.net\n  states: [failed]\n"), 0600)).To(Succeed())
+
+			hooks, err := LoadWebhooks(file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hooks).To(HaveLen(1))
+			Expect(hooks[0].States).To(Equal([]string{"failed"}))
+			Expect(hooks[0].RequestTimeout()).To(Equal(10 * time.Second))
+
+			Expect(os.WriteFile(file, []byte("- name: ops\n"), 0600)).To(Succeed())
+			_, err = LoadWebhooks(file)
+			Expect(err).To(MatchError("webhook ops requires a valid http or https url"))
+		})
+	})
+
+	Describe("Matching", func() {
+		It("Should filter transitions", func() {
+			hook := &Webhook{Name: "x", URL: "https://example.net", Machines: []string{"app"}, States: []string{"failed"}}
+			Expect(hook.MatchesTransition(&TransitionNotification{Machine: "app", ToState: "failed"})).To(BeTrue())
+			Expect(hook.MatchesTransition(&TransitionNotification{Machine: "app", ToState: "running"})).To(BeFalse())
+			Expect(hook.MatchesTransition(&TransitionNotification{Machine: "other", ToState: "failed"})).To(BeFalse())
+			Expect(hook.MatchesTransition(&TransitionNotification{Protocol: TransitionVetoProtocol, Machine: "app", ToState: "failed"})).To(BeFalse())
+
+			hook.Events = []string{WebhookWatcherStateEvent}
+			Expect(hook.MatchesTransition(&TransitionNotification{Machine: "app", ToState: "failed"})).To(BeFalse())
+		})
+
+		It("Should filter watcher states", func() {
+			hook := &Webhook{Name: "x", URL: "https://example.net", WatcherTypes: []string{"nagios"}}
+			Expect(hook.MatchesWatcherState("app", "nagios")).To(BeTrue())
+			Expect(hook.MatchesWatcherState("app", "exec")).To(BeFalse())
+
+			hook.Events = []string{WebhookTransitionEvent}
+			Expect(hook.MatchesWatcherState("app", "nagios")).To(BeFalse())
+		})
+	})
+})
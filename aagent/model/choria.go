@@ -24,4 +24,6 @@ type ChoriaProvider interface {
 	Facts() json.RawMessage
 	MachineSignerKey() string
 	MachineHTTPPort() int
+	MachineWebhooksFile() string
+	SubmissionSpool() (string, int)
 }
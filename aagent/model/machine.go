@@ -38,6 +38,7 @@ type Machine interface {
 	DataPut(key string, val any) error
 	DataGet(key string) (any, bool)
 	DataDelete(key string) error
+	PeerData() map[string]map[string]any
 	SignerKey() string
 	LookupExternalMachineState(name string) (string, error)
 	SetHttpManager(HttpManager)
@@ -329,6 +329,20 @@ func (mr *MockChoriaProviderMockRecorder) MachineSignerKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachineSignerKey", reflect.TypeOf((*MockChoriaProvider)(nil).MachineSignerKey))
 }
 
+// MachineWebhooksFile mocks base method.
+func (m *MockChoriaProvider) MachineWebhooksFile() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachineWebhooksFile")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// MachineWebhooksFile indicates an expected call of MachineWebhooksFile.
+func (mr *MockChoriaProviderMockRecorder) MachineWebhooksFile() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachineWebhooksFile", reflect.TypeOf((*MockChoriaProvider)(nil).MachineWebhooksFile))
+}
+
 // MainCollective mocks base method.
 func (m *MockChoriaProvider) MainCollective() string {
 	m.ctrl.T.Helper()
@@ -400,6 +414,21 @@ func (mr *MockChoriaProviderMockRecorder) ServerStatusFile() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerStatusFile", reflect.TypeOf((*MockChoriaProvider)(nil).ServerStatusFile))
 }
 
+// SubmissionSpool mocks base method.
+func (m *MockChoriaProvider) SubmissionSpool() (string, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmissionSpool")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// SubmissionSpool indicates an expected call of SubmissionSpool.
+func (mr *MockChoriaProviderMockRecorder) SubmissionSpool() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmissionSpool", reflect.TypeOf((*MockChoriaProvider)(nil).SubmissionSpool))
+}
+
 // MockMachine is a mock of Machine interface.
 type MockMachine struct {
 	ctrl     *gomock.Controller
@@ -702,6 +731,20 @@ func (mr *MockMachineMockRecorder) OverrideData() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OverrideData", reflect.TypeOf((*MockMachine)(nil).OverrideData))
 }
 
+// PeerData mocks base method.
+func (m *MockMachine) PeerData() map[string]map[string]any {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PeerData")
+	ret0, _ := ret[0].(map[string]map[string]any)
+	return ret0
+}
+
+// PeerData indicates an expected call of PeerData.
+func (mr *MockMachineMockRecorder) PeerData() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerData", reflect.TypeOf((*MockMachine)(nil).PeerData))
+}
+
 // PublishLifecycleEvent mocks base method.
 func (m *MockMachine) PublishLifecycleEvent(t lifecycle.Type, opts ...lifecycle.Option) {
 	m.ctrl.T.Helper()
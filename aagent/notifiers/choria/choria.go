@@ -57,14 +57,22 @@ func (n *Notifier) Errorf(m machine.InfoSource, name string, format string, args
 
 // NotifyPostTransition implements machine.NotificationService
 func (n *Notifier) NotifyPostTransition(transition *machine.TransitionNotification) (err error) {
-	n.logger.Infof("%s transitioned via event %s: from %s into %s", transition.Machine, transition.Transition, transition.FromState, transition.ToState)
+	// vetoes are published separately so consumers of transitions do not count them as transitions
+	subject := "choria.machine.transition"
+
+	if transition.IsVeto() {
+		subject = "choria.machine.transition.veto"
+		n.logger.Infof("%s vetoed event %s while in %s: %s", transition.Machine, transition.Transition, transition.FromState, transition.Reason)
+	} else {
+		n.logger.Infof("%s transitioned via event %s: from %s into %s", transition.Machine, transition.Transition, transition.FromState, transition.ToState)
+	}
 
 	j, err := json.Marshal(transition.CloudEvent())
 	if err != nil {
 		return fmt.Errorf("could not JSON encode transition notification: %s", err)
 	}
 
-	err = n.fw.PublishRaw("choria.machine.transition", j)
+	err = n.fw.PublishRaw(subject, j)
 	if err != nil {
 		return fmt.Errorf("could not publish notification: %s", err)
 	}
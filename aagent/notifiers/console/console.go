@@ -37,6 +37,11 @@ func (n *Notifier) Errorf(m machine.InfoSource, name string, format string, args
 
 // NotifyPostTransition implements machine.NotificationService
 func (n *Notifier) NotifyPostTransition(transition *machine.TransitionNotification) error {
+	if transition.IsVeto() {
+		logrus.Infof("%s vetoed event %s while in %s: %s", transition.Machine, transition.Transition, transition.FromState, transition.Reason)
+		return nil
+	}
+
 	logrus.Infof("%s transitioned via event %s: %s => %s", transition.Machine, transition.Transition, transition.FromState, transition.ToState)
 
 	return nil
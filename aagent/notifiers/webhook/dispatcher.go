@@ -0,0 +1,168 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/aagent/machine"
+	"github.com/choria-io/go-choria/submission"
+)
+
+const contentTypeHeader = "Content-Type"
+
+// Dispatcher delivers webhook requests, when a spool is configured requests are stored
+// in a Submission directory spool and retried with backoff till they succeed or expire
+type Dispatcher struct {
+	spool  submission.Store
+	client *http.Client
+	hooks  map[string]*machine.Webhook
+	log    *logrus.Entry
+	mu     sync.Mutex
+}
+
+// NewDispatcher creates a new dispatcher, when spoolDir is empty requests are attempted once without retries
+func NewDispatcher(spoolDir string, maxSize int, identity string, log *logrus.Entry) (*Dispatcher, error) {
+	d := &Dispatcher{
+		client: &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}},
+		hooks:  make(map[string]*machine.Webhook),
+		log:    log.WithField("component", "webhook"),
+	}
+
+	if spoolDir != "" {
+		spool, err := submission.NewDirectorySpool(spoolDir, maxSize, identity, d.log)
+		if err != nil {
+			return nil, err
+		}
+
+		d.spool = spool
+	}
+
+	return d, nil
+}
+
+// Notifier creates a notifier sending notifications to hooks, prefix distinguishes hooks with the same name added by different machines.
+//
+// The hooks are registered with the dispatcher so that requests spooled before a restart can be delivered
+func (d *Dispatcher) Notifier(prefix string, hooks []*machine.Webhook) *Notifier {
+	n := &Notifier{
+		dispatcher: d,
+		hooks:      hooks,
+		prefix:     prefix,
+	}
+
+	d.mu.Lock()
+	for _, hook := range hooks {
+		d.hooks[n.hookID(hook)] = hook
+	}
+	d.mu.Unlock()
+
+	return n
+}
+
+// Run delivers spooled requests till ctx is canceled
+func (d *Dispatcher) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if d.spool == nil {
+		return
+	}
+
+	wg.Add(1)
+	err := d.spool.StartPoll(ctx, wg, d.handleSpooled)
+	if err != nil {
+		d.log.Errorf("Could not start webhook spool: %v", err)
+	}
+}
+
+func (d *Dispatcher) handleSpooled(msgs []*submission.Message) error {
+	for _, m := range msgs {
+		err := m.Validate()
+		if err != nil {
+			d.log.Warnf("Discarding message %s for webhook %s: %v", m.ID, m.Subject, err)
+			d.spool.Discard(m)
+			continue
+		}
+
+		d.mu.Lock()
+		hook, ok := d.hooks[m.Subject]
+		d.mu.Unlock()
+
+		// the machine owning the hook might not be loaded yet, keep the message till it is or the message expires
+		if !ok {
+			d.log.Debugf("Retaining message %s for webhook %s that is not loaded", m.ID, m.Subject)
+			continue
+		}
+
+		err = d.deliver(context.Background(), hook, m.Payload, m.Headers[contentTypeHeader])
+		if err != nil {
+			d.log.Errorf("Delivering message %s to webhook %s failed on try %d: %v", m.ID, m.Subject, m.Tries+1, err)
+			d.spool.IncrementTries(m)
+			continue
+		}
+
+		d.spool.Complete(m)
+	}
+
+	return nil
+}
+
+// dispatch delivers or spools a request for hook
+func (d *Dispatcher) dispatch(id string, hook *machine.Webhook, body []byte, contentType string) error {
+	if d.spool == nil {
+		go func() {
+			err := d.deliver(context.Background(), hook, body, contentType)
+			if err != nil {
+				d.log.Errorf("Delivering to webhook %s failed: %v", id, err)
+			}
+		}()
+
+		return nil
+	}
+
+	msg := d.spool.NewMessage()
+	msg.Subject = id
+	msg.Payload = body
+	msg.TTL = hook.SpoolTTL().Seconds()
+	msg.Headers = map[string]string{contentTypeHeader: contentType}
+
+	return d.spool.Submit(msg)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, hook *machine.Webhook, body []byte, contentType string) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.RequestTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(contentTypeHeader, contentType)
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1024*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+
+	return nil
+}
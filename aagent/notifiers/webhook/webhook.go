@@ -0,0 +1,171 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webhook is a NotificationService that POSTs notifications to webhooks
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/choria-io/go-choria/aagent/machine"
+)
+
+const (
+	cloudEventContentType = "application/cloudevents+json"
+	templateContentType   = "application/json"
+)
+
+// TemplateData is the data available to webhook body templates
+type TemplateData struct {
+	// Kind is either transition or watcher_state
+	Kind string
+	// Identity is the node hosting the machine
+	Identity string
+	// Machine is the name of the machine
+	Machine string
+	// Transition is set for transition notifications
+	Transition *machine.TransitionNotification
+	// Watcher is the name of the watcher for watcher state notifications
+	Watcher string
+	// WatcherType is the type of watcher for watcher state notifications
+	WatcherType string
+	// State is the watcher state for watcher state notifications
+	State map[string]any
+	// Event is the notification as a CloudEvent in JSON format
+	Event string
+}
+
+// Notifier implements machine.NotificationService
+type Notifier struct {
+	dispatcher *Dispatcher
+	hooks      []*machine.Webhook
+	prefix     string
+}
+
+// Debugf implements machine.NotificationService, logging is left to other notifiers
+func (n *Notifier) Debugf(m machine.InfoSource, name string, format string, args ...any) {}
+
+// Infof implements machine.NotificationService, logging is left to other notifiers
+func (n *Notifier) Infof(m machine.InfoSource, name string, format string, args ...any) {}
+
+// Warnf implements machine.NotificationService, logging is left to other notifiers
+func (n *Notifier) Warnf(m machine.InfoSource, name string, format string, args ...any) {}
+
+// Errorf implements machine.NotificationService, logging is left to other notifiers
+func (n *Notifier) Errorf(m machine.InfoSource, name string, format string, args ...any) {}
+
+// NotifyPostTransition implements machine.NotificationService
+func (n *Notifier) NotifyPostTransition(transition *machine.TransitionNotification) error {
+	var hooks []*machine.Webhook
+	for _, hook := range n.hooks {
+		if hook.MatchesTransition(transition) {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	j, err := json.Marshal(transition.CloudEvent())
+	if err != nil {
+		return fmt.Errorf("could not JSON encode transition notification: %s", err)
+	}
+
+	data := &TemplateData{
+		Kind:       machine.WebhookTransitionEvent,
+		Identity:   transition.Identity,
+		Machine:    transition.Machine,
+		Transition: transition,
+		Event:      string(j),
+	}
+
+	return n.send(hooks, j, data)
+}
+
+// NotifyWatcherState implements machine.NotificationService
+func (n *Notifier) NotifyWatcherState(name string, detail machine.WatcherStateNotification) error {
+	j, err := detail.JSON()
+	if err != nil {
+		return fmt.Errorf("could not JSON encode watcher state: %s", err)
+	}
+
+	machineName := gjson.GetBytes(j, "data.machine").String()
+	wtype := detail.WatcherType()
+
+	var hooks []*machine.Webhook
+	for _, hook := range n.hooks {
+		if hook.MatchesWatcherState(machineName, wtype) {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	state, _ := gjson.GetBytes(j, "data").Value().(map[string]any)
+
+	data := &TemplateData{
+		Kind:        machine.WebhookWatcherStateEvent,
+		Identity:    gjson.GetBytes(j, "data.identity").String(),
+		Machine:     machineName,
+		Watcher:     name,
+		WatcherType: wtype,
+		State:       state,
+		Event:       string(j),
+	}
+
+	return n.send(hooks, j, data)
+}
+
+func (n *Notifier) send(hooks []*machine.Webhook, event []byte, data *TemplateData) error {
+	var errs []error
+
+	for _, hook := range hooks {
+		body, contentType, err := n.body(hook, event, data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %v", hook.Name, err))
+			continue
+		}
+
+		err = n.dispatcher.dispatch(n.hookID(hook), hook, body, contentType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %v", hook.Name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("could not notify webhooks: %v", errs)
+	}
+
+	return nil
+}
+
+func (n *Notifier) body(hook *machine.Webhook, event []byte, data *TemplateData) ([]byte, string, error) {
+	tpl := hook.BodyTemplate()
+	if tpl == nil {
+		return event, cloudEventContentType, nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	err := tpl.Execute(buf, data)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not render body: %v", err)
+	}
+
+	return buf.Bytes(), templateContentType, nil
+}
+
+func (n *Notifier) hookID(hook *machine.Webhook) string {
+	if n.prefix == "" {
+		return hook.Name
+	}
+
+	return fmt.Sprintf("%s.%s", n.prefix, hook.Name)
+}
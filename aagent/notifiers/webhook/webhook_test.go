@@ -0,0 +1,208 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/choria-io/go-choria/aagent/machine"
+	"github.com/choria-io/go-choria/aagent/watchers/event"
+	"github.com/choria-io/go-choria/aagent/watchers/timerwatcher"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AAgent/Notifiers/Webhook")
+}
+
+type request struct {
+	contentType string
+	header      string
+	body        string
+}
+
+var _ = Describe("Webhook", func() {
+	var (
+		srv      *httptest.Server
+		requests chan request
+		fail     atomic.Bool
+		log      *logrus.Entry
+	)
+
+	newHook := func(h *machine.Webhook) *machine.Webhook {
+		h.URL = srv.URL
+		Expect(h.Validate()).To(Succeed())
+		return h
+	}
+
+	transition := &machine.TransitionNotification{
+		Protocol:   "io.choria.machine.v1.transition",
+		Identity:   "ginkgo.example.net",
+		Machine:    "app",
+		Transition: "fail",
+		FromState:  "running",
+		ToState:    "failed",
+		Timestamp:  time.Now().Unix(),
+	}
+
+	BeforeEach(func() {
+		requests = make(chan request, 10)
+		fail.Store(false)
+
+		logger := logrus.New()
+		logger.SetOutput(GinkgoWriter)
+		log = logrus.NewEntry(logger)
+
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if fail.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			requests <- request{contentType: r.Header.Get("Content-Type"), header: r.Header.Get("X-Token"), body: string(body)}
+		}))
+		DeferCleanup(srv.Close)
+	})
+
+	Describe("NotifyPostTransition", func() {
+		It("Should send matching transitions as CloudEvents", func() {
+			d, err := NewDispatcher("", 10, "ginkgo", log)
+			Expect(err).ToNot(HaveOccurred())
+
+			n := d.Notifier("", []*machine.Webhook{
+				newHook(&machine.Webhook{Name: "all", Headers: map[string]string{"X-Token": "secret"}}),
+				newHook(&machine.Webhook{Name: "other", States: []string{"running"}}),
+			})
+
+			Expect(n.NotifyPostTransition(transition)).To(Succeed())
+
+			var req request
+			Eventually(requests).Should(Receive(&req))
+			Expect(req.contentType).To(Equal("application/cloudevents+json"))
+			Expect(req.header).To(Equal("secret"))
+
+			var ce map[string]any
+			Expect(json.Unmarshal([]byte(req.body), &ce)).To(Succeed())
+			Expect(ce["type"]).To(Equal("io.choria.machine.v1.transition"))
+			Expect(ce["data"]).To(HaveKeyWithValue("to_state", "failed"))
+
+			Consistently(requests, 100*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("Should support templated bodies", func() {
+			d, err := NewDispatcher("", 10, "ginkgo", log)
+			Expect(err).ToNot(HaveOccurred())
+
+			n := d.Notifier("", []*machine.Webhook{
+				newHook(&machine.Webhook{Name: "chat", Body: `{"text":{{ printf "%s entered %s on %s" .Machine .Transition.ToState .Identity | ToJSON }}}`}),
+			})
+
+			Expect(n.NotifyPostTransition(transition)).To(Succeed())
+
+			var req request
+			Eventually(requests).Should(Receive(&req))
+			Expect(req.contentType).To(Equal("application/json"))
+			Expect(req.body).To(Equal(`{"text":"app entered failed on ginkgo.example.net"}`))
+		})
+	})
+
+	Describe("NotifyWatcherState", func() {
+		It("Should send matching watcher states", func() {
+			d, err := NewDispatcher("", 10, "ginkgo", log)
+			Expect(err).ToNot(HaveOccurred())
+
+			n := d.Notifier("", []*machine.Webhook{
+				newHook(&machine.Webhook{Name: "timers", WatcherTypes: []string{"timer"}, Body: "{{ .Kind }} {{ .Machine }}#{{ .Watcher }} {{ .State.state }}"}),
+				newHook(&machine.Webhook{Name: "transitions", Events: []string{machine.WebhookTransitionEvent}}),
+			})
+
+			state := &timerwatcher.StateNotification{
+				Event: event.Event{Protocol: "io.choria.machine.watcher.timer.v1.state", Type: "timer", Machine: "app", Name: "wait", Identity: "ginkgo.example.net"},
+				State: "running",
+			}
+
+			Expect(n.NotifyWatcherState("wait", state)).To(Succeed())
+
+			var req request
+			Eventually(requests).Should(Receive(&req))
+			Expect(req.body).To(Equal("watcher_state app#wait running"))
+			Consistently(requests, 100*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+
+	Describe("Spooling", func() {
+		It("Should retry failed deliveries", func() {
+			d, err := NewDispatcher(GinkgoT().TempDir(), 10, "ginkgo", log)
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			wg := &sync.WaitGroup{}
+			DeferCleanup(func() {
+				cancel()
+				wg.Wait()
+			})
+
+			fail.Store(true)
+
+			n := d.Notifier("app", []*machine.Webhook{newHook(&machine.Webhook{Name: "ops"})})
+			Expect(n.NotifyPostTransition(transition)).To(Succeed())
+			Expect(d.hooks).To(HaveKey("app.ops"))
+
+			wg.Add(1)
+			go d.Run(ctx, wg)
+
+			Consistently(requests, 1500*time.Millisecond).ShouldNot(Receive())
+			fail.Store(false)
+
+			var req request
+			Eventually(requests, 10*time.Second).Should(Receive(&req))
+			Expect(req.contentType).To(Equal("application/cloudevents+json"))
+		})
+
+		It("Should deliver requests spooled before a restart", func() {
+			spool := GinkgoT().TempDir()
+			hooks := []*machine.Webhook{newHook(&machine.Webhook{Name: "ops"})}
+
+			d, err := NewDispatcher(spool, 10, "ginkgo", log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Notifier("app", hooks).NotifyPostTransition(transition)).To(Succeed())
+
+			d, err = NewDispatcher(spool, 10, "ginkgo", log)
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			wg := &sync.WaitGroup{}
+			DeferCleanup(func() {
+				cancel()
+				wg.Wait()
+			})
+
+			wg.Add(1)
+			go d.Run(ctx, wg)
+
+			// the machine owning the hook is not loaded yet
+			Consistently(requests, 1500*time.Millisecond).ShouldNot(Receive())
+
+			d.Notifier("app", hooks)
+
+			var req request
+			Eventually(requests, 10*time.Second).Should(Receive(&req))
+			Expect(req.contentType).To(Equal("application/cloudevents+json"))
+			Consistently(requests, 1500*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+})
@@ -0,0 +1,83 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/expr-lang/expr"
+	"github.com/tidwall/gjson"
+
+	"github.com/choria-io/go-choria/aagent/model"
+)
+
+type watcherStater interface {
+	WatcherState(watcher string) (any, bool)
+}
+
+// ExpressionEnv is the environment expressions about a machine are evaluated in
+//
+// It holds the machine data, facts, identity and current state, when the machine can report the
+// state of its watchers the watcher_state function returns the state of a watcher by name
+func ExpressionEnv(m model.Machine) map[string]any {
+	env := map[string]any{
+		"data":     m.Data(),
+		"facts":    m.Facts(),
+		"get_fact": func(query string) any { return gjson.GetBytes(m.Facts(), query).Value() },
+		"identity": m.Identity(),
+		"state":    m.State(),
+		"watcher_state": func(watcher string) any {
+			ws, ok := m.(watcherStater)
+			if !ok {
+				return nil
+			}
+
+			state, ok := ws.WatcherState(watcher)
+			if !ok || state == nil {
+				return nil
+			}
+
+			j, err := json.Marshal(state)
+			if err != nil {
+				return nil
+			}
+
+			var res map[string]any
+			err = json.Unmarshal(j, &res)
+			if err != nil {
+				return nil
+			}
+
+			return res
+		},
+	}
+
+	return env
+}
+
+// EvaluateBoolExpression evaluates e in env and expects a boolean result
+func EvaluateBoolExpression(e string, env map[string]any) (bool, error) {
+	if e == "" {
+		return false, fmt.Errorf("invalid expression")
+	}
+
+	prog, err := expr.Compile(e, expr.Env(env), expr.AsBool())
+	if err != nil {
+		return false, err
+	}
+
+	res, err := expr.Run(prog, env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := res.(bool)
+	if !ok {
+		return false, fmt.Errorf("match was non boolean")
+	}
+
+	return b, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+	"text/template"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	iu "github.com/choria-io/go-choria/internal/util"
+)
+
+// TemplateFuncMap is the set of functions available to templates about machine m, infof is
+// used to log when lookups fall back to their defaults
+func TemplateFuncMap(m model.Machine, infof func(format string, args ...any)) (template.FuncMap, error) {
+	jdata, err := json.Marshal(m.Data())
+	if err != nil {
+		return nil, err
+	}
+
+	input := map[string]json.RawMessage{
+		"facts": m.Facts(),
+		"data":  jdata,
+	}
+
+	jinput, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return iu.FuncMap(map[string]any{
+		"lookup": func(q string, dflt any) any {
+			r := gjson.GetBytes(jinput, q)
+			if !r.Exists() {
+				infof("Query did not match any data, returning default: %s", q)
+
+				return dflt
+			}
+
+			return r.Value()
+		},
+		"peer_lookup": func(identity string, key string, dflt any) any {
+			v, ok := m.PeerData()[identity][key]
+			if !ok {
+				infof("No shared data %s found for peer %s, returning default", key, identity)
+
+				return dflt
+			}
+
+			return v
+		},
+		"peer_data": func(key string) map[string]any {
+			res := map[string]any{}
+			for identity, data := range m.PeerData() {
+				v, ok := data[key]
+				if ok {
+					res[identity] = v
+				}
+			}
+
+			return res
+		},
+		"peers": func() []string {
+			return slices.Sorted(maps.Keys(m.PeerData()))
+		},
+	}), nil
+}
+
+// ProcessTemplate renders s using the functions from TemplateFuncMap
+func ProcessTemplate(m model.Machine, s string, infof func(format string, args ...any)) (string, error) {
+	funcs, err := TemplateFuncMap(m, infof)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := template.New("machine").Funcs(funcs).Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+
+	err = t.Execute(buf, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
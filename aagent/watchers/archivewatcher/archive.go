@@ -5,22 +5,16 @@
 package archive
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
-	"crypto/tls"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/choria-io/go-choria/aagent/model"
 	"github.com/choria-io/go-choria/aagent/util"
+	"github.com/choria-io/go-choria/aagent/watchers/archivewatcher/sources"
 	"github.com/choria-io/go-choria/aagent/watchers/event"
 	"github.com/choria-io/go-choria/aagent/watchers/watcher"
 	"github.com/choria-io/go-choria/build"
@@ -59,21 +53,29 @@ type Properties struct {
 	ArchiveChecksum string `mapstructure:"checksum"`
 	// Creates is a subdirectory that the tarball will create on untar, it has to create a sub directory
 	Creates string
+	// Format is the archive format, one of tgz, tzst or zip, detected from the archive contents when unset
+	Format string
 	// Governor is the optional name of a governor to use for concurrency control
 	Governor string
 	// GovernorTimeout is how long we'll try to access the governor
 	GovernorTimeout time.Duration `mapstructure:"governor_timeout"`
-	// Insecure skips TLS verification on https downloads (not implemented)
+	// Insecure skips TLS verification on https downloads
 	Insecure bool
-	// Password for accessing the source, required when a username is set
+	// Password for accessing the source, required when a username is set, the secret key for S3 sources
 	Password string
-	// Source is a URL to the file being downloaded, only tar.gz format is supported
+	// S3Endpoint is the URL of a S3 compatible object store to use for s3:// sources instead of AWS
+	S3Endpoint string `mapstructure:"s3_endpoint"`
+	// S3Region is the region used when signing requests for s3:// sources
+	S3Region string `mapstructure:"s3_region"`
+	// Source is a URL to the file being downloaded, supports http, https, s3, oci and objstore urls
 	Source string
 	// TargetDirectory is the directory where the tarball will be extracted
 	TargetDirectory string `mapstructure:"target"`
 	// Timeout is how long HTTP operations are allowed to take
 	Timeout time.Duration
-	// Username is the username to use when downloading, Password is required in addition
+	// TLSCA is a file holding PEM encoded certificates used to verify https servers
+	TLSCA string `mapstructure:"tls_ca"`
+	// Username is the username to use when downloading, Password is required in addition, the access key for S3 sources
 	Username string
 	// ContentChecksums a file in the archive made using sha256 used for verification of files in the archive after extraction and on every interval check
 	ContentChecksums string `mapstructure:"verify"`
@@ -91,6 +93,7 @@ type Watcher struct {
 	previousRunTime time.Duration
 	previousSource  string
 	properties      *Properties
+	format          sources.Format
 
 	lastWatch time.Time
 
@@ -283,14 +286,9 @@ func (w *Watcher) extractAndVerifyToTemp(path string) (string, error) {
 		return td, err
 	}
 
-	f, err := os.Open(path)
+	err = sources.Extract(path, td, w.format)
 	if err != nil {
-		return td, err
-	}
-
-	err = w.untar(f, td)
-	if err != nil {
-		return td, fmt.Errorf("untar failed: %s", err)
+		return td, fmt.Errorf("extract failed: %s", err)
 	}
 
 	if w.properties.ContentChecksumsChecksum != "" {
@@ -342,57 +340,6 @@ func (w *Watcher) verify(dir string) error {
 	return nil
 }
 
-func (w *Watcher) untar(s io.Reader, t string) error {
-	uncompressed, err := gzip.NewReader(s)
-	if err != nil {
-		return fmt.Errorf("unzip failed: %s", err)
-	}
-
-	tarReader := tar.NewReader(uncompressed)
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return err
-		}
-
-		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeDir {
-			return fmt.Errorf("only regular files and directories are supported")
-		}
-
-		if strings.Contains(header.Name, "..") {
-			return fmt.Errorf("invalid tar file detected")
-		}
-
-		path := filepath.Join(t, header.Name)
-		if !strings.HasPrefix(path, t) {
-			return fmt.Errorf("invalid tar file detected")
-		}
-
-		nfo := header.FileInfo()
-		if nfo.IsDir() {
-			err = os.MkdirAll(path, nfo.Mode())
-			if err != nil {
-				return err
-			}
-			continue
-		}
-
-		file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, nfo.Mode())
-		if err != nil {
-			return err
-		}
-		_, err = io.Copy(file, tarReader)
-		file.Close()
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 func (w *Watcher) mkTempDir() (string, error) {
 	// aagent loader will ignore tmp directory
 	parent := filepath.Join(w.properties.TargetDirectory, "tmp")
@@ -428,9 +375,29 @@ func (w *Watcher) downloadSourceToTemp(ctx context.Context) (string, error) {
 
 	w.previousSource = source
 
-	uri, err := url.Parse(source)
+	opts := &sources.Options{
+		Insecure:   w.properties.Insecure,
+		CA:         w.properties.TLSCA,
+		S3Endpoint: w.properties.S3Endpoint,
+		S3Region:   w.properties.S3Region,
+		UserAgent:  fmt.Sprintf("Choria Archive Watcher %s", build.Version),
+		JetStream:  w.machine.JetStreamConnection,
+	}
+
+	if w.properties.Username != "" {
+		opts.Username, err = w.ProcessTemplate(w.properties.Username)
+		if err != nil {
+			return "", fmt.Errorf("invalid username template: %v", err)
+		}
+		opts.Password, err = w.ProcessTemplate(w.properties.Password)
+		if err != nil {
+			return "", fmt.Errorf("invalid password template: %v", err)
+		}
+	}
+
+	src, err := sources.New(source, opts)
 	if err != nil {
-		return "", fmt.Errorf("invalid url: %s", err)
+		return "", err
 	}
 
 	td, err := w.mkTempDir()
@@ -441,54 +408,19 @@ func (w *Watcher) downloadSourceToTemp(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("could not create temp directory for unknown reason")
 	}
 
-	tf, err := os.CreateTemp(td, "*-archive.tgz")
+	tf, err := os.CreateTemp(td, "*-archive")
 	if err != nil {
 		os.RemoveAll(td)
 		return "", fmt.Errorf("could not create temp file: %s", err)
 	}
 	defer tf.Close()
 
-	w.Infof("Attempting to download %s to %s", uri.String(), tf.Name())
+	w.Infof("Attempting to download %s to %s", source, tf.Name())
 
 	err = func() error {
-		client := http.Client{}
-
-		if w.properties.Insecure {
-			client.Transport = &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true,
-				},
-			}
-		}
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri.String(), nil)
+		err := src.Fetch(ctx, tf)
 		if err != nil {
-			return fmt.Errorf("request failed: %s", err)
-		}
-		req.Header.Add("User-Agent", fmt.Sprintf("Choria Archive Watcher %s", build.Version))
-
-		if w.properties.Username != "" {
-			user, err := w.ProcessTemplate(w.properties.Username)
-			if err != nil {
-				return fmt.Errorf("invalid username template: %v", err)
-			}
-			pass, err := w.ProcessTemplate(w.properties.Password)
-			if err != nil {
-				return fmt.Errorf("invalid password template: %v", err)
-			}
-
-			req.SetBasicAuth(user, pass)
-		}
-
-		resp, err := client.Do(req)
-		if err != nil {
-			return fmt.Errorf("request failed: %s", err)
-		}
-		defer resp.Body.Close()
-
-		_, err = io.Copy(tf, resp.Body)
-		if err != nil {
-			return fmt.Errorf("request failed: %s", err)
+			return err
 		}
 
 		tf.Close()
@@ -593,6 +525,8 @@ func (w *Watcher) setProperties(props map[string]any) error {
 }
 
 func (w *Watcher) validate() error {
+	var err error
+
 	if w.properties.Source == "" {
 		return fmt.Errorf("source is required")
 	}
@@ -624,6 +558,11 @@ func (w *Watcher) validate() error {
 		w.properties.GovernorTimeout = 5 * time.Minute
 	}
 
+	w.format, err = sources.ParseFormat(w.properties.Format)
+	if err != nil {
+		return err
+	}
+
 	if w.properties.Timeout < 5*time.Second {
 		w.Infof("Setting timeout to minimum 5 seconds")
 		w.properties.Timeout = 5 * time.Second
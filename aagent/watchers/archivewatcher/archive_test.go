@@ -17,6 +17,7 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"github.com/choria-io/go-choria/aagent/model"
+	"github.com/choria-io/go-choria/aagent/watchers/archivewatcher/sources"
 )
 
 func TestMachine(t *testing.T) {
@@ -82,6 +83,19 @@ var _ = Describe("AAgent/Watchers/MachinesWatcher", func() {
 		})
 	})
 
+	Describe("validate", func() {
+		It("Should validate the format", func() {
+			Expect(w.format).To(Equal(sources.FormatAuto))
+
+			w.properties.Format = "rar"
+			Expect(w.validate()).To(MatchError(`unsupported archive format "rar"`))
+
+			w.properties.Format = "tar.zst"
+			Expect(w.validate()).To(Succeed())
+			Expect(w.format).To(Equal(sources.FormatTarZstd))
+		})
+	})
+
 	Describe("verify", func() {
 		BeforeEach(func() {
 			w.properties.ContentChecksums = "SHA256SUMS"
@@ -0,0 +1,243 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format is a supported archive format
+type Format string
+
+const (
+	// FormatAuto detects the format from the archive contents
+	FormatAuto Format = ""
+	// FormatTarGzip is a gzip compressed tar archive
+	FormatTarGzip Format = "tgz"
+	// FormatTarZstd is a zstd compressed tar archive
+	FormatTarZstd Format = "tzst"
+	// FormatZip is a zip archive
+	FormatZip Format = "zip"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte{0x50, 0x4b, 0x03, 0x04}
+)
+
+// ParseFormat parses a format name, accepting common aliases and file extensions
+func ParseFormat(f string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(f, ".")) {
+	case "", "auto":
+		return FormatAuto, nil
+	case "tgz", "tar.gz", "gzip":
+		return FormatTarGzip, nil
+	case "tzst", "tar.zst", "tar.zstd", "zstd":
+		return FormatTarZstd, nil
+	case "zip":
+		return FormatZip, nil
+	default:
+		return FormatAuto, fmt.Errorf("unsupported archive format %q", f)
+	}
+}
+
+// DetectFormat determines the format of an archive from its leading bytes
+func DetectFormat(file string) (Format, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return FormatAuto, err
+	}
+	defer f.Close()
+
+	head, err := bufio.NewReader(f).Peek(4)
+	if err != nil && err != io.EOF {
+		return FormatAuto, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return FormatTarGzip, nil
+	case bytes.HasPrefix(head, zstdMagic):
+		return FormatTarZstd, nil
+	case bytes.HasPrefix(head, zipMagic):
+		return FormatZip, nil
+	default:
+		return FormatAuto, fmt.Errorf("could not detect archive format")
+	}
+}
+
+// Extract extracts file into the target directory, only regular files and directories
+// are supported and entries resolving outside of target are rejected
+func Extract(file string, target string, format Format) error {
+	var err error
+
+	if format == FormatAuto {
+		format, err = DetectFormat(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch format {
+	case FormatZip:
+		return unzip(file, target)
+
+	case FormatTarGzip, FormatTarZstd:
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		var r io.Reader
+		if format == FormatTarGzip {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return fmt.Errorf("gunzip failed: %s", err)
+			}
+			defer gz.Close()
+			r = gz
+		} else {
+			zr, err := zstd.NewReader(f)
+			if err != nil {
+				return fmt.Errorf("zstd decompression failed: %s", err)
+			}
+			defer zr.Close()
+			r = zr
+		}
+
+		return untar(r, target)
+
+	default:
+		return fmt.Errorf("unsupported archive format %q", format)
+	}
+}
+
+func entryPath(target string, name string) (string, error) {
+	if strings.Contains(name, "..") {
+		return "", fmt.Errorf("invalid archive detected")
+	}
+
+	path := filepath.Join(target, name)
+	if !strings.HasPrefix(path, target) {
+		return "", fmt.Errorf("invalid archive detected")
+	}
+
+	return path, nil
+}
+
+func writeFile(path string, mode fs.FileMode, r io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	file.Close()
+
+	return err
+}
+
+func untar(s io.Reader, t string) error {
+	tarReader := tar.NewReader(s)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeDir {
+			return fmt.Errorf("only regular files and directories are supported")
+		}
+
+		path, err := entryPath(t, header.Name)
+		if err != nil {
+			return err
+		}
+
+		nfo := header.FileInfo()
+		if nfo.IsDir() {
+			err = os.MkdirAll(path, nfo.Mode())
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = writeFile(path, nfo.Mode(), tarReader)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unzip(file string, t string) error {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return fmt.Errorf("unzip failed: %s", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+		if !mode.IsRegular() && !mode.IsDir() {
+			return fmt.Errorf("only regular files and directories are supported")
+		}
+
+		path, err := entryPath(t, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if mode.IsDir() {
+			err = os.MkdirAll(path, mode.Perm()|0700)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = func() error {
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+
+			perm := mode.Perm()
+			if perm == 0 {
+				perm = 0600
+			}
+
+			return writeFile(path, perm, rc)
+		}()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Extract", func() {
+	var (
+		td    string
+		files map[string]string
+	)
+
+	BeforeEach(func() {
+		td = GinkgoT().TempDir()
+		files = map[string]string{
+			"plugin/":           "",
+			"plugin/SHA256SUMS": "sums",
+			"plugin/agent.ddl":  "ddl",
+		}
+	})
+
+	writeTar := func(w io.Writer) {
+		tw := tar.NewWriter(w)
+		for _, name := range []string{"plugin/", "plugin/SHA256SUMS", "plugin/agent.ddl"} {
+			hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}
+			if files[name] == "" {
+				hdr.Typeflag = tar.TypeDir
+				hdr.Mode = 0755
+			}
+			Expect(tw.WriteHeader(hdr)).To(Succeed())
+			_, err := tw.Write([]byte(files[name]))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+	}
+
+	create := func(format Format, names ...string) string {
+		file, err := os.CreateTemp(td, "archive")
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		switch format {
+		case FormatTarGzip:
+			gz := gzip.NewWriter(file)
+			writeTar(gz)
+			Expect(gz.Close()).To(Succeed())
+
+		case FormatTarZstd:
+			zw, err := zstd.NewWriter(file)
+			Expect(err).ToNot(HaveOccurred())
+			writeTar(zw)
+			Expect(zw.Close()).To(Succeed())
+
+		case FormatZip:
+			zw := zip.NewWriter(file)
+			if len(names) == 0 {
+				names = []string{"plugin/", "plugin/SHA256SUMS", "plugin/agent.ddl"}
+			}
+			for _, name := range names {
+				w, err := zw.Create(name)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = w.Write([]byte(files[name]))
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(zw.Close()).To(Succeed())
+		}
+
+		return file.Name()
+	}
+
+	DescribeTable("Supported formats",
+		func(format Format) {
+			archive := create(format)
+
+			detected, err := DetectFormat(archive)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(detected).To(Equal(format))
+
+			for _, f := range []Format{FormatAuto, format} {
+				target := filepath.Join(td, "target"+string(f))
+				Expect(Extract(archive, target, f)).To(Succeed())
+				Expect(os.ReadFile(filepath.Join(target, "plugin", "SHA256SUMS"))).To(Equal([]byte("sums")))
+				Expect(os.ReadFile(filepath.Join(target, "plugin", "agent.ddl"))).To(Equal([]byte("ddl")))
+			}
+		},
+		Entry("tgz", FormatTarGzip),
+		Entry("tzst", FormatTarZstd),
+		Entry("zip", FormatZip),
+	)
+
+	It("Should reject entries outside of the target", func() {
+		files["../evil"] = "evil"
+		archive := create(FormatZip, "../evil")
+
+		Expect(Extract(archive, filepath.Join(td, "target"), FormatAuto)).To(MatchError("invalid archive detected"))
+		Expect(filepath.Join(td, "evil")).ToNot(BeAnExistingFile())
+	})
+
+	It("Should fail for unknown formats", func() {
+		file := filepath.Join(td, "archive.rar")
+		Expect(os.WriteFile(file, []byte("Rar!"), 0600)).To(Succeed())
+
+		Expect(Extract(file, td, FormatAuto)).To(MatchError("could not detect archive format"))
+		Expect(Extract(file, td, FormatTarGzip)).To(MatchError(ContainSubstring("gunzip failed")))
+	})
+
+	It("Should parse formats", func() {
+		Expect(ParseFormat("")).To(Equal(FormatAuto))
+		Expect(ParseFormat(".tar.gz")).To(Equal(FormatTarGzip))
+		Expect(ParseFormat("tar.zst")).To(Equal(FormatTarZstd))
+		Expect(ParseFormat("ZIP")).To(Equal(FormatZip))
+
+		_, err := ParseFormat("rar")
+		Expect(err).To(MatchError(`unsupported archive format "rar"`))
+	})
+})
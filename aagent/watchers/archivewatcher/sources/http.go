@@ -0,0 +1,57 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type httpSource struct {
+	uri    *url.URL
+	opts   *Options
+	client *http.Client
+}
+
+func newHTTPSource(u *url.URL, opts *Options) (*httpSource, error) {
+	client, err := opts.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpSource{uri: u, opts: opts, client: client}, nil
+}
+
+func (s *httpSource) Fetch(ctx context.Context, w io.Writer) error {
+	req, err := s.opts.newRequest(ctx, s.uri.String())
+	if err != nil {
+		return err
+	}
+
+	if s.opts.Username != "" {
+		req.SetBasicAuth(s.opts.Username, s.opts.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	err = checkResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return fmt.Errorf("request failed: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// objectStoreSource fetches files from a JetStream Object Store bucket using the
+// machine connection, sources are in the form objstore://BUCKET/name
+type objectStoreSource struct {
+	bucket string
+	name   string
+	opts   *Options
+}
+
+func newObjectStoreSource(u *url.URL, opts *Options) (*objectStoreSource, error) {
+	name := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || name == "" {
+		return nil, fmt.Errorf("object store sources should be in the form objstore://BUCKET/name")
+	}
+
+	if opts.JetStream == nil {
+		return nil, fmt.Errorf("object store sources require a JetStream connection")
+	}
+
+	return &objectStoreSource{bucket: u.Host, name: name, opts: opts}, nil
+}
+
+func (s *objectStoreSource) Fetch(ctx context.Context, w io.Writer) error {
+	mgr, err := s.opts.JetStream()
+	if err != nil {
+		return fmt.Errorf("could not connect to JetStream: %s", err)
+	}
+
+	js, err := mgr.NatsConn().JetStream()
+	if err != nil {
+		return err
+	}
+
+	obs, err := js.ObjectStore(s.bucket)
+	if err != nil {
+		return fmt.Errorf("could not load object store %s: %s", s.bucket, err)
+	}
+
+	res, err := obs.Get(s.name, nats.Context(ctx))
+	if err != nil {
+		return fmt.Errorf("could not get %s from object store %s: %s", s.name, s.bucket, err)
+	}
+	defer res.Close()
+
+	// the object result verifies the digest once fully read
+	_, err = io.Copy(w, res)
+	if err != nil {
+		return fmt.Errorf("could not read %s from object store %s: %s", s.name, s.bucket, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/nats-io/jsm.go"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Object Store", func() {
+	var opts *Options
+
+	BeforeEach(func() {
+		srv, err := server.NewServer(&server.Options{
+			JetStream: true,
+			StoreDir:  GinkgoT().TempDir(),
+			Port:      -1,
+			Host:      "localhost",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		go srv.Start()
+		Expect(srv.ReadyForConnections(10 * time.Second)).To(BeTrue())
+		DeferCleanup(srv.Shutdown)
+
+		nc, err := nats.Connect(srv.ClientURL())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(nc.Close)
+
+		js, err := nc.JetStream()
+		Expect(err).ToNot(HaveOccurred())
+
+		obs, err := js.CreateObjectStore(&nats.ObjectStoreConfig{Bucket: "ARCHIVES"})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = obs.PutBytes("plugins/plugin.tgz", []byte("archive"))
+		Expect(err).ToNot(HaveOccurred())
+
+		opts = &Options{JetStream: func() (*jsm.Manager, error) { return jsm.New(nc) }}
+	})
+
+	It("Should fetch files from the bucket", func() {
+		buf := &bytes.Buffer{}
+		src, err := New("objstore://ARCHIVES/plugins/plugin.tgz", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(src.Fetch(context.Background(), buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("archive"))
+	})
+
+	It("Should handle missing files and buckets", func() {
+		src, err := New("objstore://ARCHIVES/missing.tgz", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(src.Fetch(context.Background(), &bytes.Buffer{})).To(MatchError(ContainSubstring("could not get missing.tgz from object store ARCHIVES")))
+
+		src, err = New("objstore://MISSING/plugin.tgz", opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(src.Fetch(context.Background(), &bytes.Buffer{})).To(MatchError(ContainSubstring("could not load object store MISSING")))
+	})
+})
@@ -0,0 +1,278 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	ociManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	ociTitleAnnotation      = "org.opencontainers.image.title"
+)
+
+var challengeParamRe = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// ociSource fetches a single layer of an artifact stored in an OCI registry, sources are
+// in the form oci://registry/repository:tag or oci://registry/repository@digest with an
+// optional #title fragment selecting the layer by its title annotation
+type ociSource struct {
+	registry   string
+	repository string
+	reference  string
+	title      string
+	client     *http.Client
+	opts       *Options
+	auth       string
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+func newOCISource(u *url.URL, opts *Options) (*ociSource, error) {
+	repo := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || repo == "" {
+		return nil, fmt.Errorf("oci sources should be in the form oci://registry/repository:tag")
+	}
+
+	client, err := opts.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	src := &ociSource{
+		registry: u.Host,
+		title:    u.Fragment,
+		client:   client,
+		opts:     opts,
+	}
+
+	switch {
+	case strings.Contains(repo, "@"):
+		parts := strings.SplitN(repo, "@", 2)
+		src.repository, src.reference = parts[0], parts[1]
+	case strings.LastIndex(repo, ":") > strings.LastIndex(repo, "/"):
+		idx := strings.LastIndex(repo, ":")
+		src.repository, src.reference = repo[:idx], repo[idx+1:]
+	default:
+		src.repository, src.reference = repo, "latest"
+	}
+
+	if src.repository == "" || src.reference == "" {
+		return nil, fmt.Errorf("invalid oci reference %q", repo)
+	}
+
+	return src, nil
+}
+
+func (s *ociSource) Fetch(ctx context.Context, w io.Writer) error {
+	manifest, err := s.manifest(ctx)
+	if err != nil {
+		return err
+	}
+
+	layer, err := s.selectLayer(manifest)
+	if err != nil {
+		return err
+	}
+
+	algo, expected, ok := strings.Cut(layer.Digest, ":")
+	if !ok || algo != "sha256" {
+		return fmt.Errorf("unsupported layer digest %q", layer.Digest)
+	}
+
+	resp, err := s.get(ctx, fmt.Sprintf("https://%s/v2/%s/blobs/%s", s.registry, s.repository, layer.Digest), "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(w, h), resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not fetch layer %s: %s", layer.Digest, err)
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != expected {
+		return fmt.Errorf("layer %s failed digest verification", layer.Digest)
+	}
+
+	return nil
+}
+
+func (s *ociSource) manifest(ctx context.Context) (*ociManifest, error) {
+	resp, err := s.get(ctx, fmt.Sprintf("https://%s/v2/%s/manifests/%s", s.registry, s.repository, s.reference), strings.Join([]string{ociManifestMediaType, dockerManifestMediaType}, ", "))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	manifest := &ociManifest{}
+	err = json.NewDecoder(resp.Body).Decode(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest for %s:%s: %s", s.repository, s.reference, err)
+	}
+
+	return manifest, nil
+}
+
+func (s *ociSource) selectLayer(m *ociManifest) (*ociDescriptor, error) {
+	if len(m.Layers) == 0 {
+		return nil, fmt.Errorf("manifest for %s:%s has no layers", s.repository, s.reference)
+	}
+
+	if s.title == "" {
+		return &m.Layers[0], nil
+	}
+
+	for i, l := range m.Layers {
+		if l.Annotations[ociTitleAnnotation] == s.title {
+			return &m.Layers[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no layer titled %q found in %s:%s", s.title, s.repository, s.reference)
+}
+
+// get performs a request, authenticating and retrying once if the registry requests it
+func (s *ociSource) get(ctx context.Context, uri string, accept string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := s.opts.newRequest(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if s.auth != "" {
+			req.Header.Set("Authorization", s.auth)
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %s", err)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+
+			s.auth, err = s.authenticate(ctx, challenge)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		err = checkResponse(resp)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+
+		return resp, nil
+	}
+}
+
+// authenticate handles Basic and Bearer token challenges and returns the Authorization header to use
+func (s *ociSource) authenticate(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if s.opts.Username == "" {
+			return "", fmt.Errorf("registry %s requires authentication", s.registry)
+		}
+
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(s.opts.Username+":"+s.opts.Password)), nil
+
+	case "bearer":
+		opts := map[string]string{}
+		for _, m := range challengeParamRe.FindAllStringSubmatch(params, -1) {
+			opts[strings.ToLower(m[1])] = m[2]
+		}
+
+		if opts["realm"] == "" {
+			return "", fmt.Errorf("registry %s sent a bearer challenge without a realm", s.registry)
+		}
+
+		tu, err := url.Parse(opts["realm"])
+		if err != nil {
+			return "", fmt.Errorf("invalid token realm: %s", err)
+		}
+
+		q := tu.Query()
+		if opts["service"] != "" {
+			q.Set("service", opts["service"])
+		}
+		scope := opts["scope"]
+		if scope == "" {
+			scope = fmt.Sprintf("repository:%s:pull", s.repository)
+		}
+		q.Set("scope", scope)
+		tu.RawQuery = q.Encode()
+
+		req, err := s.opts.newRequest(ctx, tu.String())
+		if err != nil {
+			return "", err
+		}
+		if s.opts.Username != "" {
+			req.SetBasicAuth(s.opts.Username, s.opts.Password)
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("token request failed: %s", err)
+		}
+		defer resp.Body.Close()
+
+		err = checkResponse(resp)
+		if err != nil {
+			return "", fmt.Errorf("token request failed: %s", err)
+		}
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&token)
+		if err != nil {
+			return "", fmt.Errorf("invalid token response: %s", err)
+		}
+
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		if token.Token == "" {
+			return "", fmt.Errorf("registry %s did not issue a token", s.registry)
+		}
+
+		return "Bearer " + token.Token, nil
+
+	default:
+		return "", fmt.Errorf("registry %s requires unsupported authentication %q", s.registry, scheme)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OCI", func() {
+	var (
+		srv    *httptest.Server
+		blobs  map[string][]byte
+		ca     string
+		layers []ociDescriptor
+	)
+
+	addLayer := func(title string, body string) {
+		digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(body)))
+		blobs[digest] = []byte(body)
+		layers = append(layers, ociDescriptor{
+			MediaType:   "application/vnd.oci.image.layer.v1.tar+gzip",
+			Digest:      digest,
+			Size:        int64(len(body)),
+			Annotations: map[string]string{ociTitleAnnotation: title},
+		})
+	}
+
+	BeforeEach(func() {
+		blobs = map[string][]byte{}
+		layers = nil
+
+		srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/token":
+				user, pass, _ := r.BasicAuth()
+				if user != "bob" || pass != "secret" || r.URL.Query().Get("scope") != "repository:choria/plugin:pull" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]string{"token": "t0ken"})
+				return
+
+			case r.Header.Get("Authorization") != "Bearer t0ken":
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="https://%s/token",service="ginkgo",scope="repository:choria/plugin:pull"`, r.Host))
+				w.WriteHeader(http.StatusUnauthorized)
+
+			case r.URL.Path == "/v2/choria/plugin/manifests/1.0.0":
+				Expect(r.Header.Get("Accept")).To(ContainSubstring(ociManifestMediaType))
+				w.Header().Set("Content-Type", ociManifestMediaType)
+				json.NewEncoder(w).Encode(ociManifest{MediaType: ociManifestMediaType, Layers: layers})
+
+			case strings.HasPrefix(r.URL.Path, "/v2/choria/plugin/blobs/"):
+				blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/choria/plugin/blobs/")]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write(blob)
+
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(srv.Close)
+
+		ca = writeCA(srv)
+	})
+
+	source := func(ref string) Source {
+		src, err := New(fmt.Sprintf("oci://%s/%s", strings.TrimPrefix(srv.URL, "https://"), ref), &Options{Username: "bob", Password: "secret", CA: ca})
+		Expect(err).ToNot(HaveOccurred())
+		return src
+	}
+
+	It("Should parse references", func() {
+		src, err := New("oci://registry.example.net/choria/plugin", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(src.(*ociSource).repository).To(Equal("choria/plugin"))
+		Expect(src.(*ociSource).reference).To(Equal("latest"))
+
+		src, err = New("oci://registry.example.net:5000/choria/plugin@sha256:abc#plugin.zip", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(src.(*ociSource).registry).To(Equal("registry.example.net:5000"))
+		Expect(src.(*ociSource).repository).To(Equal("choria/plugin"))
+		Expect(src.(*ociSource).reference).To(Equal("sha256:abc"))
+		Expect(src.(*ociSource).title).To(Equal("plugin.zip"))
+	})
+
+	It("Should fetch the first layer using token authentication", func() {
+		addLayer("plugin.tgz", "tgz archive")
+		addLayer("plugin.zip", "zip archive")
+
+		buf := &bytes.Buffer{}
+		Expect(source("choria/plugin:1.0.0").Fetch(context.Background(), buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("tgz archive"))
+	})
+
+	It("Should select layers by title", func() {
+		addLayer("plugin.tgz", "tgz archive")
+		addLayer("plugin.zip", "zip archive")
+
+		buf := &bytes.Buffer{}
+		Expect(source("choria/plugin:1.0.0#plugin.zip").Fetch(context.Background(), buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("zip archive"))
+
+		Expect(source("choria/plugin:1.0.0#plugin.rar").Fetch(context.Background(), buf)).To(MatchError(`no layer titled "plugin.rar" found in choria/plugin:1.0.0`))
+	})
+
+	It("Should verify the layer digest", func() {
+		addLayer("plugin.tgz", "tgz archive")
+		blobs[layers[0].Digest] = []byte("tampered")
+
+		Expect(source("choria/plugin:1.0.0").Fetch(context.Background(), &bytes.Buffer{})).To(MatchError(fmt.Sprintf("layer %s failed digest verification", layers[0].Digest)))
+	})
+
+	It("Should fail without valid credentials", func() {
+		addLayer("plugin.tgz", "tgz archive")
+
+		src, err := New(fmt.Sprintf("oci://%s/choria/plugin:1.0.0", strings.TrimPrefix(srv.URL, "https://")), &Options{CA: ca})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(src.Fetch(context.Background(), &bytes.Buffer{})).To(MatchError(ContainSubstring("token request failed: request failed: 401 Unauthorized")))
+	})
+})
@@ -0,0 +1,246 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3DefaultRegion   = "us-east-1"
+	s3EmptyBodySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	sigV4Algorithm    = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat   = "20060102T150405Z"
+	sigV4DateFormat   = "20060102"
+)
+
+// s3Source fetches objects from AWS S3 or compatible stores like MinIO, requests are signed
+// using AWS Signature Version 4 when credentials are known else made anonymously
+type s3Source struct {
+	bucket string
+	key    string
+	url    string
+	client *http.Client
+	opts   *Options
+	signer *sigV4Signer
+}
+
+type sigV4Signer struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+	service      string
+}
+
+func newS3Source(u *url.URL, opts *Options) (*s3Source, error) {
+	bucket := u.Host
+	key := strings.TrimPrefix(u.Path, "/")
+
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("s3 sources should be in the form s3://bucket/key")
+	}
+
+	client, err := opts.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	region := opts.S3Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = s3DefaultRegion
+	}
+
+	src := &s3Source{
+		bucket: bucket,
+		key:    key,
+		client: client,
+		opts:   opts,
+	}
+
+	src.url, err = s3ObjectURL(opts.S3Endpoint, region, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	accessKey, secretKey, token := opts.Username, opts.Password, ""
+	if accessKey == "" {
+		accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		token = os.Getenv("AWS_SESSION_TOKEN")
+	}
+
+	if accessKey != "" {
+		src.signer = &sigV4Signer{
+			accessKey:    accessKey,
+			secretKey:    secretKey,
+			sessionToken: token,
+			region:       region,
+			service:      "s3",
+		}
+	}
+
+	return src, nil
+}
+
+// s3ObjectURL uses path style access for custom endpoints as most S3 compatible stores
+// support that, and virtual hosted style against AWS
+func s3ObjectURL(endpoint string, region string, bucket string, key string) (string, error) {
+	if endpoint == "" {
+		host := "s3.amazonaws.com"
+		if region != s3DefaultRegion {
+			host = fmt.Sprintf("s3.%s.amazonaws.com", region)
+		}
+
+		return fmt.Sprintf("https://%s.%s/%s", bucket, host, awsURIEscape(key, false)), nil
+	}
+
+	eu, err := url.Parse(endpoint)
+	if err != nil || eu.Host == "" || (eu.Scheme != "http" && eu.Scheme != "https") {
+		return "", fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+
+	return fmt.Sprintf("%s://%s/%s/%s", eu.Scheme, eu.Host, bucket, awsURIEscape(key, false)), nil
+}
+
+func (s *s3Source) Fetch(ctx context.Context, w io.Writer) error {
+	req, err := s.opts.newRequest(ctx, s.url)
+	if err != nil {
+		return err
+	}
+
+	if s.signer != nil {
+		s.signer.sign(req, time.Now())
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	err = checkResponse(resp)
+	if err != nil {
+		return fmt.Errorf("could not fetch s3://%s/%s: %s", s.bucket, s.key, err)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		return fmt.Errorf("request failed: %s", err)
+	}
+
+	return nil
+}
+
+// sign adds the AWS Signature Version 4 authorization headers to a body-less request
+func (s *sigV4Signer) sign(req *http.Request, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", now.Format(sigV4DateFormat), s.region, s.service)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3EmptyBodySHA256)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "range" || lk == "content-type" || strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsURIEscape(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3EmptyBodySHA256,
+	}, "\n")
+
+	crHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, hex.EncodeToString(crHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format(sigV4DateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", sigV4Algorithm, s.accessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vals := q[k]
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, awsURIEscape(k, true)+"="+awsURIEscape(v, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// awsURIEscape escapes s as required by signature version 4, slashes are retained unless encodeSlash is set
+func awsURIEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sources retrieves archives from HTTP(S) servers, S3 compatible object stores,
+// OCI registries and JetStream Object Stores and extracts them
+package sources
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/nats-io/jsm.go"
+)
+
+// Source retrieves an archive
+type Source interface {
+	// Fetch writes the archive to w
+	Fetch(ctx context.Context, w io.Writer) error
+}
+
+// Options configure how sources are accessed
+type Options struct {
+	// Username is the HTTP basic auth or OCI registry username, or the S3 access key
+	Username string
+	// Password is the HTTP basic auth or OCI registry password, or the S3 secret key
+	Password string
+	// Insecure skips TLS verification
+	Insecure bool
+	// CA is a file holding PEM encoded certificates used to verify TLS servers
+	CA string
+	// S3Endpoint is the URL of a S3 compatible service, defaults to AWS
+	S3Endpoint string
+	// S3Region is the region objects are stored in, defaults to us-east-1
+	S3Region string
+	// UserAgent is sent with HTTP requests
+	UserAgent string
+	// JetStream provides the connection used for Object Store sources
+	JetStream func() (*jsm.Manager, error)
+}
+
+// New creates a Source for the uri based on its scheme, http, https, s3, oci and objstore are supported
+func New(uri string, opts *Options) (Source, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %s", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return newHTTPSource(u, opts)
+	case "s3":
+		return newS3Source(u, opts)
+	case "oci":
+		return newOCISource(u, opts)
+	case "objstore":
+		return newObjectStoreSource(u, opts)
+	default:
+		return nil, fmt.Errorf("unsupported source scheme %q", u.Scheme)
+	}
+}
+
+func (o *Options) httpClient() (*http.Client, error) {
+	tlsc := &tls.Config{
+		InsecureSkipVerify: o.Insecure,
+	}
+
+	if o.CA != "" {
+		pem, err := os.ReadFile(o.CA)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA: %s", err)
+		}
+
+		tlsc.RootCAs = x509.NewCertPool()
+		if !tlsc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA %s", o.CA)
+		}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsc,
+		},
+	}, nil
+}
+
+func (o *Options) newRequest(ctx context.Context, uri string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %s", err)
+	}
+
+	if o.UserAgent != "" {
+		req.Header.Set("User-Agent", o.UserAgent)
+	}
+
+	return req, nil
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if len(body) > 0 {
+		return fmt.Errorf("request failed: %s: %s", resp.Status, body)
+	}
+
+	return fmt.Errorf("request failed: %s", resp.Status)
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AAgent/Watchers/Archive/Sources")
+}
+
+// writeCA stores the certificate of a httptest TLS server in a PEM file
+func writeCA(srv *httptest.Server) string {
+	file := filepath.Join(GinkgoT().TempDir(), "ca.pem")
+	Expect(os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600)).To(Succeed())
+
+	return file
+}
+
+var _ = Describe("Sources", func() {
+	Describe("New", func() {
+		It("Should support known schemes", func() {
+			_, err := New("ftp://example.net/x.tgz", nil)
+			Expect(err).To(MatchError(`unsupported source scheme "ftp"`))
+
+			src, err := New("https://example.net/x.tgz", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src).To(BeAssignableToTypeOf(&httpSource{}))
+
+			src, err = New("s3://bucket/x.tgz", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src).To(BeAssignableToTypeOf(&s3Source{}))
+
+			src, err = New("oci://registry.example.net/choria/plugin:1.0.0", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src).To(BeAssignableToTypeOf(&ociSource{}))
+
+			_, err = New("objstore://ARCHIVES/x.tgz", nil)
+			Expect(err).To(MatchError("object store sources require a JetStream connection"))
+		})
+
+		It("Should fail for invalid CA files", func() {
+			_, err := New("https://example.net/x.tgz", &Options{CA: "/nonexisting"})
+			Expect(err).To(MatchError(ContainSubstring("could not read CA")))
+		})
+	})
+
+	Describe("HTTP", func() {
+		var srv *httptest.Server
+
+		BeforeEach(func() {
+			srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				user, pass, _ := r.BasicAuth()
+				if user != "bob" || pass != "secret" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+
+				w.Write([]byte("archive"))
+			}))
+			DeferCleanup(srv.Close)
+		})
+
+		It("Should verify TLS using the CA", func() {
+			src, err := New(srv.URL+"/x.tgz", &Options{Username: "bob", Password: "secret"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src.Fetch(context.Background(), &bytes.Buffer{})).To(MatchError(ContainSubstring("certificate")))
+
+			buf := &bytes.Buffer{}
+			src, err = New(srv.URL+"/x.tgz", &Options{Username: "bob", Password: "secret", CA: writeCA(srv)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src.Fetch(context.Background(), buf)).To(Succeed())
+			Expect(buf.String()).To(Equal("archive"))
+		})
+
+		It("Should support skipping TLS verification", func() {
+			buf := &bytes.Buffer{}
+			src, err := New(srv.URL+"/x.tgz", &Options{Username: "bob", Password: "secret", Insecure: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src.Fetch(context.Background(), buf)).To(Succeed())
+			Expect(buf.String()).To(Equal("archive"))
+		})
+
+		It("Should fail on error responses", func() {
+			src, err := New(srv.URL+"/x.tgz", &Options{Insecure: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(src.Fetch(context.Background(), &bytes.Buffer{})).To(MatchError("request failed: 403 Forbidden"))
+		})
+	})
+})
@@ -124,6 +124,7 @@ var _ = Describe("AAgent/Watchers/ExpressionsWatcher", func() {
 			machine.EXPECT().Data().Return(map[string]any{"test": 1}).AnyTimes()
 			machine.EXPECT().Facts().Return([]byte(`{"fqdn":"ginkgo.example.net"}`)).AnyTimes()
 			machine.EXPECT().Identity().Return("ginkgo.example.net").AnyTimes()
+			machine.EXPECT().State().Return("ginkgo").AnyTimes()
 
 			w.properties.FailWhen = ""
 			w.properties.SuccessWhen = ""
@@ -141,6 +142,11 @@ var _ = Describe("AAgent/Watchers/ExpressionsWatcher", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(state).To(Equal(SuccessWhen))
 
+			w.properties.SuccessWhen = "state == 'ginkgo'"
+			state, err = w.watch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(SuccessWhen))
+
 			w.properties.SuccessWhen = "data.test == 2"
 			state, err = w.watch()
 			Expect(err).ToNot(HaveOccurred())
@@ -5,40 +5,15 @@
 package expressionwatcher
 
 import (
-	"fmt"
-	"github.com/tidwall/gjson"
-
-	"github.com/expr-lang/expr"
+	"github.com/choria-io/go-choria/aagent/util"
 )
 
 func (w *Watcher) evaluateExpression(e string) (bool, error) {
-	if e == "" {
-		return false, fmt.Errorf("invalid expression")
-	}
-
-	env := map[string]any{
-		"data":     w.machine.Data(),
-		"facts":    w.machine.Facts(),
-		"get_fact": func(query string) any { return gjson.GetBytes(w.machine.Facts(), query).Value() },
-		"identity": w.machine.Identity(),
-	}
-
-	execEnv := expr.Env(env)
-	prog, err := expr.Compile(e, execEnv, expr.AsBool())
+	b, err := util.EvaluateBoolExpression(e, util.ExpressionEnv(w.machine))
 	if err != nil {
 		return false, err
 	}
 
-	res, err := expr.Run(prog, env)
-	if err != nil {
-		return false, err
-	}
-
-	b, ok := res.(bool)
-	if !ok {
-		return false, fmt.Errorf("match was non boolean")
-	}
-
 	w.Debugf("Evaluated expression %q returned: %v", e, b)
 
 	return b, nil
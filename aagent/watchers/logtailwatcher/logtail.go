@@ -0,0 +1,273 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailwatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	"github.com/choria-io/go-choria/aagent/util"
+	"github.com/choria-io/go-choria/aagent/watchers/event"
+	"github.com/choria-io/go-choria/aagent/watchers/watcher"
+	iu "github.com/choria-io/go-choria/internal/util"
+)
+
+type State int
+
+const (
+	Unknown State = iota
+	SuccessMatch
+	FailMatch
+
+	wtype   = "logtail"
+	version = "v1"
+)
+
+var stateNames = map[State]string{
+	Unknown:      "unknown",
+	SuccessMatch: "success",
+	FailMatch:    "fail",
+}
+
+type properties struct {
+	// Path is the file to follow, relative paths are relative to the machine directory
+	Path string
+	// FromStart reads a file that exists at startup from the start rather than only new lines, files created later are always read from the start
+	FromStart bool `mapstructure:"from_start"`
+	// Rules are evaluated in order against every line, the first matching rule handles the line
+	Rules []*Rule
+}
+
+type Watcher struct {
+	*watcher.Watcher
+
+	properties *properties
+	name       string
+	machine    model.Machine
+	interval   time.Duration
+	tail       *tailer
+
+	previous     State
+	previousRule string
+	previousLine string
+
+	terminate chan struct{}
+	mu        *sync.Mutex
+}
+
+func New(machine model.Machine, name string, states []string, required []model.ForeignMachineState, failEvent string, successEvent string, interval string, ai time.Duration, properties map[string]any) (any, error) {
+	var err error
+
+	lw := &Watcher{
+		name:      name,
+		machine:   machine,
+		interval:  time.Second,
+		terminate: make(chan struct{}),
+		mu:        &sync.Mutex{},
+	}
+
+	lw.Watcher, err = watcher.NewWatcher(name, wtype, ai, states, required, machine, failEvent, successEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval != "" {
+		lw.interval, err = iu.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %v", err)
+		}
+	}
+
+	err = lw.setProperties(properties)
+	if err != nil {
+		return nil, fmt.Errorf("could not set properties: %s", err)
+	}
+
+	lw.tail = newTailer(lw.properties.Path, lw.properties.FromStart)
+
+	return lw, nil
+}
+
+func (w *Watcher) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer w.tail.close()
+
+	w.Infof("Log tail watcher for %s starting", w.properties.Path)
+
+	tick := time.NewTicker(w.interval)
+	defer tick.Stop()
+
+	w.performWatch()
+
+	for {
+		select {
+		case <-tick.C:
+			w.performWatch()
+
+		case <-w.StateChangeC():
+			w.performWatch()
+
+		case <-w.terminate:
+			w.Infof("Handling terminate notification")
+			return
+
+		case <-ctx.Done():
+			w.Infof("Stopping on context interrupt")
+			return
+		}
+	}
+}
+
+func (w *Watcher) performWatch() {
+	w.mu.Lock()
+	lines, err := w.tail.lines()
+	w.mu.Unlock()
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		w.Debugf("Log file %s does not exist", w.properties.Path)
+	case err != nil:
+		w.Errorf("Could not read %s: %v", w.properties.Path, err)
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	err = w.handleLines(lines, time.Now())
+	if err != nil {
+		w.Errorf("could not handle watcher event: %s", err)
+	}
+}
+
+// handleLines matches lines against the rules, lines read while the watcher is not active are discarded
+func (w *Watcher) handleLines(lines []string, now time.Time) error {
+	if !w.ShouldWatch() {
+		w.Debugf("Skipping %d lines while inactive", len(lines))
+		return nil
+	}
+
+	for _, line := range lines {
+		rule, captures := w.matchLine(line)
+		if rule == nil {
+			continue
+		}
+
+		for k, v := range captures {
+			err := w.machine.DataPut(k, v)
+			if err != nil {
+				w.Errorf("Could not store captured value %s: %v", k, err)
+			}
+		}
+
+		if !rule.record(now) {
+			continue
+		}
+
+		err := w.handleOutcome(rule, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *Watcher) matchLine(line string) (*Rule, map[string]string) {
+	for _, rule := range w.properties.Rules {
+		matched, captures := rule.match(line)
+		if matched {
+			return rule, captures
+		}
+	}
+
+	return nil, nil
+}
+
+func (w *Watcher) handleOutcome(rule *Rule, line string) error {
+	state := FailMatch
+	if rule.Outcome == "success" {
+		state = SuccessMatch
+	}
+
+	w.mu.Lock()
+	w.previous = state
+	w.previousRule = rule.Name
+	w.previousLine = line
+	w.mu.Unlock()
+
+	w.Infof("Rule %s matched %d times, firing %s event", rule.Name, rule.Count, rule.Outcome)
+
+	w.NotifyWatcherState(w.CurrentState())
+
+	if state == SuccessMatch {
+		return w.SuccessTransition()
+	}
+
+	return w.FailureTransition()
+}
+
+func (w *Watcher) CurrentState() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return &StateNotification{
+		Event:           event.New(w.name, wtype, version, w.machine),
+		Path:            w.properties.Path,
+		PreviousOutcome: stateNames[w.previous],
+		PreviousRule:    w.previousRule,
+		PreviousLine:    w.previousLine,
+	}
+}
+
+func (w *Watcher) Delete() {
+	close(w.terminate)
+}
+
+func (w *Watcher) setProperties(props map[string]any) error {
+	if w.properties == nil {
+		w.properties = &properties{}
+	}
+
+	err := util.ParseMapStructure(props, w.properties)
+	if err != nil {
+		return err
+	}
+
+	return w.validate()
+}
+
+func (w *Watcher) validate() error {
+	if w.interval < 500*time.Millisecond {
+		return fmt.Errorf("interval should be more than 500ms: %v", w.interval)
+	}
+
+	if w.properties.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	if !filepath.IsAbs(w.properties.Path) {
+		w.properties.Path = filepath.Join(w.machine.Directory(), w.properties.Path)
+	}
+
+	if len(w.properties.Rules) == 0 {
+		return fmt.Errorf("at least one rule is required")
+	}
+
+	for _, rule := range w.properties.Rules {
+		err := rule.validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailwatcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AAgent/Watchers/LogTailWatcher")
+}
+
+var _ = Describe("LogTailWatcher", func() {
+	var (
+		mockctl     *gomock.Controller
+		mockMachine *model.MockMachine
+		td          string
+	)
+
+	newWatcher := func(props map[string]any) *Watcher {
+		wi, err := New(mockMachine, "ginkgo", []string{"run"}, nil, "fail", "success", "1s", time.Hour, props)
+		Expect(err).ToNot(HaveOccurred())
+		return wi.(*Watcher)
+	}
+
+	appendFile := func(path string, lines ...string) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+
+		for _, l := range lines {
+			_, err = fmt.Fprintln(f, l)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	}
+
+	BeforeEach(func() {
+		mockctl = gomock.NewController(GinkgoT())
+		mockMachine = model.NewMockMachine(mockctl)
+		td = GinkgoT().TempDir()
+
+		mockMachine.EXPECT().Name().Return("logtail").AnyTimes()
+		mockMachine.EXPECT().Identity().Return("ginkgo").AnyTimes()
+		mockMachine.EXPECT().InstanceID().Return("1234567890").AnyTimes()
+		mockMachine.EXPECT().Version().Return("1.0.0").AnyTimes()
+		mockMachine.EXPECT().TimeStampSeconds().Return(time.Now().Unix()).AnyTimes()
+		mockMachine.EXPECT().Directory().Return(td).AnyTimes()
+		mockMachine.EXPECT().State().Return("run").AnyTimes()
+		mockMachine.EXPECT().Debugf(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockMachine.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockctl.Finish()
+	})
+
+	Describe("setProperties", func() {
+		It("Should validate the properties", func() {
+			_, err := New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{})
+			Expect(err).To(MatchError("could not set properties: path is required"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{"path": "app.log"})
+			Expect(err).To(MatchError("could not set properties: at least one rule is required"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "100ms", time.Hour, map[string]any{"path": "app.log"})
+			Expect(err).To(MatchError("could not set properties: interval should be more than 500ms: 100ms"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{"path": "app.log", "rules": []any{map[string]any{"name": "x"}}})
+			Expect(err).To(MatchError("could not set properties: rule x requires a regex or json matchers"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{"path": "app.log", "rules": []any{map[string]any{"name": "x", "regex": "x", "outcome": "other"}}})
+			Expect(err).To(MatchError(`could not set properties: rule x has an invalid outcome "other", should be success or fail`))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{"path": "app.log", "rules": []any{map[string]any{"name": "x", "regex": "["}}})
+			Expect(err).To(MatchError(ContainSubstring("rule x has an invalid regex")))
+
+			w := newWatcher(map[string]any{
+				"path":  "app.log",
+				"rules": []any{map[string]any{"name": "oom", "regex": "OutOfMemory", "count": 5, "period": "1m"}},
+			})
+			Expect(w.properties.Path).To(Equal(filepath.Join(td, "app.log")))
+			Expect(w.properties.Rules[0].Count).To(Equal(5))
+			Expect(w.properties.Rules[0].Period).To(Equal(time.Minute))
+			Expect(w.properties.Rules[0].Outcome).To(Equal("fail"))
+		})
+	})
+
+	Describe("handleLines", func() {
+		It("Should match regex rules and store captures", func() {
+			w := newWatcher(map[string]any{
+				"path": "app.log",
+				"rules": []any{
+					map[string]any{"name": "started", "regex": `started version (?P<version>\S+)`, "outcome": "success", "data_prefix": "app_"},
+					map[string]any{"name": "panic", "regex": "panic:"},
+				},
+			})
+
+			mockMachine.EXPECT().DataPut("app_version", "1.2.3").Return(nil)
+			mockMachine.EXPECT().NotifyWatcherState("ginkgo", gomock.Any()).Do(func(_ string, s *StateNotification) {
+				Expect(s.PreviousOutcome).To(Equal("success"))
+				Expect(s.PreviousRule).To(Equal("started"))
+				Expect(s.PreviousLine).To(Equal("app started version 1.2.3"))
+			})
+			mockMachine.EXPECT().Transition("success")
+			Expect(w.handleLines([]string{"unrelated", "app started version 1.2.3"}, time.Now())).To(Succeed())
+
+			mockMachine.EXPECT().NotifyWatcherState("ginkgo", gomock.Any())
+			mockMachine.EXPECT().Transition("fail")
+			Expect(w.handleLines([]string{"panic: oops"}, time.Now())).To(Succeed())
+		})
+
+		It("Should match JSON rules", func() {
+			w := newWatcher(map[string]any{
+				"path": "app.log",
+				"rules": []any{
+					map[string]any{"name": "error", "json": map[string]any{"level": "^error$", "msg": "(?P<reason>timeout|refused)"}},
+				},
+			})
+
+			Expect(w.handleLines([]string{`{"level":"info","msg":"timeout"}`, `not json`, `{"level":"error"}`}, time.Now())).To(Succeed())
+
+			mockMachine.EXPECT().DataPut("reason", "refused").Return(nil)
+			mockMachine.EXPECT().NotifyWatcherState("ginkgo", gomock.Any())
+			mockMachine.EXPECT().Transition("fail")
+			Expect(w.handleLines([]string{`{"level":"error","msg":"connection refused"}`}, time.Now())).To(Succeed())
+		})
+
+		It("Should support rate thresholds", func() {
+			w := newWatcher(map[string]any{
+				"path":  "app.log",
+				"rules": []any{map[string]any{"name": "oom", "regex": "OutOfMemory", "count": 3, "period": "1m"}},
+			})
+
+			now := time.Now()
+			Expect(w.handleLines([]string{"OutOfMemory", "OutOfMemory"}, now)).To(Succeed())
+
+			// the earlier matches fall outside the period
+			Expect(w.handleLines([]string{"OutOfMemory", "OutOfMemory"}, now.Add(2*time.Minute))).To(Succeed())
+
+			mockMachine.EXPECT().NotifyWatcherState("ginkgo", gomock.Any())
+			mockMachine.EXPECT().Transition("fail")
+			Expect(w.handleLines([]string{"OutOfMemory"}, now.Add(2*time.Minute+time.Second))).To(Succeed())
+			Expect(w.properties.Rules[0].matches).To(BeEmpty())
+		})
+	})
+
+	Describe("tailer", func() {
+		It("Should follow files across truncation and rotation", func() {
+			path := filepath.Join(td, "app.log")
+			t := newTailer(path, false)
+			defer t.close()
+
+			_, err := t.lines()
+			Expect(err).To(MatchError(os.ErrNotExist))
+
+			appendFile(path, "created")
+			lines, err := t.lines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(Equal([]string{"created"}))
+
+			appendFile(path, "one", "two")
+			lines, err = t.lines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(Equal([]string{"one", "two"}))
+
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = f.WriteString("par")
+			Expect(err).ToNot(HaveOccurred())
+			lines, err = t.lines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(BeEmpty())
+			_, err = f.WriteString("tial\n")
+			Expect(err).ToNot(HaveOccurred())
+			f.Close()
+			lines, err = t.lines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(Equal([]string{"partial"}))
+
+			Expect(os.Truncate(path, 0)).To(Succeed())
+			appendFile(path, "truncated")
+			lines, err = t.lines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(Equal([]string{"truncated"}))
+
+			appendFile(path, "last")
+			Expect(os.Rename(path, path+".1")).To(Succeed())
+			appendFile(path, "rotated")
+			lines, err = t.lines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(Equal([]string{"last", "rotated"}))
+		})
+
+		It("Should skip existing lines in files found at startup", func() {
+			path := filepath.Join(td, "app.log")
+			appendFile(path, "before")
+
+			t := newTailer(path, false)
+			defer t.close()
+
+			lines, err := t.lines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(BeEmpty())
+
+			appendFile(path, "after")
+			lines, err = t.lines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(Equal([]string{"after"}))
+		})
+
+		It("Should read from the start when configured", func() {
+			path := filepath.Join(td, "app.log")
+			appendFile(path, "one")
+
+			t := newTailer(path, true)
+			defer t.close()
+
+			lines, err := t.lines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lines).To(Equal([]string{"one"}))
+		})
+	})
+})
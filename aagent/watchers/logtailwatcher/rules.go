@@ -0,0 +1,145 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailwatcher
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// Rule matches log lines and fires an event once enough lines matched within a period
+type Rule struct {
+	// Name identifies the rule in logs and notifications
+	Name string
+	// Regex is a regular expression matched against the entire line, named groups are stored in machine data
+	Regex string
+	// JSON matches lines holding JSON documents, keys are GJSON paths and values regular expressions the values should match
+	JSON map[string]string
+	// Count is how many lines should match within Period before the event fires, defaults to 1
+	Count int
+	// Period is the window Count matches should happen in, when unset Count matches at any time fires the event
+	Period time.Duration
+	// Outcome is the event to fire, either success or fail, defaults to fail
+	Outcome string
+	// DataPrefix is prepended to the names of captured groups when storing them in machine data
+	DataPrefix string `mapstructure:"data_prefix"`
+
+	regex   *regexp.Regexp
+	json    map[string]*regexp.Regexp
+	matches []time.Time
+}
+
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rules require a name")
+	}
+
+	if r.Regex == "" && len(r.JSON) == 0 {
+		return fmt.Errorf("rule %s requires a regex or json matchers", r.Name)
+	}
+
+	if r.Regex != "" && len(r.JSON) > 0 {
+		return fmt.Errorf("rule %s can only have one of regex or json matchers", r.Name)
+	}
+
+	if r.Count <= 0 {
+		r.Count = 1
+	}
+
+	switch r.Outcome {
+	case "":
+		r.Outcome = "fail"
+	case "success", "fail":
+	default:
+		return fmt.Errorf("rule %s has an invalid outcome %q, should be success or fail", r.Name, r.Outcome)
+	}
+
+	var err error
+
+	if r.Regex != "" {
+		r.regex, err = regexp.Compile(r.Regex)
+		if err != nil {
+			return fmt.Errorf("rule %s has an invalid regex: %v", r.Name, err)
+		}
+	}
+
+	r.json = make(map[string]*regexp.Regexp)
+	for path, re := range r.JSON {
+		r.json[path], err = regexp.Compile(re)
+		if err != nil {
+			return fmt.Errorf("rule %s has an invalid regex for %s: %v", r.Name, path, err)
+		}
+	}
+
+	return nil
+}
+
+// match checks the line against the rule and returns any captured named groups
+func (r *Rule) match(line string) (bool, map[string]string) {
+	captures := make(map[string]string)
+
+	if r.regex != nil {
+		m := r.regex.FindStringSubmatch(line)
+		if m == nil {
+			return false, nil
+		}
+
+		r.capture(r.regex, m, captures)
+
+		return true, captures
+	}
+
+	if !gjson.Valid(line) {
+		return false, nil
+	}
+
+	for path, re := range r.json {
+		v := gjson.Get(line, path)
+		if !v.Exists() {
+			return false, nil
+		}
+
+		m := re.FindStringSubmatch(v.String())
+		if m == nil {
+			return false, nil
+		}
+
+		r.capture(re, m, captures)
+	}
+
+	return true, captures
+}
+
+func (r *Rule) capture(re *regexp.Regexp, m []string, captures map[string]string) {
+	for i, name := range re.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+
+		captures[r.DataPrefix+name] = m[i]
+	}
+}
+
+// record notes a match at t and determines if the threshold was reached, the count resets once reached
+func (r *Rule) record(t time.Time) bool {
+	r.matches = append(r.matches, t)
+
+	if r.Period > 0 {
+		oldest := t.Add(-r.Period)
+		for len(r.matches) > 0 && r.matches[0].Before(oldest) {
+			r.matches = r.matches[1:]
+		}
+	}
+
+	if len(r.matches) >= r.Count {
+		r.matches = nil
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailwatcher
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"github.com/choria-io/go-choria/aagent/watchers/event"
+)
+
+// StateNotification describes the current state of the watcher
+// described by io.choria.machine.watcher.logtail.v1.state
+type StateNotification struct {
+	event.Event
+
+	Path            string `json:"path"`
+	PreviousOutcome string `json:"previous_outcome"`
+	PreviousRule    string `json:"previous_rule,omitempty"`
+	PreviousLine    string `json:"previous_line,omitempty"`
+}
+
+// JSON creates a JSON representation of the notification
+func (s *StateNotification) JSON() ([]byte, error) {
+	return json.Marshal(s.CloudEvent())
+}
+
+// CloudEvent creates a CloudEvent from the state notification
+func (s *StateNotification) CloudEvent() cloudevents.Event {
+	return s.Event.CloudEvent(s)
+}
+
+// String is a string representation of the notification suitable for printing
+func (s *StateNotification) String() string {
+	if s.PreviousRule == "" {
+		return fmt.Sprintf("%s %s#%s path: %s, previous: %s", s.Identity, s.Machine, s.Name, s.Path, s.PreviousOutcome)
+	}
+
+	return fmt.Sprintf("%s %s#%s path: %s, previous: %s by rule %s", s.Identity, s.Machine, s.Name, s.Path, s.PreviousOutcome, s.PreviousRule)
+}
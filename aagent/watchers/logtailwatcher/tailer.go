@@ -0,0 +1,155 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailwatcher
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxLine is the longest partial line that will be buffered while waiting for a line ending
+const maxLine = 64 * 1024
+
+// tailer follows a file across truncation and rotation by polling
+type tailer struct {
+	path      string
+	file      *os.File
+	reader    *bufio.Reader
+	offset    int64
+	partial   string
+	fromStart bool
+}
+
+func newTailer(path string, fromStart bool) *tailer {
+	return &tailer{path: path, fromStart: fromStart}
+}
+
+func (t *tailer) open() error {
+	f, err := os.Open(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// the file did not exist yet so whatever gets written to it once created is new
+		t.fromStart = true
+	}
+	if err != nil {
+		return err
+	}
+
+	t.offset = 0
+	if !t.fromStart {
+		t.offset, err = f.Seek(0, io.SeekEnd)
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	t.file = f
+	t.reader = bufio.NewReader(f)
+	t.partial = ""
+
+	// once a file was found any later files created by rotation are read from their start
+	t.fromStart = true
+
+	return nil
+}
+
+func (t *tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+	}
+
+	t.file = nil
+	t.reader = nil
+}
+
+// lines reads all complete lines written since the previous call
+func (t *tailer) lines() ([]string, error) {
+	if t.file == nil {
+		err := t.open()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lines, err := t.read()
+	if err != nil {
+		t.close()
+		return lines, err
+	}
+
+	pstat, err := os.Stat(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// rotated away and not yet recreated, keep reading the old file till a new one appears
+		return lines, nil
+	}
+	if err != nil {
+		return lines, err
+	}
+
+	fstat, err := t.file.Stat()
+	if err != nil {
+		t.close()
+		return lines, err
+	}
+
+	switch {
+	case !os.SameFile(fstat, pstat):
+		// rotated, the remainder of the old file was read above so we switch to the new one
+		t.close()
+
+		err = t.open()
+		if err != nil {
+			return lines, err
+		}
+
+		more, err := t.read()
+		return append(lines, more...), err
+
+	case pstat.Size() < t.offset:
+		// truncated in place
+		_, err = t.file.Seek(0, io.SeekStart)
+		if err != nil {
+			t.close()
+			return lines, err
+		}
+
+		t.offset = 0
+		t.partial = ""
+		t.reader.Reset(t.file)
+
+		more, err := t.read()
+		return append(lines, more...), err
+	}
+
+	return lines, nil
+}
+
+func (t *tailer) read() ([]string, error) {
+	var lines []string
+
+	for {
+		l, err := t.reader.ReadString('\n')
+		t.offset += int64(len(l))
+
+		if errors.Is(err, io.EOF) {
+			t.partial += l
+			if len(t.partial) > maxLine {
+				lines = append(lines, t.partial)
+				t.partial = ""
+			}
+
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+
+		lines = append(lines, strings.TrimRight(t.partial+l, "\r\n"))
+		t.partial = ""
+	}
+}
@@ -323,6 +323,20 @@ func (mr *MockMachineMockRecorder) OverrideData() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OverrideData", reflect.TypeOf((*MockMachine)(nil).OverrideData))
 }
 
+// PeerData mocks base method.
+func (m *MockMachine) PeerData() map[string]map[string]any {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PeerData")
+	ret0, _ := ret[0].(map[string]map[string]any)
+	return ret0
+}
+
+// PeerData indicates an expected call of PeerData.
+func (mr *MockMachineMockRecorder) PeerData() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerData", reflect.TypeOf((*MockMachine)(nil).PeerData))
+}
+
 // PublishLifecycleEvent mocks base method.
 func (m *MockMachine) PublishLifecycleEvent(t lifecycle.Type, opts ...lifecycle.Option) {
 	m.ctrl.T.Helper()
@@ -68,6 +68,10 @@ type ManagedMachine struct {
 	ArchiveChecksum          string `json:"checksum" yaml:"checksum" mapstructure:"checksum"`
 	Matcher                  string `json:"match" yaml:"match" mapstructure:"match"`
 	Governor                 string `json:"governor" yaml:"governor" mapstructure:"governor"`
+	Format                   string `json:"format" yaml:"format" mapstructure:"format"`
+	TLSCA                    string `json:"tls_ca" yaml:"tls_ca" mapstructure:"tls_ca"`
+	S3Endpoint               string `json:"s3_endpoint" yaml:"s3_endpoint" mapstructure:"s3_endpoint"`
+	S3Region                 string `json:"s3_region" yaml:"s3_region" mapstructure:"s3_region"`
 
 	Interval string `json:"-"`
 	Target   string `json:"-"`
@@ -63,6 +63,10 @@ type ManagedPlugin struct {
 	ArchiveChecksum          string `json:"checksum" yaml:"checksum" mapstructure:"checksum"`
 	Matcher                  string `json:"match,omitempty" yaml:"match" mapstructure:"match"`
 	Governor                 string `json:"governor,omitempty" yaml:"governor" mapstructure:"governor"`
+	Format                   string `json:"format,omitempty" yaml:"format" mapstructure:"format"`
+	TLSCA                    string `json:"tls_ca,omitempty" yaml:"tls_ca" mapstructure:"tls_ca"`
+	S3Endpoint               string `json:"s3_endpoint,omitempty" yaml:"s3_endpoint" mapstructure:"s3_endpoint"`
+	S3Region                 string `json:"s3_region,omitempty" yaml:"s3_region" mapstructure:"s3_region"`
 
 	Interval string `json:"-"`
 	Target   string `json:"-"`
@@ -0,0 +1,177 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package probewatcher
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/choria-io/go-choria/aagent/util"
+)
+
+// maxBody is the most response body that will be read for matching
+const maxBody = 1024 * 1024
+
+func (w *Watcher) probeHTTP(ctx context.Context) (*result, error) {
+	url, err := w.ProcessTemplate(w.properties.URL)
+	if err != nil {
+		return nil, fmt.Errorf("could not process url template: %v", err)
+	}
+
+	w.mu.Lock()
+	w.previousProbe = fmt.Sprintf("%s %s", w.properties.Method, url)
+	w.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, w.properties.Method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range w.properties.Headers {
+		hv, err := w.ProcessTemplate(v)
+		if err != nil {
+			return nil, fmt.Errorf("could not process header %s template: %v", k, err)
+		}
+		req.Header.Set(k, hv)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: w.properties.Insecure},
+		},
+	}
+
+	w.Debugf("Probing %s", url)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return &result{state: CRITICAL, output: fmt.Sprintf("CRITICAL: request failed: %v", err), latency: time.Since(start)}, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	latency := time.Since(start)
+	if err != nil {
+		return &result{state: CRITICAL, output: fmt.Sprintf("CRITICAL: reading response failed: %v", err), latency: latency}, nil
+	}
+
+	res := &result{
+		state:    OK,
+		latency:  latency,
+		perfData: []util.PerfData{{Label: "size", Unit: "B", Value: float64(len(body))}},
+	}
+
+	var failures []string
+
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		failure := w.checkCertExpiry(resp.TLS.PeerCertificates[0].NotAfter, res)
+		if failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+
+	switch {
+	case w.properties.StatusCode > 0 && resp.StatusCode != w.properties.StatusCode:
+		failures = append(failures, fmt.Sprintf("status code %d does not match %d", resp.StatusCode, w.properties.StatusCode))
+	case w.properties.StatusCode == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299):
+		failures = append(failures, fmt.Sprintf("status code %d is not successful", resp.StatusCode))
+	}
+
+	if w.properties.bodyMatch != nil && !w.properties.bodyMatch.Match(body) {
+		failures = append(failures, fmt.Sprintf("body does not match %s", w.properties.BodyMatch))
+	}
+
+	if len(w.properties.JSONMatch) > 0 {
+		failures = append(failures, w.checkJSON(body)...)
+	}
+
+	if len(failures) > 0 {
+		res.state = CRITICAL
+		res.output = fmt.Sprintf("CRITICAL: %s", strings.Join(failures, ", "))
+	} else {
+		res.output = fmt.Sprintf("OK: %s returned %d in %v", url, resp.StatusCode, latency.Round(time.Millisecond))
+	}
+
+	return res, nil
+}
+
+func (w *Watcher) checkJSON(body []byte) []string {
+	if !gjson.ValidBytes(body) {
+		return []string{"body is not valid JSON"}
+	}
+
+	var paths []string
+	for p := range w.properties.JSONMatch {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var failures []string
+	for _, p := range paths {
+		expected := w.properties.JSONMatch[p]
+		r := gjson.GetBytes(body, p)
+		if !r.Exists() {
+			failures = append(failures, fmt.Sprintf("%s is not set", p))
+			continue
+		}
+
+		if r.String() != expected {
+			failures = append(failures, fmt.Sprintf("%s is %q expected %q", p, r.String(), expected))
+		}
+	}
+
+	return failures
+}
+
+func (w *Watcher) checkCertExpiry(notAfter time.Time, res *result) string {
+	res.certExpiry = time.Until(notAfter)
+	res.perfData = append(res.perfData, util.PerfData{Label: "cert_expiry", Unit: "s", Value: res.certExpiry.Round(time.Second).Seconds()})
+
+	if w.properties.CertExpiry > 0 && res.certExpiry < w.properties.CertExpiry {
+		return fmt.Sprintf("certificate expires in %v", res.certExpiry.Round(time.Second))
+	}
+
+	return ""
+}
+
+func (w *Watcher) probeTCP(ctx context.Context) (*result, error) {
+	address, err := w.ProcessTemplate(w.properties.Address)
+	if err != nil {
+		return nil, fmt.Errorf("could not process address template: %v", err)
+	}
+
+	w.mu.Lock()
+	w.previousProbe = fmt.Sprintf("tcp://%s", address)
+	w.mu.Unlock()
+
+	w.Debugf("Connecting to %s", address)
+
+	dialer := &net.Dialer{}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	latency := time.Since(start)
+	if err != nil {
+		return &result{state: CRITICAL, output: fmt.Sprintf("CRITICAL: connection failed: %v", err), latency: latency}, nil
+	}
+	conn.Close()
+
+	return &result{
+		state:   OK,
+		output:  fmt.Sprintf("OK: connected to %s in %v", address, latency.Round(time.Millisecond)),
+		latency: latency,
+	}, nil
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package probewatcher
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	"github.com/choria-io/go-choria/aagent/util"
+	"github.com/choria-io/go-choria/aagent/watchers/event"
+	"github.com/choria-io/go-choria/aagent/watchers/watcher"
+	iu "github.com/choria-io/go-choria/internal/util"
+)
+
+type State int
+
+// states match those of nagios plugins
+const (
+	OK State = iota
+	WARNING
+	CRITICAL
+	UNKNOWN
+	SKIPPED
+	NOTCHECKED
+
+	wtype   = "probe"
+	version = "v1"
+)
+
+var stateNames = map[State]string{
+	OK:       "OK",
+	WARNING:  "WARNING",
+	CRITICAL: "CRITICAL",
+	UNKNOWN:  "UNKNOWN",
+
+	// internal states that do not cause transitions
+	SKIPPED:    "SKIPPED",
+	NOTCHECKED: "NOTCHECKED",
+}
+
+type properties struct {
+	// URL is a http or https URL to request
+	URL string
+	// Address is a host:port to connect to over TCP
+	Address     string
+	Method      string
+	Headers     map[string]string
+	Timeout     time.Duration
+	StatusCode  int               `mapstructure:"status_code"`
+	BodyMatch   string            `mapstructure:"body_match"`
+	JSONMatch   map[string]string `mapstructure:"json_match"`
+	CertExpiry  time.Duration     `mapstructure:"cert_expiry"`
+	Insecure    bool              `mapstructure:"tls_insecure"`
+	Annotations map[string]string
+
+	bodyMatch *regexp.Regexp
+}
+
+// Execution is a historical probe result
+type Execution struct {
+	Executed time.Time       `json:"execute"`
+	Status   int             `json:"status"`
+	PerfData []util.PerfData `json:"perfdata,omitempty"`
+}
+
+// result is the outcome of a single probe
+type result struct {
+	state      State
+	output     string
+	latency    time.Duration
+	certExpiry time.Duration
+	perfData   []util.PerfData
+}
+
+type Watcher struct {
+	*watcher.Watcher
+
+	properties  *properties
+	name        string
+	machine     model.Machine
+	machineName string
+	textFileDir string
+	interval    time.Duration
+
+	previous         State
+	previousProbe    string
+	previousOutput   string
+	previousPerfData []util.PerfData
+	previousRunTime  time.Duration
+	previousCheck    time.Time
+	history          []*Execution
+
+	terminate chan struct{}
+	mu        *sync.Mutex
+}
+
+func New(machine model.Machine, name string, states []string, required []model.ForeignMachineState, failEvent string, successEvent string, interval string, ai time.Duration, properties map[string]any) (any, error) {
+	var err error
+
+	pw := &Watcher{
+		name:        name,
+		machine:     machine,
+		machineName: machine.Name(),
+		textFileDir: machine.TextFileDirectory(),
+		interval:    time.Minute,
+		previous:    NOTCHECKED,
+		history:     []*Execution{},
+		terminate:   make(chan struct{}),
+		mu:          &sync.Mutex{},
+	}
+
+	pw.Watcher, err = watcher.NewWatcher(name, wtype, ai, states, required, machine, failEvent, successEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval != "" {
+		pw.interval, err = iu.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %v", err)
+		}
+	}
+
+	err = pw.setProperties(properties)
+	if err != nil {
+		return nil, fmt.Errorf("could not set properties: %s", err)
+	}
+
+	return pw, nil
+}
+
+func (w *Watcher) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	w.Infof("Probe watcher for %s starting", w.target())
+
+	splay := rand.N(w.interval)
+	w.Infof("Splaying first check by %v", splay)
+
+	select {
+	case <-time.NewTimer(splay).C:
+		w.performWatch(ctx)
+	case <-w.terminate:
+		return
+	case <-ctx.Done():
+		return
+	}
+
+	tick := time.NewTicker(w.interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			w.performWatch(ctx)
+
+		case <-w.StateChangeC():
+			w.performWatch(ctx)
+
+		case <-w.terminate:
+			w.Infof("Handling terminate notification")
+			return
+
+		case <-ctx.Done():
+			w.Infof("Stopping on context interrupt")
+			return
+		}
+	}
+}
+
+func (w *Watcher) performWatch(ctx context.Context) {
+	err := w.handleCheck(w.watch(ctx))
+	if err != nil {
+		w.Errorf("could not handle watcher event: %s", err)
+	}
+}
+
+func (w *Watcher) handleCheck(res *result) error {
+	if res.state == SKIPPED || res.state == NOTCHECKED {
+		return nil
+	}
+
+	w.mu.Lock()
+	w.previous = res.state
+	w.previousOutput = res.output
+	w.previousPerfData = res.perfData
+	w.previousRunTime = res.latency
+
+	if len(w.history) >= 15 {
+		w.history = w.history[1:]
+	}
+	w.history = append(w.history, &Execution{Executed: w.previousCheck, Status: int(res.state), PerfData: res.perfData})
+	w.mu.Unlock()
+
+	w.NotifyWatcherState(w.CurrentState())
+
+	err := updatePromState(w.textFileDir, w, w.machineName, w.name, res.state, res.latency.Seconds(), res.certExpiry.Seconds())
+	if err != nil {
+		w.Errorf("Could not update prometheus: %s", err)
+	}
+
+	if res.state == OK {
+		return w.SuccessTransition()
+	}
+
+	return w.FailureTransition()
+}
+
+func (w *Watcher) watch(ctx context.Context) *result {
+	if !w.ShouldWatch() {
+		return &result{state: SKIPPED}
+	}
+
+	w.mu.Lock()
+	w.previousCheck = time.Now()
+	w.mu.Unlock()
+
+	tctx, cancel := context.WithTimeout(ctx, w.properties.Timeout)
+	defer cancel()
+
+	var res *result
+	var err error
+
+	if w.properties.URL != "" {
+		res, err = w.probeHTTP(tctx)
+	} else {
+		res, err = w.probeTCP(tctx)
+	}
+	if err != nil {
+		w.Errorf("Probe failed: %s", err)
+		return &result{state: UNKNOWN, output: fmt.Sprintf("UNKNOWN: %v", err)}
+	}
+
+	res.perfData = append([]util.PerfData{{Label: "time", Unit: "s", Value: res.latency.Seconds()}}, res.perfData...)
+
+	return res
+}
+
+func (w *Watcher) target() string {
+	if w.properties.URL != "" {
+		return fmt.Sprintf("%s %s", w.properties.Method, w.properties.URL)
+	}
+
+	return fmt.Sprintf("tcp://%s", w.properties.Address)
+}
+
+func (w *Watcher) CurrentState() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s := &StateNotification{
+		Event:       event.New(w.name, wtype, version, w.machine),
+		Probe:       w.previousProbe,
+		Status:      stateNames[w.previous],
+		StatusCode:  int(w.previous),
+		Output:      w.previousOutput,
+		PerfData:    w.previousPerfData,
+		RunTime:     w.previousRunTime.Seconds(),
+		History:     w.history,
+		Annotations: w.properties.Annotations,
+	}
+
+	if !w.previousCheck.IsZero() {
+		s.CheckTime = w.previousCheck.Unix()
+	}
+
+	return s
+}
+
+func (w *Watcher) Delete() {
+	close(w.terminate)
+
+	err := deletePromState(w.textFileDir, w, w.machineName, w.name)
+	if err != nil {
+		w.Errorf("Could not delete from prometheus: %v", err)
+	}
+}
+
+func (w *Watcher) setProperties(props map[string]any) error {
+	if w.properties == nil {
+		w.properties = &properties{
+			Method:      "GET",
+			Timeout:     10 * time.Second,
+			Headers:     make(map[string]string),
+			JSONMatch:   make(map[string]string),
+			Annotations: make(map[string]string),
+		}
+	}
+
+	err := util.ParseMapStructure(props, w.properties)
+	if err != nil {
+		return err
+	}
+
+	return w.validate()
+}
+
+func (w *Watcher) validate() error {
+	if w.interval < time.Second {
+		return fmt.Errorf("interval should be more than 1 second: %v", w.interval)
+	}
+
+	if w.properties.URL == "" && w.properties.Address == "" {
+		return fmt.Errorf("url or address is required")
+	}
+
+	if w.properties.URL != "" && w.properties.Address != "" {
+		return fmt.Errorf("only one of url or address can be set")
+	}
+
+	if w.properties.Address != "" && (w.properties.BodyMatch != "" || len(w.properties.JSONMatch) > 0 || w.properties.StatusCode != 0) {
+		return fmt.Errorf("status_code, body_match and json_match can only be used with url probes")
+	}
+
+	if w.properties.Timeout <= 0 {
+		w.properties.Timeout = 10 * time.Second
+	}
+
+	if w.properties.Timeout > w.interval {
+		return fmt.Errorf("timeout %v is longer than the interval %v", w.properties.Timeout, w.interval)
+	}
+
+	if w.properties.BodyMatch != "" {
+		var err error
+		w.properties.bodyMatch, err = regexp.Compile(w.properties.BodyMatch)
+		if err != nil {
+			return fmt.Errorf("invalid body_match: %v", err)
+		}
+	}
+
+	return nil
+}
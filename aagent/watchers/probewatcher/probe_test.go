@@ -0,0 +1,205 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package probewatcher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AAgent/Watchers/ProbeWatcher")
+}
+
+var _ = Describe("ProbeWatcher", func() {
+	var (
+		mockctl     *gomock.Controller
+		mockMachine *model.MockMachine
+		td          string
+		srv         *httptest.Server
+	)
+
+	newWatcher := func(props map[string]any) *Watcher {
+		wi, err := New(mockMachine, "ginkgo", []string{"run"}, nil, "fail", "success", "1m", time.Hour, props)
+		Expect(err).ToNot(HaveOccurred())
+		return wi.(*Watcher)
+	}
+
+	BeforeEach(func() {
+		mockctl = gomock.NewController(GinkgoT())
+		mockMachine = model.NewMockMachine(mockctl)
+		td = GinkgoT().TempDir()
+
+		mockMachine.EXPECT().Name().Return("probe").AnyTimes()
+		mockMachine.EXPECT().Identity().Return("ginkgo").AnyTimes()
+		mockMachine.EXPECT().InstanceID().Return("1234567890").AnyTimes()
+		mockMachine.EXPECT().Version().Return("1.0.0").AnyTimes()
+		mockMachine.EXPECT().TimeStampSeconds().Return(time.Now().Unix()).AnyTimes()
+		mockMachine.EXPECT().TextFileDirectory().Return(td).AnyTimes()
+		mockMachine.EXPECT().State().Return("run").AnyTimes()
+		mockMachine.EXPECT().Facts().Return([]byte(`{"port":"8080"}`)).AnyTimes()
+		mockMachine.EXPECT().Data().Return(map[string]any{}).AnyTimes()
+		mockMachine.EXPECT().Debugf(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockMachine.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/health":
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"status":"ok","checks":{"db":"up"}}`)
+			default:
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprint(w, "unavailable")
+			}
+		}))
+		DeferCleanup(srv.Close)
+	})
+
+	AfterEach(func() {
+		mockctl.Finish()
+	})
+
+	Describe("setProperties", func() {
+		It("Should validate the properties", func() {
+			_, err := New(mockMachine, "ginkgo", nil, nil, "", "", "1m", time.Hour, map[string]any{})
+			Expect(err).To(MatchError("could not set properties: url or address is required"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1m", time.Hour, map[string]any{"url": "http://localhost", "address": "localhost:80"})
+			Expect(err).To(MatchError("could not set properties: only one of url or address can be set"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1m", time.Hour, map[string]any{"address": "localhost:80", "status_code": 200})
+			Expect(err).To(MatchError("could not set properties: status_code, body_match and json_match can only be used with url probes"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "5s", time.Hour, map[string]any{"address": "localhost:80"})
+			Expect(err).To(MatchError("could not set properties: timeout 10s is longer than the interval 5s"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1m", time.Hour, map[string]any{"url": "http://localhost", "body_match": "["})
+			Expect(err).To(MatchError(ContainSubstring("invalid body_match")))
+
+			w := newWatcher(map[string]any{"url": "http://localhost", "timeout": "2s", "json_match": map[string]any{"status": "ok"}})
+			Expect(w.properties.Timeout).To(Equal(2 * time.Second))
+			Expect(w.properties.Method).To(Equal("GET"))
+			Expect(w.properties.JSONMatch).To(Equal(map[string]string{"status": "ok"}))
+		})
+	})
+
+	Describe("HTTP probes", func() {
+		It("Should pass healthy services", func(ctx context.Context) {
+			w := newWatcher(map[string]any{
+				"url":        srv.URL + "/health",
+				"body_match": `"status"`,
+				"json_match": map[string]any{"status": "ok", "checks.db": "up"},
+			})
+
+			res := w.watch(ctx)
+			Expect(res.state).To(Equal(OK))
+			Expect(res.output).To(HavePrefix("OK: %s/health returned 200", srv.URL))
+			Expect(res.perfData[0].Label).To(Equal("time"))
+			Expect(res.perfData[1].Label).To(Equal("size"))
+		})
+
+		It("Should fail on assertion failures", func(ctx context.Context) {
+			w := newWatcher(map[string]any{
+				"url":         srv.URL + "/health",
+				"status_code": 201,
+				"body_match":  "missing",
+				"json_match":  map[string]any{"status": "failed", "other": "x"},
+			})
+
+			res := w.watch(ctx)
+			Expect(res.state).To(Equal(CRITICAL))
+			Expect(res.output).To(Equal(`CRITICAL: status code 200 does not match 201, body does not match missing, other is not set, status is "ok" expected "failed"`))
+
+			w = newWatcher(map[string]any{"url": srv.URL + "/down"})
+			res = w.watch(ctx)
+			Expect(res.state).To(Equal(CRITICAL))
+			Expect(res.output).To(Equal("CRITICAL: status code 503 is not successful"))
+		})
+
+		It("Should check certificate expiry", func(ctx context.Context) {
+			tsrv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			defer tsrv.Close()
+
+			w := newWatcher(map[string]any{"url": tsrv.URL})
+			res := w.watch(ctx)
+			Expect(res.state).To(Equal(CRITICAL))
+			Expect(res.output).To(ContainSubstring("certificate"))
+
+			w = newWatcher(map[string]any{"url": tsrv.URL, "tls_insecure": true})
+			res = w.watch(ctx)
+			Expect(res.state).To(Equal(OK))
+			Expect(res.certExpiry).To(BeNumerically(">", 0))
+
+			w = newWatcher(map[string]any{"url": tsrv.URL, "tls_insecure": true, "cert_expiry": "2000000h"})
+			res = w.watch(ctx)
+			Expect(res.state).To(Equal(CRITICAL))
+			Expect(res.output).To(HavePrefix("CRITICAL: certificate expires in"))
+		})
+	})
+
+	Describe("TCP probes", func() {
+		It("Should connect to the address", func(ctx context.Context) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			addr := l.Addr().String()
+
+			w := newWatcher(map[string]any{"address": addr})
+			res := w.watch(ctx)
+			Expect(res.state).To(Equal(OK))
+			Expect(res.output).To(HavePrefix("OK: connected to %s", addr))
+			Expect(w.previousProbe).To(Equal("tcp://" + addr))
+
+			l.Close()
+			res = w.watch(ctx)
+			Expect(res.state).To(Equal(CRITICAL))
+			Expect(res.output).To(HavePrefix("CRITICAL: connection failed"))
+		})
+
+		It("Should support templates", func(ctx context.Context) {
+			w := newWatcher(map[string]any{"address": `127.0.0.1:{{ lookup "facts.port" "1" }}`})
+			w.watch(ctx)
+			Expect(w.previousProbe).To(Equal("tcp://127.0.0.1:8080"))
+		})
+	})
+
+	Describe("handleCheck", func() {
+		It("Should notify, update prometheus and transition", func() {
+			w := newWatcher(map[string]any{"url": srv.URL, "annotations": map[string]any{"team": "ops"}})
+
+			mockMachine.EXPECT().NotifyWatcherState("ginkgo", gomock.Any()).Do(func(_ string, s *StateNotification) {
+				Expect(s.Status).To(Equal("OK"))
+				Expect(s.StatusCode).To(Equal(0))
+				Expect(s.Annotations).To(Equal(map[string]string{"team": "ops"}))
+				Expect(s.History).To(HaveLen(1))
+			})
+			mockMachine.EXPECT().Transition("success")
+			Expect(w.handleCheck(&result{state: OK, output: "OK", latency: time.Second})).To(Succeed())
+
+			mockMachine.EXPECT().NotifyWatcherState("ginkgo", gomock.Any())
+			mockMachine.EXPECT().Transition("fail")
+			Expect(w.handleCheck(&result{state: CRITICAL, output: "CRITICAL"})).To(Succeed())
+
+			Expect(w.handleCheck(&result{state: SKIPPED})).To(Succeed())
+
+			prom, err := os.ReadFile(filepath.Join(td, "choria_machine_probe_watcher_status.prom"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(prom)).To(ContainSubstring(`choria_machine_probe_watcher_status{machine="probe",name="ginkgo",status="CRITICAL"} 2`))
+			Expect(string(prom)).To(ContainSubstring(`choria_machine_probe_watcher_checks_count{machine="probe",name="ginkgo"} 2`))
+		})
+	})
+})
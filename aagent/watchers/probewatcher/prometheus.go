@@ -0,0 +1,115 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package probewatcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/choria-io/go-choria/internal/util"
+)
+
+type logger interface {
+	Debugf(format string, args ...any)
+}
+
+type promState struct {
+	machine    string
+	name       string
+	state      State
+	latency    float64
+	checks     int
+	certExpiry float64
+}
+
+var (
+	promStates map[string]*promState
+	mu         sync.Mutex
+)
+
+func init() {
+	mu.Lock()
+	promStates = make(map[string]*promState)
+	mu.Unlock()
+}
+
+func updatePromState(td string, log logger, machine string, name string, state State, latency float64, certExpiry float64) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	key := fmt.Sprintf("%s_%s", machine, name)
+	ps, ok := promStates[key]
+	if !ok {
+		ps = &promState{machine: machine, name: name}
+		promStates[key] = ps
+	}
+
+	ps.state = state
+	ps.latency = latency
+	ps.certExpiry = certExpiry
+	ps.checks++
+
+	return savePromState(td, log)
+}
+
+func deletePromState(td string, log logger, machine string, name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(promStates, fmt.Sprintf("%s_%s", machine, name))
+
+	return savePromState(td, log)
+}
+
+// lock should be held
+func savePromState(td string, log logger) error {
+	if td == "" {
+		log.Debugf("Not updating prometheus - text file directory is unset")
+		return nil
+	}
+
+	if !util.FileIsDir(td) {
+		log.Debugf("%q is not a directory", td)
+		return nil
+	}
+
+	tfile, err := os.CreateTemp(td, "")
+	if err != nil {
+		return fmt.Errorf("failed to create prometheus metric in %q: %s", td, err)
+	}
+	defer tfile.Close()
+
+	fmt.Fprintf(tfile, "# HELP choria_machine_probe_watcher_status Choria Probe Status\n")
+	fmt.Fprintf(tfile, "# TYPE choria_machine_probe_watcher_status gauge\n")
+	for _, ps := range promStates {
+		fmt.Fprintf(tfile, "choria_machine_probe_watcher_status{machine=%q,name=%q,status=%q} %d\n", ps.machine, ps.name, stateNames[ps.state], int(ps.state))
+	}
+
+	fmt.Fprintf(tfile, "# HELP choria_machine_probe_watcher_latency_seconds Choria Probe Latency\n")
+	fmt.Fprintf(tfile, "# TYPE choria_machine_probe_watcher_latency_seconds gauge\n")
+	for _, ps := range promStates {
+		fmt.Fprintf(tfile, "choria_machine_probe_watcher_latency_seconds{machine=%q,name=%q} %f\n", ps.machine, ps.name, ps.latency)
+	}
+
+	fmt.Fprintf(tfile, "# HELP choria_machine_probe_watcher_checks_count Choria Probe Check Count\n")
+	fmt.Fprintf(tfile, "# TYPE choria_machine_probe_watcher_checks_count counter\n")
+	for _, ps := range promStates {
+		fmt.Fprintf(tfile, "choria_machine_probe_watcher_checks_count{machine=%q,name=%q} %d\n", ps.machine, ps.name, ps.checks)
+	}
+
+	fmt.Fprintf(tfile, "# HELP choria_machine_probe_watcher_cert_expiry_seconds Choria Probe TLS Certificate Remaining Validity\n")
+	fmt.Fprintf(tfile, "# TYPE choria_machine_probe_watcher_cert_expiry_seconds gauge\n")
+	for _, ps := range promStates {
+		if ps.certExpiry != 0 {
+			fmt.Fprintf(tfile, "choria_machine_probe_watcher_cert_expiry_seconds{machine=%q,name=%q} %f\n", ps.machine, ps.name, ps.certExpiry)
+		}
+	}
+
+	tfile.Close()
+	os.Chmod(tfile.Name(), 0644)
+	return os.Rename(tfile.Name(), filepath.Join(td, "choria_machine_probe_watcher_status.prom"))
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package subjectwatcher
+
+import (
+	"github.com/choria-io/go-choria/aagent/watchers/plugin"
+)
+
+func ChoriaPlugin() *plugin.WatcherPlugin {
+	return plugin.NewWatcherPlugin(wtype, version, func() any { return &StateNotification{} }, New)
+}
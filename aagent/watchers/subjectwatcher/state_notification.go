@@ -0,0 +1,46 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package subjectwatcher
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"github.com/choria-io/go-choria/aagent/watchers/event"
+)
+
+// StateNotification describes the current state of the watcher
+// described by io.choria.machine.watcher.subject.v1.state
+type StateNotification struct {
+	event.Event
+
+	Subject         string `json:"subject,omitempty"`
+	Stream          string `json:"stream,omitempty"`
+	Consumer        string `json:"consumer,omitempty"`
+	PreviousOutcome string `json:"previous_outcome"`
+	PreviousSubject string `json:"previous_subject,omitempty"`
+	Received        uint64 `json:"received"`
+}
+
+// JSON creates a JSON representation of the notification
+func (s *StateNotification) JSON() ([]byte, error) {
+	return json.Marshal(s.CloudEvent())
+}
+
+// CloudEvent creates a CloudEvent from the state notification
+func (s *StateNotification) CloudEvent() cloudevents.Event {
+	return s.Event.CloudEvent(s)
+}
+
+// String is a string representation of the notification suitable for printing
+func (s *StateNotification) String() string {
+	if s.Stream != "" {
+		return fmt.Sprintf("%s %s#%s consumer: %s > %s, received: %d, previous: %s", s.Identity, s.Machine, s.Name, s.Stream, s.Consumer, s.Received, s.PreviousOutcome)
+	}
+
+	return fmt.Sprintf("%s %s#%s subject: %s, received: %d, previous: %s", s.Identity, s.Machine, s.Name, s.Subject, s.Received, s.PreviousOutcome)
+}
@@ -0,0 +1,478 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package subjectwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/nats-io/nats.go"
+	"github.com/tidwall/gjson"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	"github.com/choria-io/go-choria/aagent/util"
+	"github.com/choria-io/go-choria/aagent/watchers/event"
+	"github.com/choria-io/go-choria/aagent/watchers/watcher"
+	iu "github.com/choria-io/go-choria/internal/util"
+)
+
+type State int
+
+const (
+	Unknown State = iota
+	SuccessMessage
+	FailMessage
+	Filtered
+	Skipped
+	Error
+
+	wtype   = "subject"
+	version = "v1"
+)
+
+var stateNames = map[State]string{
+	Unknown:        "unknown",
+	SuccessMessage: "success",
+	FailMessage:    "fail",
+	Filtered:       "filtered",
+	Skipped:        "skipped",
+	Error:          "error",
+}
+
+type properties struct {
+	// Subject is a core NATS subject to subscribe to, supports templates
+	Subject string
+	// Queue is an optional queue group to join for core NATS subscriptions
+	Queue string
+	// Stream is the JetStream Stream holding Consumer
+	Stream string
+	// Consumer is a durable JetStream pull consumer to consume messages from
+	Consumer string
+	// Filter is an expression that has to be true for a message to be handled
+	Filter string
+	// FailWhen is an expression that fires the fail event rather than the success event for a message
+	FailWhen string `mapstructure:"fail_when"`
+	// Data maps machine data keys to GJSON queries into the message payload
+	Data map[string]string
+}
+
+type Watcher struct {
+	*watcher.Watcher
+	properties *properties
+
+	name     string
+	machine  model.Machine
+	interval time.Duration
+	sub      *nats.Subscription
+
+	previous        State
+	previousSubject string
+	received        uint64
+
+	terminate chan struct{}
+	mu        *sync.Mutex
+}
+
+func New(machine model.Machine, name string, states []string, required []model.ForeignMachineState, failEvent string, successEvent string, interval string, ai time.Duration, properties map[string]any) (any, error) {
+	var err error
+
+	sw := &Watcher{
+		name:      name,
+		machine:   machine,
+		interval:  10 * time.Second,
+		terminate: make(chan struct{}),
+		mu:        &sync.Mutex{},
+	}
+
+	sw.Watcher, err = watcher.NewWatcher(name, wtype, ai, states, required, machine, failEvent, successEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval != "" {
+		sw.interval, err = iu.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %v", err)
+		}
+	}
+
+	err = sw.setProperties(properties)
+	if err != nil {
+		return nil, fmt.Errorf("could not set properties: %s", err)
+	}
+
+	return sw, nil
+}
+
+func (w *Watcher) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if w.properties.Consumer != "" {
+		w.Infof("Subject watcher starting with JetStream consumer %s > %s", w.properties.Stream, w.properties.Consumer)
+	} else {
+		w.Infof("Subject watcher starting with subject %q", w.properties.Subject)
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// subscriptions are retried every interval till the machine has a working connection
+	tick := time.NewTicker(w.interval)
+	defer tick.Stop()
+
+	subscribe := func() {
+		if w.subscribed() {
+			return
+		}
+
+		err := w.subscribe(subCtx, wg)
+		if err != nil {
+			w.Errorf("Could not subscribe: %v", err)
+		}
+	}
+
+	subscribe()
+
+	for {
+		select {
+		case <-tick.C:
+			subscribe()
+
+		case <-w.terminate:
+			w.Infof("Handling terminate notification")
+			cancel()
+			w.unsubscribe()
+			return
+
+		case <-ctx.Done():
+			w.Infof("Stopping on context interrupt")
+			w.unsubscribe()
+			return
+		}
+	}
+}
+
+func (w *Watcher) subscribed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.sub != nil
+}
+
+func (w *Watcher) unsubscribe() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.sub != nil {
+		w.sub.Unsubscribe()
+		w.sub = nil
+	}
+}
+
+func (w *Watcher) subscribe(ctx context.Context, wg *sync.WaitGroup) error {
+	mgr, err := w.machine.JetStreamConnection()
+	if err != nil {
+		return err
+	}
+	nc := mgr.NatsConn()
+
+	if w.properties.Consumer != "" {
+		js, err := nc.JetStream()
+		if err != nil {
+			return err
+		}
+
+		sub, err := js.PullSubscribe("", w.properties.Consumer, nats.Bind(w.properties.Stream, w.properties.Consumer))
+		if err != nil {
+			return err
+		}
+
+		w.mu.Lock()
+		w.sub = sub
+		w.mu.Unlock()
+
+		wg.Add(1)
+		go w.consume(ctx, wg, sub)
+
+		return nil
+	}
+
+	subject, err := w.ProcessTemplate(w.properties.Subject)
+	if err != nil {
+		return fmt.Errorf("could not parse template for subject: %v", err)
+	}
+
+	handler := func(msg *nats.Msg) {
+		w.handleState(w.handleMessage(msg))
+	}
+
+	var sub *nats.Subscription
+	if w.properties.Queue != "" {
+		sub, err = nc.QueueSubscribe(subject, w.properties.Queue, handler)
+	} else {
+		sub, err = nc.Subscribe(subject, handler)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.Infof("Subscribed to %q", subject)
+
+	w.mu.Lock()
+	w.sub = sub
+	w.mu.Unlock()
+
+	return nil
+}
+
+// consume fetches messages from a pull consumer, messages are only fetched while the watcher is active
+func (w *Watcher) consume(ctx context.Context, wg *sync.WaitGroup, sub *nats.Subscription) {
+	defer wg.Done()
+
+	// allows a new subscription to be made should this one fail
+	defer func() {
+		w.mu.Lock()
+		if w.sub == sub {
+			w.sub = nil
+		}
+		w.mu.Unlock()
+	}()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !w.ShouldWatch() {
+			select {
+			case <-w.StateChangeC():
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return
+			}
+
+			continue
+		}
+
+		fctx, cancel := context.WithTimeout(ctx, w.interval)
+		msgs, err := sub.Fetch(1, nats.Context(fctx))
+		cancel()
+
+		switch {
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, nats.ErrTimeout):
+			continue
+
+		case errors.Is(err, context.Canceled), errors.Is(err, nats.ErrBadSubscription), errors.Is(err, nats.ErrConnectionClosed):
+			return
+
+		case err != nil:
+			w.Errorf("Could not fetch messages from %s > %s: %v", w.properties.Stream, w.properties.Consumer, err)
+
+			select {
+			case <-time.After(w.interval):
+			case <-ctx.Done():
+				return
+			}
+
+			continue
+		}
+
+		for _, msg := range msgs {
+			w.handleState(w.handleMessage(msg))
+
+			// errors handling a message are not transient so we ack regardless to avoid redeliveries
+			err = msg.Ack()
+			if err != nil {
+				w.Errorf("Could not acknowledge message: %v", err)
+			}
+		}
+	}
+}
+
+// handleMessage evaluates the filters, stores data and determines the outcome for a message
+func (w *Watcher) handleMessage(msg *nats.Msg) (State, error) {
+	if !w.ShouldWatch() {
+		return Skipped, nil
+	}
+
+	w.mu.Lock()
+	w.received++
+	w.previousSubject = msg.Subject
+	w.mu.Unlock()
+
+	env := w.messageEnv(msg)
+
+	if w.properties.Filter != "" {
+		match, err := util.EvaluateBoolExpression(w.properties.Filter, env)
+		if err != nil {
+			return Error, fmt.Errorf("filter failed: %v", err)
+		}
+
+		if !match {
+			w.Debugf("Ignoring message on %s that does not match the filter", msg.Subject)
+			return Filtered, nil
+		}
+	}
+
+	if len(w.properties.Data) > 0 {
+		if !gjson.ValidBytes(msg.Data) {
+			return Error, fmt.Errorf("message on %s is not valid JSON", msg.Subject)
+		}
+
+		for key, query := range w.properties.Data {
+			res := gjson.GetBytes(msg.Data, query)
+			if !res.Exists() {
+				w.Debugf("Query %s for data key %s did not match the message on %s", query, key, msg.Subject)
+				continue
+			}
+
+			err := w.machine.DataPut(key, res.Value())
+			if err != nil {
+				return Error, fmt.Errorf("could not store data key %s: %v", key, err)
+			}
+		}
+	}
+
+	if w.properties.FailWhen != "" {
+		fail, err := util.EvaluateBoolExpression(w.properties.FailWhen, env)
+		if err != nil {
+			return Error, fmt.Errorf("fail_when failed: %v", err)
+		}
+
+		if fail {
+			return FailMessage, nil
+		}
+	}
+
+	return SuccessMessage, nil
+}
+
+// messageEnv extends the machine expression environment with the message subject, headers and payload
+func (w *Watcher) messageEnv(msg *nats.Msg) map[string]any {
+	env := util.ExpressionEnv(w.machine)
+
+	headers := make(map[string]string)
+	for k := range msg.Header {
+		headers[k] = msg.Header.Get(k)
+	}
+
+	var payload any
+	err := json.Unmarshal(msg.Data, &payload)
+	if err != nil {
+		payload = string(msg.Data)
+	}
+
+	env["subject"] = msg.Subject
+	env["headers"] = headers
+	env["payload"] = payload
+	env["body"] = string(msg.Data)
+
+	return env
+}
+
+func (w *Watcher) handleState(state State, err error) {
+	if state == Skipped {
+		return
+	}
+
+	w.mu.Lock()
+	w.previous = state
+	w.mu.Unlock()
+
+	switch state {
+	case Error:
+		w.Errorf("Handling message failed: %v", err)
+		w.NotifyWatcherState(w.CurrentState())
+
+	case SuccessMessage:
+		w.NotifyWatcherState(w.CurrentState())
+		err = w.SuccessTransition()
+
+	case FailMessage:
+		w.NotifyWatcherState(w.CurrentState())
+		err = w.FailureTransition()
+
+	default:
+		return
+	}
+
+	if err != nil {
+		w.Errorf("Could not transition: %v", err)
+	}
+}
+
+func (w *Watcher) CurrentState() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return &StateNotification{
+		Event:           event.New(w.name, wtype, version, w.machine),
+		Subject:         w.properties.Subject,
+		Stream:          w.properties.Stream,
+		Consumer:        w.properties.Consumer,
+		PreviousOutcome: stateNames[w.previous],
+		PreviousSubject: w.previousSubject,
+		Received:        w.received,
+	}
+}
+
+func (w *Watcher) Delete() {
+	close(w.terminate)
+}
+
+func (w *Watcher) setProperties(props map[string]any) error {
+	if w.properties == nil {
+		w.properties = &properties{
+			Data: make(map[string]string),
+		}
+	}
+
+	err := util.ParseMapStructure(props, w.properties)
+	if err != nil {
+		return err
+	}
+
+	return w.validate()
+}
+
+func (w *Watcher) validate() error {
+	if w.interval < time.Second {
+		return fmt.Errorf("interval should be more than 1 second: %v", w.interval)
+	}
+
+	if w.properties.Subject == "" && w.properties.Consumer == "" {
+		return fmt.Errorf("subject or consumer is required")
+	}
+
+	if w.properties.Subject != "" && w.properties.Consumer != "" {
+		return fmt.Errorf("only one of subject or consumer can be set")
+	}
+
+	if w.properties.Consumer != "" && w.properties.Stream == "" {
+		return fmt.Errorf("stream is required when consuming from a consumer")
+	}
+
+	if w.properties.Consumer != "" && w.properties.Queue != "" {
+		return fmt.Errorf("queue can only be used with subject subscriptions")
+	}
+
+	for _, e := range []string{w.properties.Filter, w.properties.FailWhen} {
+		if e == "" {
+			continue
+		}
+
+		_, err := expr.Compile(e, expr.AsBool())
+		if err != nil {
+			return fmt.Errorf("invalid expression %q: %v", e, err)
+		}
+	}
+
+	return nil
+}
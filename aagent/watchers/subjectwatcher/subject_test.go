@@ -0,0 +1,206 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package subjectwatcher
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	"github.com/nats-io/jsm.go"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AAgent/Watchers/SubjectWatcher")
+}
+
+var _ = Describe("SubjectWatcher", func() {
+	var (
+		mockctl     *gomock.Controller
+		mockMachine *model.MockMachine
+	)
+
+	newWatcher := func(props map[string]any) *Watcher {
+		wi, err := New(mockMachine, "ginkgo", []string{"run"}, nil, "fail", "success", "1s", time.Hour, props)
+		Expect(err).ToNot(HaveOccurred())
+		return wi.(*Watcher)
+	}
+
+	BeforeEach(func() {
+		mockctl = gomock.NewController(GinkgoT())
+		mockMachine = model.NewMockMachine(mockctl)
+
+		mockMachine.EXPECT().Name().Return("subject").AnyTimes()
+		mockMachine.EXPECT().Identity().Return("ginkgo").AnyTimes()
+		mockMachine.EXPECT().InstanceID().Return("1234567890").AnyTimes()
+		mockMachine.EXPECT().Version().Return("1.0.0").AnyTimes()
+		mockMachine.EXPECT().TimeStampSeconds().Return(time.Now().Unix()).AnyTimes()
+		mockMachine.EXPECT().State().Return("run").AnyTimes()
+		mockMachine.EXPECT().Facts().Return([]byte(`{"region":"eu"}`)).AnyTimes()
+		mockMachine.EXPECT().Data().Return(map[string]any{}).AnyTimes()
+		mockMachine.EXPECT().Debugf(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockMachine.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockMachine.EXPECT().Errorf(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ string, f string, a ...any) {
+			GinkgoWriter.Printf(f+"\n", a...)
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockctl.Finish()
+	})
+
+	Describe("setProperties", func() {
+		It("Should validate the properties", func() {
+			_, err := New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{})
+			Expect(err).To(MatchError("could not set properties: subject or consumer is required"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{"subject": "x", "consumer": "x"})
+			Expect(err).To(MatchError("could not set properties: only one of subject or consumer can be set"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{"consumer": "x"})
+			Expect(err).To(MatchError("could not set properties: stream is required when consuming from a consumer"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{"consumer": "x", "stream": "x", "queue": "x"})
+			Expect(err).To(MatchError("could not set properties: queue can only be used with subject subscriptions"))
+
+			_, err = New(mockMachine, "ginkgo", nil, nil, "", "", "1s", time.Hour, map[string]any{"subject": "x", "filter": "payload =="})
+			Expect(err).To(MatchError(ContainSubstring("invalid expression")))
+
+			w := newWatcher(map[string]any{"subject": "x", "data": map[string]any{"version": "spec.version"}})
+			Expect(w.properties.Data).To(Equal(map[string]string{"version": "spec.version"}))
+		})
+	})
+
+	Describe("handleMessage", func() {
+		It("Should filter, store data and determine the outcome", func() {
+			w := newWatcher(map[string]any{
+				"subject":   "deploy.>",
+				"filter":    `payload.region == get_fact("region") && headers["Type"] == "deploy"`,
+				"fail_when": `payload.action == "stop"`,
+				"data":      map[string]any{"version": "version", "missing": "missing"},
+			})
+
+			msg := nats.NewMsg("deploy.app")
+			msg.Header.Set("Type", "deploy")
+			msg.Data = []byte(`{"region":"us","version":"1.2.3"}`)
+
+			state, err := w.handleMessage(msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(Filtered))
+
+			mockMachine.EXPECT().DataPut("version", "1.2.3").Return(nil)
+			msg.Data = []byte(`{"region":"eu","version":"1.2.3"}`)
+			state, err = w.handleMessage(msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(SuccessMessage))
+
+			mockMachine.EXPECT().DataPut("version", "1.2.4").Return(nil)
+			msg.Data = []byte(`{"region":"eu","version":"1.2.4","action":"stop"}`)
+			state, err = w.handleMessage(msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(FailMessage))
+			Expect(w.received).To(Equal(uint64(3)))
+			Expect(w.previousSubject).To(Equal("deploy.app"))
+		})
+
+		It("Should fail for invalid JSON when storing data", func() {
+			w := newWatcher(map[string]any{"subject": "x", "data": map[string]any{"version": "version"}})
+
+			state, err := w.handleMessage(&nats.Msg{Subject: "x", Data: []byte("x")})
+			Expect(err).To(MatchError("message on x is not valid JSON"))
+			Expect(state).To(Equal(Error))
+		})
+	})
+
+	Describe("Run", func() {
+		var (
+			nc  *nats.Conn
+			mgr *jsm.Manager
+		)
+
+		BeforeEach(func() {
+			srv, err := server.NewServer(&server.Options{
+				JetStream: true,
+				StoreDir:  GinkgoT().TempDir(),
+				Port:      -1,
+				Host:      "localhost",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			go srv.Start()
+			Expect(srv.ReadyForConnections(10 * time.Second)).To(BeTrue())
+			DeferCleanup(srv.Shutdown)
+
+			nc, err = nats.Connect(srv.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(nc.Close)
+
+			mgr, err = jsm.New(nc)
+			Expect(err).ToNot(HaveOccurred())
+
+			mockMachine.EXPECT().JetStreamConnection().Return(mgr, nil).AnyTimes()
+		})
+
+		run := func(w *Watcher) {
+			ctx, cancel := context.WithCancel(context.Background())
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go w.Run(ctx, wg)
+
+			DeferCleanup(func() {
+				cancel()
+				wg.Wait()
+			})
+		}
+
+		It("Should transition on core NATS messages", func() {
+			w := newWatcher(map[string]any{"subject": `deploy.{{ lookup "facts.region" "x" }}`})
+
+			transitioned := make(chan struct{}, 1)
+			mockMachine.EXPECT().NotifyWatcherState("ginkgo", gomock.Any())
+			mockMachine.EXPECT().Transition("success").Do(func(_ string, _ ...any) { transitioned <- struct{}{} })
+
+			run(w)
+			Eventually(w.subscribed).Should(BeTrue())
+
+			Expect(nc.Publish("deploy.eu", []byte("go"))).To(Succeed())
+			Eventually(transitioned).Should(Receive())
+		})
+
+		It("Should consume from durable JetStream consumers", func() {
+			_, err := mgr.NewStream("DEPLOY", jsm.Subjects("deploy.>"), jsm.MemoryStorage())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = mgr.NewConsumer("DEPLOY", jsm.DurableName("NODE"), jsm.AcknowledgeExplicit())
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = nc.Request("deploy.app", []byte("go"), time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			w := newWatcher(map[string]any{"stream": "DEPLOY", "consumer": "NODE"})
+
+			transitioned := make(chan struct{}, 1)
+			mockMachine.EXPECT().NotifyWatcherState("ginkgo", gomock.Any())
+			mockMachine.EXPECT().Transition("success").Do(func(_ string, _ ...any) { transitioned <- struct{}{} })
+
+			run(w)
+			Eventually(transitioned, 5*time.Second).Should(Receive())
+
+			Eventually(func() int {
+				cons, err := mgr.LoadConsumer("DEPLOY", "NODE")
+				Expect(err).ToNot(HaveOccurred())
+				pending, err := cons.PendingAcknowledgement()
+				Expect(err).ToNot(HaveOccurred())
+				return pending
+			}).Should(Equal(0))
+		})
+	})
+})
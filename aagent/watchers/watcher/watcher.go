@@ -15,11 +15,11 @@ import (
 	"time"
 
 	"github.com/choria-io/go-choria/aagent/model"
+	aautil "github.com/choria-io/go-choria/aagent/util"
 	"github.com/choria-io/go-choria/backoff"
 	"github.com/choria-io/go-choria/internal/util"
 	"github.com/choria-io/go-choria/lifecycle"
 	governor "github.com/choria-io/go-choria/providers/governor/streams"
-	"github.com/tidwall/gjson"
 )
 
 type Watcher struct {
@@ -200,35 +200,7 @@ func (w *Watcher) ProcessTemplate(s string) (string, error) {
 }
 
 func (w *Watcher) templateFuncMap() (template.FuncMap, error) {
-	facts := w.machine.Facts()
-	data := w.machine.Data()
-	jdata, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-
-	input := map[string]json.RawMessage{
-		"facts": facts,
-		"data":  jdata,
-	}
-
-	jinput, err := json.Marshal(input)
-	if err != nil {
-		return nil, err
-	}
-
-	return util.FuncMap(map[string]any{
-		"lookup": func(q string, dflt any) any {
-			r := gjson.GetBytes(jinput, q)
-			if !r.Exists() {
-				w.Infof("Query did not match any data, returning default: %s", q)
-
-				return dflt
-			}
-
-			return r.Value()
-		},
-	}), nil
+	return aautil.TemplateFuncMap(w.machine, w.Infof)
 }
 
 func (w *Watcher) Machine() model.Machine {
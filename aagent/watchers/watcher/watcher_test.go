@@ -5,6 +5,7 @@
 package watcher
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/choria-io/go-choria/aagent/model"
@@ -93,4 +94,22 @@ var _ = Describe("Watcher", func() {
 			Expect(w.ShouldWatch()).Should(BeTrue())
 		})
 	})
+	Describe("ProcessTemplate", func() {
+		It("Should support peer data", func() {
+			mockmachine.EXPECT().Facts().Return(json.RawMessage("{}")).AnyTimes()
+			mockmachine.EXPECT().Data().Return(map[string]any{}).AnyTimes()
+			mockmachine.EXPECT().Name().Return("ginkgo").AnyTimes()
+			mockmachine.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			mockmachine.EXPECT().PeerData().Return(map[string]map[string]any{
+				"n2.example.net": {"leader": "n2.example.net"},
+				"n1.example.net": {"leader": "n2.example.net"},
+				"n3.example.net": {},
+			}).AnyTimes()
+
+			Expect(w.ProcessTemplate(`{{ peer_lookup "n1.example.net" "leader" "none" }}`)).To(Equal("n2.example.net"))
+			Expect(w.ProcessTemplate(`{{ peer_lookup "n3.example.net" "leader" "none" }}`)).To(Equal("none"))
+			Expect(w.ProcessTemplate(`{{ peers | StringsJoin }}`)).To(Equal("n1.example.net, n2.example.net, n3.example.net"))
+			Expect(w.ProcessTemplate(`{{ len (peer_data "leader") }}`)).To(Equal("2"))
+		})
+	})
 })
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/choria-io/go-choria/broker/adapter/registry"
 	"github.com/choria-io/go-choria/broker/adapter/streams"
 	"github.com/choria-io/go-choria/inter"
 )
@@ -51,6 +52,18 @@ func RunAdapters(ctx context.Context, c inter.Framework, wg *sync.WaitGroup) err
 				return fmt.Errorf("could not start choria_streams adapter: %s", err)
 			}
 
+		case "registry":
+			n, err := registry.Create(a, c)
+			if err != nil {
+				return fmt.Errorf("could not start registry adapter: %s", err)
+			}
+
+			log.Infof("Starting %s Protocol Adapter %s", atype, a)
+			err = startAdapter(ctx, n, c, wg)
+			if err != nil {
+				return fmt.Errorf("could not start registry adapter: %s", err)
+			}
+
 		case "nats_stream":
 			return fmt.Errorf("the NATS Streaming Server adapter has been deprecated")
 
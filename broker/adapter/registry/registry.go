@@ -0,0 +1,241 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/broker/adapter/ingest"
+	"github.com/choria-io/go-choria/broker/adapter/stats"
+	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/discovery/registry"
+	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/choria-io/go-choria/providers/registration"
+)
+
+// Registry is an adapter that maintains a node registry in a Choria Streams Key-Value bucket
+// using the inventory content registration messages published by Choria Servers, the registry
+// is used by the registry discovery method.
+//
+// Configure the adapters:
+//
+//	# required
+//	plugin.choria.adapters = registry
+//	plugin.choria.adapter.registry.type = registry
+//	plugin.choria.adapter.registry.queue_len = 1000 # default
+//
+// Configure the Key-Value bucket:
+//
+//	plugin.choria.adapter.registry.bucket = CHORIA_REGISTRY # default
+//	plugin.choria.adapter.registry.ttl = 1h # default, nodes that do not register within this time are removed
+//	plugin.choria.adapter.registry.replicas = 1 # default
+//	plugin.choria.adapter.registry.workers = 10 # default
+//
+// Configure the NATS ingest:
+//
+//	plugin.choria.adapter.registry.ingest.topic = mcollective.broadcast.agent.registration
+//	plugin.choria.adapter.registry.ingest.protocol = request
+//	plugin.choria.adapter.registry.ingest.workers = 10 # default
+type Registry struct {
+	name     string
+	bucket   string
+	ttl      time.Duration
+	replicas int
+	workers  int
+	ingests  []*ingest.NatsIngest
+	work     chan ingest.Adaptable
+	conn     inter.Connector
+	kv       nats.KeyValue
+	fw       inter.Framework
+	cfg      *config.Config
+	log      *logrus.Entry
+}
+
+// Create creates a new registry adapter
+func Create(name string, fw inter.Framework) (*Registry, error) {
+	cfg := fw.Configuration()
+	prefix := fmt.Sprintf("plugin.choria.adapter.%s.", name)
+
+	worklen, err := strconv.Atoi(cfg.Option(prefix+"queue_len", "1000"))
+	if err != nil {
+		return nil, fmt.Errorf("%s should be a integer number", prefix+"queue_len")
+	}
+
+	workers, err := strconv.Atoi(cfg.Option(prefix+"workers", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("%s should be a integer number", prefix+"workers")
+	}
+
+	replicas, err := strconv.Atoi(cfg.Option(prefix+"replicas", "1"))
+	if err != nil {
+		return nil, fmt.Errorf("%s should be a integer number", prefix+"replicas")
+	}
+
+	ttl, err := util.ParseDuration(cfg.Option(prefix+"ttl", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("%s should be a duration: %s", prefix+"ttl", err)
+	}
+
+	stats.WorkQueueCapacityGauge.WithLabelValues(name, cfg.Identity).Set(float64(worklen))
+
+	adapter := &Registry{
+		name:     name,
+		bucket:   cfg.Option(prefix+"bucket", registry.DefaultBucket),
+		ttl:      ttl,
+		replicas: replicas,
+		workers:  workers,
+		work:     make(chan ingest.Adaptable, worklen),
+		fw:       fw,
+		cfg:      cfg,
+		log:      fw.Logger("registry_adapter").WithFields(logrus.Fields{"name": name}),
+	}
+
+	adapter.ingests, err = ingest.New(name, adapter.work, fw, adapter.log)
+	if err != nil {
+		return nil, fmt.Errorf("could not create adapter %s: %s", name, err)
+	}
+
+	return adapter, nil
+}
+
+func (ra *Registry) Init(ctx context.Context, cm inter.ConnectionManager) (err error) {
+	if ctx.Err() != nil {
+		return fmt.Errorf("shutdown called")
+	}
+
+	ra.conn, err = cm.NewConnector(ctx, ra.fw.MiddlewareServers, fmt.Sprintf("choria adapter %s registry", ra.name), ra.log)
+	if err != nil {
+		return fmt.Errorf("could not start Choria Streams connection: %s", err)
+	}
+
+	ra.kv, err = kv.NewKV(ra.conn.Nats(), ra.bucket, true, kv.WithTTL(ra.ttl), kv.WithHistory(1), kv.WithReplicas(ra.replicas))
+	if err != nil {
+		return fmt.Errorf("could not load registry bucket %s: %s", ra.bucket, err)
+	}
+
+	for _, worker := range ra.ingests {
+		if ctx.Err() != nil {
+			return fmt.Errorf("shutdown called")
+		}
+
+		err = worker.Connect(ctx, cm)
+		if err != nil {
+			return fmt.Errorf("failure during NATS initial connections: %s", err)
+		}
+	}
+
+	ra.log.Infof("Maintaining node registry in bucket %s with %d workers", ra.bucket, ra.workers)
+
+	return nil
+}
+
+func (ra *Registry) Process(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for i := 0; i < ra.workers; i++ {
+		wg.Add(1)
+		go ra.updater(ctx, wg, fmt.Sprintf("%s.%d", ra.name, i))
+	}
+
+	for _, worker := range ra.ingests {
+		wg.Add(1)
+		go worker.Receiver(ctx, wg)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		ra.log.Debugf("Disconnecting from Choria Streams")
+		ra.conn.Close()
+	}()
+}
+
+func (ra *Registry) updater(ctx context.Context, wg *sync.WaitGroup, name string) {
+	defer wg.Done()
+
+	bytes := stats.BytesCtr.WithLabelValues(name, "output", ra.cfg.Identity)
+	ectr := stats.ErrorCtr.WithLabelValues(name, "output", ra.cfg.Identity)
+	ctr := stats.ReceivedMsgsCtr.WithLabelValues(name, "output", ra.cfg.Identity)
+	timer := stats.ProcessTime.WithLabelValues(name, "output", ra.cfg.Identity)
+	workqlen := stats.WorkQueueLengthGauge.WithLabelValues(ra.name, ra.cfg.Identity)
+
+	updatef := func(r ingest.Adaptable) {
+		obs := prometheus.NewTimer(timer)
+		defer obs.ObserveDuration()
+		defer func() { workqlen.Set(float64(len(ra.work))) }()
+
+		inv, err := registration.ParseInventoryContent(r.Message())
+		if err != nil {
+			ra.log.Debugf("Discarding registration data from %s: %s", r.SenderID(), err)
+			ectr.Inc()
+			return
+		}
+
+		j, err := json.Marshal(nodeFromInventory(r.SenderID(), r.Time(), inv))
+		if err != nil {
+			ra.log.Warnf("Cannot JSON encode registry entry for %s, discarding: %s", r.SenderID(), err)
+			ectr.Inc()
+			return
+		}
+
+		bytes.Add(float64(len(j)))
+
+		_, err = ra.kv.Put(r.SenderID(), j)
+		if err != nil {
+			ra.log.Warnf("Could not update registry entry for %s, discarding: %s", r.SenderID(), err)
+			ectr.Inc()
+			return
+		}
+
+		ctr.Inc()
+	}
+
+	for {
+		select {
+		case r := <-ra.work:
+			updatef(r)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func nodeFromInventory(identity string, updated time.Time, inv *registration.InventoryData) *registry.Node {
+	node := &registry.Node{
+		Identity:    identity,
+		Collectives: inv.Collectives,
+		Facts:       inv.Facts,
+		Classes:     inv.Classes,
+		Agents:      []string{},
+		Updated:     updated.UTC(),
+	}
+
+	for _, agent := range inv.Agents {
+		node.Agents = append(node.Agents, agent.Name)
+	}
+
+	for _, m := range inv.AutoAgents {
+		node.Machines = append(node.Machines, registry.MachineState{Name: m.Name, Version: m.Version, State: m.State})
+	}
+
+	if inv.BuildInfo != nil {
+		node.Version = inv.BuildInfo.Version
+	}
+
+	return node
+}
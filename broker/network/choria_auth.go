@@ -25,6 +25,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/security/denylist"
 )
 
 // ChoriaAuth implements the Nats server.Authentication interface and
@@ -49,6 +50,9 @@ import (
 // will be placed in the provisioning account and unable to connect to the
 // fleet or provisioned nodes. This is only enabled if plugin.choria.network.provisioning.signer_cert
 // is set
+//
+// When plugin.security.choria.revocation_signers is set client and server JWTs are checked against
+// the signed revocation list kept in Choria Streams and revoked tokens are denied
 type ChoriaAuth struct {
 	clientAllowList           []string
 	isTLS                     bool
@@ -66,6 +70,8 @@ type ChoriaAuth struct {
 	systemUser                string
 	systemPass                string
 	tokenCache                map[string]ed25519.PublicKey
+	denyList                  *denylist.List
+	revocationBucket          string
 	log                       *logrus.Entry
 	mu                        sync.Mutex
 }
@@ -767,6 +773,11 @@ func (a *ChoriaAuth) parseServerJWT(jwts string) (claims *tokens.ServerClaims, e
 		return nil, fmt.Errorf("no public key in claims")
 	}
 
+	err = a.denyList.CheckServerClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
@@ -868,6 +879,11 @@ func (a *ChoriaAuth) parseClientIDJWT(jwts string) (claims *tokens.ClientIDClaim
 		return nil, fmt.Errorf("no public key in claims")
 	}
 
+	err = a.denyList.CheckClientClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
@@ -882,7 +898,7 @@ func (a *ChoriaAuth) setClientFleetManagementPermissions(subs []string, pubs []s
 	return subs, pubs
 }
 
-func (a *ChoriaAuth) setMinimalClientPermissions(_ *server.User, caller string, subs []string, pubs []string) ([]string, []string) {
+func (a *ChoriaAuth) setMinimalClientPermissions(_ *server.User, caller string, org string, subs []string, pubs []string) ([]string, []string) {
 	switch {
 	case caller == emptyString:
 		subs = append(subs, "*.reply.>")
@@ -891,6 +907,7 @@ func (a *ChoriaAuth) setMinimalClientPermissions(_ *server.User, caller string,
 		subs = append(subs,
 			fmt.Sprintf("*.reply.%x.>", sha256Hash),
 		)
+		pubs = a.setAsyncRepliesPermissions(pubs, org, fmt.Sprintf("%x", sha256Hash))
 		a.log.Debugf("Creating FIPS140 ACLs for a private reply subject for %q", sha256Hash)
 
 	default:
@@ -900,6 +917,7 @@ func (a *ChoriaAuth) setMinimalClientPermissions(_ *server.User, caller string,
 			fmt.Sprintf("*.reply.%x.>", md5Hash),
 			fmt.Sprintf("*.reply.%x.>", sha256Hash),
 		)
+		pubs = a.setAsyncRepliesPermissions(pubs, org, fmt.Sprintf("%x", md5Hash), fmt.Sprintf("%x", sha256Hash))
 		a.log.Debugf("Creating ACLs for a private reply subject for %x and %x", md5Hash, sha256Hash)
 	}
 
@@ -919,6 +937,60 @@ func (a *ChoriaAuth) setStreamsAdminPermissions(user *server.User, subs []string
 	return subs, pubs
 }
 
+// setAsyncRepliesPermissions allows a client to read its own replies to asynchronous requests from the
+// CHORIA_RPC_REPLIES stream, consumers may only be made with a filter matching the private reply hashes.
+// Streams users can read the entire stream using their broader Streams access.
+func (a *ChoriaAuth) setAsyncRepliesPermissions(pubs []string, org string, hashes ...string) []string {
+	prefix := "$JS.API"
+	if org != "" && org != "choria" {
+		prefix = "choria.streams"
+	}
+
+	pubs = append(pubs,
+		fmt.Sprintf("%s.STREAM.INFO.CHORIA_RPC_REPLIES", prefix),
+		fmt.Sprintf("%s.CONSUMER.DELETE.CHORIA_RPC_REPLIES.*", prefix),
+		"$JS.FC.CHORIA_RPC_REPLIES.>",
+	)
+
+	for _, hash := range hashes {
+		pubs = append(pubs, fmt.Sprintf("%s.CONSUMER.CREATE.CHORIA_RPC_REPLIES.*.*.reply.%s.async.>", prefix, hash))
+	}
+
+	return pubs
+}
+
+// setRevocationListPermissions allows read only access to the JWT revocation Key-Value bucket when the broker
+// has revocation signers configured, servers therefore only load revocations when their broker enforces them
+func (a *ChoriaAuth) setRevocationListPermissions(user *server.User, org string) {
+	if a.denyList == nil {
+		return
+	}
+
+	bucket := a.revocationBucket
+	if bucket == "" {
+		bucket = denylist.DefaultBucket
+	}
+
+	prefix := "$JS.API"
+	if org != "choria" {
+		prefix = "choria.streams"
+	}
+
+	stream := fmt.Sprintf("KV_%s", bucket)
+
+	user.Permissions.Publish.Allow = append(user.Permissions.Publish.Allow,
+		fmt.Sprintf("%s.STREAM.INFO.%s", prefix, stream),
+		fmt.Sprintf("%s.STREAM.MSG.GET.%s", prefix, stream),
+		fmt.Sprintf("%s.DIRECT.GET.%s", prefix, stream),
+		fmt.Sprintf("%s.DIRECT.GET.%s.>", prefix, stream),
+		fmt.Sprintf("%s.CONSUMER.CREATE.%s", prefix, stream),
+		fmt.Sprintf("%s.CONSUMER.CREATE.%s.>", prefix, stream),
+		fmt.Sprintf("%s.CONSUMER.INFO.%s.*", prefix, stream),
+		fmt.Sprintf("%s.CONSUMER.DELETE.%s.*", prefix, stream),
+		"$JS.FC.>",
+	)
+}
+
 func (a *ChoriaAuth) setStreamsUserPermissions(user *server.User, org string, subs []string, pubs []string) ([]string, []string) {
 	if user.Account != a.choriaAccount {
 		return subs, pubs
@@ -958,7 +1030,8 @@ func (a *ChoriaAuth) setEventsViewerPermissions(user *server.User, subs []string
 		subs = append(subs,
 			"choria.lifecycle.event.>",
 			"choria.machine.watcher.>",
-			"choria.machine.transition")
+			"choria.machine.transition",
+			"choria.machine.transition.veto")
 	case a.provisioningAccount:
 		// provisioner should only listen to one specific kind of event, not strictly needed but its what it is
 		subs = append(subs, "choria.lifecycle.event.*.provision_mode_server")
@@ -1008,7 +1081,7 @@ func (a *ChoriaAuth) setClientTokenPermissions(user *server.User, caller string,
 		return allSubjects, allSubjects, nil, nil, nil
 	}
 
-	subs, pubs = a.setMinimalClientPermissions(user, caller, subs, pubs)
+	subs, pubs = a.setMinimalClientPermissions(user, caller, org, subs, pubs)
 
 	if client != nil {
 		subs = append(subs, client.AdditionalSubscribeSubjects...)
@@ -1127,6 +1200,7 @@ func (a *ChoriaAuth) setClaimsBasedServerPermissions(user *server.User, claims *
 		Allow: []string{
 			"choria.lifecycle.>",
 			"choria.machine.transition",
+			"choria.machine.transition.veto",
 			"choria.machine.watcher.>",
 		},
 	}
@@ -1178,6 +1252,9 @@ func (a *ChoriaAuth) setClaimsBasedServerPermissions(user *server.User, claims *
 		}
 	}
 
+	// servers always need to read the revocation list even when not allowed to use Streams
+	a.setRevocationListPermissions(user, claims.OrganizationUnit)
+
 	if claims.Permissions != nil && claims.Permissions.Streams {
 		prefix := "$JS.API"
 		if claims.OrganizationUnit != "choria" {
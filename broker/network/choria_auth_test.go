@@ -25,6 +25,7 @@ import (
 	"github.com/choria-io/go-choria/choria"
 	"github.com/choria-io/go-choria/integration/testutil"
 	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/security/denylist"
 	"github.com/choria-io/tokens"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/nats-io/nats-server/v2/server"
@@ -402,6 +403,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 							Allow: []string{
 								"choria.lifecycle.>",
 								"choria.machine.transition",
+								"choria.machine.transition.veto",
 								"choria.machine.watcher.>",
 								"c1.reply.>",
 								"c1.broadcast.agent.registration",
@@ -520,6 +522,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 							Allow: []string{
 								"choria.lifecycle.>",
 								"choria.machine.transition",
+								"choria.machine.transition.veto",
 								"choria.machine.watcher.>",
 								"c1.reply.>",
 								"c1.broadcast.agent.registration",
@@ -565,6 +568,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 							Allow: []string{
 								"choria.lifecycle.>",
 								"choria.machine.transition",
+								"choria.machine.transition.veto",
 								"choria.machine.watcher.>",
 								"c1.reply.>",
 								"c1.broadcast.agent.registration",
@@ -599,6 +603,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 								Allow: []string{
 									"choria.lifecycle.>",
 									"choria.machine.transition",
+									"choria.machine.transition.veto",
 									"choria.machine.watcher.>",
 									"c1.reply.>",
 									"c1.broadcast.agent.registration",
@@ -642,6 +647,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 								Allow: []string{
 									"choria.lifecycle.>",
 									"choria.machine.transition",
+									"choria.machine.transition.veto",
 									"choria.machine.watcher.>",
 									"c1.reply.>",
 									"c1.broadcast.agent.registration",
@@ -686,6 +692,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 							Allow: []string{
 								"choria.lifecycle.>",
 								"choria.machine.transition",
+								"choria.machine.transition.veto",
 								"choria.machine.watcher.>",
 								"other",
 								"subject",
@@ -760,8 +767,20 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 							Allow: []string{"*.reply.e33bf0376d4accbb4a8fd24b2f840b2e.>", "*.reply.f76b6d2a7755caf66ca1908e16dd59f01a466a1615e5273df535df56f471386d.>"},
 						}))
 					}
+					pubs := []string{
+						"$JS.API.STREAM.INFO.CHORIA_RPC_REPLIES",
+						"$JS.API.CONSUMER.DELETE.CHORIA_RPC_REPLIES.*",
+						"$JS.FC.CHORIA_RPC_REPLIES.>",
+					}
+					if !fips140.Enabled() {
+						pubs = append(pubs, "$JS.API.CONSUMER.CREATE.CHORIA_RPC_REPLIES.*.*.reply.e33bf0376d4accbb4a8fd24b2f840b2e.async.>")
+					}
+					pubs = append(pubs,
+						"$JS.API.CONSUMER.CREATE.CHORIA_RPC_REPLIES.*.*.reply.f76b6d2a7755caf66ca1908e16dd59f01a466a1615e5273df535df56f471386d.async.>",
+						"$SYS.REQ.USER.INFO",
+					)
 					Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
-						Allow: []string{"$SYS.REQ.USER.INFO"},
+						Allow: pubs,
 					}))
 				})
 
@@ -1776,6 +1795,37 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 				Expect(claims.CallerID).To(Equal("up=ginkgo"))
 			})
 
+			It("Should reject revoked tokens", func() {
+				edPublicKey, _, err := choria.Ed25519KeyPair()
+				Expect(err).ToNot(HaveOccurred())
+				signerPub, signerPri, err := choria.Ed25519KeyPair()
+				Expect(err).ToNot(HaveOccurred())
+
+				auth.denyList, err = denylist.New("", []string{hex.EncodeToString(signerPub)}, log)
+				Expect(err).ToNot(HaveOccurred())
+
+				auth.clientJwtSigners = []string{filepath.Join(td, "public.pem")}
+				signed := createSignedClientJWT(privateKey, map[string]any{
+					"purpose":    tokens.ClientIDPurpose,
+					"public_key": hex.EncodeToString(edPublicKey),
+				})
+
+				_, err = auth.parseClientIDJWT(signed)
+				Expect(err).ToNot(HaveOccurred())
+
+				entry, err := denylist.NewEntry(denylist.Caller, "up=ginkgo", "", time.Time{})
+				Expect(err).ToNot(HaveOccurred())
+				data, err := entry.Sign(signerPri)
+				Expect(err).ToNot(HaveOccurred())
+				key, err := entry.Key()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(auth.denyList.Put(key, data)).To(Succeed())
+
+				claims, err := auth.parseClientIDJWT(signed)
+				Expect(err).To(MatchError(denylist.ErrRevoked))
+				Expect(claims).To(BeNil())
+			})
+
 			It("Should check the public key", func() {
 				auth.clientJwtSigners = []string{filepath.Join(td, "public.pem")}
 				signed := createSignedClientJWT(privateKey, map[string]any{
@@ -1976,7 +2026,8 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 				Expect(user.Permissions.Subscribe).To(Equal(&server.SubjectPermission{
 					Allow: append(minSub, "choria.lifecycle.event.>",
 						"choria.machine.watcher.>",
-						"choria.machine.transition"),
+						"choria.machine.transition",
+						"choria.machine.transition.veto"),
 				}))
 				Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
 					Allow: minPub,
@@ -2078,8 +2129,34 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 						Allow: []string{"*.reply.0f47cbbd2accc01a51e57261d6e64b8b.>", "*.reply.67646c695fa94352ee5c860d2d0456d6d9fa98c0e213685e8ad39e9b54afae89.>"},
 					}))
 				}
+				asyncPub := []string{
+					"$JS.API.STREAM.INFO.CHORIA_RPC_REPLIES",
+					"$JS.API.CONSUMER.DELETE.CHORIA_RPC_REPLIES.*",
+					"$JS.FC.CHORIA_RPC_REPLIES.>",
+				}
+				if !fips140.Enabled() {
+					asyncPub = append(asyncPub, "$JS.API.CONSUMER.CREATE.CHORIA_RPC_REPLIES.*.*.reply.0f47cbbd2accc01a51e57261d6e64b8b.async.>")
+				}
+				asyncPub = append(asyncPub, "$JS.API.CONSUMER.CREATE.CHORIA_RPC_REPLIES.*.*.reply.67646c695fa94352ee5c860d2d0456d6d9fa98c0e213685e8ad39e9b54afae89.async.>")
 				Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
-					Allow: minPub,
+					Allow: append(asyncPub, minPub...),
+				}))
+			})
+
+			It("Should use the Streams API prefix for async replies of other organizations", func() {
+				user.Account = auth.choriaAccount
+				auth.setClientPermissions(user, "u=ginkgo", &tokens.ClientIDClaims{OrganizationUnit: "other"}, log)
+				asyncPub := []string{
+					"choria.streams.STREAM.INFO.CHORIA_RPC_REPLIES",
+					"choria.streams.CONSUMER.DELETE.CHORIA_RPC_REPLIES.*",
+					"$JS.FC.CHORIA_RPC_REPLIES.>",
+				}
+				if !fips140.Enabled() {
+					asyncPub = append(asyncPub, "choria.streams.CONSUMER.CREATE.CHORIA_RPC_REPLIES.*.*.reply.0f47cbbd2accc01a51e57261d6e64b8b.async.>")
+				}
+				asyncPub = append(asyncPub, "choria.streams.CONSUMER.CREATE.CHORIA_RPC_REPLIES.*.*.reply.67646c695fa94352ee5c860d2d0456d6d9fa98c0e213685e8ad39e9b54afae89.async.>")
+				Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
+					Allow: append(asyncPub, minPub...),
 				}))
 			})
 
@@ -2096,6 +2173,40 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 		})
 	})
 
+	Describe("setRevocationListPermissions", func() {
+		It("Should only grant access when revocation signers are set", func() {
+			user.Permissions.Publish = &server.SubjectPermission{}
+			auth.setRevocationListPermissions(user, "choria")
+			Expect(user.Permissions.Publish.Allow).To(BeEmpty())
+
+			signerPub, _, err := choria.Ed25519KeyPair()
+			Expect(err).ToNot(HaveOccurred())
+			auth.denyList, err = denylist.New("", []string{hex.EncodeToString(signerPub)}, logrus.NewEntry(logrus.New()))
+			Expect(err).ToNot(HaveOccurred())
+
+			auth.setRevocationListPermissions(user, "choria")
+			Expect(user.Permissions.Publish.Allow).To(Equal([]string{
+				"$JS.API.STREAM.INFO.KV_CHORIA_JWT_REVOCATIONS",
+				"$JS.API.STREAM.MSG.GET.KV_CHORIA_JWT_REVOCATIONS",
+				"$JS.API.DIRECT.GET.KV_CHORIA_JWT_REVOCATIONS",
+				"$JS.API.DIRECT.GET.KV_CHORIA_JWT_REVOCATIONS.>",
+				"$JS.API.CONSUMER.CREATE.KV_CHORIA_JWT_REVOCATIONS",
+				"$JS.API.CONSUMER.CREATE.KV_CHORIA_JWT_REVOCATIONS.>",
+				"$JS.API.CONSUMER.INFO.KV_CHORIA_JWT_REVOCATIONS.*",
+				"$JS.API.CONSUMER.DELETE.KV_CHORIA_JWT_REVOCATIONS.*",
+				"$JS.FC.>",
+			}))
+
+			user.Permissions.Publish = &server.SubjectPermission{}
+			auth.revocationBucket = "OTHER"
+			auth.setRevocationListPermissions(user, "other")
+			Expect(user.Permissions.Publish.Allow).To(ContainElements(
+				"choria.streams.STREAM.INFO.KV_OTHER",
+				"choria.streams.CONSUMER.CREATE.KV_OTHER.>",
+			))
+		})
+	})
+
 	Describe("setServerPermissions", func() {
 		It("Should set correct permissions", func() {
 			auth.setServerPermissions(user, nil, log)
@@ -2183,6 +2294,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 			expectedPublish := []string{
 				"choria.lifecycle.>",
 				"choria.machine.transition",
+				"choria.machine.transition.veto",
 				"choria.machine.watcher.>",
 				"custom.publish.subject",
 				collective + ".reply.>",
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/tlssetup"
 	natsd "github.com/nats-io/nats-server/v2/server"
 	"github.com/sirupsen/logrus"
 
@@ -41,6 +42,9 @@ type Server struct {
 
 	started bool
 
+	// key pairs loaded for custom gateway and leafnode tls, reloaded alongside the security provider credentials
+	keyPairs []*tlssetup.KeyPair
+
 	mu *sync.Mutex
 }
 
@@ -144,16 +148,18 @@ func NewServer(c inter.Framework, bi BuildInfoProvider, debug bool) (s *Server,
 	issuerBased := len(s.config.Choria.IssuerNames) > 0
 
 	choriaAuth := &ChoriaAuth{
-		clientAllowList: s.config.Choria.NetworkAllowedClientHosts,
-		choriaAccount:   s.choriaAccount,
-		denyServers:     s.config.Choria.NetworkDenyServers,
-		isTLS:           s.isClientTlSBroker(),
-		log:             s.choria.Logger("authentication"),
-		systemAccount:   s.systemAccount,
-		systemPass:      s.config.Choria.NetworkSystemPassword,
-		systemUser:      s.config.Choria.NetworkSystemUsername,
-		tokenCache:      make(map[string]ed25519.PublicKey),
-		issuerTokens:    make(map[string]string),
+		clientAllowList:  s.config.Choria.NetworkAllowedClientHosts,
+		choriaAccount:    s.choriaAccount,
+		denyServers:      s.config.Choria.NetworkDenyServers,
+		isTLS:            s.isClientTlSBroker(),
+		log:              s.choria.Logger("authentication"),
+		systemAccount:    s.systemAccount,
+		systemPass:       s.config.Choria.NetworkSystemPassword,
+		systemUser:       s.config.Choria.NetworkSystemUsername,
+		tokenCache:       make(map[string]ed25519.PublicKey),
+		issuerTokens:     make(map[string]string),
+		denyList:         s.choria.TokenDenyList(),
+		revocationBucket: s.config.Choria.ChoriaSecurityRevocationBucket,
 	}
 
 	if issuerBased {
@@ -254,6 +260,12 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) {
 		s.log.Errorf("could not setup system streams: %s", err)
 	}
 
+	wg.Add(1)
+	go s.watchTokenDenyList(ctx, wg)
+
+	wg.Add(1)
+	go s.watchSecurityCredentials(ctx, wg)
+
 	<-ctx.Done()
 
 	s.log.Warn("Choria Network Broker shutting down")
@@ -0,0 +1,137 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/lifecycle"
+	"github.com/nats-io/nats.go"
+)
+
+// watchSecurityCredentials periodically loads renewed certificates so new client, cluster, gateway and
+// leafnode connections use them without restarting the broker
+func (s *Server) watchSecurityCredentials(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if !s.IsTLS() {
+		return
+	}
+
+	interval := s.config.Choria.SecurityCredentialReload
+	if interval <= 0 {
+		s.log.Infof("Reloading renewed security credentials is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.reloadSecurityCredentials()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) reloadSecurityCredentials() {
+	changed, err := s.reloadCredentials()
+	if err != nil {
+		s.log.Errorf("Could not reload security credentials: %s", err)
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	s.log.Infof("Reloaded renewed security credentials: %s", strings.Join(changed, ", "))
+
+	err = s.publishCredentialsReloaded(changed)
+	if err != nil {
+		s.log.Errorf("Could not publish credentials reloaded event: %s", err)
+	}
+}
+
+func (s *Server) reloadCredentials() (changed []string, err error) {
+	var errs []error
+
+	changed, err = s.choria.ReloadSecurityCredentials()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	s.mu.Lock()
+	keyPairs := s.keyPairs
+	s.mu.Unlock()
+
+	for _, kp := range keyPairs {
+		reloaded, err := kp.Reload()
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case reloaded:
+			s.log.Infof("Reloaded certificate %s expiring %s", kp.CertFile(), kp.Certificate().Leaf.NotAfter)
+			if !slices.Contains(changed, "certificate") {
+				changed = append(changed, "certificate")
+			}
+		}
+	}
+
+	return changed, errors.Join(errs...)
+}
+
+func (s *Server) publishCredentialsReloaded(changed []string) error {
+	event, err := lifecycle.New(lifecycle.CredentialsReloaded, lifecycle.Identity(s.config.Identity), lifecycle.Component("broker"), lifecycle.Credentials(changed...))
+	if err != nil {
+		return err
+	}
+
+	if s.config.Choria.LegacyLifeCycleFormat {
+		event.SetFormat(lifecycle.ChoriaFormat)
+	} else {
+		event.SetFormat(lifecycle.CloudEventV1Format)
+	}
+
+	// in-process connections do not need tls
+	nc, err := nats.Connect(s.opts.ClientAdvertise, nats.InProcessServer(s), nats.Secure(&tls.Config{InsecureSkipVerify: true}))
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	err = lifecycle.PublishEvent(event, &eventConnector{nc})
+	if err != nil {
+		return err
+	}
+
+	return nc.Flush()
+}
+
+// eventConnector publishes lifecycle events over a plain nats connection
+type eventConnector struct {
+	nc *nats.Conn
+}
+
+func (c *eventConnector) PublishRaw(target string, data []byte) error {
+	return c.nc.Publish(target, data)
+}
+
+func (c *eventConnector) PublishRawMsg(msg *nats.Msg) error {
+	return c.nc.PublishMsg(msg)
+}
+
+func (c *eventConnector) RequestRawMsgWithContext(ctx context.Context, msg *nats.Msg) (*nats.Msg, error) {
+	return c.nc.RequestMsgWithContext(ctx, msg)
+}
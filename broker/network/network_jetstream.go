@@ -54,7 +54,7 @@ func (s *Server) configureSystemStreams(ctx context.Context) error {
 	var err error
 
 	cfg := s.config.Choria
-	if cfg.NetworkExecutorReplicas == -1 || cfg.NetworkEventStoreReplicas == -1 || cfg.NetworkMachineStoreReplicas == -1 || cfg.NetworkStreamAdvisoryReplicas == -1 || cfg.NetworkLeaderElectionReplicas == -1 {
+	if cfg.NetworkExecutorReplicas == -1 || cfg.NetworkEventStoreReplicas == -1 || cfg.NetworkMachineStoreReplicas == -1 || cfg.NetworkStreamAdvisoryReplicas == -1 || cfg.NetworkLeaderElectionReplicas == -1 || cfg.NetworkRPCReplyReplicas == -1 {
 		delay := time.Duration(rand.N(60)+10) * time.Second
 		s.log.Infof("Configuring system streams after %v", delay)
 		err = backoff.Default.Sleep(ctx, delay)
@@ -97,6 +97,11 @@ func (s *Server) configureSystemStreams(ctx context.Context) error {
 			s.log.Infof("Setting Choria Streams Executor Replicas to %d", count)
 			cfg.NetworkExecutorReplicas = count
 		}
+
+		if cfg.NetworkRPCReplyReplicas == -1 {
+			s.log.Infof("Setting Choria Streams RPC Reply Replicas to %d", count)
+			cfg.NetworkRPCReplyReplicas = count
+		}
 	}
 
 	err = backoff.TwentySec.For(ctx, func(try int) error {
@@ -134,6 +139,19 @@ func (s *Server) configureSystemStreams(ctx context.Context) error {
 		return fmt.Errorf("could not create stream CHORIA_STREAM_ADVISORIES: %w", err)
 	}
 
+	if cfg.NetworkRPCReplyStoreDuration > 0 {
+		// the subjects cover any collective and so overlaps the JetStream API, servers do not need acks for replies
+		scfg, err := jsm.NewStreamConfiguration(jsm.DefaultStream, jsm.FileStorage(), jsm.Subjects("*.reply.*.async.>"), jsm.MaxAge(cfg.NetworkRPCReplyStoreDuration), jsm.Replicas(cfg.NetworkRPCReplyReplicas), jsm.NoAck())
+		if err != nil {
+			return fmt.Errorf("could not create configuration: %s", err)
+		}
+
+		err = s.createOrUpdateStreamWithConfig("CHORIA_RPC_REPLIES", *scfg, mgr)
+		if err != nil {
+			return fmt.Errorf("could not create stream CHORIA_RPC_REPLIES: %w", err)
+		}
+	}
+
 	err = scout.ConfigureStreams(nc, s.log.WithField("component", "scout"))
 	if err != nil {
 		return err
@@ -0,0 +1,51 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+
+	"github.com/choria-io/go-choria/backoff"
+	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/nats-io/nats.go"
+)
+
+// watchTokenDenyList keeps the list of revoked JWTs used to authenticate connections up to date
+func (s *Server) watchTokenDenyList(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	list := s.choria.TokenDenyList()
+	if list == nil {
+		return
+	}
+
+	s.log.Infof("Rejecting revoked JWT tokens listed in the %s bucket", list.Bucket())
+
+	var nc *nats.Conn
+
+	err := backoff.TwentySec.For(ctx, func(try int) error {
+		var err error
+
+		// in-process connections do not need tls
+		nc, err = nats.Connect(s.opts.ClientAdvertise, nats.InProcessServer(s), nats.Secure(&tls.Config{InsecureSkipVerify: true}))
+		if err != nil {
+			s.log.Warnf("Could not connect to broker using in-process connection to watch JWT revocations: %s", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	defer nc.Close()
+
+	wg.Add(1)
+	list.Watch(ctx, wg, func(_ context.Context) (nats.KeyValue, error) {
+		return kv.LoadKV(nc, list.Bucket())
+	})
+}
@@ -7,8 +7,9 @@ package network
 import (
 	"crypto/tls"
 	"crypto/x509"
-	"fmt"
 	"os"
+
+	"github.com/choria-io/go-choria/tlssetup"
 )
 
 func (s *Server) extractKeyedConfigString(prefix string, key string, property string, dflt string) (result string) {
@@ -62,17 +63,16 @@ func (s *Server) genTLSc(pri string, pub string, ca string, verify bool) (tlsc *
 	}
 
 	if pri != "" && pub != "" {
-		cert, err := tls.LoadX509KeyPair(pub, pri)
+		kp, err := tlssetup.NewKeyPair(pub, pri)
 		if err != nil {
-			return nil, fmt.Errorf("could not load certificate %s and key %s: %s", pub, pri, err)
+			return nil, err
 		}
 
-		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
-		if err != nil {
-			return nil, fmt.Errorf("error parsing certificate: %v", err)
-		}
+		kp.Configure(tlsc)
 
-		tlsc.Certificates = []tls.Certificate{cert}
+		s.mu.Lock()
+		s.keyPairs = append(s.keyPairs, kp)
+		s.mu.Unlock()
 	}
 
 	if ca != "" {
@@ -6,6 +6,7 @@ package choria
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/fips140"
 	"crypto/md5"
 	"crypto/sha256"
@@ -19,7 +20,6 @@ import (
 	"time"
 
 	"github.com/choria-io/go-choria/inter"
-	"github.com/choria-io/tokens"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/choria-io/go-choria/backoff"
@@ -84,7 +84,9 @@ type Connection struct {
 	outbox            chan *nats.Msg
 	subMu             sync.Mutex
 	conMu             sync.Mutex
+	tokenMu           sync.Mutex
 	token             string
+	seed              ed25519.PrivateKey
 	expire            time.Time
 	uniqueId          string
 	ipc               bool
@@ -620,6 +622,84 @@ func (conn *Connection) isJwtAuth() bool {
 	return (conn.config.Choria.ServerAnonTLS || conn.config.Choria.ClientAnonTLS || conn.fw.security.BackingTechnology() == inter.SecurityTechnologyED25519JWT) && !conn.fw.ProvisionMode()
 }
 
+// currentToken is the token to authenticate with, once the security provider loaded a renewed token and verified
+// it against its seed that token replaces the one the connection was made with as long as it is for the same
+// identity since reply subjects can not change while connected
+func (conn *Connection) currentToken() string {
+	conn.tokenMu.Lock()
+	defer conn.tokenMu.Unlock()
+
+	reloader, ok := conn.fw.security.(inter.TokenReloader)
+	if !ok {
+		return conn.token
+	}
+
+	token, seed := reloader.ReloadedToken()
+	if token == "" || token == conn.token {
+		return conn.token
+	}
+
+	exp, err := conn.fw.signerTokenExpiry(token, "renewed token")
+	if err != nil {
+		conn.log.Warnf("Could not use the renewed JWT token, using the previously loaded token: %s", err)
+		return conn.token
+	}
+
+	_, uid, token, err := conn.fw.uniqueIDFromUnverifiedToken(token)
+	switch {
+	case err != nil:
+		conn.log.Warnf("Could not use the renewed JWT token, using the previously loaded token: %s", err)
+
+	case uid != conn.uniqueId:
+		conn.log.Errorf("The JWT token was replaced with one for a different identity, using the previously loaded token")
+
+	default:
+		conn.log.Infof("Using renewed JWT token valid for %v", time.Until(exp))
+		conn.token = token
+		conn.seed = seed
+		conn.expire = exp
+	}
+
+	return conn.token
+}
+
+func (conn *Connection) userJWT() (string, error) {
+	token := conn.currentToken()
+
+	conn.tokenMu.Lock()
+	exp := conn.expire
+	conn.tokenMu.Unlock()
+
+	if time.Now().After(exp) {
+		conn.log.Errorf("Cannot sign connection NONCE: token is expired by %v", time.Since(exp))
+		return "", fmt.Errorf("token expired")
+	}
+
+	return token, nil
+}
+
+func (conn *Connection) signNonce(seedFile string, nonce []byte) ([]byte, error) {
+	conn.tokenMu.Lock()
+	exp := conn.expire
+	seed := conn.seed
+	conn.tokenMu.Unlock()
+
+	if time.Now().After(exp) {
+		conn.log.Errorf("Cannot sign connection NONCE: token is expired by %v", time.Since(exp))
+		return nil, fmt.Errorf("token expired")
+	}
+
+	// a renewed token is paired with the seed it was verified against as the seed file might already be replaced again
+	if seed != nil {
+		conn.log.Debugf("Signing nonce using the seed matching the renewed token")
+		return util.Ed25519Sign(seed, nonce)
+	}
+
+	conn.log.Debugf("Signing nonce using seed file %s", seedFile)
+
+	return util.Ed25519SignWithSeedFile(seedFile, nonce)
+}
+
 // Connect creates a new connection to NATS.
 //
 // This will block until connected - basically forever should it never work.  Due to shortcomings
@@ -734,15 +814,15 @@ func (conn *Connection) Connect(ctx context.Context) (err error) {
 		if conn.token == "" {
 			return fmt.Errorf("no valid token found to sign connection NONCE")
 		}
-		options = append(options, nats.Token(conn.token))
+
+		// handlers read the token on every connection attempt so renewed tokens are used on reconnect
+		options = append(options, nats.TokenHandler(conn.currentToken))
 
 		seedFile, err := conn.fw.SignerSeedFile()
 		if err == nil && seedFile != "" {
-			_, jwth, sigh, err := tokens.NatsConnectionHelpers(conn.token, conn.config.MainCollective, seedFile, conn.log)
-			if err != nil {
-				return err
-			}
-			options = append(options, nats.UserJWT(jwth, sigh))
+			options = append(options, nats.UserJWT(conn.userJWT, func(nonce []byte) ([]byte, error) {
+				return conn.signNonce(seedFile, nonce)
+			}))
 		}
 
 	case conn.config.DisableTLS:
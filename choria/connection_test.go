@@ -6,6 +6,7 @@ package choria
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/fips140"
 	"crypto/md5"
 	"crypto/sha256"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/inter"
 	imock "github.com/choria-io/go-choria/inter/imocks"
 	"github.com/choria-io/tokens"
 	. "github.com/onsi/ginkgo/v2"
@@ -81,11 +83,8 @@ var _ = Describe("Connector", func() {
 	})
 
 	Describe("NewConnector", func() {
-		genToken := func(id string) string {
-			tf, err := os.CreateTemp("", "")
-			Expect(err).ToNot(HaveOccurred())
-
-			pk, _, err := Ed25519KeyPair()
+		newToken := func(id string) (string, ed25519.PrivateKey) {
+			pk, sk, err := Ed25519KeyPair()
 			Expect(err).ToNot(HaveOccurred())
 
 			t, err := tokens.NewServerClaims(id, []string{"choria"}, "choria", nil, []string{}, pk, "ginkgo", time.Hour)
@@ -93,6 +92,15 @@ var _ = Describe("Connector", func() {
 			s, err := tokens.SignTokenWithKeyFile(t, "testdata/rsa/signer-key.pem")
 			Expect(err).ToNot(HaveOccurred())
 
+			return s, sk
+		}
+
+		genToken := func(id string) string {
+			tf, err := os.CreateTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			s, _ := newToken(id)
+
 			_, err = tf.WriteString(s)
 			Expect(err).ToNot(HaveOccurred())
 			tf.Close()
@@ -130,5 +138,52 @@ var _ = Describe("Connector", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(conn).ToNot(BeNil())
 		})
+
+		It("Should only use renewed tokens verified by the security provider", func() {
+			cfg.InitiatedByServer = true
+			cfg.Choria.ServerAnonTLS = true
+			fw, err := NewWithConfig(cfg)
+			Expect(err).ToNot(HaveOccurred())
+
+			t := genToken(cfg.Identity)
+			defer os.RemoveAll(t)
+			cfg.Choria.ServerTokenFile = t
+
+			security := &tokenReloadingSecurity{SecurityProvider: fw.security}
+			fw.security = security
+
+			connector, err := fw.NewConnector(context.Background(), fw.MiddlewareServers, "ginkgo", fw.Logger("ginkgo"))
+			Expect(err).ToNot(HaveOccurred())
+			conn := connector.(*Connection)
+			original := conn.currentToken()
+
+			// a renewed token on disk is not used till the security provider verified it against its seed
+			renewed, seed := newToken(cfg.Identity)
+			Expect(os.WriteFile(t, []byte(renewed), 0600)).To(Succeed())
+			Expect(conn.currentToken()).To(Equal(original))
+
+			other, _ := newToken("other.example.net")
+			security.token = other
+			Expect(conn.currentToken()).To(Equal(original))
+
+			security.token = renewed
+			security.seed = seed
+			Expect(conn.currentToken()).To(Equal(renewed))
+
+			// the nonce is signed using the seed matching the renewed token
+			sig, err := conn.signNonce("/nonexisting", []byte("nonce"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ed25519.Verify(seed.Public().(ed25519.PublicKey), []byte("nonce"), sig)).To(BeTrue())
+		})
 	})
 })
+
+type tokenReloadingSecurity struct {
+	inter.SecurityProvider
+	token string
+	seed  ed25519.PrivateKey
+}
+
+func (s *tokenReloadingSecurity) ReloadedToken() (string, ed25519.PrivateKey) {
+	return s.token, s.seed
+}
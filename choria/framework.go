@@ -44,6 +44,7 @@ import (
 	"github.com/choria-io/go-choria/build"
 	"github.com/choria-io/go-choria/config"
 
+	"github.com/choria-io/go-choria/providers/security/denylist"
 	"github.com/choria-io/go-choria/providers/security/filesec"
 	"github.com/choria-io/go-choria/providers/security/puppetsec"
 	"github.com/choria-io/go-choria/puppet"
@@ -58,6 +59,7 @@ type Framework struct {
 	inProcessConnection nats.InProcessConnProvider
 	customRequestSigner inter.RequestSigner
 	security            inter.SecurityProvider
+	denyList            *denylist.List
 	log                 *log.Logger
 
 	bi       *build.Info
@@ -156,6 +158,13 @@ func (fw *Framework) setupSecurity() error {
 		signer = signers.NewAAAServiceHTTPSigner()
 	}
 
+	if len(fw.Config.Choria.ChoriaSecurityRevocationSigners) > 0 {
+		fw.denyList, err = denylist.New(fw.Config.Choria.ChoriaSecurityRevocationBucket, fw.Config.Choria.ChoriaSecurityRevocationSigners, fw.Logger("revocation"))
+		if err != nil {
+			return fmt.Errorf("invalid JWT revocation configuration: %s", err)
+		}
+	}
+
 	switch fw.Config.Choria.SecurityProvider {
 	case "puppet":
 		fw.security, err = puppetsec.New(
@@ -183,7 +192,8 @@ func (fw *Framework) setupSecurity() error {
 		fw.security, err = choria.New(
 			choria.WithChoriaConfig(fw.Config),
 			choria.WithLog(fw.Logger("security")),
-			choria.WithSigner(signer))
+			choria.WithSigner(signer),
+			choria.WithDenyList(fw.denyList))
 
 	default:
 		err = fmt.Errorf("unknown security provider %s", fw.Config.Choria.SecurityProvider)
@@ -748,6 +758,12 @@ func (fw *Framework) UniqueIDFromUnverifiedToken() (id string, uid string, exp t
 		return "", "", exp, "", err
 	}
 
+	id, uid, token, err = fw.uniqueIDFromUnverifiedToken(ts)
+
+	return id, uid, exp, token, err
+}
+
+func (fw *Framework) uniqueIDFromUnverifiedToken(ts string) (id string, uid string, token string, err error) {
 	var t *jwt.Token
 	var tid string
 
@@ -757,14 +773,14 @@ func (fw *Framework) UniqueIDFromUnverifiedToken() (id string, uid string, exp t
 		t, tid, err = tokens.UnverifiedCallerFromClientIDToken(ts)
 	}
 	if err != nil {
-		return "", "", exp, "", err
+		return "", "", "", err
 	}
 
 	if fips140.Enabled() {
-		return tid, fmt.Sprintf("%x", sha256.Sum256([]byte(tid))), exp, t.Raw, nil
+		return tid, fmt.Sprintf("%x", sha256.Sum256([]byte(tid))), t.Raw, nil
 	}
 
-	return tid, fmt.Sprintf("%x", md5.Sum([]byte(tid))), exp, t.Raw, nil
+	return tid, fmt.Sprintf("%x", md5.Sum([]byte(tid))), t.Raw, nil
 }
 
 // SignerSeedFile is the path to the seed file for JWT auth
@@ -824,29 +840,41 @@ func (fw *Framework) SignerToken() (token string, expiry time.Time, err error) {
 		return "", exp, fmt.Errorf("could not read token file: %v", err)
 	}
 
-	purpose := tokens.TokenPurpose(string(tb))
+	exp, err = fw.signerTokenExpiry(string(tb), tf)
+	if err != nil {
+		return "", exp, err
+	}
+
+	return strings.TrimSpace(string(tb)), exp, nil
+}
+
+// signerTokenExpiry validates token for use as signer token and determines its expiry time, source names the token in logs and errors
+func (fw *Framework) signerTokenExpiry(token string, source string) (time.Time, error) {
+	var exp time.Time
+
+	purpose := tokens.TokenPurpose(token)
 	switch purpose {
 	case tokens.ClientIDPurpose:
-		claims, err := tokens.ParseClientIDTokenUnverified(string(tb))
+		claims, err := tokens.ParseClientIDTokenUnverified(token)
 		if err != nil {
-			return "", exp, err
+			return exp, err
 		}
 		err = util.IsValidJwt(claims)
 		if err != nil {
-			fw.log.Warnf("Authentication token %s is not valid: %v", tf, err)
-			return "", exp, err
+			fw.log.Warnf("Authentication token %s is not valid: %v", source, err)
+			return exp, err
 		}
 		exp = claims.ExpireTime()
 
 	case tokens.ServerPurpose:
-		claims, err := tokens.ParseServerTokenUnverified(string(tb))
+		claims, err := tokens.ParseServerTokenUnverified(token)
 		if err != nil {
-			return "", exp, err
+			return exp, err
 		}
 		err = util.IsValidJwt(claims)
 		if err != nil {
-			fw.log.Warnf("Authentication token %s is not valid: %v", tf, err)
-			return "", exp, err
+			fw.log.Warnf("Authentication token %s is not valid: %v", source, err)
+			return exp, err
 		}
 		exp = claims.ExpireTime()
 
@@ -854,10 +882,10 @@ func (fw *Framework) SignerToken() (token string, expiry time.Time, err error) {
 		// nothing to verify here
 
 	default:
-		return "", exp, fmt.Errorf("cannot use token %s with purpose %q as signer token", tf, purpose)
+		return exp, fmt.Errorf("cannot use token %s with purpose %q as signer token", source, purpose)
 	}
 
-	return strings.TrimSpace(string(tb)), exp, err
+	return exp, nil
 }
 
 // HTTPClient creates a *http.Client prepared by the security provider with certificates and more set
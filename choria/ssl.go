@@ -9,6 +9,9 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"time"
+
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/providers/security/denylist"
 )
 
 // PublicCert is the parsed public certificate
@@ -47,3 +50,29 @@ func (fw *Framework) ValidateSecurity() (errors []string, ok bool) {
 func (fw *Framework) SecurityProvider() string {
 	return fw.security.Provider()
 }
+
+// SignBytes signs b using the active security provider
+func (fw *Framework) SignBytes(b []byte) ([]byte, error) {
+	return fw.security.SignBytes(b)
+}
+
+// VerifySignatureBytes verifies that sig over dat was made by the holder of public using the active security provider
+func (fw *Framework) VerifySignatureBytes(dat []byte, sig []byte, public ...[]byte) (bool, string) {
+	return fw.security.VerifySignatureBytes(dat, sig, public...)
+}
+
+// TokenDenyList is the list of revoked JWT tokens, nil unless plugin.security.choria.revocation_signers is set
+func (fw *Framework) TokenDenyList() *denylist.List {
+	return fw.denyList
+}
+
+// ReloadSecurityCredentials loads renewed certificates and tokens when the security provider supports it, changed
+// lists the kinds of credential that were reloaded
+func (fw *Framework) ReloadSecurityCredentials() (changed []string, err error) {
+	reloader, ok := fw.security.(inter.CredentialReloader)
+	if !ok {
+		return nil, nil
+	}
+
+	return reloader.ReloadCredentials()
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/choria-io/go-choria/providers/discovery/external"
 	"github.com/choria-io/go-choria/providers/discovery/inventory"
 	"github.com/choria-io/go-choria/providers/discovery/puppetdb"
+	"github.com/choria-io/go-choria/providers/discovery/registry"
 )
 
 // InventoryNS is a NodeSource that uses Choria inventory to discover nodes
@@ -75,6 +76,68 @@ func (b *InventoryNS) Discover(ctx context.Context, fw inter.Framework, filters
 	return copier(), nil
 }
 
+// RegistryNS is a NodeSource that uses node inventories stored in Choria Streams to discover nodes
+type RegistryNS struct {
+	// Collective is the collective to discover nodes in, defaults to the main collective
+	Collective string
+
+	nodeCache []string
+	f         *protocol.Filter
+
+	sync.Mutex
+}
+
+// Reset resets the internal node cache
+func (r *RegistryNS) Reset() {
+	r.Lock()
+	defer r.Unlock()
+
+	r.nodeCache = []string{}
+}
+
+// Discover performs the discovery of nodes against the Choria Streams registry
+func (r *RegistryNS) Discover(ctx context.Context, fw inter.Framework, filters []FilterFunc) ([]string, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	copier := func() []string {
+		out := make([]string, len(r.nodeCache))
+		copy(out, r.nodeCache)
+
+		return out
+	}
+
+	if len(r.nodeCache) > 0 {
+		return copier(), nil
+	}
+
+	var err error
+
+	r.f, err = parseFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.nodeCache == nil {
+		r.nodeCache = []string{}
+	}
+
+	cfg := fw.Configuration()
+	collective := r.Collective
+	if collective == "" {
+		collective = cfg.MainCollective
+	}
+
+	nodes, err := registry.New(fw).Discover(ctx, registry.Filter(r.f), registry.Collective(collective), registry.Timeout(time.Second*time.Duration(cfg.DiscoveryTimeout)))
+	if err != nil {
+		return []string{}, err
+	}
+
+	r.nodeCache = nodes
+
+	return copier(), nil
+}
+
 // BroadcastNS is a NodeSource that uses the Choria network broadcast method to discover nodes
 type BroadcastNS struct {
 	nodeCache []string
@@ -49,6 +49,8 @@ func DiscoveryMethod(m string) InitializationOption {
 			o.ns = &PuppetDBNS{}
 		case "inventory":
 			o.ns = &InventoryNS{}
+		case "registry":
+			o.ns = &RegistryNS{}
 		default:
 			o.ns = &BroadcastNS{}
 		}
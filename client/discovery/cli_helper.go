@@ -23,6 +23,7 @@ import (
 	"github.com/choria-io/go-choria/providers/discovery/flatfile"
 	"github.com/choria-io/go-choria/providers/discovery/inventory"
 	"github.com/choria-io/go-choria/providers/discovery/puppetdb"
+	"github.com/choria-io/go-choria/providers/discovery/registry"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -86,7 +87,7 @@ func (o *StandardOptions) Merge(opt *StandardOptions) {
 
 // AddSelectionFlags adds the --dm and --discovery-timeout options
 func (o *StandardOptions) AddSelectionFlags(app inter.FlagApp) {
-	app.Flag("dm", "Sets a discovery method (mc, choria, file, external, inventory)").EnumVar(&o.DiscoveryMethod, "broadcast", "choria", "mc", "file", "flatfile", "external", "inventory")
+	app.Flag("dm", "Sets a discovery method (mc, choria, file, external, inventory, registry)").EnumVar(&o.DiscoveryMethod, "broadcast", "choria", "mc", "file", "flatfile", "external", "inventory", "registry")
 	app.Flag("discovery-timeout", "Timeout for doing discovery").PlaceHolder("SECONDS").IntVar(&o.DiscoveryTimeout)
 	app.Flag("discovery-window", "Enables a sliding window based dynamic discovery timeout (experimental)").UnNegatableBoolVar(&o.DynamicDiscoveryTimeout)
 }
@@ -142,7 +143,7 @@ func (o *StandardOptions) Discover(ctx context.Context, fw inter.Framework, agen
 			return nil, 0, err
 		}
 
-	case len(filter.Compound) > 0 && o.DiscoveryMethod != "broadcast" && o.DiscoveryMethod != "inventory" && o.DiscoveryMethod != "mc":
+	case len(filter.Compound) > 0 && o.DiscoveryMethod != "broadcast" && o.DiscoveryMethod != "inventory" && o.DiscoveryMethod != "registry" && o.DiscoveryMethod != "mc":
 		o.DiscoveryMethod = "broadcast"
 		logger.Debugf("Forcing discovery mode to broadcast to support compound filters")
 
@@ -196,6 +197,8 @@ func (o *StandardOptions) Discover(ctx context.Context, fw inter.Framework, agen
 		nodes, err = flatfile.New(fw).Discover(ctx, flatfile.Reader(sourceFile), flatfile.Format(fformat), flatfile.DiscoveryOptions(o.DiscoveryOptions))
 	case "inventory":
 		nodes, err = inventory.New(fw).Discover(ctx, inventory.Filter(filter), inventory.Collective(o.Collective), inventory.DiscoveryOptions(o.DiscoveryOptions))
+	case "registry":
+		nodes, err = registry.New(fw).Discover(ctx, registry.Filter(filter), registry.Collective(o.Collective), registry.Timeout(to), registry.DiscoveryOptions(o.DiscoveryOptions))
 	default:
 		return nil, 0, fmt.Errorf("unsupported discovery method %q", o.DiscoveryMethod)
 	}
@@ -0,0 +1,279 @@
+// generated code; DO NOT EDIT
+//
+// Client for Choria RPC Agent 'executor' Version 0.29.4 generated using Choria version 0.29.4
+
+package executorclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/choria-io/go-choria/protocol"
+	rpcclient "github.com/choria-io/go-choria/providers/agent/mcorpc/client"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc/ddl/agent"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc/replyfmt"
+)
+
+// PurgeRequester performs a RPC request to executor#purge
+type PurgeRequester struct {
+	r    *requester
+	outc chan *PurgeOutput
+}
+
+// PurgeOutput is the output from the purge action
+type PurgeOutput struct {
+	details *ResultDetails
+	reply   map[string]any
+}
+
+// PurgeResult is the result from a purge action
+type PurgeResult struct {
+	ddl        *agent.DDL
+	stats      *rpcclient.Stats
+	outputs    []*PurgeOutput
+	rpcreplies []*replyfmt.RPCReply
+	mu         sync.Mutex
+}
+
+func (d *PurgeResult) RenderResults(w io.Writer, format RenderFormat, displayMode DisplayMode, verbose bool, silent bool, colorize bool, log Log) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stats == nil {
+		return fmt.Errorf("result stats is not set, result was not completed")
+	}
+
+	results := &replyfmt.RPCResults{
+		Agent:   d.stats.Agent(),
+		Action:  d.stats.Action(),
+		Replies: d.rpcreplies,
+		Stats:   d.stats,
+	}
+
+	addl, err := d.ddl.ActionInterface(d.stats.Action())
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case JSONFormat:
+		return results.RenderJSON(w, addl)
+	case TableFormat:
+		return results.RenderTable(w, addl)
+	case TXTFooter:
+		results.RenderTXTFooter(w, verbose)
+		return nil
+	default:
+		return results.RenderTXT(w, addl, verbose, silent, replyfmt.DisplayMode(displayMode), colorize, log)
+	}
+}
+
+// Stats is the rpc request stats
+func (d *PurgeResult) Stats() Stats {
+	return d.stats
+}
+
+// ResultDetails is the details about the request
+func (d *PurgeOutput) ResultDetails() *ResultDetails {
+	return d.details
+}
+
+// HashMap is the raw output data
+func (d *PurgeOutput) HashMap() map[string]any {
+	return d.reply
+}
+
+// JSON is the JSON representation of the output data
+func (d *PurgeOutput) JSON() ([]byte, error) {
+	return json.Marshal(d.reply)
+}
+
+// ParsePurgeOutput parses the result value from the Purge action into target
+func (d *PurgeOutput) ParsePurgeOutput(target any) error {
+	j, err := d.JSON()
+	if err != nil {
+		return fmt.Errorf("could not access payload: %s", err)
+	}
+
+	err = json.Unmarshal(j, target)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal JSON payload: %s", err)
+	}
+
+	return nil
+}
+
+// Do performs the request
+func (d *PurgeRequester) Do(ctx context.Context) (*PurgeResult, error) {
+	dres := &PurgeResult{ddl: d.r.client.ddl}
+
+	addl, err := dres.ddl.ActionInterface(d.r.action)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := func(pr protocol.Reply, r *rpcclient.RPCReply) {
+		// filtered by expr filter
+		if r == nil {
+			return
+		}
+
+		output := &PurgeOutput{
+			reply: make(map[string]any),
+			details: &ResultDetails{
+				sender:  pr.SenderID(),
+				code:    int(r.Statuscode),
+				message: r.Statusmsg,
+				ts:      pr.Time(),
+			},
+		}
+
+		addl.SetOutputDefaults(output.reply)
+
+		err := json.Unmarshal(r.Data, &output.reply)
+		if err != nil {
+			d.r.client.errorf("Could not decode reply from %s: %s", pr.SenderID(), err)
+		}
+
+		// caller wants a channel so we dont return a resultset too (lots of memory etc)
+		// this is unused now, no support for setting a channel
+		if d.outc != nil {
+			d.outc <- output
+			return
+		}
+
+		// else prepare our result set
+		dres.mu.Lock()
+		dres.outputs = append(dres.outputs, output)
+		dres.rpcreplies = append(dres.rpcreplies, &replyfmt.RPCReply{
+			Sender:   pr.SenderID(),
+			RPCReply: r,
+		})
+		dres.mu.Unlock()
+	}
+
+	res, err := d.r.do(ctx, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	dres.stats = res
+
+	return dres, nil
+}
+
+// AllOutputs provide access to all outputs
+func (d *PurgeResult) AllOutputs() []*PurgeOutput {
+	return d.outputs
+}
+
+// EachOutput iterates over all results received
+func (d *PurgeResult) EachOutput(h func(r *PurgeOutput)) {
+	for _, resp := range d.outputs {
+		h(resp)
+	}
+}
+
+// Action is an optional input to the purge action
+//
+// Description: The action that created a job
+func (d *PurgeRequester) Action(v string) *PurgeRequester {
+	d.r.args["action"] = v
+
+	return d
+}
+
+// Agent is an optional input to the purge action
+//
+// Description: The agent that create a job
+func (d *PurgeRequester) Agent(v string) *PurgeRequester {
+	d.r.args["agent"] = v
+
+	return d
+}
+
+// Before is an optional input to the purge action
+//
+// Description: Unix timestamp to limit jobs on
+func (d *PurgeRequester) Before(v int64) *PurgeRequester {
+	d.r.args["before"] = v
+
+	return d
+}
+
+// Caller is an optional input to the purge action
+//
+// Description: The caller id that created a job
+func (d *PurgeRequester) Caller(v string) *PurgeRequester {
+	d.r.args["caller"] = v
+
+	return d
+}
+
+// Command is an optional input to the purge action
+//
+// Description: The command that was executed
+func (d *PurgeRequester) Command(v string) *PurgeRequester {
+	d.r.args["command"] = v
+
+	return d
+}
+
+// Completed is an optional input to the purge action
+//
+// Description: Limit to jobs that were completed
+func (d *PurgeRequester) Completed(v bool) *PurgeRequester {
+	d.r.args["completed"] = v
+
+	return d
+}
+
+// Identity is an optional input to the purge action
+//
+// Description: The host identity that created the job
+func (d *PurgeRequester) Identity(v string) *PurgeRequester {
+	d.r.args["identity"] = v
+
+	return d
+}
+
+// Requestid is an optional input to the purge action
+//
+// Description: The Request ID that created the job
+func (d *PurgeRequester) Requestid(v string) *PurgeRequester {
+	d.r.args["requestid"] = v
+
+	return d
+}
+
+// Since is an optional input to the purge action
+//
+// Description: Unix timestamp to limit jobs on
+func (d *PurgeRequester) Since(v int64) *PurgeRequester {
+	d.r.args["since"] = v
+
+	return d
+}
+
+// Purged is the value of the purged output
+//
+// Description: List of job IDs that were removed
+func (d *PurgeOutput) Purged() []any {
+	val := d.reply["purged"]
+
+	return val.([]any)
+
+}
+
+// Skipped is the value of the skipped output
+//
+// Description: The number of matched jobs that could not be removed
+func (d *PurgeOutput) Skipped() int64 {
+	val := d.reply["skipped"]
+
+	return val.(int64)
+
+}
@@ -0,0 +1,224 @@
+// generated code; DO NOT EDIT
+//
+// Client for Choria RPC Agent 'executor' Version 0.29.4 generated using Choria version 0.29.4
+
+package executorclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/choria-io/go-choria/protocol"
+	rpcclient "github.com/choria-io/go-choria/providers/agent/mcorpc/client"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc/ddl/agent"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc/replyfmt"
+)
+
+// StartRequester performs a RPC request to executor#start
+type StartRequester struct {
+	r    *requester
+	outc chan *StartOutput
+}
+
+// StartOutput is the output from the start action
+type StartOutput struct {
+	details *ResultDetails
+	reply   map[string]any
+}
+
+// StartResult is the result from a start action
+type StartResult struct {
+	ddl        *agent.DDL
+	stats      *rpcclient.Stats
+	outputs    []*StartOutput
+	rpcreplies []*replyfmt.RPCReply
+	mu         sync.Mutex
+}
+
+func (d *StartResult) RenderResults(w io.Writer, format RenderFormat, displayMode DisplayMode, verbose bool, silent bool, colorize bool, log Log) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stats == nil {
+		return fmt.Errorf("result stats is not set, result was not completed")
+	}
+
+	results := &replyfmt.RPCResults{
+		Agent:   d.stats.Agent(),
+		Action:  d.stats.Action(),
+		Replies: d.rpcreplies,
+		Stats:   d.stats,
+	}
+
+	addl, err := d.ddl.ActionInterface(d.stats.Action())
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case JSONFormat:
+		return results.RenderJSON(w, addl)
+	case TableFormat:
+		return results.RenderTable(w, addl)
+	case TXTFooter:
+		results.RenderTXTFooter(w, verbose)
+		return nil
+	default:
+		return results.RenderTXT(w, addl, verbose, silent, replyfmt.DisplayMode(displayMode), colorize, log)
+	}
+}
+
+// Stats is the rpc request stats
+func (d *StartResult) Stats() Stats {
+	return d.stats
+}
+
+// ResultDetails is the details about the request
+func (d *StartOutput) ResultDetails() *ResultDetails {
+	return d.details
+}
+
+// HashMap is the raw output data
+func (d *StartOutput) HashMap() map[string]any {
+	return d.reply
+}
+
+// JSON is the JSON representation of the output data
+func (d *StartOutput) JSON() ([]byte, error) {
+	return json.Marshal(d.reply)
+}
+
+// ParseStartOutput parses the result value from the Start action into target
+func (d *StartOutput) ParseStartOutput(target any) error {
+	j, err := d.JSON()
+	if err != nil {
+		return fmt.Errorf("could not access payload: %s", err)
+	}
+
+	err = json.Unmarshal(j, target)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal JSON payload: %s", err)
+	}
+
+	return nil
+}
+
+// Do performs the request
+func (d *StartRequester) Do(ctx context.Context) (*StartResult, error) {
+	dres := &StartResult{ddl: d.r.client.ddl}
+
+	addl, err := dres.ddl.ActionInterface(d.r.action)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := func(pr protocol.Reply, r *rpcclient.RPCReply) {
+		// filtered by expr filter
+		if r == nil {
+			return
+		}
+
+		output := &StartOutput{
+			reply: make(map[string]any),
+			details: &ResultDetails{
+				sender:  pr.SenderID(),
+				code:    int(r.Statuscode),
+				message: r.Statusmsg,
+				ts:      pr.Time(),
+			},
+		}
+
+		addl.SetOutputDefaults(output.reply)
+
+		err := json.Unmarshal(r.Data, &output.reply)
+		if err != nil {
+			d.r.client.errorf("Could not decode reply from %s: %s", pr.SenderID(), err)
+		}
+
+		// caller wants a channel so we dont return a resultset too (lots of memory etc)
+		// this is unused now, no support for setting a channel
+		if d.outc != nil {
+			d.outc <- output
+			return
+		}
+
+		// else prepare our result set
+		dres.mu.Lock()
+		dres.outputs = append(dres.outputs, output)
+		dres.rpcreplies = append(dres.rpcreplies, &replyfmt.RPCReply{
+			Sender:   pr.SenderID(),
+			RPCReply: r,
+		})
+		dres.mu.Unlock()
+	}
+
+	res, err := d.r.do(ctx, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	dres.stats = res
+
+	return dres, nil
+}
+
+// AllOutputs provide access to all outputs
+func (d *StartResult) AllOutputs() []*StartOutput {
+	return d.outputs
+}
+
+// EachOutput iterates over all results received
+func (d *StartResult) EachOutput(h func(r *StartOutput)) {
+	for _, resp := range d.outputs {
+		h(resp)
+	}
+}
+
+// Args is an optional input to the start action
+//
+// Description: Arguments to pass to the command
+func (d *StartRequester) Args(v []any) *StartRequester {
+	d.r.args["args"] = v
+
+	return d
+}
+
+// Environment is an optional input to the start action
+//
+// Description: Environment variables to set for the command
+func (d *StartRequester) Environment(v map[string]any) *StartRequester {
+	d.r.args["environment"] = v
+
+	return d
+}
+
+// Heartbeat is an optional input to the start action
+//
+// Description: Interval in seconds between heartbeats published about the running command, 0 disables
+func (d *StartRequester) Heartbeat(v int64) *StartRequester {
+	d.r.args["heartbeat"] = v
+
+	return d
+}
+
+// TrackOutput is an optional input to the start action
+//
+// Description: Publishes the command output to Choria Submission
+func (d *StartRequester) TrackOutput(v bool) *StartRequester {
+	d.r.args["track_output"] = v
+
+	return d
+}
+
+// Id is the value of the id output
+//
+// Description: The unique ID for the job
+func (d *StartOutput) Id() string {
+	val := d.reply["id"]
+
+	return val.(string)
+
+}
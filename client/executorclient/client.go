@@ -252,6 +252,66 @@ func (p *ExecutorClient) List() *ListRequester {
 	return d
 }
 
+// Purge performs the purge action
+//
+// Description: Removes completed jobs matching certain criteria from the spool
+//
+// Optional Inputs:
+//   - action (string) - The action that created a job
+//   - agent (string) - The agent that create a job
+//   - before (int64) - Unix timestamp to limit jobs on
+//   - caller (string) - The caller id that created a job
+//   - command (string) - The command that was executed
+//   - completed (bool) - Limit to jobs that were completed
+//   - identity (string) - The host identity that created the job
+//   - requestid (string) - The Request ID that created the job
+//   - since (int64) - Unix timestamp to limit jobs on
+func (p *ExecutorClient) Purge() *PurgeRequester {
+	d := &PurgeRequester{
+		outc: nil,
+		r: &requester{
+			args:   map[string]any{},
+			action: "purge",
+			client: p,
+		},
+	}
+
+	action, _ := p.ddl.ActionInterface(d.r.action)
+	action.SetDefaults(d.r.args)
+
+	return d
+}
+
+// Start performs the start action
+//
+// Description: Starts a command under the process supervisor
+//
+// Required Inputs:
+//   - command (string) - The command to execute
+//
+// Optional Inputs:
+//   - args ([]any) - Arguments to pass to the command
+//   - environment (map[string]any) - Environment variables to set for the command
+//   - heartbeat (int64) - Interval in seconds between heartbeats published about the running command, 0 disables
+//   - track_output (bool) - Publishes the command output to Choria Submission
+func (p *ExecutorClient) Start(inputCommand string) *StartRequester {
+	d := &StartRequester{
+		outc: nil,
+		r: &requester{
+			args: map[string]any{
+				"command": inputCommand,
+			},
+			action: "start",
+			client: p,
+		},
+	}
+
+	action, _ := p.ddl.ActionInterface(d.r.action)
+	action.SetDefaults(d.r.args)
+
+	return d
+}
+
 // Status performs the status action
 //
 // Description: Requests the status of a job by ID
@@ -7,5 +7,7 @@
 // Actions:
 //   - Signal - Sends a signal to a process
 //   - List - Lists jobs matching certain criteria
+//   - Purge - Removes completed jobs matching certain criteria from the spool
+//   - Start - Starts a command under the process supervisor
 //   - Status - Requests the status of a job by ID
 package executorclient
@@ -0,0 +1,126 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/client/discovery"
+	"github.com/choria-io/go-choria/client/executorclient"
+)
+
+type executorPurgeCommand struct {
+	command
+
+	fo *discovery.StandardOptions
+
+	agent     string
+	action    string
+	caller    string
+	procCmd   string
+	identity  string
+	requestID string
+	olderThan time.Duration
+	since     time.Duration
+	completed bool
+	json      bool
+}
+
+func init() {
+	cli.commands = append(cli.commands, &executorPurgeCommand{})
+}
+
+func (p *executorPurgeCommand) Setup() error {
+	if exec, ok := cmdWithFullCommand("executor"); ok {
+		p.cmd = exec.Cmd().Command("purge", "Removes completed jobs from the executor spool")
+		p.cmd.Flag("agent", "Purge jobs created by a specific agent").StringVar(&p.agent)
+		p.cmd.Flag("action", "Purge jobs created by a specific action").StringVar(&p.action)
+		p.cmd.Flag("caller", "Purge jobs created by a specific caller").StringVar(&p.caller)
+		p.cmd.Flag("command", "Purge jobs that executed a specific command").StringVar(&p.procCmd)
+		p.cmd.Flag("identity", "Purge jobs created on a specific host identity").StringVar(&p.identity)
+		p.cmd.Flag("request", "Purge jobs created by a specific request").StringVar(&p.requestID)
+		p.cmd.Flag("older", "Purge jobs created longer than this duration ago").PlaceHolder("DURATION").DurationVar(&p.olderThan)
+		p.cmd.Flag("since", "Purge jobs created within this duration").PlaceHolder("DURATION").DurationVar(&p.since)
+		p.cmd.Flag("completed", "Purge all completed jobs").UnNegatableBoolVar(&p.completed)
+		p.cmd.Flag("json", "Renders result in JSON format").UnNegatableBoolVar(&p.json)
+
+		p.fo = discovery.NewStandardOptions()
+		p.fo.AddFilterFlags(p.cmd)
+		p.fo.AddFlatFileFlags(p.cmd)
+		p.fo.AddSelectionFlags(p.cmd)
+	}
+
+	return nil
+}
+
+func (p *executorPurgeCommand) Configure() (err error) {
+	return commonConfigure()
+}
+
+func (p *executorPurgeCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	if p.agent == "" && p.action == "" && p.caller == "" && p.procCmd == "" && p.identity == "" && p.requestID == "" && p.olderThan == 0 && p.since == 0 && !p.completed {
+		return fmt.Errorf("at least one job matching criteria is required")
+	}
+
+	p.fo.SetDefaultsFromChoria(c)
+	log := c.Logger("executor")
+
+	opts := []executorclient.InitializationOption{
+		executorclient.Logger(log), executorclient.Discovery(executorclient.NewMetaNS(p.fo, true)),
+	}
+	if !p.json {
+		opts = append(opts, executorclient.Progress())
+	}
+
+	ec, err := executorclient.New(c, opts...)
+	if err != nil {
+		return err
+	}
+
+	req := ec.Purge()
+	if p.agent != "" {
+		req.Agent(p.agent)
+	}
+	if p.action != "" {
+		req.Action(p.action)
+	}
+	if p.caller != "" {
+		req.Caller(p.caller)
+	}
+	if p.procCmd != "" {
+		req.Command(p.procCmd)
+	}
+	if p.identity != "" {
+		req.Identity(p.identity)
+	}
+	if p.requestID != "" {
+		req.Requestid(p.requestID)
+	}
+	if p.olderThan > 0 {
+		req.Before(time.Now().Add(-p.olderThan).Unix())
+	}
+	if p.since > 0 {
+		req.Since(time.Now().Add(-p.since).Unix())
+	}
+	if p.completed {
+		req.Completed(true)
+	}
+
+	res, err := req.Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	format := executorclient.TextFormat
+	if p.json {
+		format = executorclient.JSONFormat
+	}
+	return res.RenderResults(os.Stdout, format, executorclient.DisplayDDL, debug, false, true, log)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/security/denylist"
+)
+
+type jWTListRevokedCommand struct {
+	json bool
+
+	command
+}
+
+func (l *jWTListRevokedCommand) Setup() (err error) {
+	if jwt, ok := cmdWithFullCommand("jwt"); ok {
+		l.cmd = jwt.Cmd().Command("list-revoked", "Lists JWT tokens in the signed revocation list").Alias("revoked")
+		l.cmd.Flag("json", "Produce JSON output").UnNegatableBoolVar(&l.json)
+	}
+
+	return nil
+}
+
+func (l *jWTListRevokedCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (l *jWTListRevokedCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	list := c.TokenDenyList()
+	if list == nil {
+		return fmt.Errorf("plugin.security.choria.revocation_signers must be set to verify revocations")
+	}
+
+	store, err := c.KV(ctx, nil, list.Bucket(), false)
+	if err != nil {
+		return err
+	}
+
+	err = list.Load(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	entries := list.Entries()
+
+	if l.json {
+		if entries == nil {
+			entries = []*denylist.Entry{}
+		}
+
+		j, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(j))
+
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No tokens are revoked in the %s bucket\n", list.Bucket())
+		return nil
+	}
+
+	table := util.NewUTF8Table("Kind", "Value", "Revoked", "Expires", "Reason")
+	for _, entry := range entries {
+		expires := "never"
+		if !entry.Expires.IsZero() {
+			expires = util.RenderDuration(time.Until(entry.Expires))
+		}
+
+		table.AddRow(entry.Kind, entry.Value, entry.Revoked.Format(time.RFC3339), expires, entry.Reason)
+	}
+
+	fmt.Println(table.Render())
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &jWTListRevokedCommand{})
+}
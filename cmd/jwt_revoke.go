@@ -0,0 +1,217 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/choria-io/go-choria/providers/security/denylist"
+	"github.com/choria-io/tokens"
+)
+
+type jWTRevokeCommand struct {
+	signingKey string
+	token      string
+	id         string
+	publicKey  string
+	caller     string
+	reason     string
+	validity   time.Duration
+
+	command
+}
+
+func (r *jWTRevokeCommand) Setup() (err error) {
+	if jwt, ok := cmdWithFullCommand("jwt"); ok {
+		r.cmd = jwt.Cmd().Command("revoke", "Revokes JWT tokens using the signed revocation list")
+		r.cmd.Arg("signing-key", "Path to a private key trusted to sign revocations").Required().ExistingFileVar(&r.signingKey)
+		r.cmd.Arg("token", "A JWT file to revoke by its unique ID").ExistingFileVar(&r.token)
+		r.cmd.Flag("id", "Revokes the token with this unique ID").PlaceHolder("ID").StringVar(&r.id)
+		r.cmd.Flag("public-key", "Revokes all tokens holding this Ed25519 public key").PlaceHolder("KEY").StringVar(&r.publicKey)
+		r.cmd.Flag("caller", "Revokes all client tokens issued to this Caller ID").PlaceHolder("CALLER").StringVar(&r.caller)
+		r.cmd.Flag("reason", "The reason for revoking the token").StringVar(&r.reason)
+		r.cmd.Flag("validity", "How long the revocation should be active, defaults to the token expiry time or forever").DurationVar(&r.validity)
+	}
+
+	return nil
+}
+
+func (r *jWTRevokeCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (r *jWTRevokeCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	entries, err := revocationEntries(r.token, r.id, r.publicKey, r.caller, r.reason, r.validity)
+	if err != nil {
+		return err
+	}
+
+	pub, pri, err := iu.Ed25519KeyPairFromSeedFile(r.signingKey)
+	if err != nil {
+		return err
+	}
+
+	list := c.TokenDenyList()
+	if list == nil {
+		fmt.Println(c.Colorize("yellow", "WARNING: plugin.security.choria.revocation_signers is not set, revocations are not enforced by this configuration"))
+		fmt.Println()
+	} else if !trustedRevocationSigner(list, pub) {
+		fmt.Println(c.Colorizef("yellow", "WARNING: %x is not listed in plugin.security.choria.revocation_signers, revocations it signs will be ignored", pub))
+		fmt.Println()
+	}
+
+	store, err := c.KV(ctx, nil, cfg.Choria.ChoriaSecurityRevocationBucket, true, kv.WithHistory(denylist.History), kv.WithoutPurge())
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		key, err := entry.Key()
+		if err != nil {
+			return err
+		}
+
+		data, err := entry.Sign(pri)
+		if err != nil {
+			return err
+		}
+
+		_, err = store.Put(key, data)
+		if err != nil {
+			return fmt.Errorf("could not store revocation of %s %s: %s", entry.Kind, entry.Value, err)
+		}
+
+		if entry.Expires.IsZero() {
+			fmt.Printf("Revoked %s %s\n", entry.Kind, entry.Value)
+		} else {
+			fmt.Printf("Revoked %s %s until %s\n", entry.Kind, entry.Value, entry.Expires.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+func trustedRevocationSigner(list *denylist.List, pub ed25519.PublicKey) bool {
+	for _, signer := range list.Signers() {
+		if bytes.Equal(signer, pub) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// revocationEntries creates unsigned entries for the token file and flags shared by revoke and unrevoke
+func revocationEntries(tokenFile string, id string, publicKey string, caller string, reason string, validity time.Duration) ([]*denylist.Entry, error) {
+	var entries []*denylist.Entry
+
+	var expires time.Time
+	if validity > 0 {
+		expires = time.Now().Add(validity)
+	}
+
+	add := func(kind denylist.Kind, value string, expires time.Time) error {
+		entry, err := denylist.NewEntry(kind, value, reason, expires)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	}
+
+	if tokenFile != "" {
+		claims, err := tokenStandardClaims(tokenFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if claims.ID == "" {
+			return nil, fmt.Errorf("%s has no unique ID, revoke it using --public-key", tokenFile)
+		}
+
+		// a revoked token ID is of no interest once the token expired
+		tokenExpires := expires
+		if tokenExpires.IsZero() {
+			tokenExpires = claims.ExpireTime()
+		}
+
+		err = add(denylist.TokenID, claims.ID, tokenExpires)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	flags := []struct {
+		kind  denylist.Kind
+		value string
+	}{
+		{denylist.TokenID, id},
+		{denylist.PublicKey, publicKey},
+		{denylist.Caller, caller},
+	}
+
+	for _, flag := range flags {
+		if flag.value == "" {
+			continue
+		}
+
+		err := add(flag.kind, flag.value, expires)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("a token file, --id, --public-key or --caller is required")
+	}
+
+	return entries, nil
+}
+
+func tokenStandardClaims(file string) (*tokens.StandardClaims, error) {
+	tb, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	token := strings.TrimSpace(string(tb))
+
+	switch tokens.TokenPurpose(token) {
+	case tokens.ClientIDPurpose:
+		claims, err := tokens.ParseClientIDTokenUnverified(token)
+		if err != nil {
+			return nil, err
+		}
+
+		return &claims.StandardClaims, nil
+
+	case tokens.ServerPurpose:
+		claims, err := tokens.ParseServerTokenUnverified(token)
+		if err != nil {
+			return nil, err
+		}
+
+		return &claims.StandardClaims, nil
+
+	default:
+		return nil, fmt.Errorf("only client and server tokens can be revoked")
+	}
+}
+
+func init() {
+	cli.commands = append(cli.commands, &jWTRevokeCommand{})
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/security/denylist"
+	"github.com/nats-io/nats.go"
+)
+
+type jWTUnrevokeCommand struct {
+	signingKey string
+	token      string
+	id         string
+	publicKey  string
+	caller     string
+	force      bool
+
+	command
+}
+
+func (u *jWTUnrevokeCommand) Setup() (err error) {
+	if jwt, ok := cmdWithFullCommand("jwt"); ok {
+		u.cmd = jwt.Cmd().Command("unrevoke", "Removes JWT tokens from the signed revocation list")
+		u.cmd.Arg("signing-key", "Path to a private key trusted to sign revocations").Required().ExistingFileVar(&u.signingKey)
+		u.cmd.Arg("token", "A JWT file to restore by its unique ID").ExistingFileVar(&u.token)
+		u.cmd.Flag("id", "Restores the token with this unique ID").PlaceHolder("ID").StringVar(&u.id)
+		u.cmd.Flag("public-key", "Restores tokens holding this Ed25519 public key").PlaceHolder("KEY").StringVar(&u.publicKey)
+		u.cmd.Flag("caller", "Restores client tokens issued to this Caller ID").PlaceHolder("CALLER").StringVar(&u.caller)
+		u.cmd.Flag("force", "Force removal without prompting").Short('f').UnNegatableBoolVar(&u.force)
+	}
+
+	return nil
+}
+
+func (u *jWTUnrevokeCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (u *jWTUnrevokeCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	entries, err := revocationEntries(u.token, u.id, u.publicKey, u.caller, "", 0)
+	if err != nil {
+		return err
+	}
+
+	_, pri, err := iu.Ed25519KeyPairFromSeedFile(u.signingKey)
+	if err != nil {
+		return err
+	}
+
+	store, err := c.KV(ctx, nil, cfg.Choria.ChoriaSecurityRevocationBucket, false)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		key, err := entry.Key()
+		if err != nil {
+			return err
+		}
+
+		// deleted keys still hold revocations in their history
+		_, err = store.History(key)
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			fmt.Printf("%s %s is not revoked\n", entry.Kind, entry.Value)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if !u.force {
+			ok, err := iu.PromptForConfirmation("Really restore access for %s %s", entry.Kind, entry.Value)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Skipping")
+				continue
+			}
+		}
+
+		// removals are signed, servers and brokers ignore keys that are simply deleted
+		removal, err := denylist.NewRemoval(entry.Kind, entry.Value)
+		if err != nil {
+			return err
+		}
+
+		data, err := removal.Sign(pri)
+		if err != nil {
+			return err
+		}
+
+		_, err = store.Put(key, data)
+		if err != nil {
+			return fmt.Errorf("could not store removal of %s %s: %s", entry.Kind, entry.Value, err)
+		}
+
+		fmt.Printf("Removed revocation of %s %s\n", entry.Kind, entry.Value)
+	}
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &jWTUnrevokeCommand{})
+}
@@ -1,4 +1,4 @@
-// Copyright (c) 2019-2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2019-2025, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -10,39 +10,123 @@ import (
 
 	"github.com/choria-io/go-choria/aagent/machine"
 	"github.com/choria-io/go-choria/aagent/watchers"
+	"github.com/choria-io/go-choria/client/choria_utilclient"
+	"github.com/choria-io/go-choria/client/discovery"
 )
 
 type mGraphCommand struct {
 	command
 	souceDir string
+	format   string
+	live     bool
+
+	fo *discovery.StandardOptions
 }
 
-func (c *mGraphCommand) Setup() (err error) {
+func (g *mGraphCommand) Setup() (err error) {
 	if machine, ok := cmdWithFullCommand("machine"); ok {
-		c.cmd = machine.Cmd().Command("graph", "Produce a DOT graph of an autonomous agent definition")
-		c.cmd.Arg("source", "Directory containing the machine definition").Required().ExistingDirVar(&c.souceDir)
+		g.cmd = machine.Cmd().Command("graph", "Produce a graph of an autonomous agent definition")
+		g.cmd.Arg("source", "Directory containing the machine definition").Required().ExistingDirVar(&g.souceDir)
+		g.cmd.Flag("format", "The format to produce the graph in (dot, mermaid, svg, html)").Default("dot").EnumVar(&g.format, "dot", "mermaid", "svg", "html")
+		g.cmd.Flag("live", "Annotate states with the number of nodes currently in them").UnNegatableBoolVar(&g.live)
+
+		g.fo = discovery.NewStandardOptions()
+		g.fo.AddFilterFlags(g.cmd)
+		g.fo.AddSelectionFlags(g.cmd)
+		g.fo.AddFlatFileFlags(g.cmd)
 	}
 
 	return nil
 }
 
-func (c *mGraphCommand) Configure() error {
-	return nil
+func (g *mGraphCommand) Configure() error {
+	if !g.live {
+		return nil
+	}
+
+	return commonConfigure()
 }
 
-func (c *mGraphCommand) Run(wg *sync.WaitGroup) (err error) {
+func (g *mGraphCommand) Run(wg *sync.WaitGroup) (err error) {
 	defer wg.Done()
 
-	m, err := machine.FromDir(c.souceDir, watchers.New(ctx))
+	m, err := machine.FromDir(g.souceDir, watchers.New(ctx))
 	if err != nil {
 		return err
 	}
 
-	fmt.Print(m.Graph())
+	opts := &machine.GraphOptions{}
+
+	if g.live {
+		opts.StateCounts, err = g.liveStates(m.MachineName)
+		if err != nil {
+			return err
+		}
+	}
+
+	graph, err := m.RenderGraph(machine.GraphFormat(g.format), opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(graph)
 
 	return nil
 }
 
+// liveStates counts the nodes in each state of the machine across the discovered fleet
+func (g *mGraphCommand) liveStates(name string) (map[string]int, error) {
+	log := c.Logger("machine")
+
+	g.fo.SetDefaultsFromChoria(c)
+
+	nodes, _, err := g.fo.Discover(ctx, c, "choria_util", true, false, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("did not discover any nodes")
+	}
+
+	cu, err := choria_utilclient.New(c, choria_utilclient.Logger(log))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := cu.OptionTargets(nodes).MachineStates().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Stats().OKCount() == 0 {
+		return nil, fmt.Errorf("no responses received")
+	}
+
+	counts := map[string]int{}
+
+	res.EachOutput(func(r *choria_utilclient.MachineStatesOutput) {
+		if !r.ResultDetails().OK() {
+			log.Errorf("received an error from %s: %s", r.ResultDetails().Sender(), r.ResultDetails().StatusMessage())
+			return
+		}
+
+		for _, s := range r.States() {
+			state, ok := s.(map[string]any)
+			if !ok || state["name"] != name {
+				continue
+			}
+
+			current, ok := state["state"].(string)
+			if ok {
+				counts[current]++
+			}
+		}
+	})
+
+	return counts, nil
+}
+
 func init() {
 	cli.commands = append(cli.commands, &mGraphCommand{})
 }
@@ -30,18 +30,26 @@ type mPluginsAddCommand struct {
 	checksum       string
 	verifyChecksum string
 	name           string
+	format         string
+	tlsCA          string
+	s3Endpoint     string
+	s3Region       string
 }
 
 func (c *mPluginsAddCommand) Setup() (err error) {
 	if machine, ok := cmdWithFullCommand("machine plugins"); ok {
 		c.cmd = machine.Cmd().Command("add", "Add or Update a reference to a plugin to the Plugins Watcher manifest")
 		c.cmd.Arg("name", "Unique name for this plugin").Required().StringVar(&c.name)
-		c.cmd.Arg("plugin", "The URL to the tar file holding the plugin").Required().URLVar(&c.pluginUrl)
+		c.cmd.Arg("plugin", "The URL to the archive holding the plugin").Required().URLVar(&c.pluginUrl)
 		c.cmd.Arg("checksum", "SHA256 checksum of the archive").Required().StringVar(&c.checksum)
 		c.cmd.Arg("verify-checksum", "SHA256 checksum of the verification file").Required().StringVar(&c.verifyChecksum)
 		c.cmd.Flag("manifest", "Path to the manifest to edit").Default("plugins.json").StringVar(&c.manifest)
 		c.cmd.Flag("matcher", "Limit deployment using an expression").IsSetByUser(&c.matcherIsSet).StringVar(&c.matcher)
 		c.cmd.Flag("governor", "Limit deployment using a Governor").IsSetByUser(&c.governorIsSet).StringVar(&c.governor)
+		c.cmd.Flag("format", "The archive format, detected from the contents when not set").EnumVar(&c.format, "tgz", "tzst", "zip")
+		c.cmd.Flag("tls-ca", "Path to a CA used to verify the HTTPS server on managed nodes").StringVar(&c.tlsCA)
+		c.cmd.Flag("s3-endpoint", "URL of a S3 compatible object store to use for s3:// sources").StringVar(&c.s3Endpoint)
+		c.cmd.Flag("s3-region", "The region used for s3:// sources").StringVar(&c.s3Region)
 	}
 
 	return nil
@@ -139,6 +147,18 @@ func (c *mPluginsAddCommand) updatePlugin(plugin *pluginswatcher.ManagedPlugin,
 	if c.matcherIsSet {
 		plugin.Matcher = c.matcher
 	}
+	if c.format != "" {
+		plugin.Format = c.format
+	}
+	if c.tlsCA != "" {
+		plugin.TLSCA = c.tlsCA
+	}
+	if c.s3Endpoint != "" {
+		plugin.S3Endpoint = c.s3Endpoint
+	}
+	if c.s3Region != "" {
+		plugin.S3Region = c.s3Region
+	}
 	if user != nil {
 		plugin.Username = user.Username()
 		plugin.Password, _ = user.Password()
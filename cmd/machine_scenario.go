@@ -0,0 +1,102 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/choria-io/go-choria/aagent/machine"
+)
+
+type mTestCommand struct {
+	command
+	sourceDir string
+	scenarios []string
+	verbose   bool
+	json      bool
+}
+
+func (c *mTestCommand) Setup() (err error) {
+	if machine, ok := cmdWithFullCommand("machine"); ok {
+		c.cmd = machine.Cmd().Command("test", "Tests the logic of a machine by replaying scenarios of watcher outcomes and events")
+		c.cmd.Arg("source", "Directory containing the machine definition").Required().ExistingDirVar(&c.sourceDir)
+		c.cmd.Arg("scenario", "Scenario files to replay against the machine").Required().ExistingFilesVar(&c.scenarios)
+		c.cmd.Flag("verbose", "Show the transitions performed by each scenario").Short('v').UnNegatableBoolVar(&c.verbose)
+		c.cmd.Flag("json", "Produce JSON output").UnNegatableBoolVar(&c.json)
+	}
+
+	return nil
+}
+
+func (c *mTestCommand) Configure() error {
+	return nil
+}
+
+func (c *mTestCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	var results []*machine.ScenarioResult
+	failed := 0
+
+	for _, file := range c.scenarios {
+		scenario, err := machine.LoadScenario(file)
+		if err != nil {
+			return err
+		}
+
+		res, err := machine.RunScenario(ctx, c.sourceDir, scenario)
+		if err != nil {
+			return fmt.Errorf("scenario %s failed: %w", scenario.Name, err)
+		}
+
+		if !res.Passed() {
+			failed++
+		}
+
+		results = append(results, res)
+	}
+
+	if c.json {
+		j, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(j))
+	} else {
+		for _, res := range results {
+			if res.Passed() {
+				fmt.Printf("PASS: %s\n", res.Name)
+			} else {
+				fmt.Printf("FAIL: %s\n", res.Name)
+			}
+
+			if c.verbose {
+				for _, l := range res.Log {
+					fmt.Printf("      %s\n", l)
+				}
+			}
+
+			for _, f := range res.Failures {
+				fmt.Printf("      %s\n", f)
+			}
+		}
+
+		fmt.Println()
+		fmt.Printf("%d scenarios, %d passed, %d failed\n", len(results), len(results)-failed, failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d scenarios failed", failed)
+	}
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mTestCommand{})
+}
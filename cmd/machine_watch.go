@@ -68,12 +68,13 @@ func (w *mWatchCommand) Run(wg *sync.WaitGroup) (err error) {
 	states := make(chan inter.ConnectorMessage, 100)
 
 	if w.shouldViewTransitions() {
-		topic := "choria.machine.transition"
-		w.log.Infof("Viewing transitions on topic %s", topic)
+		for _, topic := range []string{"choria.machine.transition", "choria.machine.transition.veto"} {
+			w.log.Infof("Viewing transitions on topic %s", topic)
 
-		err = conn.QueueSubscribe(ctx, c.UniqueID(), topic, "", transitions)
-		if err != nil {
-			return fmt.Errorf("could not subscribe to choria.machine.transition: %s", err)
+			err = conn.QueueSubscribe(ctx, c.UniqueID(), topic, "", transitions)
+			if err != nil {
+				return fmt.Errorf("could not subscribe to %s: %s", topic, err)
+			}
 		}
 	}
 
@@ -10,10 +10,12 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/choria-io/go-choria/client/discovery"
 	"github.com/choria-io/go-choria/inter"
 	"github.com/choria-io/go-choria/internal/fs"
@@ -44,6 +46,16 @@ type reqCommand struct {
 	limitSeed          int64
 	batch              int
 	batchSleep         int
+	batchCanary        int
+	batchGrowth        float64
+	batchMax           int
+	batchMaxFailures   string
+	batchAbortExpr     string
+	batchConfirm       bool
+	batchRemaining     string
+	journal            string
+	resume             string
+	resumeState        *rpc.JournalState
 	verbose            bool
 	jsonOnly           bool
 	jsonLinesOnly      bool
@@ -53,6 +65,7 @@ type reqCommand struct {
 	workers            int
 	reply              string
 	sort               bool
+	async              bool
 
 	fo *discovery.StandardOptions
 
@@ -81,15 +94,29 @@ that match the filter.
    # include only responses where the array item includes 'needle'
    --filter-replies 'ok() && include(data("array"), "needle")'
 
+Requests made using --async store replies in Choria Streams, the replies
+can later be shown using the request ID:
+
+   choria req results <request id> [--wait 10s]
+
+Requests made using --journal record the status of every node, should the
+request be interrupted or nodes fail it can be retried on only those nodes
+using the original action, inputs and DDL:
+
+   choria req --resume <journal>
+
 `
 
-	r.cmd = cli.app.Command("req", "Invokes Choria RPC Actions").Alias("rpc").Alias("request")
+	req := cli.app.Command("req", "Invokes Choria RPC Actions").Alias("rpc").Alias("request")
+	req.HelpLong(help)
+	req.CheatFile(fs.FS, "req", "cheats/req.md")
+
+	r.cmd = req.Command("invoke", "Invokes a Choria RPC Action").Default()
 	r.cmd.HelpLong(help)
-	r.cmd.CheatFile(fs.FS, "req", "cheats/req.md")
 
 	r.cmd.Flag("config", "Config file to use").PlaceHolder("FILE").StringVar(&configFile)
-	r.cmd.Arg("agent", "The agent to invoke").Required().StringVar(&r.agent)
-	r.cmd.Arg("action", "The action to invoke").Required().StringVar(&r.action)
+	r.cmd.Arg("agent", "The agent to invoke").StringVar(&r.agent)
+	r.cmd.Arg("action", "The action to invoke").StringVar(&r.action)
 	r.cmd.Arg("args", "Arguments to pass to the action in key=val format").StringMapVar(&r.args)
 	r.cmd.Flag("json", "Produce JSON output only").Short('j').UnNegatableBoolVar(&r.jsonOnly)
 	r.cmd.Flag("jsonl", "Produce JSON Lines output only").UnNegatableBoolVar(&r.jsonLinesOnly)
@@ -107,6 +134,15 @@ that match the filter.
 	r.cmd.Flag("limit-seed", "Seed value for deterministic random limits").PlaceHolder("SEED").Int64Var(&r.limitSeed)
 	r.cmd.Flag("batch", "Do requests in batches").PlaceHolder("SIZE").IntVar(&r.batch)
 	r.cmd.Flag("batch-sleep", "Sleep time between batches").PlaceHolder("SECONDS").IntVar(&r.batchSleep)
+	r.cmd.Flag("batch-canary", "Do a first batch of this size before the rest of the rollout").PlaceHolder("SIZE").IntVar(&r.batchCanary)
+	r.cmd.Flag("batch-growth", "Multiply the batch size by this factor after every batch").PlaceHolder("FACTOR").Float64Var(&r.batchGrowth)
+	r.cmd.Flag("batch-max", "The maximum size batches can grow to").PlaceHolder("SIZE").IntVar(&r.batchMax)
+	r.cmd.Flag("batch-max-failures", "Abort the rollout after a batch where more than this percentage of nodes failed").PlaceHolder("PERCENT").StringVar(&r.batchMaxFailures)
+	r.cmd.Flag("batch-abort-expr", "Count nodes with replies matching this expr filter as failed").PlaceHolder("EXPR").StringVar(&r.batchAbortExpr)
+	r.cmd.Flag("batch-confirm", "Ask for confirmation before every batch after the first").UnNegatableBoolVar(&r.batchConfirm)
+	r.cmd.Flag("batch-remaining", "Write nodes not contacted due to an aborted rollout to a file for use with --nodes").PlaceHolder("FILE").StringVar(&r.batchRemaining)
+	r.cmd.Flag("journal", "Record the request and the status of every node in a journal").PlaceHolder("FILE").StringVar(&r.journal)
+	r.cmd.Flag("resume", "Repeat the request in a journal on nodes that did not reply or failed").PlaceHolder("FILE").StringVar(&r.resume)
 	r.cmd.Flag("workers", "How many workers to start for receiving messages").Default("3").IntVar(&r.workers)
 	r.cmd.Flag("np", "Disable the progress bar").UnNegatableBoolVar(&r.noProgress)
 	r.cmd.Flag("verbose", "Enable verbose output").Short('v').UnNegatableBoolVar(&r.verbose)
@@ -115,6 +151,7 @@ that match the filter.
 	r.cmd.Flag("filter-replies", "Filter replies using a expr filter").PlaceHolder("EXPR").StringVar(&r.exprFilter)
 	r.cmd.Flag("reply-to", "Set a custom reply subject").PlaceHolder("TARGET").Short('r').StringVar(&r.reply)
 	r.cmd.Flag("sort", "Sort replies by responder identity").UnNegatableBoolVar(&r.sort)
+	r.cmd.Flag("async", "Store replies in Choria Streams for later retrieval using 'choria req results'").UnNegatableBoolVar(&r.async)
 
 	return
 }
@@ -199,50 +236,121 @@ func (r *reqCommand) responseHandler(results *replyfmt.RPCResults) func(pr proto
 	}
 }
 
+func (r *reqCommand) prepareOutput() (err error) {
+	if r.outputFile != "" {
+		r.outputFileHandle, err = os.Create(r.outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output-file: %s", err)
+		}
+	} else {
+		r.outputFileHandle = os.Stdout
+	}
+	r.outputWriter = bufio.NewWriter(r.outputFileHandle)
+
+	if r.jsonLinesOnly || r.jsonOnly || r.senderNamesOnly {
+		r.silent = true
+		r.noProgress = true
+	}
+
+	return nil
+}
+
 func (r *reqCommand) prepareConfiguration() (err error) {
-	agent, err := rpc.New(c, r.agent)
+	if r.resumeState != nil {
+		err = r.prepareResumedRequest()
+		if err != nil {
+			return err
+		}
+	} else {
+		agent, err := rpc.New(c, r.agent)
+		if err != nil {
+			return err
+		}
+
+		err = agent.ResolveDDL(ctx)
+		if err != nil {
+			return err
+		}
+
+		r.ddl = agent.DDL()
+
+		r.actionInterface, err = r.ddl.ActionInterface(r.action)
+		if err != nil {
+			return err
+		}
+
+		r.input, _, err = r.actionInterface.ValidateAndConvertToDDLTypes(r.args)
+		if err != nil {
+			return fmt.Errorf("invalid input: %s", err)
+		}
+	}
+
+	r.filter, err = r.parseFilterOptions()
 	if err != nil {
-		return err
+		return fmt.Errorf("could not parse filters: %s", err)
 	}
 
-	err = agent.ResolveDDL(ctx)
+	err = r.prepareOutput()
 	if err != nil {
 		return err
 	}
 
-	r.ddl = agent.DDL()
+	r.fo.SetDefaultsFromChoria(c)
 
-	r.actionInterface, err = r.ddl.ActionInterface(r.action)
-	if err != nil {
-		return err
+	if r.resumeState != nil && r.resumeState.Collective != "" {
+		r.fo.Collective = r.resumeState.Collective
 	}
 
-	r.input, _, err = r.actionInterface.ValidateAndConvertToDDLTypes(r.args)
+	return nil
+}
+
+// loadJournal reads the journal given using --resume and sets the agent and action to those of the original request
+func (r *reqCommand) loadJournal() (err error) {
+	if len(r.args) > 0 {
+		return fmt.Errorf("arguments can not be given when resuming a request, the original inputs are used")
+	}
+
+	r.resumeState, err = rpc.LoadJournal(r.resume)
 	if err != nil {
-		return fmt.Errorf("invalid input: %s", err)
+		return fmt.Errorf("could not load journal: %s", err)
 	}
 
-	r.filter, err = r.parseFilterOptions()
+	if (r.agent != "" && r.agent != r.resumeState.Agent) || (r.action != "" && r.action != r.resumeState.Action) {
+		return fmt.Errorf("journal %s is for %s#%s", r.resume, r.resumeState.Agent, r.resumeState.Action)
+	}
+
+	r.agent = r.resumeState.Agent
+	r.action = r.resumeState.Action
+
+	// further requests are recorded in the same journal so it can be resumed again
+	if r.journal == "" {
+		r.journal = r.resume
+	}
+
+	return nil
+}
+
+// prepareResumedRequest restores the DDL and inputs of the request being resumed
+func (r *reqCommand) prepareResumedRequest() (err error) {
+	r.ddl, err = agentddl.NewFromBytes(r.resumeState.DDL)
 	if err != nil {
-		return fmt.Errorf("could not parse filters: %s", err)
+		return fmt.Errorf("invalid DDL in journal: %s", err)
 	}
 
-	if r.outputFile != "" {
-		r.outputFileHandle, err = os.Create(r.outputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output-file: %s", err)
-		}
-	} else {
-		r.outputFileHandle = os.Stdout
+	r.actionInterface, err = r.ddl.ActionInterface(r.action)
+	if err != nil {
+		return err
 	}
-	r.outputWriter = bufio.NewWriter(r.outputFileHandle)
 
-	if r.jsonLinesOnly || r.jsonOnly || r.senderNamesOnly {
-		r.silent = true
-		r.noProgress = true
+	if r.ddl.Metadata.Service {
+		return fmt.Errorf("requests to service agents can not be resumed")
 	}
 
-	r.fo.SetDefaultsFromChoria(c)
+	r.input = make(map[string]any)
+	err = json.Unmarshal(r.resumeState.Payload, &r.input)
+	if err != nil {
+		return fmt.Errorf("invalid payload in journal: %s", err)
+	}
 
 	return nil
 }
@@ -269,6 +377,23 @@ func (r *reqCommand) Run(wg *sync.WaitGroup) (err error) {
 
 	r.startTime = time.Now()
 
+	if r.async && r.reply != "" {
+		return fmt.Errorf("--async and --reply-to can not be used together")
+	}
+
+	if (r.journal != "" || r.resume != "") && (r.async || r.reply != "") {
+		return fmt.Errorf("--journal and --resume can not be used with --async or --reply-to")
+	}
+
+	if r.resume != "" {
+		err = r.loadJournal()
+		if err != nil {
+			return err
+		}
+	} else if r.agent == "" || r.action == "" {
+		return fmt.Errorf("an agent and action is required")
+	}
+
 	err = r.prepareConfiguration()
 	if err != nil {
 		return err
@@ -290,6 +415,18 @@ func (r *reqCommand) Run(wg *sync.WaitGroup) (err error) {
 		nodes = []string{"service"}
 		r.configureProgressBar(1, 1)
 
+	case r.resumeState != nil:
+		nodes = r.resumeState.Pending()
+		expected = len(nodes)
+		if expected == 0 {
+			fmt.Fprintf(r.outputWriter, "All %d nodes in journal %s completed successfully\n", len(r.resumeState.Targets), r.resume)
+			return nil
+		}
+
+		if !r.silent {
+			fmt.Printf("Resuming request on %d of %d nodes that did not complete\n", expected, len(r.resumeState.Targets))
+		}
+
 	default:
 		nodes, err = r.discover()
 		if err != nil {
@@ -323,6 +460,10 @@ func (r *reqCommand) Run(wg *sync.WaitGroup) (err error) {
 		}),
 	}
 
+	if r.async {
+		opts = append(opts, rpc.AsyncReplies())
+	}
+
 	if publishOnly {
 		opts = append(opts, rpc.ReplyTo(r.reply))
 
@@ -336,6 +477,10 @@ func (r *reqCommand) Run(wg *sync.WaitGroup) (err error) {
 		opts = append(opts, rpc.Filter(f))
 	}
 
+	if r.batchConfirm {
+		r.noProgress = true
+	}
+
 	if r.batch > 0 {
 		if r.batchSleep == 0 {
 			r.batchSleep = 1
@@ -344,6 +489,19 @@ func (r *reqCommand) Run(wg *sync.WaitGroup) (err error) {
 		opts = append(opts, rpc.InBatches(r.batch, r.batchSleep))
 	}
 
+	if r.isRollout() {
+		policy, err := r.rolloutPolicy()
+		if err != nil {
+			return err
+		}
+
+		opts = append(opts, rpc.Rollout(policy))
+	}
+
+	if r.journal != "" {
+		opts = append(opts, rpc.Journal(r.journal))
+	}
+
 	if r.limit != "" {
 		opts = append(opts, rpc.LimitSize(r.limit))
 	}
@@ -381,6 +539,23 @@ func (r *reqCommand) Run(wg *sync.WaitGroup) (err error) {
 		return nil
 	}
 
+	if r.async {
+		if !r.noProgress {
+			uiprogress.Stop()
+			fmt.Println()
+		}
+
+		if r.silent {
+			fmt.Fprintln(r.outputWriter, rpcres.Stats().RequestID)
+			return nil
+		}
+
+		fmt.Fprintf(r.outputWriter, "Published request %s to %d nodes, retrieve replies using:\n\n", rpcres.Stats().RequestID, expected)
+		fmt.Fprintf(r.outputWriter, "   choria req results %s\n", rpcres.Stats().RequestID)
+
+		return nil
+	}
+
 	results.Stats = rpcres.Stats()
 	results.Stats.OverrideDiscoveryTime(r.discoveryStartTime, dend)
 
@@ -400,7 +575,90 @@ func (r *reqCommand) Run(wg *sync.WaitGroup) (err error) {
 		return fmt.Errorf("could not display results: %s", err)
 	}
 
-	return
+	err = r.rolloutSummary(results.Stats)
+	if err != nil {
+		return err
+	}
+
+	return r.journalSummary(results.Stats)
+}
+
+// journalSummary shows how to retry a journaled request on nodes that did not complete
+func (r *reqCommand) journalSummary(stats *rpc.Stats) error {
+	if r.journal == "" || r.jsonOnly || r.jsonLinesOnly || r.senderNamesOnly {
+		return nil
+	}
+
+	if stats.OKCount() == len(*stats.DiscoveredNodes()) && len(stats.UntouchedNodes()) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(r.outputWriter, "\nRetry nodes that did not complete using choria req --resume %s\n", r.journal)
+
+	return r.outputWriter.Flush()
+}
+
+// isRollout determines if any of the rollout policy flags were given
+func (r *reqCommand) isRollout() bool {
+	return r.batchCanary > 0 || r.batchGrowth > 0 || r.batchMax > 0 || r.batchMaxFailures != "" || r.batchAbortExpr != "" || r.batchConfirm
+}
+
+func (r *reqCommand) rolloutPolicy() (*rpc.RolloutPolicy, error) {
+	policy := &rpc.RolloutPolicy{
+		CanarySize:   r.batchCanary,
+		GrowthFactor: r.batchGrowth,
+		MaxBatchSize: r.batchMax,
+		AbortExpr:    r.batchAbortExpr,
+	}
+
+	if r.batchMaxFailures != "" {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(r.batchMaxFailures, "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --batch-max-failures: %s", err)
+		}
+
+		policy.MaxFailureRatio = pct / 100
+	}
+
+	if r.batchConfirm {
+		policy.Confirm = func(completed *rpc.Stats, next []string) (bool, error) {
+			ans := false
+			err := survey.AskOne(&survey.Confirm{
+				Message: fmt.Sprintf("%d OK and %d failed responses so far, continue with the next %d nodes", completed.OKCount(), completed.FailCount(), len(next)),
+				Default: ans,
+			}, &ans)
+
+			return ans, err
+		}
+	}
+
+	return policy, policy.Validate()
+}
+
+// rolloutSummary shows and saves the nodes that were not contacted when a rollout stopped early
+func (r *reqCommand) rolloutSummary(stats *rpc.Stats) error {
+	untouched := stats.UntouchedNodes()
+	if len(untouched) == 0 {
+		return nil
+	}
+
+	if r.batchRemaining != "" {
+		err := os.WriteFile(r.batchRemaining, []byte(strings.Join(untouched, "\n")+"\n"), 0600)
+		if err != nil {
+			return fmt.Errorf("could not save remaining nodes: %s", err)
+		}
+	}
+
+	if r.jsonOnly || r.jsonLinesOnly || r.senderNamesOnly {
+		return nil
+	}
+
+	fmt.Fprintf(r.outputWriter, "\n%s, %d nodes were not contacted\n", stats.StopReason(), len(untouched))
+	if r.batchRemaining != "" {
+		fmt.Fprintf(r.outputWriter, "\nResume the rollout using --nodes %s\n", r.batchRemaining)
+	}
+
+	return r.outputWriter.Flush()
 }
 
 func (r *reqCommand) displayResults(res *replyfmt.RPCResults) error {
@@ -0,0 +1,129 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	rpc "github.com/choria-io/go-choria/providers/agent/mcorpc/client"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc/replyfmt"
+)
+
+// reqResultsCommand shows the replies to a request made using --async, it reuses the
+// output handling of the req command
+type reqResultsCommand struct {
+	reqCommand
+
+	requestID string
+	wait      time.Duration
+}
+
+func (r *reqResultsCommand) Setup() (err error) {
+	req := cli.app.GetCommand("req")
+	if req == nil {
+		return nil
+	}
+
+	r.cmd = req.Command("results", "Shows the replies to a request made using --async")
+	r.cmd.Flag("config", "Config file to use").PlaceHolder("FILE").StringVar(&configFile)
+	r.cmd.Arg("id", "The request ID to show replies for").Required().StringVar(&r.requestID)
+	r.cmd.Flag("wait", "How long to wait for outstanding replies").PlaceHolder("DURATION").DurationVar(&r.wait)
+	r.cmd.Flag("json", "Produce JSON output only").Short('j').UnNegatableBoolVar(&r.jsonOnly)
+	r.cmd.Flag("jsonl", "Produce JSON Lines output only").UnNegatableBoolVar(&r.jsonLinesOnly)
+	r.cmd.Flag("table", "Produce a Table output of successful responses").UnNegatableBoolVar(&r.tableOnly)
+	r.cmd.Flag("senders", "Produce a list of sender identities of successful responses").UnNegatableBoolVar(&r.senderNamesOnly)
+	r.cmd.Flag("verbose", "Enable verbose output").Short('v').UnNegatableBoolVar(&r.verbose)
+	r.cmd.Flag("display", "Display only a subset of results (ok, failed, all, none)").EnumVar(&r.displayOverride, "ok", "failed", "all", "none")
+	r.cmd.Flag("output-file", "Filename to write output to").PlaceHolder("FILENAME").Short('o').StringVar(&r.outputFile)
+	r.cmd.Flag("filter-replies", "Filter replies using a expr filter").PlaceHolder("EXPR").StringVar(&r.exprFilter)
+	r.cmd.Flag("sort", "Sort replies by responder identity").UnNegatableBoolVar(&r.sort)
+
+	return nil
+}
+
+func (r *reqResultsCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	res, err := rpc.FetchAsyncResults(ctx, c, r.requestID, r.wait)
+	if err != nil {
+		return fmt.Errorf("could not retrieve results for request %s: %s", r.requestID, err)
+	}
+
+	err = r.prepareOutput()
+	if err != nil {
+		return err
+	}
+	defer r.outputWriter.Flush()
+
+	var first *rpc.RPCReply
+	for _, reply := range res.Replies {
+		first, err = rpc.ParseReply(reply)
+		if err == nil {
+			r.agent = reply.Agent()
+			r.action = first.Action
+			break
+		}
+	}
+	if first == nil {
+		return fmt.Errorf("no valid replies found for request %s", res.RequestID)
+	}
+
+	agent, err := rpc.New(c, r.agent)
+	if err != nil {
+		return err
+	}
+
+	err = agent.ResolveDDL(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.ddl = agent.DDL()
+	r.actionInterface, err = r.ddl.ActionInterface(r.action)
+	if err != nil {
+		return err
+	}
+
+	results := &replyfmt.RPCResults{
+		Agent:   r.agent,
+		Action:  r.action,
+		Replies: []*replyfmt.RPCReply{},
+	}
+
+	results.Stats, err = res.Process(r.agent, r.action, r.exprFilter, r.responseHandler(results))
+	if err != nil {
+		return err
+	}
+
+	if r.sort {
+		sort.Slice(results.Replies, func(i, j int) bool {
+			return results.Replies[i].Sender < results.Replies[j].Sender
+		})
+	}
+
+	err = r.displayResults(results)
+	if err != nil {
+		return fmt.Errorf("could not display results: %s", err)
+	}
+
+	if !r.silent {
+		if res.Invalid > 0 {
+			fmt.Fprintf(r.outputWriter, "%d stored replies could not be processed\n", res.Invalid)
+		}
+
+		if !res.Complete() {
+			fmt.Fprintf(r.outputWriter, "Received %d of %d expected replies, use --wait to wait for outstanding replies\n", len(res.Replies), res.Expected)
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &reqResultsCommand{})
+}
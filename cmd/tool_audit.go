@@ -0,0 +1,35 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"sync"
+)
+
+type tAuditCommand struct {
+	command
+}
+
+func (a *tAuditCommand) Setup() (err error) {
+	if tool, ok := cmdWithFullCommand("tool"); ok {
+		a.cmd = tool.Cmd().Command("audit", "Inspect RPC audit logs")
+	}
+
+	return nil
+}
+
+func (a *tAuditCommand) Configure() error {
+	return nil
+}
+
+func (a *tAuditCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &tAuditCommand{})
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/choria-io/go-choria/providers/agent/mcorpc/audit"
+)
+
+type tAuditVerifyCommand struct {
+	command
+
+	logfile    string
+	publicFile string
+	json       bool
+}
+
+func (v *tAuditVerifyCommand) Setup() (err error) {
+	if parent, ok := cmdWithFullCommand("tool audit"); ok {
+		v.cmd = parent.Cmd().Command("verify", "Verifies the integrity of a hash chained RPC audit log")
+		v.cmd.Arg("log", "The audit log to verify").Required().ExistingFileVar(&v.logfile)
+		v.cmd.Flag("public", "Certificate or token of the node that signed the log, enables signature verification").PlaceHolder("FILE").ExistingFileVar(&v.publicFile)
+		v.cmd.Flag("json", "Produce JSON output").UnNegatableBoolVar(&v.json)
+	}
+
+	return nil
+}
+
+func (v *tAuditVerifyCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (v *tAuditVerifyCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	var verifier audit.SignatureVerifier
+
+	if v.publicFile != "" {
+		pub, err := os.ReadFile(v.publicFile)
+		if err != nil {
+			return err
+		}
+
+		verifier = func(dat []byte, sig []byte) (bool, string) {
+			return c.VerifySignatureBytes(dat, sig, pub)
+		}
+	}
+
+	f, err := os.Open(v.logfile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := audit.VerifyChain(f, verifier)
+	if err != nil {
+		return err
+	}
+
+	if v.json {
+		j, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(j))
+	} else {
+		v.showReport(report)
+	}
+
+	if !report.IsValid() {
+		return fmt.Errorf("audit log %s failed verification with %d issue(s)", v.logfile, len(report.Issues))
+	}
+
+	return nil
+}
+
+func (v *tAuditVerifyCommand) showReport(report *audit.ChainReport) {
+	for _, issue := range report.Issues {
+		if issue.Sequence > 0 {
+			fmt.Printf("Line %d (sequence %d): %s: %s\n", issue.Line, issue.Sequence, issue.Problem, issue.Message)
+		} else {
+			fmt.Printf("Line %d: %s: %s\n", issue.Line, issue.Problem, issue.Message)
+		}
+	}
+
+	if len(report.Issues) > 0 {
+		fmt.Println()
+	}
+
+	fmt.Printf("             Records: %d\n", report.Records)
+	fmt.Printf("     Chained Records: %d\n", report.Chained)
+	fmt.Printf("          Signatures: %d\n", report.Signatures)
+
+	if v.publicFile != "" {
+		fmt.Printf(" Verified Signatures: %d\n", report.VerifiedSignatures)
+		if len(report.Signers) > 0 {
+			fmt.Printf("             Signers: %s\n", strings.Join(report.Signers, ", "))
+		}
+	}
+
+	if report.Chained == 0 {
+		fmt.Println()
+		fmt.Println("WARNING: the log has no chained records, enable plugin.rpcaudit.chain on the server")
+	}
+}
+
+func init() {
+	cli.commands = append(cli.commands, &tAuditVerifyCommand{})
+}
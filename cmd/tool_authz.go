@@ -0,0 +1,35 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"sync"
+)
+
+type tAuthzCommand struct {
+	command
+}
+
+func (a *tAuthzCommand) Setup() (err error) {
+	if tool, ok := cmdWithFullCommand("tool"); ok {
+		a.cmd = tool.Cmd().Command("authz", "Inspect and test RPC authorization policies")
+	}
+
+	return nil
+}
+
+func (a *tAuthzCommand) Configure() error {
+	return nil
+}
+
+func (a *tAuthzCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &tAuthzCommand{})
+}
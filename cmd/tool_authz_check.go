@@ -0,0 +1,224 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/protocol"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc"
+	"github.com/choria-io/tokens"
+)
+
+type tAuthzCheckCommand struct {
+	command
+
+	agent     string
+	action    string
+	caller    string
+	tokenFile string
+	input     string
+	inputFile string
+	facts     string
+	classes   string
+	policies  string
+	provider  string
+	agents    []string
+	trace     bool
+	json      bool
+}
+
+func (a *tAuthzCheckCommand) Setup() (err error) {
+	if parent, ok := cmdWithFullCommand("tool authz"); ok {
+		a.cmd = parent.Cmd().Command("check", "Evaluates a request against the authorization policies without performing it")
+		a.cmd.Arg("agent", "The agent being requested").Required().StringVar(&a.agent)
+		a.cmd.Arg("action", "The action being requested").Required().StringVar(&a.action)
+		a.cmd.Flag("caller", "The caller id making the request").PlaceHolder("ID").StringVar(&a.caller)
+		a.cmd.Flag("token", "Client JWT making the request, sets the caller when --caller is not given").PlaceHolder("FILE").ExistingFileVar(&a.tokenFile)
+		a.cmd.Flag("input", "JSON request data").PlaceHolder("JSON").StringVar(&a.input)
+		a.cmd.Flag("input-file", "File holding JSON request data").PlaceHolder("FILE").ExistingFileVar(&a.inputFile)
+		a.cmd.Flag("facts", "Facts of the node receiving the request, defaults to the configured fact source").PlaceHolder("FILE").ExistingFileVar(&a.facts)
+		a.cmd.Flag("classes", "Classes of the node receiving the request, defaults to the configured classes file").PlaceHolder("FILE").ExistingFileVar(&a.classes)
+		a.cmd.Flag("policies", "Directory holding policy files, defaults to the policies directory next to the configuration file").PlaceHolder("DIR").ExistingDirVar(&a.policies)
+		a.cmd.Flag("provider", "The authorization provider to use instead of the configured one").EnumVar(&a.provider, "action_policy", "rego_policy", "aaasvc", "aaasvc_policy")
+		a.cmd.Flag("agents", "Agents known to the node receiving the request").PlaceHolder("AGENT").StringsVar(&a.agents)
+		a.cmd.Flag("trace", "Shows a trace of the evaluation").UnNegatableBoolVar(&a.trace)
+		a.cmd.Flag("json", "Produce JSON output").UnNegatableBoolVar(&a.json)
+	}
+
+	return nil
+}
+
+func (a *tAuthzCheckCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (a *tAuthzCheckCommand) request() (*mcorpc.Request, error) {
+	reqID, err := c.NewRequestID()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &mcorpc.Request{
+		Agent:      a.agent,
+		Action:     a.action,
+		Data:       json.RawMessage("{}"),
+		RequestID:  reqID,
+		SenderID:   cfg.Identity,
+		CallerID:   a.caller,
+		Collective: cfg.MainCollective,
+		TTL:        cfg.TTL,
+		Time:       time.Now(),
+		Filter:     protocol.NewFilter(),
+	}
+
+	if a.tokenFile != "" {
+		token, err := os.ReadFile(a.tokenFile)
+		if err != nil {
+			return nil, err
+		}
+
+		req.CallerPublicData = strings.TrimSpace(string(token))
+
+		if req.CallerID == "" {
+			claims, err := tokens.ParseClientIDTokenUnverified(req.CallerPublicData)
+			if err != nil {
+				return nil, fmt.Errorf("invalid token: %w", err)
+			}
+
+			req.CallerID = claims.CallerID
+		}
+	}
+
+	if req.CallerID == "" {
+		return nil, fmt.Errorf("a caller id is required, pass --caller or --token")
+	}
+
+	input := a.input
+	if a.inputFile != "" {
+		dat, err := os.ReadFile(a.inputFile)
+		if err != nil {
+			return nil, err
+		}
+
+		input = string(dat)
+	}
+
+	if input != "" {
+		if !json.Valid([]byte(input)) {
+			return nil, fmt.Errorf("request data is not valid JSON")
+		}
+
+		req.Data = json.RawMessage(input)
+	}
+
+	return req, nil
+}
+
+func (a *tAuthzCheckCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	req, err := a.request()
+	if err != nil {
+		return err
+	}
+
+	if a.provider != "" {
+		cfg.RPCAuthorizationProvider = a.provider
+	}
+
+	if a.facts != "" {
+		cfg.FactSourceFile = a.facts
+	}
+
+	if a.classes != "" {
+		cfg.ClassesFile = a.classes
+	}
+
+	cfg.RPCAuthorization = true
+
+	decision, err := mcorpc.CheckAuthorization(c, cfg, &mcorpc.AuthorizationCheck{
+		Request:   req,
+		PolicyDir: a.policies,
+		Agents:    a.agents,
+	})
+	if err != nil {
+		return err
+	}
+
+	if a.json {
+		if !a.trace {
+			decision.Trace = nil
+		}
+
+		j, err := json.MarshalIndent(decision, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(j))
+	} else {
+		a.showDecision(req, decision)
+	}
+
+	if !decision.Allowed {
+		return fmt.Errorf("request %s#%s by %s would be denied", req.Agent, req.Action, req.CallerID)
+	}
+
+	return nil
+}
+
+func (a *tAuthzCheckCommand) showDecision(req *mcorpc.Request, decision *mcorpc.AuthorizationDecision) {
+	result := "DENIED"
+	if decision.Allowed {
+		result = "ALLOWED"
+	}
+
+	fmt.Printf("    Request: %s#%s by %s\n", req.Agent, req.Action, req.CallerID)
+	fmt.Printf("   Provider: %s\n", decision.Provider)
+	fmt.Printf("   Decision: %s\n", result)
+
+	if decision.Policy != "" {
+		fmt.Printf("     Policy: %s\n", decision.Policy)
+	}
+
+	switch {
+	case decision.Line > 0:
+		fmt.Printf("       Rule: line %d: %s\n", decision.Line, strings.ReplaceAll(decision.Rule, "\t", " "))
+	case decision.Rule != "":
+		fmt.Printf("       Rule: %s\n", decision.Rule)
+	}
+
+	for _, rule := range decision.Rules[min(1, len(decision.Rules)):] {
+		fmt.Printf("             line %d: %s\n", rule.Line, rule.Rule)
+	}
+
+	if decision.Reason != "" {
+		fmt.Printf("     Reason: %s\n", decision.Reason)
+	}
+
+	if decision.Error != "" {
+		fmt.Printf("      Error: %s\n", decision.Error)
+	}
+
+	if a.trace && len(decision.Trace) > 0 {
+		fmt.Println()
+		fmt.Println("Trace:")
+		fmt.Println()
+
+		for _, line := range decision.Trace {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+func init() {
+	cli.commands = append(cli.commands, &tAuthzCheckCommand{})
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/tlssetup"
+)
+
+type tCRLCommand struct {
+	command
+
+	source   string
+	certFile string
+	json     bool
+}
+
+func init() {
+	cli.commands = append(cli.commands, &tCRLCommand{})
+}
+
+func (r *tCRLCommand) Setup() error {
+	if tool, ok := cmdWithFullCommand("tool"); ok {
+		r.cmd = tool.Cmd().Command("crl", "Inspects the Certificate Revocation List used by the x509 security providers")
+		r.cmd.Flag("crl", "The file or URL to load the CRL from instead of plugin.security.crl").PlaceHolder("SOURCE").StringVar(&r.source)
+		r.cmd.Flag("cert", "Checks if a PEM encoded certificate was revoked").PlaceHolder("FILE").ExistingFileVar(&r.certFile)
+		r.cmd.Flag("json", "Produce JSON output").UnNegatableBoolVar(&r.json)
+	}
+
+	return nil
+}
+
+func (r *tCRLCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (r *tCRLCommand) Run(wg *sync.WaitGroup) error {
+	defer wg.Done()
+
+	if r.source == "" {
+		r.source = cfg.Choria.SecurityCRL
+	}
+
+	if r.source == "" {
+		return fmt.Errorf("no CRL configured, set plugin.security.crl or use --crl")
+	}
+
+	checker := tlssetup.NewRevocationChecker(r.source, cfg.Choria.SecurityCRLRefresh, false, false)
+	checker.SetLogger(c.Logger("crl"))
+
+	info, err := checker.CRL()
+	if err != nil {
+		return err
+	}
+
+	if r.certFile != "" {
+		return r.checkCert(info)
+	}
+
+	if r.json {
+		j, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(j))
+
+		return nil
+	}
+
+	fmt.Printf("Certificate Revocation List %s\n\n", info.Source)
+	fmt.Printf("       Issuer: %s\n", info.Issuer)
+	if info.Number != "" {
+		fmt.Printf("       Number: %s\n", info.Number)
+	}
+	fmt.Printf("  This Update: %s (%s ago)\n", info.ThisUpdate.Format(time.RFC3339), util.RenderDuration(time.Since(info.ThisUpdate)))
+	if !info.NextUpdate.IsZero() {
+		if time.Now().After(info.NextUpdate) {
+			fmt.Printf("  Next Update: %s (%s)\n", info.NextUpdate.Format(time.RFC3339), c.Colorizef("red", "%s overdue", util.RenderDuration(time.Since(info.NextUpdate))))
+		} else {
+			fmt.Printf("  Next Update: %s (in %s)\n", info.NextUpdate.Format(time.RFC3339), util.RenderDuration(time.Until(info.NextUpdate)))
+		}
+	}
+	fmt.Printf("      Revoked: %d certificates\n", len(info.Revoked))
+
+	if len(info.Revoked) == 0 {
+		return nil
+	}
+
+	serials := make([]string, 0, len(info.Revoked))
+	for serial := range info.Revoked {
+		serials = append(serials, serial)
+	}
+	sort.Strings(serials)
+
+	table := util.NewUTF8Table("Serial", "Revoked")
+	for _, serial := range serials {
+		table.AddRow(serial, info.Revoked[serial])
+	}
+
+	fmt.Println()
+	fmt.Println(table.Render())
+
+	return nil
+}
+
+func (r *tCRLCommand) checkCert(info *tlssetup.CRLInfo) error {
+	pb, err := os.ReadFile(r.certFile)
+	if err != nil {
+		return err
+	}
+
+	block, _ := pem.Decode(pb)
+	if block == nil {
+		return fmt.Errorf("could not decode PEM data in %s", r.certFile)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	if cert.Issuer.String() != info.Issuer {
+		return fmt.Errorf("certificate %s is issued by %s and not covered by the CRL from %s", cert.Subject.CommonName, cert.Issuer, info.Issuer)
+	}
+
+	revoked, ok := info.Revoked[cert.SerialNumber.String()]
+	if !ok {
+		fmt.Printf("Certificate %s with serial %s is not revoked\n", cert.Subject.CommonName, cert.SerialNumber)
+		return nil
+	}
+
+	return fmt.Errorf("certificate %s with serial %s was revoked at %s", cert.Subject.CommonName, cert.SerialNumber, revoked)
+}
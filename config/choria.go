@@ -27,9 +27,10 @@ type ChoriaPluginConfig struct {
 	Provision            bool `confkey:"plugin.choria.server.provision" default:"false" url:"https://choria-io.github.io/provisioner/"`              // Specifically enable or disable provisioning
 	ProvisionAllowUpdate bool `confkey:"plugin.choria.server.provision.allow_update" default:"false" url:"https://choria-io.github.io/provisioner/"` // Allows the provisioner to perform in-place version updates
 
-	ExternalDiscoveryCommand         string `confkey:"plugin.choria.discovery.external.command" type:"path_string"` // The command to use for external discovery
-	InventoryDiscoverySource         string `confkey:"plugin.choria.discovery.inventory.source" type:"path_string"` // The file to read for inventory discovery
-	BroadcastDiscoveryDynamicTimeout bool   `confkey:"plugin.choria.discovery.broadcast.windowed_timeout"`          // Enables the experimental dynamic timeout for choria/mc discovery
+	ExternalDiscoveryCommand         string `confkey:"plugin.choria.discovery.external.command" type:"path_string"`       // The command to use for external discovery
+	InventoryDiscoverySource         string `confkey:"plugin.choria.discovery.inventory.source" type:"path_string"`       // The file to read for inventory discovery
+	RegistryDiscoveryBucket          string `confkey:"plugin.choria.discovery.registry.bucket" default:"CHORIA_REGISTRY"` // The Choria Streams Key-Value bucket holding node inventories maintained by the registry adapter
+	BroadcastDiscoveryDynamicTimeout bool   `confkey:"plugin.choria.discovery.broadcast.windowed_timeout"`                // Enables the experimental dynamic timeout for choria/mc discovery
 
 	FederationCollectives     []string `confkey:"plugin.choria.federation.collectives" type:"comma_split" environment:"CHORIA_FED_COLLECTIVE" url:"https://choria.io/docs/federation/"` // List of known remote collectives accessible via Federation Brokers
 	FederationMiddlewareHosts []string `confkey:"plugin.choria.federation_middleware_hosts" type:"comma_split" url:"https://choria.io/docs/federation/"`                                // Middleware brokers used by the Federation Broker, if unset uses SRV
@@ -73,6 +74,8 @@ type ChoriaPluginConfig struct {
 	NetworkProvisioningClientPassword  string        `confkey:"plugin.choria.network.provisioning.client_password"`                                                // Password the provisioned clients should use to connect
 	NetworkProvisioningWithoutToken    bool          `confkey:"plugin.choria.network.provisioning.provisioner_without_token"`                                      // Allows a provisioner without a token to connect over TLS using username and password.  This facilitates v1 provisioning on an Issuer based network
 	NetworkProvisioningTokenSignerFile string        `confkey:"plugin.choria.network.provisioning.signer_cert" type:"path_string"`                                 // Path to the public cert that signs provisioning tokens, enables accepting provisioning connections into the provisioning account
+	NetworkRPCReplyStoreDuration       time.Duration `confkey:"plugin.choria.network.stream.rpc_reply_retention" type:"duration" default:"24h"`                    // When not zero enables retaining replies to asynchronous RPC requests in the Stream Store
+	NetworkRPCReplyReplicas            int           `confkey:"plugin.choria.network.stream.rpc_reply_replicas" default:"-1"`                                      // When configuring asynchronous RPC reply storage ensure data is replicated in the cluster over this many servers, -1 means count of peers
 	NetworkServerTokenSigners          []string      `confkey:"plugin.choria.network.server_signer_cert" type:"comma_split"`                                       // Fully qualified Paths to the public certificates used by the Provisioner Service to sign server JWT tokens. This enables servers with signed JWTs to use unverified TLS to connect. Can also be a list of ed25519 public keys.
 	NetworkStreamAdvisoryDuration      time.Duration `confkey:"plugin.choria.network.stream.advisory_retention" type:"duration" default:"168h"`                    // When not zero enables retaining Stream advisories in the Stream Store
 	NetworkStreamAdvisoryReplicas      int           `confkey:"plugin.choria.network.stream.advisory_replicas" default:"-1"`                                       // When configuring Stream advisories storage ensure data is replicated in the cluster over this many servers, -1 means count of peers
@@ -119,6 +122,13 @@ type ChoriaPluginConfig struct {
 	CertnameAllowList        []string `confkey:"plugin.choria.security.certname_whitelist" type:"comma_split" default:"\\.mcollective$,\\.choria$"`                                                                     // Patterns of certificate names that are allowed to be clients
 	SecurityAllowLegacyCerts bool     `confkey:"plugin.security.support_legacy_certificates" default:"false"`                                                                                                           // Allow certificates without SANs to be used
 
+	SecurityCRL        string        `confkey:"plugin.security.crl"`                                      // A file or http(s) URL with a Certificate Revocation List used to reject revoked x509 certificates
+	SecurityCRLRefresh time.Duration `confkey:"plugin.security.crl_refresh" type:"duration" default:"1h"` // How often the Certificate Revocation List is reloaded, it is also reloaded once its next update time passed
+	SecurityOCSP       bool          `confkey:"plugin.security.ocsp" default:"false"`                     // Checks x509 certificates against the OCSP responders listed in them
+	SecurityOCSPStrict bool          `confkey:"plugin.security.ocsp_strict" default:"false"`              // Rejects certificates when their OCSP responders cannot be reached or do not know the certificate
+
+	SecurityCredentialReload time.Duration `confkey:"plugin.security.credential_reload" type:"duration" default:"1m"` // Certificates, keys and tokens are polled for renewals on this fixed interval, they are not watched for changes, renewals are used for new connections without a restart while the broker /varz monitoring keeps reporting the certificate loaded at start, 0 disables reloading
+
 	RemoteSignerTokenSeedFile string `confkey:"plugin.choria.security.request_signer.seed_file" type:"path_string" url:"https://github.com/choria-io/aaasvc"`  // Path to the seed file used to access a Central Authenticator
 	RemoteSignerTokenFile     string `confkey:"plugin.choria.security.request_signer.token_file" type:"path_string" url:"https://github.com/choria-io/aaasvc"` // Path to the token used to access a Central Authenticator
 	RemoteSignerURL           string `confkey:"plugin.choria.security.request_signer.url" url:"https://choria-io.github.io/aaasvc/"`                           // URL to the Signing Service
@@ -132,6 +142,9 @@ type ChoriaPluginConfig struct {
 	ChoriaSecuritySeedFile       string   `confkey:"plugin.security.choria.seed_file" type:"path_string"`       // The path to the seed file
 	ChoriaSecuritySignReplies    bool     `confkey:"plugin.security.choria.sign_replies" default:"true"`        // Disables signing replies which would significantly trim down the size of replies but would remove the ability to verify signatures or verify message origin
 
+	ChoriaSecurityRevocationSigners []string `confkey:"plugin.security.choria.revocation_signers" type:"comma_split"`              // Ed25519 public keys of entities allowed to sign JWT revocations in hex encoded format, enables rejecting revoked client and server tokens once the list is loaded from Choria Streams, tokens are accepted until then. Tokens are only checked when connecting so revoking one does not close existing connections. Brokers need this set to give servers access to the list
+	ChoriaSecurityRevocationBucket  string   `confkey:"plugin.security.choria.revocation_bucket" default:"CHORIA_JWT_REVOCATIONS"` // The Choria Streams Key-Value bucket holding the signed JWT revocation list
+
 	FileSecurityCertificate string `confkey:"plugin.security.file.certificate" type:"path_string"` // When using file security provider, the path to the public certificate
 	FileSecurityKey         string `confkey:"plugin.security.file.key" type:"path_string"`         // When using file security provider, the path to the private key
 	FileSecurityCA          string `confkey:"plugin.security.file.ca" type:"path_string"`          // When using file security provider, the path to the Certificate Authority public certificate
@@ -148,7 +161,8 @@ type ChoriaPluginConfig struct {
 	MachineSourceDir string `confkey:"plugin.choria.machine.store" url:"https://choria.io/docs/autoagents/"` // Directory where Autonomous Agents are stored
 	// Public key used to sign data for watchers like machines watcher. Will override the value compiled in or in the watcher definitions if set here. This is primarily to allow development environments to use different private keys.
 	MachinesSignerPublicKey string `confkey:"plugin.choria.machine.signing_key"`
-	MachinesHTTPPort        int    `confkey:"plugin.choria.machine.http_port"` // Enables interacting with autonomous agents via HTTP
+	MachinesHTTPPort        int    `confkey:"plugin.choria.machine.http_port"`                   // Enables interacting with autonomous agents via HTTP
+	MachinesWebhooks        string `confkey:"plugin.choria.machine.webhooks" type:"path_string"` // Path to a YAML file listing webhooks that receive notifications from all Autonomous Agents, deliveries are retried using the Submission spool when configured
 
 	StatusFilePath               string   `confkey:"plugin.choria.status_file_path" type:"path_string"`                              // Path to a JSON file to write server health information to regularly
 	StatusUpdateSeconds          int      `confkey:"plugin.choria.status_update_interval" default:"30"`                              // How frequently to write to the status_file_path
@@ -161,6 +175,8 @@ type ChoriaPluginConfig struct {
 
 	RequireClientFilter bool `confkey:"plugin.choria.require_client_filter" default:"false"` // If a client filter should always be required, only used in Go clients
 
+	AgentMaxQueue int `confkey:"plugin.choria.agents.max_queue" default:"100"` // The number of requests that can wait for a free slot in agents with concurrency limits, overridden per agent using plugin.<agent>.max_queue
+
 	RegistryServiceStore string `confkey:"plugin.choria.services.registry.store" type:"path_string"`                                // Directory where the Registry service finds DDLs to read
 	RegistryClientCache  string `confkey:"plugin.choria.services.registry.cache" type:"path_string"  environment:"CHORIA_REGISTRY"` // Directory where the Registry client stores DDLs found in the registry
 
@@ -171,9 +187,25 @@ type ChoriaPluginConfig struct {
 	RPCAuditLogfileGroup string `confkey:"plugin.rpcaudit.logfile.group"`               // User group to set file ownership to
 	RPCAuditLogFileMode  string `confkey:"plugin.rpcaudit.logfile.mode" default:"0600"` // File mode to apply to the file
 
+	RPCAuditChain             bool `confkey:"plugin.rpcaudit.chain" default:"false"`             // Adds a sequence number and the hash of the previous record to every record in the audit log making changes detectable
+	RPCAuditChainSignInterval int  `confkey:"plugin.rpcaudit.chain.sign_interval" default:"100"` // Signs the audit log hash chain using the security provider every this many records, 0 disables signing
+
+	RPCAuditReceived bool `confkey:"plugin.rpcaudit.received" default:"false"` // Writes an additional record marked with the received phase before authorizing and handling a request so requests that never complete are audited
+
+	RPCAuditSinks             []string `confkey:"plugin.rpcaudit.sinks" default:"file" type:"comma_split"`       // Where to write audit records, one or more of file, submission and syslog
+	RPCAuditSubmissionSubject string   `confkey:"plugin.rpcaudit.submission.subject" default:"choria.audit.rpc"` // The subject audit records are published to by the submission sink, the server identity is appended
+	RPCAuditSyslogAddress     string   `confkey:"plugin.rpcaudit.syslog.address"`                                // The syslog server the syslog sink sends RFC5424 records to like udp://host:514, tcp://host:514 or unix:///dev/log
+	RPCAuditSyslogFacility    string   `confkey:"plugin.rpcaudit.syslog.facility" default:"audit"`               // The syslog facility used by the syslog sink
+
 	ExecutorEnabled bool   `confkey:"plugin.choria.executor.enabled" default:"false"`  // Enables the long running command executor
 	ExecutorSpool   string `confkey:"plugin.choria.executor.spool" type:"path_string"` // Path where the command executor writes state
 
+	ExecutorRetentionMaxAge       time.Duration `confkey:"plugin.choria.executor.retention.max_age" type:"duration" default:"0s"`        // Completed jobs older than this are removed from the spool, 0 disables
+	ExecutorRetentionFailedMaxAge time.Duration `confkey:"plugin.choria.executor.retention.failed_max_age" type:"duration" default:"0s"` // Failed jobs, including jobs that did not start within 10 minutes, older than this are removed from the spool, when 0 the max_age setting applies to failed jobs also
+	ExecutorRetentionMaxCount     int           `confkey:"plugin.choria.executor.retention.max_count" default:"0"`                       // The maximum number of completed jobs to keep in the spool, 0 disables
+	ExecutorRetentionMaxBytes     int           `confkey:"plugin.choria.executor.retention.max_bytes" default:"0"`                       // The maximum total size of completed jobs in the spool in bytes, 0 disables
+	ExecutorRetentionInterval     time.Duration `confkey:"plugin.choria.executor.retention.interval" type:"duration" default:"10m"`      // How frequently the retention policy is enforced on the spool
+
 	AutonomousAgentsDownload           bool   `confkey:"plugin.machines.download"`                        // Activate run-time installation of Autonomous Agents
 	AutonomousAgentsBucket             string `confkey:"plugin.machines.bucket" default:"CHORIA_PLUGINS"` // The KV bucket to query for plugins to install
 	AutonomousAgentsKey                string `confkey:"plugin.machines.key" default:"plugins"`           // The Key to query in KV bucket for plugins to install
@@ -82,7 +82,7 @@ type Config struct {
 	TTL int `confkey:"ttl" default:"60"`
 
 	// The default discovery plugin to use. The default "mc" uses a network broadcast, "choria" uses PuppetDB, external calls external commands
-	DefaultDiscoveryMethod string `confkey:"default_discovery_method" default:"mc" validate:"enum=mc,broadcast,puppetdb,choria,external,inventory"`
+	DefaultDiscoveryMethod string `confkey:"default_discovery_method" default:"mc" validate:"enum=mc,broadcast,puppetdb,choria,external,inventory,registry"`
 
 	// Where to look for YAML or JSON based facts
 	FactSourceFile string `confkey:"plugin.yaml" type:"path_string"`
@@ -37,6 +37,7 @@ var docStrings = map[string]string{
 	"plugin.choria.server.provision.allow_update":                  "Allows the provisioner to perform in-place version updates",
 	"plugin.choria.discovery.external.command":                     "The command to use for external discovery",
 	"plugin.choria.discovery.inventory.source":                     "The file to read for inventory discovery",
+	"plugin.choria.discovery.registry.bucket":                      "The Choria Streams Key-Value bucket holding node inventories maintained by the registry adapter",
 	"plugin.choria.discovery.broadcast.windowed_timeout":           "Enables the experimental dynamic timeout for choria/mc discovery",
 	"plugin.choria.federation.collectives":                         "List of known remote collectives accessible via Federation Brokers",
 	"plugin.choria.federation_middleware_hosts":                    "Middleware brokers used by the Federation Broker, if unset uses SRV",
@@ -77,6 +78,8 @@ var docStrings = map[string]string{
 	"plugin.choria.network.provisioning.client_password":           "Password the provisioned clients should use to connect",
 	"plugin.choria.network.provisioning.provisioner_without_token": "Allows a provisioner without a token to connect over TLS using username and password.  This facilitates v1 provisioning on an Issuer based network",
 	"plugin.choria.network.provisioning.signer_cert":               "Path to the public cert that signs provisioning tokens, enables accepting provisioning connections into the provisioning account",
+	"plugin.choria.network.stream.rpc_reply_retention":             "When not zero enables retaining replies to asynchronous RPC requests in the Stream Store",
+	"plugin.choria.network.stream.rpc_reply_replicas":              "When configuring asynchronous RPC reply storage ensure data is replicated in the cluster over this many servers, -1 means count of peers",
 	"plugin.choria.network.server_signer_cert":                     "Fully qualified Paths to the public certificates used by the Provisioner Service to sign server JWT tokens. This enables servers with signed JWTs to use unverified TLS to connect. Can also be a list of ed25519 public keys.",
 	"plugin.choria.network.stream.advisory_retention":              "When not zero enables retaining Stream advisories in the Stream Store",
 	"plugin.choria.network.stream.advisory_replicas":               "When configuring Stream advisories storage ensure data is replicated in the cluster over this many servers, -1 means count of peers",
@@ -117,6 +120,11 @@ var docStrings = map[string]string{
 	"plugin.choria.security.privileged_users":                      "Patterns of certificate names that would be considered privileged and able to set custom callers",
 	"plugin.choria.security.certname_whitelist":                    "Patterns of certificate names that are allowed to be clients",
 	"plugin.security.support_legacy_certificates":                  "Allow certificates without SANs to be used",
+	"plugin.security.crl":                                          "A file or http(s) URL with a Certificate Revocation List used to reject revoked x509 certificates",
+	"plugin.security.crl_refresh":                                  "How often the Certificate Revocation List is reloaded, it is also reloaded once its next update time passed",
+	"plugin.security.ocsp":                                         "Checks x509 certificates against the OCSP responders listed in them",
+	"plugin.security.ocsp_strict":                                  "Rejects certificates when their OCSP responders cannot be reached or do not know the certificate",
+	"plugin.security.credential_reload":                            "Certificates, keys and tokens are polled for renewals on this fixed interval, they are not watched for changes, renewals are used for new connections without a restart while the broker /varz monitoring keeps reporting the certificate loaded at start, 0 disables reloading",
 	"plugin.choria.security.request_signer.seed_file":              "Path to the seed file used to access a Central Authenticator",
 	"plugin.choria.security.request_signer.token_file":             "Path to the token used to access a Central Authenticator",
 	"plugin.choria.security.request_signer.url":                    "URL to the Signing Service",
@@ -128,6 +136,8 @@ var docStrings = map[string]string{
 	"plugin.security.choria.token_file":                            "The path to the JWT token file",
 	"plugin.security.choria.seed_file":                             "The path to the seed file",
 	"plugin.security.choria.sign_replies":                          "Disables signing replies which would significantly trim down the size of replies but would remove the ability to verify signatures or verify message origin",
+	"plugin.security.choria.revocation_signers":                    "Ed25519 public keys of entities allowed to sign JWT revocations in hex encoded format, enables rejecting revoked client and server tokens once the list is loaded from Choria Streams, tokens are accepted until then. Tokens are only checked when connecting so revoking one does not close existing connections. Brokers need this set to give servers access to the list",
+	"plugin.security.choria.revocation_bucket":                     "The Choria Streams Key-Value bucket holding the signed JWT revocation list",
 	"plugin.security.file.certificate":                             "When using file security provider, the path to the public certificate",
 	"plugin.security.file.key":                                     "When using file security provider, the path to the private key",
 	"plugin.security.file.ca":                                      "When using file security provider, the path to the Certificate Authority public certificate",
@@ -141,6 +151,7 @@ var docStrings = map[string]string{
 	"plugin.choria.machine.store":                                  "Directory where Autonomous Agents are stored",
 	"plugin.choria.machine.signing_key":                            "Public key used to sign data for watchers like machines watcher. Will override the value compiled in or in the watcher definitions if set here. This is primarily to allow development environments to use different private keys.",
 	"plugin.choria.machine.http_port":                              "Enables interacting with autonomous agents via HTTP",
+	"plugin.choria.machine.webhooks":                               "Path to a YAML file listing webhooks that receive notifications from all Autonomous Agents, deliveries are retried using the Submission spool when configured",
 	"plugin.choria.status_file_path":                               "Path to a JSON file to write server health information to regularly",
 	"plugin.choria.status_update_interval":                         "How frequently to write to the status_file_path",
 	"plugin.choria.prometheus_textfile_directory":                  "Directory where Prometheus Node Exporter textfile collector reads data",
@@ -150,6 +161,7 @@ var docStrings = map[string]string{
 	"plugin.scout.goss.denied_local_resources":                     "List of resource types to deny for Goss manifests loaded from local disk",
 	"plugin.scout.goss.denied_remote_resources":                    "List of resource types to deny when Goss manifests or variables were received over rpc",
 	"plugin.choria.require_client_filter":                          "If a client filter should always be required, only used in Go clients",
+	"plugin.choria.agents.max_queue":                               "The number of requests that can wait for a free slot in agents with concurrency limits, overridden per agent using plugin.<agent>.max_queue",
 	"plugin.choria.services.registry.store":                        "Directory where the Registry service finds DDLs to read",
 	"plugin.choria.services.registry.cache":                        "Directory where the Registry client stores DDLs found in the registry",
 	"plugin.choria.submission.spool":                               "Path to a directory holding messages to submit to the middleware",
@@ -157,8 +169,20 @@ var docStrings = map[string]string{
 	"plugin.rpcaudit.logfile":                                      "Path to the RPC audit log",
 	"plugin.rpcaudit.logfile.group":                                "User group to set file ownership to",
 	"plugin.rpcaudit.logfile.mode":                                 "File mode to apply to the file",
+	"plugin.rpcaudit.chain":                                        "Adds a sequence number and the hash of the previous record to every record in the audit log making changes detectable",
+	"plugin.rpcaudit.chain.sign_interval":                          "Signs the audit log hash chain using the security provider every this many records, 0 disables signing",
+	"plugin.rpcaudit.received":                                     "Writes an additional record marked with the received phase before authorizing and handling a request so requests that never complete are audited",
+	"plugin.rpcaudit.sinks":                                        "Where to write audit records, one or more of file, submission and syslog",
+	"plugin.rpcaudit.submission.subject":                           "The subject audit records are published to by the submission sink, the server identity is appended",
+	"plugin.rpcaudit.syslog.address":                               "The syslog server the syslog sink sends RFC5424 records to like udp://host:514, tcp://host:514 or unix:///dev/log",
+	"plugin.rpcaudit.syslog.facility":                              "The syslog facility used by the syslog sink",
 	"plugin.choria.executor.enabled":                               "Enables the long running command executor",
 	"plugin.choria.executor.spool":                                 "Path where the command executor writes state",
+	"plugin.choria.executor.retention.max_age":                     "Completed jobs older than this are removed from the spool, 0 disables",
+	"plugin.choria.executor.retention.failed_max_age":              "Failed jobs, including jobs that did not start within 10 minutes, older than this are removed from the spool, when 0 the max_age setting applies to failed jobs also",
+	"plugin.choria.executor.retention.max_count":                   "The maximum number of completed jobs to keep in the spool, 0 disables",
+	"plugin.choria.executor.retention.max_bytes":                   "The maximum total size of completed jobs in the spool in bytes, 0 disables",
+	"plugin.choria.executor.retention.interval":                    "How frequently the retention policy is enforced on the spool",
 	"plugin.machines.download":                                     "Activate run-time installation of Autonomous Agents",
 	"plugin.machines.bucket":                                       "The KV bucket to query for plugins to install",
 	"plugin.machines.key":                                          "The Key to query in KV bucket for plugins to install",
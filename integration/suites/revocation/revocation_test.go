@@ -0,0 +1,151 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package revocation
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/choria-io/go-choria/integration/testbroker"
+	"github.com/choria-io/go-choria/integration/testutil"
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/choria-io/go-choria/providers/security/denylist"
+	"github.com/choria-io/tokens"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRevocation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Integration/JWT Revocation")
+}
+
+var _ = Describe("JWT Revocation", func() {
+	var (
+		ctx            context.Context
+		cancel         context.CancelFunc
+		wg             sync.WaitGroup
+		brokerLogBuff  *gbytes.Buffer
+		issuerPubK     ed25519.PublicKey
+		issuerSeedFile string
+		revokerPubK    ed25519.PublicKey
+		revokerPriK    ed25519.PrivateKey
+		rootDir        string
+		store          nats.KeyValue
+	)
+
+	BeforeEach(func() {
+		var err error
+		var logger *logrus.Logger
+
+		rootDir = GinkgoT().TempDir()
+
+		issuerSeedFile = filepath.Join(rootDir, "issuer")
+		issuerPubK, _, err = iu.Ed25519KeyPairToFile(issuerSeedFile)
+		Expect(err).ToNot(HaveOccurred())
+
+		revokerPubK, revokerPriK, err = iu.Ed25519KeyPair()
+		Expect(err).ToNot(HaveOccurred())
+
+		brokerLogBuff, logger = testutil.GbytesLogger(logrus.DebugLevel)
+		ctx, cancel = context.WithTimeout(context.Background(), 45*time.Second)
+		DeferCleanup(func() {
+			cancel()
+			Eventually(brokerLogBuff, 5).Should(gbytes.Say("Choria Network Broker shut down"))
+		})
+
+		tokenFile, _, _, priFile, err := testutil.CreateChoriaTokenAndKeys(rootDir, issuerSeedFile, nil, func(pk ed25519.PublicKey) (jwt.Claims, error) {
+			return tokens.NewServerClaims("localhost", []string{"choria"}, "choria", nil, nil, pk, "", time.Hour)
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err := iu.ExecuteTemplateFile("testdata/broker.conf", map[string]any{
+			"seed":    priFile,
+			"token":   tokenFile,
+			"issuer":  hex.EncodeToString(issuerPubK),
+			"store":   filepath.Join(rootDir, "store"),
+			"revoker": hex.EncodeToString(revokerPubK),
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(rootDir, "broker.conf"), cfg, 0644)).To(Succeed())
+
+		broker, err := testbroker.StartNetworkBrokerWithConfigFile(ctx, &wg, filepath.Join(rootDir, "broker.conf"), logger)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(brokerLogBuff, 1).Should(gbytes.Say("Server is ready"))
+
+		// revocations are made over an in-process connection that has full access
+		nc, err := nats.Connect(nats.DefaultURL, nats.InProcessServer(broker), nats.Secure(&tls.Config{InsecureSkipVerify: true}))
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(nc.Close)
+
+		Eventually(func() error {
+			store, err = kv.NewKV(nc, denylist.DefaultBucket, true, kv.WithHistory(denylist.History), kv.WithoutPurge())
+			return err
+		}, 10).Should(Succeed())
+	})
+
+	revoke := func(pubK ed25519.PublicKey) {
+		entry, err := denylist.NewEntry(denylist.PublicKey, hex.EncodeToString(pubK), "ginkgo", time.Time{})
+		Expect(err).ToNot(HaveOccurred())
+		key, err := entry.Key()
+		Expect(err).ToNot(HaveOccurred())
+		data, err := entry.Sign(revokerPriK)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = store.Put(key, data)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	startServer := func(perms *tokens.ServerPermissions) *gbytes.Buffer {
+		logbuff, logger := testutil.GbytesLogger(logrus.DebugLevel)
+
+		td, err := os.MkdirTemp(rootDir, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		tfile, _, _, sfile, err := testutil.CreateChoriaTokenAndKeys(td, issuerSeedFile, nil, func(pk ed25519.PublicKey) (jwt.Claims, error) {
+			return tokens.NewServerClaims("srv.example.net", []string{"choria"}, "choria", perms, nil, pk, "ginkgo", time.Minute)
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, err := iu.ExecuteTemplateFile("testdata/server.conf", map[string]any{
+			"name":    "srv.example.net",
+			"token":   tfile,
+			"seed":    sfile,
+			"issuer":  hex.EncodeToString(issuerPubK),
+			"revoker": hex.EncodeToString(revokerPubK),
+		}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		cfile := filepath.Join(td, "server.conf")
+		Expect(os.WriteFile(cfile, cfg, 0644)).To(Succeed())
+
+		_, err = testutil.StartServerInstance(ctx, &wg, cfile, logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		Eventually(logbuff).Should(gbytes.Say("Connected to nats://localhost:4222"))
+
+		return logbuff
+	}
+
+	It("Should allow servers without Streams access to load the revocations", func() {
+		revoked, _, err := iu.Ed25519KeyPair()
+		Expect(err).ToNot(HaveOccurred())
+		revoke(revoked)
+
+		logbuff := startServer(nil)
+		Eventually(logbuff, 10).Should(gbytes.Say("Loaded 1 JWT revocations"))
+		Expect(logbuff).ToNot(gbytes.Say("Could not access JWT revocation bucket"))
+	})
+})
@@ -17,6 +17,7 @@ import (
 	election "github.com/choria-io/go-choria/providers/election/streams"
 	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/choria-io/go-choria/providers/security/denylist"
 	"github.com/choria-io/go-choria/srvcache"
 	"github.com/nats-io/nats.go"
 	"github.com/sirupsen/logrus"
@@ -95,16 +96,20 @@ type Framework interface {
 	PuppetDBServers() (servers srvcache.Servers, err error)
 	PuppetSetting(setting string) (string, error)
 	QuerySrvRecords(records []string) (srvcache.Servers, error)
+	ReloadSecurityCredentials() (changed []string, err error)
 	SetLogWriter(out io.Writer)
 	SetLogger(logger *logrus.Logger)
 	SetupLogging(debug bool) (err error)
+	SignBytes(b []byte) (signature []byte, err error)
 	SignerSeedFile() (f string, err error)
 	SignerToken() (token string, exp time.Time, err error)
 	SignerTokenFile() (f string, err error)
 	SupportsProvisioning() bool
 	TLSConfig() (*tls.Config, error)
+	TokenDenyList() *denylist.List
 	TrySrvLookup(names []string, defaultSrv srvcache.Server) (srvcache.Server, error)
 	UniqueID() string
 	UniqueIDFromUnverifiedToken() (id string, uid string, exp time.Time, token string, err error)
 	ValidateSecurity() (errors []string, ok bool)
+	VerifySignatureBytes(dat []byte, sig []byte, public ...[]byte) (should bool, signer string)
 }
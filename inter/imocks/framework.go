@@ -25,6 +25,7 @@ import (
 	election "github.com/choria-io/go-choria/providers/election/streams"
 	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	kv "github.com/choria-io/go-choria/providers/kv"
+	denylist "github.com/choria-io/go-choria/providers/security/denylist"
 	srvcache "github.com/choria-io/go-choria/srvcache"
 	nats "github.com/nats-io/nats.go"
 	logrus "github.com/sirupsen/logrus"
@@ -1508,6 +1509,21 @@ func (mr *MockFrameworkMockRecorder) QuerySrvRecords(records any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuerySrvRecords", reflect.TypeOf((*MockFramework)(nil).QuerySrvRecords), records)
 }
 
+// ReloadSecurityCredentials mocks base method.
+func (m *MockFramework) ReloadSecurityCredentials() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReloadSecurityCredentials")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReloadSecurityCredentials indicates an expected call of ReloadSecurityCredentials.
+func (mr *MockFrameworkMockRecorder) ReloadSecurityCredentials() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReloadSecurityCredentials", reflect.TypeOf((*MockFramework)(nil).ReloadSecurityCredentials))
+}
+
 // RequestProtocol mocks base method.
 func (m *MockFramework) RequestProtocol() protocol.ProtocolVersion {
 	m.ctrl.T.Helper()
@@ -1560,6 +1576,21 @@ func (mr *MockFrameworkMockRecorder) SetupLogging(debug any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetupLogging", reflect.TypeOf((*MockFramework)(nil).SetupLogging), debug)
 }
 
+// SignBytes mocks base method.
+func (m *MockFramework) SignBytes(b []byte) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignBytes", b)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignBytes indicates an expected call of SignBytes.
+func (mr *MockFrameworkMockRecorder) SignBytes(b any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignBytes", reflect.TypeOf((*MockFramework)(nil).SignBytes), b)
+}
+
 // SignerSeedFile mocks base method.
 func (m *MockFramework) SignerSeedFile() (string, error) {
 	m.ctrl.T.Helper()
@@ -1635,6 +1666,20 @@ func (mr *MockFrameworkMockRecorder) TLSConfig() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TLSConfig", reflect.TypeOf((*MockFramework)(nil).TLSConfig))
 }
 
+// TokenDenyList mocks base method.
+func (m *MockFramework) TokenDenyList() *denylist.List {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TokenDenyList")
+	ret0, _ := ret[0].(*denylist.List)
+	return ret0
+}
+
+// TokenDenyList indicates an expected call of TokenDenyList.
+func (mr *MockFrameworkMockRecorder) TokenDenyList() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TokenDenyList", reflect.TypeOf((*MockFramework)(nil).TokenDenyList))
+}
+
 // TrySrvLookup mocks base method.
 func (m *MockFramework) TrySrvLookup(names []string, defaultSrv srvcache.Server) (srvcache.Server, error) {
 	m.ctrl.T.Helper()
@@ -1696,3 +1741,23 @@ func (mr *MockFrameworkMockRecorder) ValidateSecurity() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateSecurity", reflect.TypeOf((*MockFramework)(nil).ValidateSecurity))
 }
+
+// VerifySignatureBytes mocks base method.
+func (m *MockFramework) VerifySignatureBytes(dat, sig []byte, public ...[]byte) (bool, string) {
+	m.ctrl.T.Helper()
+	varargs := []any{dat, sig}
+	for _, a := range public {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifySignatureBytes", varargs...)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(string)
+	return ret0, ret1
+}
+
+// VerifySignatureBytes indicates an expected call of VerifySignatureBytes.
+func (mr *MockFrameworkMockRecorder) VerifySignatureBytes(dat, sig any, public ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{dat, sig}, public...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySignatureBytes", reflect.TypeOf((*MockFramework)(nil).VerifySignatureBytes), varargs...)
+}
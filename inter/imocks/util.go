@@ -102,6 +102,7 @@ func NewFrameworkForTests(ctrl *gomock.Controller, logWriter io.Writer, opts ...
 	fw.EXPECT().Configuration().Return(mopts.cfg).AnyTimes()
 	fw.EXPECT().Logger(gomock.AssignableToTypeOf("")).Return(logrus.NewEntry(logger)).AnyTimes()
 	fw.EXPECT().NewRequestID().Return(util.RandomHexString(), nil).AnyTimes()
+	fw.EXPECT().TokenDenyList().Return(nil).AnyTimes()
 	fw.EXPECT().FederationCollectives().DoAndReturn(
 		func() []string {
 			if len(fw.Configuration().Choria.FederationCollectives) == 0 {
@@ -6,6 +6,7 @@ package inter
 
 import (
 	"context"
+	"crypto/ed25519"
 	"crypto/tls"
 	"crypto/x509"
 	"net/http"
@@ -91,3 +92,15 @@ type SecurityProvider interface {
 	// as argument
 	Enroll(ctx context.Context, wait time.Duration, cb func(digest string, try int)) error
 }
+
+// CredentialReloader is implemented by security providers that can load renewed credentials without a restart
+type CredentialReloader interface {
+	// ReloadCredentials reads the credentials from disk again, changed lists the kinds of credential that changed
+	ReloadCredentials() (changed []string, err error)
+}
+
+// TokenReloader is implemented by security providers that load renewed JWT tokens once the matching seed is in place
+type TokenReloader interface {
+	// ReloadedToken is the most recently renewed token and the seed it was verified against, token is empty until a renewal was loaded
+	ReloadedToken() (token string, seed ed25519.PrivateKey)
+}
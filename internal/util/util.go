@@ -21,6 +21,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"text/template"
 	"time"
 	"unicode"
@@ -591,6 +592,15 @@ func IsExecutableInPath(c string) bool {
 	return p != ""
 }
 
+// TerminateOnCancel arranges for cmd to receive SIGTERM rather than SIGKILL when its context is cancelled,
+// should the process not exit within grace it will be killed
+func TerminateOnCancel(cmd *exec.Cmd, grace time.Duration) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = grace
+}
+
 // HasPrefix checks if s has any one of prefixes
 func HasPrefix(s string, prefixes ...string) bool {
 	for _, p := range prefixes {
@@ -5,9 +5,14 @@
 package util
 
 import (
+	"context"
 	"fmt"
+	"os/exec"
+	"runtime"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -27,6 +32,26 @@ var _ = Describe("Internal/Util", func() {
 		})
 	})
 
+	Describe("TerminateOnCancel", func() {
+		It("Should terminate the process when the context is cancelled", func() {
+			if runtime.GOOS == "windows" {
+				Skip("not supported on windows")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cmd := exec.CommandContext(ctx, "sleep", "10")
+			TerminateOnCancel(cmd, time.Second)
+
+			Expect(cmd.Start()).To(Succeed())
+			cancel()
+
+			Expect(cmd.Wait()).To(HaveOccurred())
+			status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+			Expect(ok).To(BeTrue())
+			Expect(status.Signal()).To(Equal(syscall.SIGTERM))
+		})
+	})
+
 	Describe("Sha256Bytes", func() {
 		It("Should correctly calculate the checksum", func() {
 			Expect(Sha256HashBytes([]byte("sample file"))).To(Equal("9f28ca60126cb0c438bc90f6d323efb4abf699f976c18a7a88cdb166e45e22ec"))
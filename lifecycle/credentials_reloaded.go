@@ -0,0 +1,70 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CredentialsReloadedEvent is a io.choria.lifecycle.v1.credentials_reloaded event
+//
+// In addition to the usual required fields it requires Credentials() specified when producing this kind of event
+type CredentialsReloadedEvent struct {
+	basicEvent
+	Credentials []string `json:"credentials"`
+}
+
+func init() {
+	eventTypes["credentials_reloaded"] = CredentialsReloaded
+
+	eventJSONParsers[CredentialsReloaded] = func(j []byte) (Event, error) {
+		return newCredentialsReloadedEventFromJSON(j)
+	}
+
+	eventFactories[CredentialsReloaded] = func(opts ...Option) Event {
+		return newCredentialsReloadedEvent(opts...)
+	}
+}
+
+func newCredentialsReloadedEvent(opts ...Option) *CredentialsReloadedEvent {
+	event := &CredentialsReloadedEvent{basicEvent: newBasicEvent("credentials_reloaded")}
+
+	for _, o := range opts {
+		o(event)
+	}
+
+	return event
+}
+
+func newCredentialsReloadedEventFromJSON(j []byte) (*CredentialsReloadedEvent, error) {
+	event := newCredentialsReloadedEvent()
+
+	err := json.Unmarshal(j, event)
+	if err != nil {
+		return nil, err
+	}
+
+	switch event.EventProtocol {
+	case "io.choria.lifecycle.v1.credentials_reloaded":
+	case "choria:lifecycle:credentials_reloaded:1":
+		event.EventProtocol = "io.choria.lifecycle.v1.credentials_reloaded"
+	default:
+		return nil, fmt.Errorf("invalid protocol '%s'", event.EventProtocol)
+	}
+
+	return event, nil
+}
+
+// String is text suitable to display on the console etc
+func (e *CredentialsReloadedEvent) String() string {
+	return fmt.Sprintf("[credentials_reloaded] %s: %s reloaded %s", e.Ident, e.Component(), strings.Join(e.Credentials, ", "))
+}
+
+// SetCredentials sets the kinds of credential that were reloaded
+func (e *CredentialsReloadedEvent) SetCredentials(credentials ...string) {
+	e.Credentials = credentials
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package lifecycle
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CredentialsReloadedEvent", func() {
+	Describe("newCredentialsReloadedEvent", func() {
+		It("Should create the event and set options", func() {
+			event := newCredentialsReloadedEvent(Component("ginkgo"), Credentials("certificate", "token"))
+			Expect(event.Component()).To(Equal("ginkgo"))
+			Expect(event.Type()).To(Equal(CredentialsReloaded))
+			Expect(event.Credentials).To(Equal([]string{"certificate", "token"}))
+		})
+	})
+
+	Describe("newCredentialsReloadedEventFromJSON", func() {
+		It("Should detect invalid protocols", func() {
+			_, err := newCredentialsReloadedEventFromJSON([]byte(`{"protocol":"x"}`))
+			Expect(err).To(MatchError("invalid protocol 'x'"))
+		})
+
+		It("Should parse valid events", func() {
+			event, err := newCredentialsReloadedEventFromJSON([]byte(`{"protocol":"io.choria.lifecycle.v1.credentials_reloaded", "component":"ginkgo", "credentials":["certificate"]}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(event.Component()).To(Equal("ginkgo"))
+			Expect(event.Type()).To(Equal(CredentialsReloaded))
+			Expect(event.TypeString()).To(Equal("credentials_reloaded"))
+			Expect(event.Credentials).To(Equal([]string{"certificate"}))
+		})
+	})
+
+	Describe("Credentials", func() {
+		It("Should only apply to credential events", func() {
+			event := newUpgradeEvent()
+			Expect(Credentials("token")(event)).To(MatchError("cannot set credentials, event does not implement CredentialsEvent"))
+		})
+	})
+
+	Describe("Target", func() {
+		It("Should return the right target", func() {
+			e := newCredentialsReloadedEvent(Component("ginkgo"))
+			t, err := e.Target()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal("choria.lifecycle.event.credentials_reloaded.ginkgo"))
+		})
+	})
+
+	Describe("String", func() {
+		It("Should return the right string", func() {
+			e := newCredentialsReloadedEvent(Component("ginkgo"), Identity("node.example"), Credentials("token", "seed"))
+			Expect(e.String()).To(Equal("[credentials_reloaded] node.example: ginkgo reloaded token, seed"))
+		})
+	})
+})
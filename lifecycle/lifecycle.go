@@ -50,6 +50,9 @@ const (
 
 	// Upgraded is an event that can be fired to indicate a component was upgraded
 	Upgraded
+
+	// CredentialsReloaded is an event components can publish when they loaded renewed credentials without restarting
+	CredentialsReloaded
 )
 
 //lint:ignore U1000 #1768 support for external clients
@@ -67,6 +70,8 @@ func (t Type) String() string {
 		return "Governor"
 	case Upgraded:
 		return "Upgraded"
+	case CredentialsReloaded:
+		return "CredentialsReloaded"
 	default:
 		return "Unknown"
 	}
@@ -48,7 +48,7 @@ var _ = Describe("Events", func() {
 
 	Describe("EventTypeNames", func() {
 		It("Should list all known types", func() {
-			Expect(EventTypeNames()).To(Equal([]string{"alive", "governor", "provisioned", "shutdown", "startup", "upgraded"}))
+			Expect(EventTypeNames()).To(Equal([]string{"alive", "credentials_reloaded", "governor", "provisioned", "shutdown", "startup", "upgraded"}))
 		})
 	})
 
@@ -27,6 +27,11 @@ type ComponentEvent interface {
 	SetComponent(string)
 }
 
+// CredentialsEvent is an event that relates to reloaded credentials
+type CredentialsEvent interface {
+	SetCredentials(credentials ...string)
+}
+
 // GovernedEvent is an event that relates to Governors
 type GovernedEvent interface {
 	SetGovernor(name string)
@@ -128,3 +133,17 @@ func GovernorSequence(seq uint64) Option {
 		return nil
 	}
 }
+
+// Credentials sets the kinds of credential that were reloaded
+func Credentials(credentials ...string) Option {
+	return func(e any) error {
+		event, ok := e.(CredentialsEvent)
+		if !ok {
+			return errors.New("cannot set credentials, event does not implement CredentialsEvent")
+		}
+
+		event.SetCredentials(credentials...)
+
+		return nil
+	}
+}
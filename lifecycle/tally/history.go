@@ -0,0 +1,351 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tally
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// LifecycleActivity is lifecycle events by component and event type
+	LifecycleActivity = "lifecycle"
+	// TransitionActivity is autonomous agent transitions by machine and destination state
+	TransitionActivity = "transition"
+	// GovernorActivity is governor events by governor and event type
+	GovernorActivity = "governor"
+	// ExecWatcherActivity is exec watcher runs by machine/watcher and outcome
+	ExecWatcherActivity = "exec_watcher"
+)
+
+// historyResolution is the size of the time buckets activity is counted in
+const historyResolution = time.Minute
+
+type historyKey struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Event    string `json:"event"`
+	Identity string `json:"identity"`
+}
+
+// history keeps counters of activity per node in time buckets covering a rolling window
+type history struct {
+	window   time.Duration
+	counters map[historyKey]map[int64]int
+
+	mu sync.Mutex
+}
+
+// HistoryQuery selects activity from the history, empty fields match all values
+type HistoryQuery struct {
+	Kind  string
+	Name  string
+	Event string
+	// Since limits the query to recent activity, defaults to the entire history window
+	Since time.Duration
+	// MinCount only includes nodes with at least this much activity
+	MinCount int
+}
+
+// ActivitySummary is the total activity for a kind, name and event
+type ActivitySummary struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Event string `json:"event"`
+	Count int    `json:"count"`
+	Nodes int    `json:"nodes"`
+}
+
+// NodeActivity is the activity of a single node
+type NodeActivity struct {
+	Identity string    `json:"identity"`
+	Count    int       `json:"count"`
+	Last     time.Time `json:"last"`
+}
+
+// HistoryResult is the result of a history query
+type HistoryResult struct {
+	Kind     string            `json:"kind,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Event    string            `json:"event,omitempty"`
+	Since    time.Time         `json:"since"`
+	Total    int               `json:"total"`
+	Activity []ActivitySummary `json:"activity"`
+	Nodes    []NodeActivity    `json:"nodes"`
+}
+
+type historyRecord struct {
+	historyKey
+	Buckets map[int64]int `json:"buckets"`
+}
+
+type historySnapshot struct {
+	Window  time.Duration   `json:"window"`
+	Created time.Time       `json:"created"`
+	Records []historyRecord `json:"records"`
+}
+
+func newHistory(window time.Duration) *history {
+	return &history{
+		window:   window,
+		counters: make(map[historyKey]map[int64]int),
+	}
+}
+
+func (h *history) bucket(t time.Time) int64 {
+	return t.Truncate(historyResolution).Unix()
+}
+
+func (h *history) record(kind string, name string, event string, identity string, t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := historyKey{Kind: kind, Name: name, Event: event, Identity: identity}
+	buckets, ok := h.counters[key]
+	if !ok {
+		buckets = make(map[int64]int)
+		h.counters[key] = buckets
+	}
+
+	buckets[h.bucket(t)]++
+}
+
+// prune removes activity older than the history window
+func (h *history) prune(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	oldest := h.bucket(now.Add(-h.window))
+
+	for key, buckets := range h.counters {
+		for b := range buckets {
+			if b < oldest {
+				delete(buckets, b)
+			}
+		}
+
+		if len(buckets) == 0 {
+			delete(h.counters, key)
+		}
+	}
+}
+
+func (h *history) query(q HistoryQuery, now time.Time) *HistoryResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	since := q.Since
+	if since <= 0 || since > h.window {
+		since = h.window
+	}
+
+	res := &HistoryResult{
+		Kind:     q.Kind,
+		Name:     q.Name,
+		Event:    q.Event,
+		Since:    now.Add(-since).Truncate(historyResolution).UTC(),
+		Activity: []ActivitySummary{},
+		Nodes:    []NodeActivity{},
+	}
+
+	oldest := res.Since.Unix()
+	summaries := map[historyKey]*ActivitySummary{}
+	nodes := map[string]*NodeActivity{}
+
+	for key, buckets := range h.counters {
+		if (q.Kind != "" && key.Kind != q.Kind) || (q.Name != "" && key.Name != q.Name) || (q.Event != "" && key.Event != q.Event) {
+			continue
+		}
+
+		count := 0
+		var last int64
+		for b, c := range buckets {
+			if b < oldest {
+				continue
+			}
+
+			count += c
+			last = max(last, b)
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		res.Total += count
+
+		skey := historyKey{Kind: key.Kind, Name: key.Name, Event: key.Event}
+		summary, ok := summaries[skey]
+		if !ok {
+			summary = &ActivitySummary{Kind: key.Kind, Name: key.Name, Event: key.Event}
+			summaries[skey] = summary
+		}
+		summary.Count += count
+		summary.Nodes++
+
+		node, ok := nodes[key.Identity]
+		if !ok {
+			node = &NodeActivity{Identity: key.Identity}
+			nodes[key.Identity] = node
+		}
+		node.Count += count
+		if lt := time.Unix(last, 0).UTC(); lt.After(node.Last) {
+			node.Last = lt
+		}
+	}
+
+	for _, s := range summaries {
+		res.Activity = append(res.Activity, *s)
+	}
+
+	sort.Slice(res.Activity, func(i, j int) bool {
+		a, b := res.Activity[i], res.Activity[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Event < b.Event
+	})
+
+	for _, n := range nodes {
+		if n.Count >= q.MinCount {
+			res.Nodes = append(res.Nodes, *n)
+		}
+	}
+
+	sort.Slice(res.Nodes, func(i, j int) bool {
+		if res.Nodes[i].Count != res.Nodes[j].Count {
+			return res.Nodes[i].Count > res.Nodes[j].Count
+		}
+		return res.Nodes[i].Identity < res.Nodes[j].Identity
+	})
+
+	return res
+}
+
+// snapshots creates gzip compressed JSON copies of the history, the activity is split over as many snapshots
+// as needed to keep each no larger than limit bytes
+func (h *history) snapshots(now time.Time, limit int) ([][]byte, error) {
+	h.mu.Lock()
+	records := make([]historyRecord, 0, len(h.counters))
+	for key, buckets := range h.counters {
+		rec := historyRecord{historyKey: key, Buckets: make(map[int64]int, len(buckets))}
+		for b, c := range buckets {
+			rec.Buckets[b] = c
+		}
+		records = append(records, rec)
+	}
+	window := h.window
+	h.mu.Unlock()
+
+	return encodeSnapshots(historySnapshot{Window: window, Created: now.UTC(), Records: records}, limit)
+}
+
+func encodeSnapshots(snap historySnapshot, limit int) ([][]byte, error) {
+	data, err := encodeSnapshot(snap)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) <= limit {
+		return [][]byte{data}, nil
+	}
+
+	switch len(snap.Records) {
+	case 0:
+		return nil, fmt.Errorf("an empty history snapshot needs %d bytes which exceeds the limit of %d bytes", len(data), limit)
+	case 1:
+		rec := snap.Records[0]
+		return nil, fmt.Errorf("activity of %s %s on %s needs %d bytes which exceeds the limit of %d bytes", rec.Name, rec.Event, rec.Identity, len(data), limit)
+	}
+
+	// split into enough parts to likely fit based on the current size, parts that are still too big are split again
+	parts := min(len(snap.Records), len(data)/limit+1)
+	size := (len(snap.Records) + parts - 1) / parts
+
+	var result [][]byte
+	for i := 0; i < len(snap.Records); i += size {
+		part := snap
+		part.Records = snap.Records[i:min(i+size, len(snap.Records))]
+
+		encoded, err := encodeSnapshots(part, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, encoded...)
+	}
+
+	return result, nil
+}
+
+func encodeSnapshot(snap historySnapshot) ([]byte, error) {
+	j, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(j)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gz.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// restore merges a snapshot into the history, keeping the highest count for every time bucket
+// since multiple tally instances observe the same events
+func (h *history) restore(data []byte) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	j, err := io.ReadAll(gz)
+	if err != nil {
+		return err
+	}
+
+	var snap historySnapshot
+	err = json.Unmarshal(j, &snap)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, rec := range snap.Records {
+		buckets, ok := h.counters[rec.historyKey]
+		if !ok {
+			buckets = make(map[int64]int)
+			h.counters[rec.historyKey] = buckets
+		}
+
+		for b, c := range rec.Buckets {
+			if c > buckets[b] {
+				buckets[b] = c
+			}
+		}
+	}
+
+	return nil
+}
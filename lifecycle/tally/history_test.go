@@ -0,0 +1,151 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tally
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// recordFleet records an hour of transitions for nodes running a few machines each
+func recordFleet(h *history, nodes int, now time.Time) {
+	for n := range nodes {
+		identity := fmt.Sprintf("n%d.example.net", n)
+		for _, machine := range []string{"nats", "puppet", "docker"} {
+			for _, state := range []string{"running", "failed", "stopped"} {
+				for m := range 60 {
+					if (n+m)%7 == 0 {
+						h.record(TransitionActivity, machine, state, identity, now.Add(-time.Duration(m)*time.Minute))
+					}
+				}
+			}
+		}
+	}
+}
+
+var _ = Describe("History", func() {
+	var (
+		h   *history
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		h = newHistory(2 * time.Hour)
+		now = time.Now()
+
+		h.record(TransitionActivity, "nats", "failed", "n1.example.net", now.Add(-90*time.Minute))
+		h.record(TransitionActivity, "nats", "failed", "n1.example.net", now.Add(-30*time.Minute))
+		h.record(TransitionActivity, "nats", "failed", "n1.example.net", now.Add(-10*time.Minute))
+		h.record(TransitionActivity, "nats", "failed", "n2.example.net", now.Add(-5*time.Minute))
+		h.record(TransitionActivity, "nats", "running", "n2.example.net", now.Add(-5*time.Minute))
+		h.record(GovernorActivity, "PUPPET", "enter", "n1.example.net", now)
+	})
+
+	Describe("query", func() {
+		It("Should find activity within the period", func() {
+			res := h.query(HistoryQuery{Kind: TransitionActivity, Event: "failed", Since: time.Hour}, now)
+			Expect(res.Total).To(Equal(3))
+			Expect(res.Activity).To(Equal([]ActivitySummary{{Kind: TransitionActivity, Name: "nats", Event: "failed", Count: 3, Nodes: 2}}))
+			Expect(res.Nodes).To(HaveLen(2))
+			Expect(res.Nodes[0].Identity).To(Equal("n1.example.net"))
+			Expect(res.Nodes[0].Count).To(Equal(2))
+			Expect(res.Nodes[1].Identity).To(Equal("n2.example.net"))
+
+			res = h.query(HistoryQuery{Kind: TransitionActivity, Event: "failed", MinCount: 2}, now)
+			Expect(res.Total).To(Equal(4))
+			Expect(res.Nodes).To(HaveLen(1))
+			Expect(res.Nodes[0].Count).To(Equal(3))
+		})
+
+		It("Should summarize all activity", func() {
+			res := h.query(HistoryQuery{}, now)
+			Expect(res.Total).To(Equal(6))
+			Expect(res.Activity).To(HaveLen(3))
+			Expect(res.Activity[0].Kind).To(Equal(GovernorActivity))
+		})
+	})
+
+	Describe("prune", func() {
+		It("Should remove old activity", func() {
+			h.prune(now.Add(time.Hour))
+			res := h.query(HistoryQuery{Kind: TransitionActivity, Event: "failed"}, now)
+			Expect(res.Total).To(Equal(3))
+
+			h.prune(now.Add(3 * time.Hour))
+			Expect(h.counters).To(BeEmpty())
+		})
+	})
+
+	Describe("snapshots", func() {
+		It("Should merge snapshots", func() {
+			snaps, err := h.snapshots(now, maxHistoryChunk)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snaps).To(HaveLen(1))
+
+			other := newHistory(2 * time.Hour)
+			other.record(TransitionActivity, "nats", "failed", "n2.example.net", now.Add(-5*time.Minute))
+			other.record(TransitionActivity, "nats", "failed", "n3.example.net", now)
+			Expect(other.restore(snaps[0])).To(Succeed())
+
+			res := other.query(HistoryQuery{Kind: TransitionActivity, Event: "failed"}, now)
+			Expect(res.Total).To(Equal(5))
+			Expect(res.Nodes).To(HaveLen(3))
+		})
+
+		It("Should split large histories", func() {
+			large := newHistory(2 * time.Hour)
+			recordFleet(large, 1000, now)
+
+			snaps, err := large.snapshots(now, 16*1024)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(snaps)).To(BeNumerically(">", 1))
+
+			restored := newHistory(2 * time.Hour)
+			for _, snap := range snaps {
+				Expect(len(snap)).To(BeNumerically("<=", 16*1024))
+				Expect(restored.restore(snap)).To(Succeed())
+			}
+
+			Expect(restored.query(HistoryQuery{}, now)).To(Equal(large.query(HistoryQuery{}, now)))
+		})
+
+		It("Should fail when a single record exceeds the limit", func() {
+			_, err := h.snapshots(now, 10)
+			Expect(err).To(MatchError(ContainSubstring("exceeds the limit of 10 bytes")))
+		})
+	})
+
+	Describe("HistoryHandler", func() {
+		It("Should serve queries", func() {
+			recorder := &Recorder{history: h}
+			handler := recorder.HistoryHandler()
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/history?kind=transition&event=failed&since=1h&min=2", nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+			res := HistoryResult{}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &res)).To(Succeed())
+			Expect(res.Kind).To(Equal(TransitionActivity))
+			Expect(res.Nodes).To(HaveLen(1))
+			Expect(res.Nodes[0].Identity).To(Equal("n1.example.net"))
+
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/history?since=x", nil))
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/history", nil))
+			Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
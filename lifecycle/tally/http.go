@@ -0,0 +1,83 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tally
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/choria-io/go-choria/internal/util"
+)
+
+// History queries the recorded activity history
+func (r *Recorder) History(q HistoryQuery) *HistoryResult {
+	return r.history.query(q, time.Now())
+}
+
+// HistoryHandler is a HTTP handler serving the activity history as JSON, it is intended
+// to be mounted next to the Prometheus handler, for example on /history.
+//
+// The query parameters kind, name and event select the activity, since limits it to a recent
+// period like 1h and min only lists nodes with at least that much activity. Nodes that moved
+// to the failed state of the nats machine more than twice in the last hour can be found using:
+//
+//	/history?kind=transition&name=nats&event=failed&since=1h&min=3
+func (r *Recorder) HistoryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "only GET requests are supported", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q, err := parseHistoryQuery(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		j, err := json.Marshal(r.History(*q))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(j)
+	})
+}
+
+func parseHistoryQuery(req *http.Request) (*HistoryQuery, error) {
+	params := req.URL.Query()
+
+	q := &HistoryQuery{
+		Kind:  params.Get("kind"),
+		Name:  params.Get("name"),
+		Event: params.Get("event"),
+	}
+
+	var err error
+
+	since := params.Get("since")
+	if since != "" {
+		q.Since, err = util.ParseDuration(since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+
+	minCount := params.Get("min")
+	if minCount != "" {
+		q.MinCount, err = strconv.Atoi(minCount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min: %w", err)
+		}
+	}
+
+	return q, nil
+}
@@ -6,6 +6,7 @@ package tally
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -18,6 +19,9 @@ type options struct {
 	Connector  Connector
 	StatPrefix string
 	Election   string
+
+	HistoryWindow time.Duration
+	HistoryBucket string
 }
 
 // Option configures Options
@@ -42,6 +46,10 @@ func (o *options) Validate() error {
 		o.Log = logrus.NewEntry(logrus.New())
 	}
 
+	if o.HistoryWindow <= 0 {
+		o.HistoryWindow = 24 * time.Hour
+	}
+
 	return nil
 }
 
@@ -86,3 +94,17 @@ func Election(name string) Option {
 		o.Election = name
 	}
 }
+
+// HistoryWindow sets how long activity history is kept, defaults to 24 hours
+func HistoryWindow(d time.Duration) Option {
+	return func(o *options) {
+		o.HistoryWindow = d
+	}
+}
+
+// HistoryBucket persists activity history in a Choria Streams Key-Value bucket so that it survives restarts and leader changes
+func HistoryBucket(bucket string) Option {
+	return func(o *options) {
+		o.HistoryBucket = bucket
+	}
+}
@@ -7,6 +7,7 @@ package tally
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/choria-io/go-choria/aagent/watchers/execwatcher"
 	"github.com/choria-io/go-choria/backoff"
@@ -23,10 +24,15 @@ import (
 	"github.com/choria-io/go-choria/inter"
 	"github.com/choria-io/go-choria/internal/util"
 	"github.com/choria-io/go-choria/lifecycle"
+	"github.com/choria-io/go-choria/providers/kv"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// maxHistoryChunk is the largest compressed history snapshot stored in a single key, larger histories are stored
+// across multiple keys
+const maxHistoryChunk = 256 * 1024
+
 // Connector is a connection to the middleware
 type Connector interface {
 	QueueSubscribe(ctx context.Context, name string, subject string, group string, output chan inter.ConnectorMessage) error
@@ -40,6 +46,8 @@ type Recorder struct {
 	options  *options
 	active   int32
 	observed map[string]*observations
+	history  *history
+	store    nats.KeyValue
 
 	// lifecycle
 	okEvents       *prometheus.CounterVec
@@ -90,6 +98,8 @@ func New(opts ...Option) (recorder *Recorder, err error) {
 		recorder.active = 1
 	}
 
+	recorder.history = newHistory(recorder.options.HistoryWindow)
+
 	recorder.createStats()
 
 	return recorder, nil
@@ -98,6 +108,9 @@ func New(opts ...Option) (recorder *Recorder, err error) {
 func (r *Recorder) wonCb() {
 	atomic.StoreInt32(&r.active, 1)
 	r.options.Log.Infof("Became leader")
+
+	// the previous leader might have seen events we missed
+	r.loadHistory()
 }
 
 func (r *Recorder) lostCb() {
@@ -209,6 +222,7 @@ func (r *Recorder) processGovernor(e lifecycle.Event) error {
 	governor := e.(*lifecycle.GovernorEvent)
 
 	r.governorEvents.WithLabelValues(governor.Component(), governor.Governor, string(governor.EventType), r.activeLabel()).Inc()
+	r.history.record(GovernorActivity, governor.Governor, string(governor.EventType), governor.Identity(), time.Now())
 
 	return nil
 }
@@ -221,6 +235,7 @@ func (r *Recorder) process(e lifecycle.Event) (err error) {
 	defer obs.ObserveDuration()
 
 	r.eventTypes.WithLabelValues(e.Component(), e.TypeString(), r.activeLabel()).Inc()
+	r.history.record(LifecycleActivity, e.Component(), e.TypeString(), e.Identity(), time.Now())
 
 	switch e.Type() {
 	case lifecycle.Alive:
@@ -278,6 +293,141 @@ func (r *Recorder) maintenance() {
 	}
 }
 
+func (r *Recorder) historyMaintenance() {
+	r.history.prune(time.Now())
+
+	// only the leader writes so that instances that just started do not replace a more complete history
+	if r.store == nil || atomic.LoadInt32(&r.active) == 0 {
+		return
+	}
+
+	snaps, err := r.history.snapshots(time.Now(), r.historyChunkSize())
+	if err != nil {
+		r.options.Log.Errorf("Could not create history snapshot: %v", err)
+		return
+	}
+
+	base := r.historyStoreKey()
+	for i, snap := range snaps {
+		_, err = r.store.Put(fmt.Sprintf("%s.%d", base, i), snap)
+		if err != nil {
+			r.options.Log.Errorf("Could not store history in bucket %s: %v", r.options.HistoryBucket, err)
+			return
+		}
+	}
+
+	keys, err := r.historyKeys()
+	if err != nil {
+		r.options.Log.Errorf("Could not list history keys in bucket %s: %v", r.options.HistoryBucket, err)
+		return
+	}
+
+	// removes parts left over from a larger history and history stored in a single key by older versions
+	for _, key := range keys {
+		if key != base {
+			part, _ := strconv.Atoi(strings.TrimPrefix(key, base+"."))
+			if part < len(snaps) {
+				continue
+			}
+		}
+
+		err = r.store.Purge(key)
+		if err != nil {
+			r.options.Log.Errorf("Could not remove history key %s from bucket %s: %v", key, r.options.HistoryBucket, err)
+		}
+	}
+}
+
+func (r *Recorder) loadHistory() {
+	if r.store == nil {
+		return
+	}
+
+	keys, err := r.historyKeys()
+	if err != nil {
+		r.options.Log.Errorf("Could not load history from bucket %s: %v", r.options.HistoryBucket, err)
+		return
+	}
+
+	for _, key := range keys {
+		entry, err := r.store.Get(key)
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			r.options.Log.Errorf("Could not load history from bucket %s: %v", r.options.HistoryBucket, err)
+			return
+		}
+
+		err = r.history.restore(entry.Value())
+		if err != nil {
+			r.options.Log.Errorf("Could not restore history from key %s in bucket %s: %v", key, r.options.HistoryBucket, err)
+			continue
+		}
+
+		r.options.Log.Infof("Restored activity history from key %s stored at %v", key, entry.Created())
+	}
+}
+
+// historyKeys finds the keys holding the history of this recorder
+func (r *Recorder) historyKeys() ([]string, error) {
+	keys, err := r.store.Keys()
+	if errors.Is(err, nats.ErrNoKeysFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	base := r.historyStoreKey()
+
+	var found []string
+	for _, key := range keys {
+		if key == base {
+			found = append(found, key)
+			continue
+		}
+
+		part, ok := strings.CutPrefix(key, base+".")
+		if !ok {
+			continue
+		}
+
+		_, err = strconv.Atoi(part)
+		if err == nil {
+			found = append(found, key)
+		}
+	}
+
+	return found, nil
+}
+
+// historyChunkSize is the largest history snapshot stored in a single key
+func (r *Recorder) historyChunkSize() int {
+	size := maxHistoryChunk
+
+	if r.options.Connector != nil {
+		nc := r.options.Connector.Nats()
+		if nc != nil && nc.MaxPayload() > 0 {
+			size = min(size, int(nc.MaxPayload())/2)
+		}
+	}
+
+	return size
+}
+
+func (r *Recorder) historyStoreKey() string {
+	component := r.options.Component
+	switch component {
+	case "":
+		component = "machines"
+	case "*":
+		component = "all"
+	}
+
+	return fmt.Sprintf("%s.%s", r.options.StatPrefix, component)
+}
+
 func (r *Recorder) processStateTransition(m inter.ConnectorMessage) (err error) {
 	ce := cloudevents.NewEvent("1.0")
 	event := &machine.TransitionNotification{}
@@ -292,11 +442,16 @@ func (r *Recorder) processStateTransition(m inter.ConnectorMessage) (err error)
 		return fmt.Errorf("could not parse transition event: %s", err)
 	}
 
+	if event.IsVeto() {
+		return nil
+	}
+
 	if event.Protocol != "io.choria.machine.v1.transition" {
 		return fmt.Errorf("unknown notification protocol %s", event.Protocol)
 	}
 
 	r.transitionEvent.WithLabelValues(event.Machine, event.Version, event.Transition, event.FromState, event.ToState, r.activeLabel()).Inc()
+	r.history.record(TransitionActivity, event.Machine, event.ToState, event.Identity, time.Now())
 
 	return nil
 }
@@ -338,6 +493,15 @@ func (r *Recorder) Run(ctx context.Context) (err error) {
 		return err
 	}
 
+	if r.options.HistoryBucket != "" {
+		r.store, err = kv.NewKV(r.options.Connector.Nats(), r.options.HistoryBucket, true, kv.WithHistory(1))
+		if err != nil {
+			return fmt.Errorf("cannot access history KV Bucket %s: %v", r.options.HistoryBucket, err)
+		}
+
+		r.loadHistory()
+	}
+
 	if r.options.Election != "" {
 		r.options.Log.Warnf("Starting leader election in campaign %s", r.options.Election)
 
@@ -395,6 +559,7 @@ func (r *Recorder) Run(ctx context.Context) (err error) {
 
 		case <-maintSched.C:
 			r.maintenance()
+			r.historyMaintenance()
 
 		case <-ctx.Done():
 			return nil
@@ -425,6 +590,7 @@ func (r *Recorder) processExecWatcherState(m inter.ConnectorMessage) error {
 		return nil
 	}
 
+	r.history.record(ExecWatcherActivity, fmt.Sprintf("%s/%s", event.Machine, event.Name), event.PreviousOutcome, event.Identity, time.Now())
 	r.execWatchRuntime.WithLabelValues(event.Machine, event.Version, event.Name, r.activeLabel()).Observe(time.Duration(event.PreviousRunTime).Seconds())
 
 	return nil
@@ -10,6 +10,9 @@ import (
 	"time"
 
 	"github.com/choria-io/go-choria/lifecycle"
+	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 
@@ -35,6 +38,7 @@ var _ = Describe("Tally", func() {
 		recorder = &Recorder{
 			active:   1,
 			observed: make(map[string]*observations),
+			history:  newHistory(time.Hour),
 			options: &options{
 				Component:  "ginkgo",
 				StatPrefix: "tally",
@@ -72,6 +76,58 @@ var _ = Describe("Tally", func() {
 		})
 	})
 
+	Describe("historyMaintenance", func() {
+		It("Should store large histories across keys", func() {
+			srv, err := server.NewServer(&server.Options{
+				JetStream: true,
+				StoreDir:  GinkgoT().TempDir(),
+				Port:      -1,
+				Host:      "localhost",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			go srv.Start()
+			Expect(srv.ReadyForConnections(10 * time.Second)).To(BeTrue())
+			DeferCleanup(srv.Shutdown)
+
+			nc, err := nats.Connect(srv.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(nc.Close)
+
+			recorder.options.HistoryBucket = "TALLY"
+			recorder.store, err = kv.NewKV(nc, "TALLY", true, kv.WithHistory(1))
+			Expect(err).ToNot(HaveOccurred())
+
+			// history stored by older versions and parts of a previously larger history
+			_, err = recorder.store.Put("tally.ginkgo", []byte("old"))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = recorder.store.Put("tally.ginkgo.20", []byte("old"))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = recorder.store.Put("tally.other.0", []byte("other"))
+			Expect(err).ToNot(HaveOccurred())
+
+			now := time.Now()
+			recordFleet(recorder.history, 10000, now)
+			recorder.historyMaintenance()
+
+			keys, err := recorder.historyKeys()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(keys)).To(BeNumerically(">", 1))
+			Expect(keys).ToNot(ContainElements("tally.ginkgo", "tally.ginkgo.20"))
+			for _, key := range keys {
+				entry, err := recorder.store.Get(key)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(entry.Value())).To(BeNumerically("<=", maxHistoryChunk))
+			}
+
+			_, err = recorder.store.Get("tally.other.0")
+			Expect(err).ToNot(HaveOccurred())
+
+			restored := &Recorder{history: newHistory(time.Hour), store: recorder.store, options: recorder.options}
+			restored.loadHistory()
+			Expect(restored.history.query(HistoryQuery{}, now)).To(Equal(recorder.history.query(HistoryQuery{}, now)))
+		})
+	})
+
 	Describe("elections", func() {
 		It("Should correctly label metrics", func() {
 			event, err := lifecycle.New(lifecycle.Startup, lifecycle.Component("ginkgo"), lifecycle.Version("1.2.3"), lifecycle.Identity("ginkgo.example.net"))
@@ -182,6 +238,10 @@ var _ = Describe("Tally", func() {
 				recorder.process(event)
 				Expect(getPromCountValue(recorder.governorEvents, "ginkgo", "GINKGO", "enter", "1")).To(Equal(1.0))
 				Expect(getPromCountValue(recorder.governorEvents, "ginkgo", "GINKGO", "exit", "1")).To(Equal(1.0))
+
+				res := recorder.History(HistoryQuery{Kind: GovernorActivity, Name: "GINKGO"})
+				Expect(res.Total).To(Equal(2))
+				Expect(res.Activity).To(HaveLen(2))
 			})
 		})
 
@@ -5,10 +5,13 @@
 package opa
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/rego"
 	"github.com/open-policy-agent/opa/v1/topdown"
 	"github.com/sirupsen/logrus"
@@ -105,6 +108,113 @@ func (e *Evaluator) Evaluate(ctx context.Context, inputs any) (pass bool, err er
 	return pass, nil
 }
 
+// MatchedRule is a rule that contributed to the result of an evaluation
+type MatchedRule struct {
+	// Line is the line in the policy where the rule starts
+	Line int `json:"line"`
+	// Rule is the first line of the rule
+	Rule string `json:"rule"`
+}
+
+// Explanation describes how an evaluation reached its result
+type Explanation struct {
+	// Allowed is the result of the evaluation
+	Allowed bool `json:"allowed"`
+	// Rules are the rules that produced the result
+	Rules []MatchedRule `json:"rules,omitempty"`
+	// Trace is the evaluation trace
+	Trace string `json:"trace,omitempty"`
+}
+
+// Explain evaluates like Evaluate and also records the rules that produced the result and a trace of the evaluation
+func (e *Evaluator) Explain(ctx context.Context, inputs any) (*Explanation, error) {
+	buf := topdown.NewBufferTracer()
+
+	rs, err := e.pq.Eval(ctx, rego.EvalInput(inputs), rego.EvalQueryTracer(buf))
+	if err != nil {
+		return nil, fmt.Errorf("could not evaluate rego policy %s: %s", e.module, err)
+	}
+
+	if len(rs) != 1 {
+		return nil, fmt.Errorf("invalid result from rego policy %s: expected 1 received %d", e.module, len(rs))
+	}
+
+	pass, ok := rs[0].Expressions[0].Value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("did not receive a boolean for 'allow' from rego evaluation of %s", e.module)
+	}
+
+	trace := &bytes.Buffer{}
+	topdown.PrettyTrace(trace, *buf)
+
+	explanation := &Explanation{
+		Allowed: pass,
+		Trace:   trace.String(),
+	}
+
+	// rules that were exited successfully produced a value for the queried name
+	name := e.query[strings.LastIndex(e.query, ".")+1:]
+	seen := map[int]bool{}
+
+	for _, event := range *buf {
+		if event.Op != topdown.ExitOp {
+			continue
+		}
+
+		rule, ok := event.Node.(*ast.Rule)
+		if !ok || rule.Location == nil || rule.Head.Ref().String() != name || seen[rule.Location.Row] {
+			continue
+		}
+
+		seen[rule.Location.Row] = true
+
+		explanation.Rules = append(explanation.Rules, matchedRule(rule))
+	}
+
+	// default rules are not traced so when nothing matched the default rule produced the result
+	if len(explanation.Rules) == 0 {
+		rule, err := e.defaultRule(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if rule != nil {
+			explanation.Rules = append(explanation.Rules, matchedRule(rule))
+		}
+	}
+
+	return explanation, nil
+}
+
+func (e *Evaluator) defaultRule(name string) (*ast.Rule, error) {
+	policy, err := e.policy()
+	if err != nil {
+		return nil, err
+	}
+
+	module, err := ast.ParseModule(e.module, string(policy))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range module.Rules {
+		if rule.Default && rule.Location != nil && rule.Head.Ref().String() == name {
+			return rule, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func matchedRule(rule *ast.Rule) MatchedRule {
+	text, _, _ := strings.Cut(string(rule.Location.Text), "\n")
+	if rule.Default {
+		text = rule.String()
+	}
+
+	return MatchedRule{Line: rule.Location.Row, Rule: strings.TrimSpace(text)}
+}
+
 func (e *Evaluator) policy() (p []byte, err error) {
 	if len(e.opts.policyCode) > 0 {
 		return e.opts.policyCode, nil
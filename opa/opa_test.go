@@ -95,4 +95,22 @@ var _ = Describe("Opa", func() {
 			Expect(pass).To(BeTrue())
 		})
 	})
+
+	Describe("Explain", func() {
+		It("Should report the matching rules", func() {
+			e, err := New("io.choria.ginkgo", "data.io.choria.ginkgo.allow", Logger(log), File("testdata/test1.rego"))
+			Expect(err).ToNot(HaveOccurred())
+
+			res, err := e.Explain(context.Background(), map[string]any{"hello": "world"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Rules).To(Equal([]MatchedRule{{Line: 5, Rule: "allow if {"}}))
+			Expect(res.Trace).To(ContainSubstring("input.hello"))
+
+			res, err = e.Explain(context.Background(), map[string]any{"hello": "other"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Allowed).To(BeFalse())
+			Expect(res.Rules).To(Equal([]MatchedRule{{Line: 3, Rule: "default allow = false"}}))
+		})
+	})
 })
@@ -10,11 +10,13 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/choria-io/go-choria/inter"
 	"github.com/sirupsen/logrus"
 
 	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/opa"
 	"github.com/choria-io/go-choria/protocol"
 	"github.com/choria-io/go-choria/providers/agent/mcorpc/audit"
 	"github.com/choria-io/go-choria/server/agents"
@@ -116,8 +118,33 @@ func (a *Agent) HandleMessage(ctx context.Context, msg inter.Message, request pr
 		return
 	}
 
+	authorized := true
+
+	// audits once the request is handled so the outcome is known, runs before the reply is published
+	if a.Config.RPCAudit {
+		var phase string
+
+		// optionally records the request before it is authorized or run so requests that never complete are audited
+		if a.Config.Choria.RPCAuditReceived {
+			received := audit.NewMessage(request, rpcrequest.Agent, rpcrequest.Action, rpcrequest.Data)
+			received.Phase = audit.PhaseReceived
+			a.audit(received)
+
+			phase = audit.PhaseCompleted
+		}
+
+		started := time.Now()
+		defer func() {
+			msg := audit.NewMessage(request, rpcrequest.Agent, rpcrequest.Action, rpcrequest.Data)
+			msg.SetOutcome(a.Config.Identity, authorized, int(reply.Statuscode), reply.Statusmsg, time.Since(started))
+			msg.Phase = phase
+			a.audit(msg)
+		}()
+	}
+
 	if a.Config.RPCAuthorization {
-		if !a.authorize(rpcrequest) {
+		authorized = a.authorize(rpcrequest)
+		if !authorized {
 			a.Log.Warnf("Denying %s access to %s#%s based on authorization policy for request %s", request.CallerID(), rpcrequest.Agent, rpcrequest.Action, request.RequestID())
 			reply.Statuscode = Aborted
 			reply.Statusmsg = "You are not authorized to call this agent or action"
@@ -125,10 +152,6 @@ func (a *Agent) HandleMessage(ctx context.Context, msg inter.Message, request pr
 		}
 	}
 
-	if a.Config.RPCAudit {
-		audit.Request(request, rpcrequest.Agent, rpcrequest.Action, rpcrequest.Data, a.Config)
-	}
-
 	a.Log.Infof("Handling message %s for %s#%s from %s", msg.RequestID(), a.Name(), rpcrequest.Action, request.CallerID())
 
 	action(ctx, rpcrequest, reply, a, conn)
@@ -182,6 +205,16 @@ func (a *Agent) publish(rpcreply *Reply, msg inter.Message, request protocol.Req
 	outbox <- reply
 }
 
+func (a *Agent) audit(msg *audit.Message) {
+	auditor, err := audit.Shared(a.Choria)
+	if err != nil {
+		a.Log.Errorf("Auditing is not functional: %v", err)
+		return
+	}
+
+	auditor.Audit(msg)
+}
+
 func (a *Agent) newReply() *Reply {
 	reply := &Reply{
 		Statuscode: OK,
@@ -248,32 +281,93 @@ func AuthorizeRequest(fw inter.Framework, req *Request, cfg *config.Config, si a
 		return false
 	}
 
+	allowed, err := authorizeRequest(fw, req, cfg, si, "", nil, log)
+	if err != nil {
+		log.Errorf("Could not process authorization: %v", err)
+	}
+
+	return allowed
+}
+
+// authorizeRequest authorizes req using the authorizer configured in cfg, policies are read from dir when set
+//
+// When decision is not nil the authorizer explains its decision into it including any error that caused
+// the request to be denied, an error is only returned for unsupported authorizers
+func authorizeRequest(fw inter.Framework, req *Request, cfg *config.Config, si regoInfoSource, dir string, decision *AuthorizationDecision, log *logrus.Entry) (bool, error) {
+	var (
+		allowed     bool
+		explanation *opa.Explanation
+		err         error
+	)
+
 	prov := strings.ToLower(cfg.RPCAuthorizationProvider)
+	explain := decision != nil
 
 	switch prov {
 	case "action_policy":
-		return actionPolicyAuthorize(req, cfg, log)
+		authz := newActionPolicy(req, cfg, dir, log)
+		allowed = authz.authorize()
+
+		if explain {
+			decision.Policy = authz.policyFile
+			decision.Line = authz.line
+			decision.Rule = authz.rule
+			decision.Reason = authz.reason
+		}
 
 	case "rego_policy":
-		auth, err := regoPolicyAuthorize(req, fw, si, cfg, log)
+		authz := newRegoPolicy(req, fw, si, cfg, dir, log)
+		authz.explain = explain
+		allowed, err = authz.authorize()
 		if err != nil {
 			log.Errorf("Could not process Open Policy Agent policy: %v", err)
-			return false
 		}
-		return auth
+
+		if explain {
+			decision.Policy = authz.policyFile
+			explanation = authz.explanation
+		}
 
 	case "aaasvc", "aaasvc_policy":
-		auth, err := aaasvcPolicyAuthorize(req, cfg, log)
+		authz := newAaasvcPolicy(req, cfg, log)
+		authz.explain = explain
+		allowed, err = authz.authorize()
 		if err != nil {
 			log.Errorf("Could not process JWT policy: %v", err)
-			return false
 		}
-		return auth
+
+		if explain {
+			decision.Policy = authz.policy
+			decision.Rule = authz.rule
+			explanation = authz.explanation
+		}
 
 	default:
-		log.Errorf("Unsupported authorization provider: %s", prov)
+		return false, fmt.Errorf("unsupported authorization provider: %q", cfg.RPCAuthorizationProvider)
+	}
 
+	if err != nil {
+		allowed = false
+	}
+
+	if explain {
+		decision.Provider = prov
+		decision.Allowed = allowed
+
+		if err != nil {
+			decision.Error = err.Error()
+		}
+
+		if explanation != nil {
+			decision.Rules = explanation.Rules
+			if len(explanation.Rules) > 0 {
+				decision.Line = explanation.Rules[0].Line
+				decision.Rule = explanation.Rules[0].Rule
+			}
+
+			decision.Trace = append(decision.Trace, traceLines(explanation.Trace)...)
+		}
 	}
 
-	return false
+	return allowed, nil
 }
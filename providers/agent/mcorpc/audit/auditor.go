@@ -0,0 +1,134 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/submission"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNoSinks indicates that auditing is enabled but no sinks could be configured
+var ErrNoSinks = errors.New("no audit sinks configured")
+
+// Sink receives audit messages and stores or ships them
+type Sink interface {
+	// Name is the name used to select the sink in configuration
+	Name() string
+	// Audit records a single audit message
+	Audit(msg *Message) error
+}
+
+// Auditor writes audit messages to all configured sinks
+type Auditor struct {
+	sinks []Sink
+	log   *logrus.Entry
+}
+
+type sharedAuditor struct {
+	auditor *Auditor
+	err     error
+}
+
+var (
+	shared   = make(map[inter.Framework]*sharedAuditor)
+	sharedMu sync.Mutex
+)
+
+// Shared is the auditor used by all agents of a framework, it is created on first use so every
+// agent writes through the same sinks, spool and syslog connection
+func Shared(fw inter.Framework) (*Auditor, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	s, ok := shared[fw]
+	if !ok {
+		auditor, err := New(fw)
+		s = &sharedAuditor{auditor: auditor, err: err}
+		shared[fw] = s
+	}
+
+	return s.auditor, s.err
+}
+
+// New creates an auditor using the sinks configured in plugin.rpcaudit.sinks
+func New(fw inter.Framework) (*Auditor, error) {
+	cfg := fw.Configuration()
+	a := &Auditor{log: fw.Logger("audit")}
+
+	for _, name := range cfg.Choria.RPCAuditSinks {
+		var sink Sink
+		var err error
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "file":
+			var fsink *fileSink
+			fsink, err = newFileSink(cfg)
+			if err == nil {
+				fsink.signer = fw
+				sink = fsink
+			}
+
+		case "submission":
+			if cfg.Choria.SubmissionSpool == "" {
+				err = fmt.Errorf("submission spool is not configured")
+				break
+			}
+
+			var spool *submission.Spool
+			spool, err = submission.NewFromChoria(fw, submission.Directory)
+			if err == nil {
+				sink = newSubmissionSink(spool, fmt.Sprintf("%s.%s", cfg.Choria.RPCAuditSubmissionSubject, cfg.Identity))
+			}
+
+		case "syslog":
+			sink, err = newSyslogSink(cfg)
+
+		case "":
+			continue
+
+		default:
+			err = fmt.Errorf("unknown sink")
+		}
+
+		if err != nil {
+			a.log.Errorf("Could not configure audit sink %s: %v", name, err)
+			continue
+		}
+
+		a.sinks = append(a.sinks, sink)
+	}
+
+	if len(a.sinks) == 0 {
+		return nil, ErrNoSinks
+	}
+
+	return a, nil
+}
+
+// NewWithSinks creates an auditor that writes to specific sinks
+func NewWithSinks(log *logrus.Entry, sinks ...Sink) *Auditor {
+	return &Auditor{sinks: sinks, log: log}
+}
+
+// Audit writes msg to all sinks, true when every sink recorded the message
+func (a *Auditor) Audit(msg *Message) bool {
+	ok := true
+
+	for _, sink := range a.sinks {
+		err := sink.Audit(msg)
+		if err != nil {
+			a.log.Warnf("Auditing request %s using the %s sink failed: %v", msg.RequestID, sink.Name(), err)
+			ok = false
+		}
+	}
+
+	return ok
+}
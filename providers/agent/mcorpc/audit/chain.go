@@ -0,0 +1,322 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ChainProblem is the kind of problem found while verifying a hash chained audit log
+type ChainProblem string
+
+const (
+	// ChainGap indicates records are missing from the log
+	ChainGap ChainProblem = "gap"
+	// ChainReordered indicates records are not in sequence order
+	ChainReordered ChainProblem = "reordered"
+	// ChainModified indicates a record does not match the hash recorded in the record following it
+	ChainModified ChainProblem = "modified"
+	// ChainInvalid indicates a record could not be parsed
+	ChainInvalid ChainProblem = "invalid"
+	// ChainBadSignature indicates a chain signature did not verify
+	ChainBadSignature ChainProblem = "bad_signature"
+	// ChainUnchained indicates a record without a sequence was found inside the chain
+	ChainUnchained ChainProblem = "unchained"
+)
+
+// Signer signs data using the security provider
+type Signer interface {
+	SignBytes(b []byte) (signature []byte, err error)
+}
+
+// SignatureVerifier verifies chain signatures, returns the signer when valid
+type SignatureVerifier func(dat []byte, sig []byte) (bool, string)
+
+// ChainIssue is a single problem found in a hash chained audit log
+type ChainIssue struct {
+	Line     int          `json:"line"`
+	Sequence uint64       `json:"sequence,omitempty"`
+	Problem  ChainProblem `json:"problem"`
+	Message  string       `json:"message"`
+}
+
+// ChainReport is the result of verifying a hash chained audit log
+type ChainReport struct {
+	// Records is the number of records in the log
+	Records int `json:"records"`
+	// Chained is the number of records that are part of the hash chain
+	Chained int `json:"chained"`
+	// Signatures is the number of signed records
+	Signatures int `json:"signatures"`
+	// VerifiedSignatures is the number of signatures that were verified successfully
+	VerifiedSignatures int `json:"verified_signatures"`
+	// Signers are the identities that made the verified signatures
+	Signers []string `json:"signers,omitempty"`
+	// Issues are problems found in the log
+	Issues []ChainIssue `json:"issues,omitempty"`
+}
+
+// IsValid indicates that no problems were found
+func (r *ChainReport) IsValid() bool {
+	return len(r.Issues) == 0
+}
+
+// chainState is the last written record of a hash chained log
+type chainState struct {
+	sequence uint64
+	hash     string
+}
+
+// chains is the chain state per log file, access is protected by mu
+var chains = map[string]*chainState{}
+
+// chainSigningData is the data signed for a record, signing the previous hash covers all preceding records
+func chainSigningData(sequence uint64, previousHash string) []byte {
+	return []byte(fmt.Sprintf("%d:%s", sequence, previousHash))
+}
+
+func recordHash(record []byte) string {
+	sum := sha256.Sum256(record)
+	return hex.EncodeToString(sum[:])
+}
+
+// chainStateFor retrieves the chain state for logfile, resuming from the last chained record in the file when not known, must be called with mu held
+//
+// Unchained records, like those written by the Ruby auditor into the same file, are skipped. When a record
+// that is not JSON is found a new chain segment is started as the chain can not be resumed from it
+func chainStateFor(logfile string) (*chainState, error) {
+	state, ok := chains[logfile]
+	if ok {
+		return state, nil
+	}
+
+	state = &chainState{}
+
+	err := eachLineReverse(logfile, func(line []byte) bool {
+		msg := Message{}
+		err := json.Unmarshal(line, &msg)
+		if err != nil {
+			log.Warnf("Starting a new audit chain segment in %s, the last record could not be parsed: %v", logfile, err)
+			return false
+		}
+
+		if msg.Sequence == 0 {
+			return true
+		}
+
+		state.sequence = msg.Sequence
+		state.hash = recordHash(line)
+
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chains[logfile] = state
+
+	return state, nil
+}
+
+// eachLineReverse calls cb for every non empty line in file starting from the last line without reading the entire file, stops when cb returns false
+func eachLineReverse(file string, cb func(line []byte) bool) error {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var (
+		end   = stat.Size()
+		chunk = int64(4096)
+		buf   []byte
+	)
+
+	for end > 0 {
+		start := max(end-chunk, 0)
+
+		part := make([]byte, end-start)
+		_, err = f.ReadAt(part, start)
+		if err != nil {
+			return err
+		}
+
+		buf = append(part, buf...)
+		end = start
+
+		for {
+			idx := bytes.LastIndexByte(buf, '\n')
+			if idx < 0 {
+				break
+			}
+
+			line := bytes.TrimSpace(buf[idx+1:])
+			buf = buf[:idx]
+
+			if len(line) > 0 && !cb(line) {
+				return nil
+			}
+		}
+	}
+
+	line := bytes.TrimSpace(buf)
+	if len(line) > 0 {
+		cb(line)
+	}
+
+	return nil
+}
+
+// chainRecord adds the chain fields to msg, signing when due, and returns the JSON record, must be called with mu held
+func chainRecord(logfile string, msg *Message, signer Signer, signInterval int) ([]byte, func(), error) {
+	state, err := chainStateFor(logfile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg.Sequence = state.sequence + 1
+	msg.PreviousHash = state.hash
+	msg.Signature = ""
+
+	if signer != nil && signInterval > 0 && msg.Sequence%uint64(signInterval) == 0 {
+		sig, err := signer.SignBytes(chainSigningData(msg.Sequence, msg.PreviousHash))
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not sign the audit chain: %w", err)
+		}
+
+		msg.Signature = base64.StdEncoding.EncodeToString(sig)
+	}
+
+	j, err := json.Marshal(msg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("the auditing data could not be represented as JSON: %w", err)
+	}
+
+	// the state is only updated once the record is written
+	commit := func() {
+		state.sequence = msg.Sequence
+		state.hash = recordHash(j)
+	}
+
+	return j, commit, nil
+}
+
+// VerifyChain walks a hash chained audit log and reports gaps, reordering and modified records
+//
+// Signatures are only verified when verifier is not nil. The first chained record in the log
+// is trusted as the start of the chain as logs might have been rotated, a record that can not
+// be parsed is reported and the record following it is trusted as the start of a new segment.
+// Unchained records are counted and skipped before the start of a segment, once a segment has
+// started they are reported as they could have been inserted into the chain
+func VerifyChain(r io.Reader, verifier SignatureVerifier) (*ChainReport, error) {
+	report := &ChainReport{}
+	signers := map[string]struct{}{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	var (
+		line     int
+		prevSeq  uint64
+		prevHash string
+		started  bool
+	)
+
+	issue := func(seq uint64, problem ChainProblem, format string, a ...any) {
+		report.Issues = append(report.Issues, ChainIssue{Line: line, Sequence: seq, Problem: problem, Message: fmt.Sprintf(format, a...)})
+	}
+
+	for scanner.Scan() {
+		line++
+		record := scanner.Bytes()
+		if len(bytes.TrimSpace(record)) == 0 {
+			continue
+		}
+
+		report.Records++
+
+		msg := Message{}
+		err := json.Unmarshal(record, &msg)
+		if err != nil {
+			issue(0, ChainInvalid, "could not parse record: %v", err)
+			started = false
+			continue
+		}
+
+		// unchained records, like those written by the Ruby auditor into the same file, are not part of the chain
+		// but once a segment started nothing can vouch for them
+		if msg.Sequence == 0 {
+			if started {
+				issue(0, ChainUnchained, "record is not part of the chain following sequence %d", prevSeq)
+			}
+			continue
+		}
+
+		report.Chained++
+
+		if started {
+			switch {
+			case msg.Sequence <= prevSeq:
+				issue(msg.Sequence, ChainReordered, "sequence %d follows %d", msg.Sequence, prevSeq)
+			case msg.Sequence > prevSeq+1:
+				issue(msg.Sequence, ChainGap, "%d records missing between sequence %d and %d", msg.Sequence-prevSeq-1, prevSeq, msg.Sequence)
+			case msg.PreviousHash != prevHash:
+				issue(prevSeq, ChainModified, "record %d does not match the hash recorded in record %d", prevSeq, msg.Sequence)
+			}
+		}
+
+		if msg.Signature != "" {
+			report.Signatures++
+
+			if verifier != nil {
+				sig, err := base64.StdEncoding.DecodeString(msg.Signature)
+				if err != nil {
+					issue(msg.Sequence, ChainBadSignature, "invalid signature encoding: %v", err)
+				} else if ok, signer := verifier(chainSigningData(msg.Sequence, msg.PreviousHash), sig); ok {
+					report.VerifiedSignatures++
+					signers[signer] = struct{}{}
+				} else {
+					issue(msg.Sequence, ChainBadSignature, "signature did not verify")
+				}
+			}
+		}
+
+		started = true
+		prevSeq = msg.Sequence
+		prevHash = recordHash(record)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	for signer := range signers {
+		report.Signers = append(report.Signers, signer)
+	}
+	sort.Strings(report.Signers)
+
+	return report, nil
+}
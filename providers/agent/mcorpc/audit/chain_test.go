@@ -0,0 +1,264 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/choria-io/go-choria/protocol/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type testSigner struct{}
+
+func (s *testSigner) SignBytes(b []byte) ([]byte, error) {
+	return append([]byte("signed:"), b...), nil
+}
+
+type failingSigner struct{}
+
+func (s *failingSigner) SignBytes(b []byte) ([]byte, error) {
+	return nil, errors.New("simulated failure")
+}
+
+func testVerifier(dat []byte, sig []byte) (bool, string) {
+	return bytes.Equal(sig, append([]byte("signed:"), dat...)), "server.example.net"
+}
+
+var _ = Describe("Chain", func() {
+	var (
+		msg     *Message
+		sink    *fileSink
+		logfile string
+	)
+
+	BeforeEach(func() {
+		req, err := v1.NewRequest("test_agent", "test.node", "choria=rip.mcollective", 120, "uniq_req_id", "mcollective")
+		Expect(err).ToNot(HaveOccurred())
+
+		msg = NewMessage(req, "test_agent", "test_action", json.RawMessage(`{"hello":"world"}`))
+		logfile = filepath.Join(GinkgoT().TempDir(), "audit.log")
+		sink = &fileSink{logfile: logfile, mode: 0600, chain: true, signer: &testSigner{}, signInterval: 2}
+
+		DeferCleanup(func() {
+			mu.Lock()
+			delete(chains, logfile)
+			mu.Unlock()
+		})
+	})
+
+	writeRecords := func(count int) []string {
+		for i := 0; i < count; i++ {
+			Expect(sink.Audit(msg)).To(Succeed())
+		}
+
+		j, err := os.ReadFile(logfile)
+		Expect(err).ToNot(HaveOccurred())
+
+		return strings.Split(strings.TrimSpace(string(j)), "\n")
+	}
+
+	verify := func(lines []string) *ChainReport {
+		report, err := VerifyChain(strings.NewReader(strings.Join(lines, "\n")), testVerifier)
+		Expect(err).ToNot(HaveOccurred())
+		return report
+	}
+
+	It("Should chain and sign records", func() {
+		lines := writeRecords(4)
+		Expect(lines).To(HaveLen(4))
+
+		for i, line := range lines {
+			am := Message{}
+			Expect(json.Unmarshal([]byte(line), &am)).To(Succeed())
+			Expect(am.Sequence).To(Equal(uint64(i + 1)))
+
+			if i == 0 {
+				Expect(am.PreviousHash).To(BeEmpty())
+			} else {
+				Expect(am.PreviousHash).To(Equal(recordHash([]byte(lines[i-1]))))
+			}
+
+			Expect(am.Signature != "").To(Equal(am.Sequence%2 == 0))
+		}
+
+		// other sinks should not see the chain fields
+		Expect(msg.Sequence).To(BeZero())
+
+		report := verify(lines)
+		Expect(report.IsValid()).To(BeTrue())
+		Expect(report.Records).To(Equal(4))
+		Expect(report.Chained).To(Equal(4))
+		Expect(report.Signatures).To(Equal(2))
+		Expect(report.VerifiedSignatures).To(Equal(2))
+		Expect(report.Signers).To(Equal([]string{"server.example.net"}))
+	})
+
+	It("Should resume the chain from an existing log", func() {
+		writeRecords(2)
+
+		mu.Lock()
+		delete(chains, logfile)
+		mu.Unlock()
+
+		lines := writeRecords(1)
+		Expect(lines).To(HaveLen(3))
+
+		am := Message{}
+		Expect(json.Unmarshal([]byte(lines[2]), &am)).To(Succeed())
+		Expect(am.Sequence).To(Equal(uint64(3)))
+		Expect(am.PreviousHash).To(Equal(recordHash([]byte(lines[1]))))
+		Expect(verify(lines).IsValid()).To(BeTrue())
+	})
+
+	rubyRecord := `{"timestamp":"2025-01-01T00:00:00.000000+0000","request_id":"ruby_req_id","agent":"rpcutil","action":"ping"}`
+
+	appendLine := func(line string) {
+		f, err := os.OpenFile(logfile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.WriteString(line + "\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+	}
+
+	It("Should skip unchained records before the chain", func() {
+		// a line written by the Ruby auditor
+		appendLine(rubyRecord)
+
+		lines := writeRecords(2)
+		Expect(lines).To(HaveLen(3))
+
+		report := verify(lines)
+		Expect(report.IsValid()).To(BeTrue())
+		Expect(report.Records).To(Equal(3))
+		Expect(report.Chained).To(Equal(2))
+	})
+
+	It("Should resume the chain past unchained records", func() {
+		writeRecords(2)
+		appendLine(rubyRecord)
+
+		mu.Lock()
+		delete(chains, logfile)
+		mu.Unlock()
+
+		lines := writeRecords(1)
+		Expect(lines).To(HaveLen(4))
+
+		am := Message{}
+		Expect(json.Unmarshal([]byte(lines[3]), &am)).To(Succeed())
+		Expect(am.Sequence).To(Equal(uint64(3)))
+		Expect(am.PreviousHash).To(Equal(recordHash([]byte(lines[1]))))
+
+		report := verify(lines)
+		Expect(report.Records).To(Equal(4))
+		Expect(report.Chained).To(Equal(3))
+		Expect(report.Issues).To(HaveLen(1))
+		Expect(report.Issues[0].Problem).To(Equal(ChainUnchained))
+		Expect(report.Issues[0].Line).To(Equal(3))
+	})
+
+	It("Should report unchained records inserted into the chain", func() {
+		lines := writeRecords(3)
+
+		forged := []string{lines[0], rubyRecord, lines[1], lines[2]}
+		report := verify(forged)
+		Expect(report.IsValid()).To(BeFalse())
+		Expect(report.Chained).To(Equal(3))
+		Expect(report.Issues).To(HaveLen(1))
+		Expect(report.Issues[0].Problem).To(Equal(ChainUnchained))
+		Expect(report.Issues[0].Line).To(Equal(2))
+		Expect(report.Issues[0].Message).To(Equal("record is not part of the chain following sequence 1"))
+	})
+
+	It("Should skip unchained records after a record that is not JSON", func() {
+		lines := writeRecords(2)
+
+		report := verify([]string{lines[0], "{\"timestamp\":", rubyRecord, lines[1]})
+		Expect(report.Issues).To(HaveLen(1))
+		Expect(report.Issues[0].Problem).To(Equal(ChainInvalid))
+		Expect(report.Issues[0].Line).To(Equal(2))
+	})
+
+	It("Should start a new segment after a record that is not JSON", func() {
+		writeRecords(2)
+
+		f, err := os.OpenFile(logfile, os.O_APPEND|os.O_WRONLY, 0600)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.WriteString("{\"timestamp\":\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		mu.Lock()
+		delete(chains, logfile)
+		mu.Unlock()
+
+		lines := writeRecords(2)
+		Expect(lines).To(HaveLen(5))
+
+		am := Message{}
+		Expect(json.Unmarshal([]byte(lines[3]), &am)).To(Succeed())
+		Expect(am.Sequence).To(Equal(uint64(1)))
+		Expect(am.PreviousHash).To(BeEmpty())
+
+		report := verify(lines)
+		Expect(report.Issues).To(HaveLen(1))
+		Expect(report.Issues[0].Problem).To(Equal(ChainInvalid))
+		Expect(report.Issues[0].Line).To(Equal(3))
+	})
+
+	It("Should not report chaining errors as JSON errors", func() {
+		sink.signer = &failingSigner{}
+		sink.signInterval = 1
+		Expect(sink.Audit(msg)).To(MatchError("could not sign the audit chain: simulated failure"))
+	})
+
+	It("Should detect gaps", func() {
+		lines := writeRecords(4)
+		report := verify(append(lines[0:1], lines[2:]...))
+		Expect(report.Issues).To(HaveLen(1))
+		Expect(report.Issues[0].Problem).To(Equal(ChainGap))
+		Expect(report.Issues[0].Sequence).To(Equal(uint64(3)))
+	})
+
+	It("Should detect reordering", func() {
+		lines := writeRecords(3)
+		lines[1], lines[2] = lines[2], lines[1]
+		report := verify(lines)
+		Expect(report.IsValid()).To(BeFalse())
+		Expect(report.Issues[0].Problem).To(Equal(ChainGap))
+		Expect(report.Issues[1].Problem).To(Equal(ChainReordered))
+	})
+
+	It("Should detect modified records", func() {
+		lines := writeRecords(3)
+		lines[1] = strings.Replace(lines[1], "test_action", "other_action", 1)
+		report := verify(lines)
+		Expect(report.Issues).To(HaveLen(1))
+		Expect(report.Issues[0].Problem).To(Equal(ChainModified))
+		Expect(report.Issues[0].Sequence).To(Equal(uint64(2)))
+		Expect(report.Issues[0].Line).To(Equal(3))
+	})
+
+	It("Should detect bad signatures", func() {
+		lines := writeRecords(2)
+
+		am := Message{}
+		Expect(json.Unmarshal([]byte(lines[1]), &am)).To(Succeed())
+		am.PreviousHash = recordHash([]byte("other"))
+		j, err := json.Marshal(am)
+		Expect(err).ToNot(HaveOccurred())
+
+		report := verify([]string{string(j)})
+		Expect(report.Issues).To(HaveLen(1))
+		Expect(report.Issues[0].Problem).To(Equal(ChainBadSignature))
+	})
+})
@@ -5,11 +5,14 @@
 // Package audit is a auditing system that's compatible with the
 // one found in the mcollective-choria Ruby project, log lines will
 // be identical and can be put in the same file as the ruby one
+//
+// Audit records can be written to a number of sinks, the local file,
+// Choria Submission and RFC5424 syslog, the Go agents write a record
+// including its outcome once a request is handled
 package audit
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/fs"
 	"os"
 	"os/user"
@@ -25,7 +28,17 @@ import (
 
 var mu = &sync.Mutex{}
 
+const (
+	// PhaseReceived marks the record written when a request is received
+	PhaseReceived = "received"
+	// PhaseCompleted marks the record written once a request is handled
+	PhaseCompleted = "completed"
+)
+
 // Message is the format of a Choria audit log
+//
+// The outcome fields are only set once a request completed and are omitted when
+// only the request is audited, keeping those lines identical to the Ruby ones
 type Message struct {
 	TimeStamp   string          `json:"timestamp"`
 	RequestID   string          `json:"request_id"`
@@ -35,29 +48,31 @@ type Message struct {
 	Agent       string          `json:"agent"`
 	Action      string          `json:"action"`
 	Data        json.RawMessage `json:"data"`
-}
-
-// Request writes a audit log to a configured log
-func Request(request protocol.Request, agent string, action string, data json.RawMessage, cfg *config.Config) bool {
-	if !cfg.RPCAudit {
-		return false
-	}
 
-	logfile := cfg.Choria.RPCAuditLogfile
-	logfileGroup := cfg.Choria.RPCAuditLogfileGroup
-
-	logfileMode, err := strconv.ParseUint(cfg.Choria.RPCAuditLogFileMode, 0, 32)
-	if err != nil {
-		log.Errorf("Failed to parse plugin.rpcaudit.logfile.mode: %v", err)
-		return false
-	}
-
-	if logfile == "" {
-		log.Warnf("Choria RPC Auditing is enabled but no logfile is configured, skipping")
-		return false
-	}
+	// Identity is the server that handled the request
+	Identity string `json:"identity,omitempty"`
+	// Authorized is the decision of the authorization system
+	Authorized *bool `json:"authorized,omitempty"`
+	// StatusCode is the SimpleRPC status code of the reply
+	StatusCode *int `json:"statuscode,omitempty"`
+	// StatusMessage is the SimpleRPC status message of the reply
+	StatusMessage string `json:"statusmsg,omitempty"`
+	// Duration is how long, in seconds, the request took to handle
+	Duration float64 `json:"duration,omitempty"`
+	// Phase is PhaseReceived or PhaseCompleted, only set when received requests are audited in addition to their outcome
+	Phase string `json:"phase,omitempty"`
+
+	// Sequence is the position of the record in a hash chained log
+	Sequence uint64 `json:"sequence,omitempty"`
+	// PreviousHash is the SHA256 hash of the preceding record in a hash chained log
+	PreviousHash string `json:"previous_hash,omitempty"`
+	// Signature is a periodic signature of the sequence and previous hash made using the security provider
+	Signature string `json:"signature,omitempty"`
+}
 
-	amsg := Message{
+// NewMessage creates an audit message for a request
+func NewMessage(request protocol.Request, agent string, action string, data json.RawMessage) *Message {
+	return &Message{
 		TimeStamp:   time.Now().UTC().Format("2006-01-02T15:04:05.000000-0700"),
 		RequestID:   request.RequestID(),
 		RequestTime: request.Time().UTC().Unix(),
@@ -67,26 +82,32 @@ func Request(request protocol.Request, agent string, action string, data json.Ra
 		Action:      action,
 		Data:        data,
 	}
+}
 
-	j, err := json.Marshal(amsg)
-	if err != nil {
-		log.Warnf("Auditing is not functional because the auditing data could not be represented as JSON: %s", err)
+// SetOutcome records the outcome of handling the request
+func (m *Message) SetOutcome(identity string, authorized bool, statusCode int, statusMessage string, duration time.Duration) {
+	m.Identity = identity
+	m.Authorized = &authorized
+	m.StatusCode = &statusCode
+	m.StatusMessage = statusMessage
+	m.Duration = duration.Seconds()
+}
+
+// Request writes a audit log to a configured log
+func Request(request protocol.Request, agent string, action string, data json.RawMessage, cfg *config.Config) bool {
+	if !cfg.RPCAudit {
 		return false
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-
-	f, err := createAuditLog(logfile, logfileGroup, uint32(logfileMode))
+	sink, err := newFileSink(cfg)
 	if err != nil {
-		log.Warnf("Auditing is not functional because opening the logfile '%s' failed: %s", logfile, err)
+		log.Warnf("Auditing is not functional: %v", err)
 		return false
 	}
-	defer f.Close()
 
-	_, err = f.WriteString(fmt.Sprintf("%s\n", string(j)))
+	err = sink.Audit(NewMessage(request, agent, action, data))
 	if err != nil {
-		log.Warnf("Auditing is not functional because writing to logfile '%s' failed: %s", logfile, err)
+		log.Warnf("Auditing is not functional: %v", err)
 		return false
 	}
 
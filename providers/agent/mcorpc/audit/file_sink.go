@@ -0,0 +1,88 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/choria-io/go-choria/config"
+)
+
+// fileSink appends audit messages as JSON lines to a local file, optionally hash chained
+type fileSink struct {
+	logfile      string
+	group        string
+	mode         uint32
+	chain        bool
+	signer       Signer
+	signInterval int
+}
+
+func newFileSink(cfg *config.Config) (*fileSink, error) {
+	if cfg.Choria.RPCAuditLogfile == "" {
+		return nil, fmt.Errorf("no logfile is configured")
+	}
+
+	mode, err := strconv.ParseUint(cfg.Choria.RPCAuditLogFileMode, 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse plugin.rpcaudit.logfile.mode: %w", err)
+	}
+
+	return &fileSink{
+		logfile:      cfg.Choria.RPCAuditLogfile,
+		group:        cfg.Choria.RPCAuditLogfileGroup,
+		mode:         uint32(mode),
+		chain:        cfg.Choria.RPCAuditChain,
+		signInterval: cfg.Choria.RPCAuditChainSignInterval,
+	}, nil
+}
+
+func (s *fileSink) Name() string {
+	return "file"
+}
+
+func (s *fileSink) Audit(msg *Message) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var (
+		j      []byte
+		commit func()
+		err    error
+	)
+
+	if s.chain {
+		// chain fields are specific to this file so other sinks should not see them
+		record := *msg
+		j, commit, err = chainRecord(s.logfile, &record, s.signer, s.signInterval)
+		if err != nil {
+			return err
+		}
+	} else {
+		j, err = json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("the auditing data could not be represented as JSON: %w", err)
+		}
+	}
+
+	f, err := createAuditLog(s.logfile, s.group, s.mode)
+	if err != nil {
+		return fmt.Errorf("opening the logfile '%s' failed: %w", s.logfile, err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\n", string(j))
+	if err != nil {
+		return fmt.Errorf("writing to logfile '%s' failed: %w", s.logfile, err)
+	}
+
+	if commit != nil {
+		commit()
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	imock "github.com/choria-io/go-choria/inter/imocks"
+	v1 "github.com/choria-io/go-choria/protocol/v1"
+	"github.com/choria-io/go-choria/submission"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+type testSubmitter struct {
+	msgs []*submission.Message
+}
+
+func (s *testSubmitter) NewMessage() *submission.Message {
+	return &submission.Message{}
+}
+
+func (s *testSubmitter) Submit(msg *submission.Message) error {
+	s.msgs = append(s.msgs, msg)
+	return nil
+}
+
+var _ = Describe("Sinks", func() {
+	var msg *Message
+
+	BeforeEach(func() {
+		req, err := v1.NewRequest("test_agent", "test.node", "choria=rip.mcollective", 120, "uniq_req_id", "mcollective")
+		Expect(err).ToNot(HaveOccurred())
+
+		msg = NewMessage(req, "test_agent", "test_action", json.RawMessage(`{"hello":"world"}`))
+	})
+
+	Describe("Message", func() {
+		It("Should only include the outcome when set", func() {
+			j, err := json.Marshal(msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(j)).ToNot(ContainSubstring("authorized"))
+			Expect(string(j)).ToNot(ContainSubstring("statuscode"))
+
+			msg.SetOutcome("server.example.net", false, 1, "denied", 1500*time.Millisecond)
+			j, err = json.Marshal(msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(j)).To(ContainSubstring(`"identity":"server.example.net","authorized":false,"statuscode":1,"statusmsg":"denied","duration":1.5`))
+		})
+	})
+
+	Describe("New", func() {
+		It("Should configure the requested sinks", func() {
+			mockctl := gomock.NewController(GinkgoT())
+			fw, cfg := imock.NewFrameworkForTests(mockctl, GinkgoWriter, imock.LogDiscard())
+			cfg.Choria.RPCAuditLogfile = filepath.Join(GinkgoT().TempDir(), "audit.log")
+			cfg.Choria.RPCAuditSyslogAddress = "udp://127.0.0.1:514"
+
+			cfg.Choria.RPCAuditSinks = []string{"file", "syslog", "submission", "other"}
+			auditor, err := New(fw)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(auditor.sinks).To(HaveLen(2))
+			Expect(auditor.sinks[0].Name()).To(Equal("file"))
+			Expect(auditor.sinks[1].Name()).To(Equal("syslog"))
+
+			cfg.Choria.RPCAuditSinks = []string{"other"}
+			_, err = New(fw)
+			Expect(err).To(MatchError(ErrNoSinks))
+		})
+	})
+
+	Describe("Shared", func() {
+		It("Should create one auditor per framework", func() {
+			mockctl := gomock.NewController(GinkgoT())
+			fw, cfg := imock.NewFrameworkForTests(mockctl, GinkgoWriter, imock.LogDiscard())
+			cfg.Choria.RPCAuditLogfile = filepath.Join(GinkgoT().TempDir(), "audit.log")
+			cfg.Choria.RPCAuditSinks = []string{"file"}
+
+			auditor, err := Shared(fw)
+			Expect(err).ToNot(HaveOccurred())
+			again, err := Shared(fw)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(again).To(BeIdenticalTo(auditor))
+
+			other, ocfg := imock.NewFrameworkForTests(mockctl, GinkgoWriter, imock.LogDiscard())
+			ocfg.Choria.RPCAuditLogfile = cfg.Choria.RPCAuditLogfile
+			ocfg.Choria.RPCAuditSinks = []string{"file"}
+			again, err = Shared(other)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(again).ToNot(BeIdenticalTo(auditor))
+		})
+	})
+
+	Describe("File", func() {
+		It("Should append records", func() {
+			mockctl := gomock.NewController(GinkgoT())
+			_, cfg := imock.NewFrameworkForTests(mockctl, GinkgoWriter)
+			cfg.Choria.RPCAuditLogfile = filepath.Join(GinkgoT().TempDir(), "audit.log")
+
+			sink, err := newFileSink(cfg)
+			Expect(err).ToNot(HaveOccurred())
+
+			msg.SetOutcome("server.example.net", true, 0, "OK", time.Second)
+			Expect(sink.Audit(msg)).To(Succeed())
+			Expect(sink.Audit(msg)).To(Succeed())
+
+			j, err := os.ReadFile(cfg.Choria.RPCAuditLogfile)
+			Expect(err).ToNot(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(string(j)), "\n")
+			Expect(lines).To(HaveLen(2))
+
+			am := Message{}
+			Expect(json.Unmarshal([]byte(lines[0]), &am)).To(Succeed())
+			Expect(*am.Authorized).To(BeTrue())
+			Expect(*am.StatusCode).To(Equal(0))
+			Expect(am.Duration).To(Equal(1.0))
+		})
+	})
+
+	Describe("Submission", func() {
+		It("Should submit reliable messages", func() {
+			submitter := &testSubmitter{}
+			sink := newSubmissionSink(submitter, "choria.audit.rpc.example.net")
+
+			Expect(sink.Audit(msg)).To(Succeed())
+			Expect(submitter.msgs).To(HaveLen(1))
+			Expect(submitter.msgs[0].Subject).To(Equal("choria.audit.rpc.example.net"))
+			Expect(submitter.msgs[0].Reliable).To(BeTrue())
+
+			am := Message{}
+			Expect(json.Unmarshal(submitter.msgs[0].Payload, &am)).To(Succeed())
+			Expect(am.RequestID).To(Equal("uniq_req_id"))
+		})
+	})
+
+	Describe("Syslog", func() {
+		It("Should parse addresses", func() {
+			n, a, err := parseSyslogAddress("udp://localhost:514")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal("udp"))
+			Expect(a).To(Equal("localhost:514"))
+
+			n, a, err = parseSyslogAddress("unix:///dev/log")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal("unixgram"))
+			Expect(a).To(Equal("/dev/log"))
+
+			_, _, err = parseSyslogAddress("http://localhost")
+			Expect(err).To(MatchError(ContainSubstring("unsupported scheme")))
+		})
+
+		It("Should send RFC5424 messages", func() {
+			pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer pc.Close()
+
+			mockctl := gomock.NewController(GinkgoT())
+			_, cfg := imock.NewFrameworkForTests(mockctl, GinkgoWriter)
+			cfg.Identity = "server.example.net"
+			cfg.Choria.RPCAuditSyslogAddress = "udp://" + pc.LocalAddr().String()
+
+			sink, err := newSyslogSink(cfg)
+			Expect(err).ToNot(HaveOccurred())
+
+			msg.SetOutcome("server.example.net", false, 1, "denied", time.Second)
+			Expect(sink.Audit(msg)).To(Succeed())
+
+			buf := make([]byte, 4096)
+			pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+			n, _, err := pc.ReadFrom(buf)
+			Expect(err).ToNot(HaveOccurred())
+
+			// facility audit (13) and severity warning (4) for denied requests
+			Expect(string(buf[:n])).To(MatchRegexp(`^<108>1 \S+ server\.example\.net choria \d+ rpc - \{.+"authorized":false.+\}$`))
+		})
+	})
+})
@@ -0,0 +1,47 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/choria-io/go-choria/submission"
+)
+
+// Submitter stores messages in the Choria Submission spool
+type Submitter interface {
+	NewMessage() *submission.Message
+	Submit(msg *submission.Message) error
+}
+
+// submissionSink publishes audit messages reliably via Choria Submission
+type submissionSink struct {
+	submit  Submitter
+	subject string
+}
+
+func newSubmissionSink(submit Submitter, subject string) *submissionSink {
+	return &submissionSink{submit: submit, subject: subject}
+}
+
+func (s *submissionSink) Name() string {
+	return "submission"
+}
+
+func (s *submissionSink) Audit(msg *Message) error {
+	j, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("the auditing data could not be represented as JSON: %w", err)
+	}
+
+	smsg := s.submit.NewMessage()
+	smsg.Subject = s.subject
+	smsg.Payload = j
+	smsg.Reliable = true
+	smsg.Priority = 1
+
+	return s.submit.Submit(smsg)
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/config"
+)
+
+const (
+	syslogSeverityWarning = 4
+	syslogSeverityNotice  = 5
+)
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"audit":    13,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// syslogSink sends audit messages as RFC5424 formatted syslog messages
+type syslogSink struct {
+	network  string
+	address  string
+	facility int
+	hostname string
+	appName  string
+	conn     net.Conn
+	mu       sync.Mutex
+}
+
+func newSyslogSink(cfg *config.Config) (*syslogSink, error) {
+	if cfg.Choria.RPCAuditSyslogAddress == "" {
+		return nil, fmt.Errorf("no syslog address is configured")
+	}
+
+	network, address, err := parseSyslogAddress(cfg.Choria.RPCAuditSyslogAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	facility, ok := syslogFacilities[strings.ToLower(cfg.Choria.RPCAuditSyslogFacility)]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", cfg.Choria.RPCAuditSyslogFacility)
+	}
+
+	hostname := cfg.Identity
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+
+	return &syslogSink{
+		network:  network,
+		address:  address,
+		facility: facility,
+		hostname: hostname,
+		appName:  "choria",
+	}, nil
+}
+
+// parseSyslogAddress parses addresses like udp://host:514, tcp://host:514 and unix:///dev/log
+func parseSyslogAddress(addr string) (string, string, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid syslog address %q: %w", addr, err)
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+		if u.Host == "" {
+			return "", "", fmt.Errorf("invalid syslog address %q: host is required", addr)
+		}
+
+		return u.Scheme, u.Host, nil
+
+	case "unix", "unixgram":
+		if u.Path == "" {
+			return "", "", fmt.Errorf("invalid syslog address %q: path is required", addr)
+		}
+
+		return "unixgram", u.Path, nil
+
+	default:
+		return "", "", fmt.Errorf("invalid syslog address %q: unsupported scheme %q", addr, u.Scheme)
+	}
+}
+
+func (s *syslogSink) Name() string {
+	return "syslog"
+}
+
+func (s *syslogSink) Audit(msg *Message) error {
+	line, err := s.format(msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// tcp streams use octet counting framing as per RFC6587
+	if s.network == "tcp" {
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// we retry once on a new connection to recover from servers that closed the connection
+	for try := 0; try < 2; try++ {
+		if s.conn == nil {
+			s.conn, err = net.DialTimeout(s.network, s.address, 2*time.Second)
+			if err != nil {
+				return fmt.Errorf("could not connect to syslog server %s: %w", s.address, err)
+			}
+		}
+
+		s.conn.SetWriteDeadline(time.Now().Add(2 * time.Second))
+		_, err = s.conn.Write([]byte(line))
+		if err == nil {
+			return nil
+		}
+
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	return fmt.Errorf("could not write to syslog server %s: %w", s.address, err)
+}
+
+// format creates a RFC5424 message with the JSON audit message as body
+func (s *syslogSink) format(msg *Message, ts time.Time) (string, error) {
+	j, err := json.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("the auditing data could not be represented as JSON: %w", err)
+	}
+
+	severity := syslogSeverityNotice
+	if msg.Authorized != nil && !*msg.Authorized {
+		severity = syslogSeverityWarning
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s - %s", s.facility*8+severity, ts.UTC().Format(time.RFC3339Nano), syslogHeaderValue(s.hostname), s.appName, os.Getpid(), "rpc", j), nil
+}
+
+// syslogHeaderValue ensures header values are printable US-ASCII without spaces
+func syslogHeaderValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+
+		return r
+	}, v)
+}
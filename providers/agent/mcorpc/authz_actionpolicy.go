@@ -21,7 +21,7 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func actionPolicyAuthorize(req *Request, cfg *config.Config, log *logrus.Entry) bool {
+func newActionPolicy(req *Request, cfg *config.Config, dir string, log *logrus.Entry) *actionPolicy {
 	logger := log.WithFields(logrus.Fields{
 		"authorizer": "actionpolicy",
 		"agent":      req.Agent,
@@ -31,6 +31,7 @@ func actionPolicyAuthorize(req *Request, cfg *config.Config, log *logrus.Entry)
 	authz := &actionPolicy{
 		cfg:     cfg,
 		req:     req,
+		dir:     dir,
 		matcher: &actionPolicyPolicy{log: logger},
 		groups:  make(map[string][]string),
 		log:     logger,
@@ -41,40 +42,55 @@ func actionPolicyAuthorize(req *Request, cfg *config.Config, log *logrus.Entry)
 		authz.log.Errorf("failed to parse groups file: %s", err)
 	}
 
-	return authz.authorize()
+	return authz
 }
 
 type actionPolicy struct {
 	cfg     *config.Config
 	req     *Request
+	dir     string
 	log     *logrus.Entry
 	matcher *actionPolicyPolicy
 	groups  map[string][]string
+
+	// details of the last decision
+	policyFile string
+	line       int
+	rule       string
+	reason     string
 }
 
 func (a *actionPolicy) authorize() bool {
 	policyFile, err := a.lookupPolicyFile()
 	if err != nil {
 		a.log.Errorf("Could not lookup policy files: %s", err)
+		a.reason = err.Error()
 		return false
 	}
 
 	if policyFile == "" {
 		if a.allowUnconfigured() {
 			a.log.Infof("Allowing unconfigured agent request after failing to find any suitable policy file")
+			a.reason = "Allowing unconfigured agent"
 			return true
 		}
 
 		a.log.Infof("Denying unconfigured agent request after failing to find any suitable policy file")
+		a.reason = "Denying unconfigured agent"
 		return false
 	}
 
+	a.policyFile = policyFile
+
 	allowed, reason, err := a.evaluatePolicy(policyFile)
 	if err != nil {
 		a.log.Errorf("Authorizing request %s failed: %s", a.req.RequestID, err)
+		a.reason = err.Error()
 		return false
 	}
 
+	a.reason = reason
+
 	if !allowed {
 		a.log.Infof("Denying request %s: %s", a.req.RequestID, reason)
 		return false
@@ -97,10 +113,19 @@ func (a *actionPolicy) evaluatePolicy(f string) (allowed bool, denyreason string
 	defaultRe := regexp.MustCompile(`^policy\s+default\s+(\w+)`)
 	policyRe := regexp.MustCompile(`^(allow|deny)\t+(.+?)\t+(.+?)\t+(.+?)(\t+(.+?))*$`)
 	allowed = a.allowUnconfigured()
+	a.line = 0
+	a.rule = ""
+
+	var (
+		lineNo      int
+		defaultLine int
+		defaultRule string
+	)
 
 	scanner := bufio.NewScanner(pf)
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNo++
 
 		if commentRe.MatchString(line) {
 			continue
@@ -116,6 +141,9 @@ func (a *actionPolicy) evaluatePolicy(f string) (allowed bool, denyreason string
 				allowed = false
 			}
 
+			defaultLine = lineNo
+			defaultRule = line
+
 		} else if policyRe.MatchString(line) {
 			matched := policyRe.FindStringSubmatch(line)
 			if a.matcher.IsCompound(matched[4]) || a.matcher.IsCompound(matched[6]) {
@@ -130,6 +158,10 @@ func (a *actionPolicy) evaluatePolicy(f string) (allowed bool, denyreason string
 			}
 
 			if pmatch {
+				a.log.Debugf("line %d matched the request: %s", lineNo, line)
+				a.line = lineNo
+				a.rule = line
+
 				if matched[1] == "allow" {
 					return true, "", nil
 				}
@@ -148,6 +180,9 @@ func (a *actionPolicy) evaluatePolicy(f string) (allowed bool, denyreason string
 		return false, "", err
 	}
 
+	a.line = defaultLine
+	a.rule = defaultRule
+
 	if allowed {
 		return allowed, "", nil
 	}
@@ -201,8 +236,16 @@ func (a *actionPolicy) defaultPolicyFileName() string {
 	return a.cfg.Option("plugin.actionpolicy.default_name", "default")
 }
 
+func (a *actionPolicy) policyDir() string {
+	if a.dir != "" {
+		return a.dir
+	}
+
+	return filepath.Join(filepath.Dir(a.cfg.ConfigFile), "policies")
+}
+
 func (a *actionPolicy) lookupPolicyFile() (string, error) {
-	agentPolicy := filepath.Join(filepath.Dir(a.cfg.ConfigFile), "policies", a.req.Agent+".policy")
+	agentPolicy := filepath.Join(a.policyDir(), a.req.Agent+".policy")
 
 	a.log.Debugf("Looking up agent policy in %s", agentPolicy)
 	if util.FileExist(agentPolicy) {
@@ -210,7 +253,7 @@ func (a *actionPolicy) lookupPolicyFile() (string, error) {
 	}
 
 	if a.shouldUseDefault() {
-		defaultPolicy := filepath.Join(filepath.Dir(a.cfg.ConfigFile), "policies", a.defaultPolicyFileName()+".policy")
+		defaultPolicy := filepath.Join(a.policyDir(), a.defaultPolicyFileName()+".policy")
 		if util.FileExist(defaultPolicy) {
 			return defaultPolicy, nil
 		}
@@ -221,7 +264,7 @@ func (a *actionPolicy) lookupPolicyFile() (string, error) {
 
 func (a *actionPolicy) parseGroupFile(gfile string) error {
 	if gfile == "" {
-		gfile = filepath.Join(filepath.Dir(a.cfg.ConfigFile), "policies", "groups")
+		gfile = filepath.Join(a.policyDir(), "groups")
 	}
 
 	if !util.FileExist(gfile) {
@@ -0,0 +1,125 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mcorpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/filter/classes"
+	"github.com/choria-io/go-choria/filter/facts"
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/opa"
+	"github.com/sirupsen/logrus"
+)
+
+// AuthorizationCheck is a request to evaluate against the configured authorizer without dispatching it to an agent
+type AuthorizationCheck struct {
+	// Request is the request to authorize, CallerPublicData should hold the client JWT for the aaasvc authorizer
+	Request *Request
+	// PolicyDir holds action policy files and the rego directory, defaults to the policies directory next to the configuration file
+	PolicyDir string
+	// Agents are the agents known to the server, used as input to rego policies
+	Agents []string
+}
+
+// AuthorizationDecision is the outcome of an authorization check
+type AuthorizationDecision struct {
+	// Provider is the authorizer that made the decision
+	Provider string `json:"provider"`
+	// Allowed indicates if the request would be allowed
+	Allowed bool `json:"allowed"`
+	// Policy is the policy file or source that was evaluated
+	Policy string `json:"policy,omitempty"`
+	// Line is the line in Policy that matched the request
+	Line int `json:"line,omitempty"`
+	// Rule is the policy line, agent list entry or rego rule that matched the request
+	Rule string `json:"rule,omitempty"`
+	// Rules are the rego rules that produced the decision
+	Rules []opa.MatchedRule `json:"rules,omitempty"`
+	// Reason explains a denial when known
+	Reason string `json:"reason,omitempty"`
+	// Error is the error that prevented evaluation, requests are denied on error
+	Error string `json:"error,omitempty"`
+	// Trace is the log of the evaluation including rego traces
+	Trace []string `json:"trace,omitempty"`
+}
+
+// checkInfoSource supplies rego inputs from the configured fact and classes files
+type checkInfoSource struct {
+	facts   json.RawMessage
+	classes []string
+	agents  []string
+}
+
+func (s *checkInfoSource) Facts() json.RawMessage { return s.facts }
+func (s *checkInfoSource) Classes() []string      { return s.classes }
+func (s *checkInfoSource) KnownAgents() []string  { return s.agents }
+
+// CheckAuthorization evaluates a request against the authorizer configured in cfg and explains the decision
+//
+// Facts and classes are read from the FactSourceFile and ClassesFile settings, no agent is called
+func CheckAuthorization(fw inter.Framework, cfg *config.Config, check *AuthorizationCheck) (*AuthorizationDecision, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("configuration is required")
+	}
+	if check == nil || check.Request == nil {
+		return nil, fmt.Errorf("request is required")
+	}
+	if check.Request.Agent == "" {
+		return nil, fmt.Errorf("agent is required")
+	}
+
+	trace := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(trace)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true, DisableQuote: true})
+	log := logrus.NewEntry(logger)
+
+	si := &checkInfoSource{facts: json.RawMessage("{}"), agents: check.Agents}
+
+	if strings.ToLower(cfg.RPCAuthorizationProvider) == "rego_policy" {
+		var err error
+
+		if cfg.FactSourceFile != "" {
+			si.facts, err = facts.JSON(cfg.FactSourceFile, log)
+			if err != nil {
+				return nil, fmt.Errorf("could not read facts: %w", err)
+			}
+		}
+
+		if cfg.ClassesFile != "" {
+			si.classes, err = classes.ReadClasses(cfg.ClassesFile)
+			if err != nil {
+				return nil, fmt.Errorf("could not read classes: %w", err)
+			}
+		}
+	}
+
+	decision := &AuthorizationDecision{}
+
+	_, err := authorizeRequest(fw, check.Request, cfg, si, check.PolicyDir, decision, log)
+	if err != nil {
+		return nil, err
+	}
+
+	// the evaluation log precedes the rego trace recorded by the authorizer
+	decision.Trace = append(traceLines(trace.String()), decision.Trace...)
+
+	return decision, nil
+}
+
+func traceLines(trace string) []string {
+	trace = strings.TrimSpace(trace)
+	if trace == "" {
+		return nil
+	}
+
+	return strings.Split(trace, "\n")
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mcorpc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/choria-io/go-choria/config"
+	imock "github.com/choria-io/go-choria/inter/imocks"
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/protocol"
+	"github.com/choria-io/tokens"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("CheckAuthorization", func() {
+	var (
+		mockctl *gomock.Controller
+		fw      *imock.MockFramework
+		cfg     *config.Config
+		check   *AuthorizationCheck
+	)
+
+	BeforeEach(func() {
+		overRideRegoName = ""
+		mockctl = gomock.NewController(GinkgoT())
+		fw, cfg = imock.NewFrameworkForTests(mockctl, GinkgoWriter)
+		fw.EXPECT().ProvisionMode().Return(false).AnyTimes()
+
+		cfg.ConfigFile = "testdata/server.conf"
+		cfg.RPCAuthorization = true
+
+		check = &AuthorizationCheck{
+			PolicyDir: "testdata/policies",
+			Request: &Request{
+				Agent:      "example1",
+				Action:     "test",
+				CallerID:   "choria=ginkgo.mcollective",
+				Collective: "ginkgo",
+				Data:       json.RawMessage(`{}`),
+				Time:       time.Now(),
+				Filter:     protocol.NewFilter(),
+			},
+		}
+	})
+
+	AfterEach(func() {
+		mockctl.Finish()
+	})
+
+	It("Should require a supported provider", func() {
+		cfg.RPCAuthorizationProvider = "other"
+		_, err := CheckAuthorization(fw, cfg, check)
+		Expect(err).To(MatchError(`unsupported authorization provider: "other"`))
+	})
+
+	Describe("action_policy", func() {
+		BeforeEach(func() {
+			cfg.RPCAuthorizationProvider = "action_policy"
+			check.PolicyDir = GinkgoT().TempDir()
+
+			policy := "policy default deny\n\n# allow ginkgo\nallow\tchoria=ginkgo.mcollective\ttest\t*\t*\n"
+			Expect(os.WriteFile(filepath.Join(check.PolicyDir, "example1.policy"), []byte(policy), 0600)).To(Succeed())
+		})
+
+		It("Should report the matching policy line", func() {
+			d, err := CheckAuthorization(fw, cfg, check)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Allowed).To(BeTrue())
+			Expect(d.Policy).To(Equal(filepath.Join(check.PolicyDir, "example1.policy")))
+			Expect(d.Line).To(Equal(4))
+			Expect(d.Rule).To(Equal("allow\tchoria=ginkgo.mcollective\ttest\t*\t*"))
+			Expect(d.Trace).To(ContainElement(ContainSubstring("line 4 matched the request")))
+		})
+
+		It("Should report default policies", func() {
+			check.Request.Action = "other"
+			d, err := CheckAuthorization(fw, cfg, check)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Allowed).To(BeFalse())
+			Expect(d.Line).To(Equal(1))
+			Expect(d.Rule).To(Equal("policy default deny"))
+			Expect(d.Reason).To(Equal("Denying based on default policy in example1.policy"))
+		})
+
+		It("Should report missing policies", func() {
+			check.Request.Agent = "missing"
+			d, err := CheckAuthorization(fw, cfg, check)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Allowed).To(BeFalse())
+			Expect(d.Reason).To(Equal("no policy found for missing"))
+		})
+	})
+
+	Describe("rego_policy", func() {
+		BeforeEach(func() {
+			cfg.RPCAuthorizationProvider = "rego_policy"
+			cfg.FactSourceFile = "testdata/policies/rego/facts.json"
+			cfg.ClassesFile = "testdata/policies/rego/classes.txt"
+			check.Request.Agent = "ginkgo"
+			check.Request.Action = "boop"
+		})
+
+		It("Should report the matching rule", func() {
+			d, err := CheckAuthorization(fw, cfg, check)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Allowed).To(BeTrue())
+			Expect(d.Policy).To(Equal("testdata/policies/rego/ginkgo.rego"))
+			Expect(d.Line).To(Equal(5))
+			Expect(d.Rule).To(Equal("allow if {"))
+			Expect(d.Trace).To(ContainElement(ContainSubstring("input.facts.stub")))
+		})
+
+		It("Should report the default rule", func() {
+			check.Request.Action = "other"
+			d, err := CheckAuthorization(fw, cfg, check)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Allowed).To(BeFalse())
+			Expect(d.Line).To(Equal(3))
+			Expect(d.Rule).To(Equal("default allow = false"))
+		})
+	})
+
+	Describe("aaasvc", func() {
+		BeforeEach(func() {
+			cfg.RPCAuthorizationProvider = "aaasvc"
+		})
+
+		It("Should report the matching agent list entry", func() {
+			pubk, prik, err := iu.Ed25519KeyPair()
+			Expect(err).ToNot(HaveOccurred())
+
+			claims, err := tokens.NewClientIDClaims("ginkgo", []string{"other.*", "example1.test"}, "choria", nil, "", "", time.Hour, nil, pubk)
+			Expect(err).ToNot(HaveOccurred())
+			check.Request.CallerPublicData, err = tokens.SignToken(claims, prik)
+			Expect(err).ToNot(HaveOccurred())
+
+			d, err := CheckAuthorization(fw, cfg, check)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Allowed).To(BeTrue())
+			Expect(d.Policy).To(Equal("agent list"))
+			Expect(d.Rule).To(Equal("example1.test"))
+
+			check.Request.Action = "other"
+			d, err = CheckAuthorization(fw, cfg, check)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Allowed).To(BeFalse())
+			Expect(d.Rule).To(BeEmpty())
+		})
+
+		It("Should support the aaasvc_policy alias", func() {
+			cfg.RPCAuthorizationProvider = "aaasvc_policy"
+			check.Request.CallerPublicData = "blah"
+			d, err := CheckAuthorization(fw, cfg, check)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Provider).To(Equal("aaasvc_policy"))
+			Expect(d.Allowed).To(BeFalse())
+			Expect(d.Error).To(ContainSubstring("invalid token in request"))
+		})
+
+		It("Should report errors", func() {
+			check.Request.CallerPublicData = "blah"
+			d, err := CheckAuthorization(fw, cfg, check)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Allowed).To(BeFalse())
+			Expect(d.Error).To(ContainSubstring("invalid token in request"))
+		})
+	})
+})
@@ -24,22 +24,30 @@ type aaasvcPolicy struct {
 	cfg *config.Config
 	req *Request
 	log *logrus.Entry
+
+	// explain records the matching policy entry or rules of the decision in policy, rule and explanation
+	explain     bool
+	policy      string
+	rule        string
+	explanation *opa.Explanation
 }
 
 func aaasvcPolicyAuthorize(req *Request, cfg *config.Config, log *logrus.Entry) (bool, error) {
+	return newAaasvcPolicy(req, cfg, log).authorize()
+}
+
+func newAaasvcPolicy(req *Request, cfg *config.Config, log *logrus.Entry) *aaasvcPolicy {
 	logger := log.WithFields(logrus.Fields{
 		"authorizer": "aaasvc",
 		"agent":      req.Agent,
 		"request":    req.RequestID,
 	})
 
-	authz := &aaasvcPolicy{
+	return &aaasvcPolicy{
 		cfg: cfg,
 		req: req,
 		log: logger,
 	}
-
-	return authz.authorize()
 }
 
 func (r *aaasvcPolicy) authorize() (bool, error) {
@@ -69,10 +77,30 @@ func (r *aaasvcPolicy) authorize() (bool, error) {
 	case hasAgents:
 		r.log.Debugf("Processing using agent list")
 
-		allowed, err = EvaluateAgentListPolicy(r.req.Agent, r.req.Action, claims.AllowedAgents, r.log)
+		r.policy = "agent list"
+		r.rule, err = matchAgentListPolicy(r.req.Agent, r.req.Action, claims.AllowedAgents)
+		allowed = r.rule != ""
 	case hasOpa:
 		r.log.Debugf("Processing using opa policy")
 
+		r.policy = "opa policy"
+		if r.explain {
+			var evaluator *opa.Evaluator
+			var inputs map[string]any
+
+			evaluator, inputs, err = newOpenPolicyAgentEvaluator(r.req, claims.OPAPolicy, claims, "server", r.log)
+			if err != nil {
+				return false, err
+			}
+
+			r.explanation, err = evaluator.Explain(context.Background(), inputs)
+			if err != nil {
+				return false, err
+			}
+
+			return r.explanation.Allowed, nil
+		}
+
 		allowed, err = EvaluateOpenPolicyAgentPolicy(r.req, claims.OPAPolicy, claims, "server", r.log)
 	}
 
@@ -80,19 +108,25 @@ func (r *aaasvcPolicy) authorize() (bool, error) {
 }
 
 func EvaluateAgentListPolicy(agent string, action string, policy []string, _ *logrus.Entry) (bool, error) {
-	if len(policy) == 0 {
-		return false, nil
+	match, err := matchAgentListPolicy(agent, action, policy)
+	if err != nil {
+		return false, err
 	}
 
+	return match != "", nil
+}
+
+// matchAgentListPolicy finds the agent list entry that allows the action, empty when none does
+func matchAgentListPolicy(agent string, action string, policy []string) (string, error) {
 	for _, allow := range policy {
 		// all things are allowed
 		if allow == "*" {
-			return true, nil
+			return allow, nil
 		}
 
 		parts := strings.Split(allow, ".")
 		if len(parts) != 2 {
-			return false, fmt.Errorf("invalid agent policy: %s", allow)
+			return "", fmt.Errorf("invalid agent policy: %s", allow)
 		}
 
 		// it's a claim for a different agent so pass, no need to check it here
@@ -102,23 +136,36 @@ func EvaluateAgentListPolicy(agent string, action string, policy []string, _ *lo
 
 		// agent matches, action is * so allow it
 		if parts[1] == "*" {
-			return true, nil
+			return allow, nil
 		}
 
 		// agent matches, action matches, allow it
 		if action == parts[1] {
-			return true, nil
+			return allow, nil
 		}
 	}
 
-	return false, nil
-
+	return "", nil
 }
 
 // EvaluateOpenPolicyAgentPolicy evaluates a rego policy document, typically embedded in a JWT token, against a request.  Shared by Choria and AAA Service
 func EvaluateOpenPolicyAgentPolicy(req *Request, policy string, claims *tokens.ClientIDClaims, site string, log *logrus.Entry) (allowed bool, err error) {
+	evaluator, inputs, err := newOpenPolicyAgentEvaluator(req, policy, claims, site, log)
+	if err != nil {
+		return false, err
+	}
+
+	allowed, err = evaluator.Evaluate(context.Background(), inputs)
+	if err != nil {
+		return false, err
+	}
+
+	return allowed, nil
+}
+
+func newOpenPolicyAgentEvaluator(req *Request, policy string, claims *tokens.ClientIDClaims, site string, log *logrus.Entry) (*opa.Evaluator, map[string]any, error) {
 	if policy == "" {
-		return false, fmt.Errorf("invalid policy given")
+		return nil, nil, fmt.Errorf("invalid policy given")
 	}
 
 	eopts := []opa.Option{
@@ -133,20 +180,15 @@ func EvaluateOpenPolicyAgentPolicy(req *Request, policy string, claims *tokens.C
 
 	evaluator, err := opa.New("io.choria.aaasvc", "data.io.choria.aaasvc.allow", eopts...)
 	if err != nil {
-		return false, fmt.Errorf("could not initialize opa evaluator: %v", err)
+		return nil, nil, fmt.Errorf("could not initialize opa evaluator: %v", err)
 	}
 
 	inputs, err := opaInputs(req, req.Data, site, claims)
 	if err != nil {
-		return false, err
-	}
-
-	allowed, err = evaluator.Evaluate(context.Background(), inputs)
-	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
 
-	return allowed, nil
+	return evaluator, inputs, nil
 }
 
 func opaInputs(req *Request, data json.RawMessage, site string, claims *tokens.ClientIDClaims) (map[string]any, error) {
@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/choria-io/go-choria/inter"
-	"github.com/choria-io/go-choria/server/agents"
 	"path/filepath"
 
 	"github.com/choria-io/go-choria/config"
@@ -19,30 +18,42 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// regoInfoSource is the server information used as input to rego policies
+type regoInfoSource interface {
+	Facts() json.RawMessage
+	Classes() []string
+	KnownAgents() []string
+}
+
 type regoPolicy struct {
 	cfg *config.Config
 	req *Request
 	fw  inter.Framework
-	si  agents.ServerInfoSource
+	si  regoInfoSource
 	log *logrus.Entry
+	dir string
+
+	// explain records the policy file and matching rules of the decision in policyFile and explanation
+	explain     bool
+	policyFile  string
+	explanation *opa.Explanation
 }
 
-func regoPolicyAuthorize(req *Request, fw inter.Framework, si agents.ServerInfoSource, cfg *config.Config, log *logrus.Entry) (bool, error) {
+func newRegoPolicy(req *Request, fw inter.Framework, si regoInfoSource, cfg *config.Config, dir string, log *logrus.Entry) *regoPolicy {
 	logger := log.WithFields(logrus.Fields{
 		"authorizer": "regoPolicy",
 		"agent":      req.Agent,
 		"request":    req.RequestID,
 	})
 
-	authz := &regoPolicy{
+	return &regoPolicy{
 		cfg: cfg,
 		req: req,
 		si:  si,
 		fw:  fw,
 		log: logger,
+		dir: dir,
 	}
-
-	return authz.authorize()
 }
 
 func (r *regoPolicy) authorize() (bool, error) {
@@ -55,6 +66,8 @@ func (r *regoPolicy) authorize() (bool, error) {
 		return false, fmt.Errorf("policy file could not be found")
 	}
 
+	r.policyFile = policyFile
+
 	eopts := []opa.Option{
 		opa.Logger(r.log),
 		opa.File(policyFile),
@@ -71,7 +84,17 @@ func (r *regoPolicy) authorize() (bool, error) {
 	}
 
 	inputs := r.regoInputs()
-	allowed, err := evaluator.Evaluate(context.Background(), inputs)
+
+	var allowed bool
+	if r.explain {
+		r.explanation, err = evaluator.Explain(context.Background(), inputs)
+		if r.explanation != nil {
+			allowed = r.explanation.Allowed
+		}
+	} else {
+		allowed, err = evaluator.Evaluate(context.Background(), inputs)
+	}
+
 	switch err := err.(type) {
 	case nil:
 		break
@@ -93,6 +116,9 @@ var overRideRegoName string
 
 func (r *regoPolicy) lookupPolicyFile() (string, error) {
 	dir := filepath.Join(filepath.Dir(r.cfg.ConfigFile), "policies", "rego")
+	if r.dir != "" {
+		dir = filepath.Join(r.dir, "rego")
+	}
 
 	regoPolicy := filepath.Join(dir, r.req.Agent+".rego")
 	if overRideRegoName != "" {
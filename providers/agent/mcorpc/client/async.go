@@ -0,0 +1,247 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"crypto/fips140"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/protocol"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc"
+	"github.com/expr-lang/expr/vm"
+	"github.com/nats-io/nats.go"
+)
+
+// AsyncReplyStream is the Choria Streams stream holding replies to asynchronous requests
+const AsyncReplyStream = "CHORIA_RPC_REPLIES"
+
+// ErrNoAsyncResults indicates that no replies were found for an asynchronous request
+var ErrNoAsyncResults = errors.New("no replies found")
+
+// AsyncResults are the replies to an asynchronous request retrieved from Choria Streams
+type AsyncResults struct {
+	// RequestID is the request the replies belong to
+	RequestID string
+	// Expected is how many nodes the request was sent to, 0 when unknown
+	Expected int
+	// Replies are the replies received so far
+	Replies []protocol.Reply
+	// Invalid is the number of stored replies that could not be processed
+	Invalid int
+}
+
+// Complete indicates that all expected replies were received, including those that could not be processed
+func (r *AsyncResults) Complete() bool {
+	return r.Expected > 0 && len(r.Replies)+r.Invalid >= r.Expected
+}
+
+// Process passes every reply to handler like a normal request would and returns the request statistics,
+// replies not matching the expr filter are passed to handler as nil RPC replies
+func (r *AsyncResults) Process(agent string, action string, exprFilter string, handler Handler) (*Stats, error) {
+	stats := NewStats()
+	stats.RequestID = r.RequestID
+	stats.SetAgent(agent)
+	stats.SetAction(action)
+	stats.Start()
+	defer stats.End()
+
+	var senders []string
+	for _, reply := range r.Replies {
+		senders = append(senders, reply.SenderID())
+	}
+	stats.SetDiscoveredNodes(senders)
+
+	var prog *vm.Program
+
+	for _, reply := range r.Replies {
+		stats.RecordReceived(reply.SenderID())
+
+		rpcreply, err := ParseReply(reply)
+		switch {
+		case err != nil:
+			stats.FailedRequestInc()
+			continue
+		case rpcreply.Statuscode == mcorpc.OK:
+			stats.PassedRequestInc()
+		default:
+			stats.FailedRequestInc()
+		}
+
+		if handler == nil {
+			continue
+		}
+
+		shouldShow := true
+		if exprFilter != "" {
+			shouldShow, prog, err = rpcreply.MatchExpr(exprFilter, prog)
+			if err != nil {
+				return nil, fmt.Errorf("expr filter parsing failed in reply from %s: %w", reply.SenderID(), err)
+			}
+		}
+
+		if shouldShow {
+			handler(reply, rpcreply)
+		} else {
+			handler(reply, nil)
+		}
+	}
+
+	return stats, nil
+}
+
+// AsyncReplySubject is the subject servers publish replies to for an asynchronous request, it is
+// private to the caller like normal replies and includes the number of nodes the request was sent
+// to so results can be waited on later
+func AsyncReplySubject(collective string, caller string, requestID string, expected int) string {
+	return fmt.Sprintf("%s.reply.%s.async.%s.%d", collective, callerReplyHash(caller), requestID, expected)
+}
+
+func asyncReplyFilter(caller string, requestID string) string {
+	return fmt.Sprintf("*.reply.%s.async.%s.*", callerReplyHash(caller), requestID)
+}
+
+// callerReplyHash is the hash of the caller used in private reply subjects, the broker
+// only allows callers to read replies matching their own hash
+func callerReplyHash(caller string) string {
+	if fips140.Enabled() {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(caller)))
+	}
+
+	return fmt.Sprintf("%x", md5.Sum([]byte(caller)))
+}
+
+// parseAsyncReplySubject extracts the expected reply count from an asynchronous reply subject
+func parseAsyncReplySubject(subject string) (expected int, err error) {
+	parts := strings.Split(subject, ".")
+	if len(parts) != 6 || parts[1] != "reply" || parts[3] != "async" {
+		return 0, fmt.Errorf("invalid asynchronous reply subject %q", subject)
+	}
+
+	return strconv.Atoi(parts[5])
+}
+
+// AsyncReplies sends replies to Choria Streams instead of the client, the request returns once published
+// and the replies can later be retrieved using FetchAsyncResults()
+func AsyncReplies() RequestOption {
+	return func(o *RequestOptions) {
+		o.Async = true
+		o.ProcessReplies = false
+	}
+}
+
+// FetchAsyncResults retrieves the replies to a request made using AsyncReplies() from Choria Streams
+//
+// When wait is above zero it waits up to that long for all expected replies to arrive
+func FetchAsyncResults(ctx context.Context, fw inter.Framework, requestID string, wait time.Duration) (*AsyncResults, error) {
+	conn, err := fw.NewConnector(ctx, fw.MiddlewareServers, fmt.Sprintf("async results %s", requestID), fw.Logger("async"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	js, err := conn.Nats().JetStream()
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to Choria Streams: %w", err)
+	}
+
+	res := &AsyncResults{RequestID: requestID}
+
+	err = fetchAsyncReplies(ctx, js, fw.CallerID(), requestID, wait, res, func(data []byte) error {
+		reply, err := fw.NewReplyFromTransportJSON(data, false)
+		if err != nil {
+			return err
+		}
+
+		res.Replies = append(res.Replies, reply)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// fetchAsyncReplies reads all stored replies made by caller for requestID passing each to cb, waiting for more up to wait
+func fetchAsyncReplies(ctx context.Context, js nats.JetStreamContext, caller string, requestID string, wait time.Duration, res *AsyncResults, cb func([]byte) error) error {
+	if requestID == "" || strings.ContainsAny(requestID, ".*> ") {
+		return fmt.Errorf("invalid request id %q", requestID)
+	}
+
+	filter := asyncReplyFilter(caller, requestID)
+
+	nfo, err := js.StreamInfo(AsyncReplyStream, &nats.StreamInfoRequest{SubjectsFilter: filter})
+	if err != nil {
+		return fmt.Errorf("could not load stream %s: %w", AsyncReplyStream, err)
+	}
+
+	var available uint64
+	for subject, count := range nfo.State.Subjects {
+		available += count
+		res.Expected, err = parseAsyncReplySubject(subject)
+		if err != nil {
+			return err
+		}
+	}
+
+	if available == 0 && wait == 0 {
+		return fmt.Errorf("%w for request %s", ErrNoAsyncResults, requestID)
+	}
+
+	sub, err := js.SubscribeSync(filter, nats.BindStream(AsyncReplyStream), nats.OrderedConsumer(), nats.DeliverAll())
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	timeout, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	var received uint64
+
+	for {
+		caughtUp := received >= available
+		if caughtUp && (wait == 0 || res.Complete()) {
+			break
+		}
+
+		mctx := timeout
+		if !caughtUp {
+			// stored messages are read even when the wait time passed
+			mctx = ctx
+		}
+
+		msg, err := sub.NextMsgWithContext(mctx)
+		if err != nil {
+			if caughtUp && timeout.Err() != nil && ctx.Err() == nil {
+				break
+			}
+
+			return err
+		}
+
+		received++
+
+		if res.Expected == 0 {
+			res.Expected, _ = parseAsyncReplySubject(msg.Subject)
+		}
+
+		err = cb(msg.Data)
+		if err != nil {
+			res.Invalid++
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/choria-io/go-choria/protocol"
+	v1 "github.com/choria-io/go-choria/protocol/v1"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("McoRPC/Client/Async", func() {
+	Describe("Subjects", func() {
+		It("Should encode and parse the expected replies", func() {
+			subj := AsyncReplySubject("mcollective", "choria=rip.mcollective", "abc123", 10)
+			Expect(subj).To(Equal("mcollective.reply.37bdf08389145a56c13096c178802d3b.async.abc123.10"))
+
+			expected, err := parseAsyncReplySubject(subj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expected).To(Equal(10))
+
+			_, err = parseAsyncReplySubject("mcollective.reply.abc123")
+			Expect(err).To(MatchError(ContainSubstring("invalid asynchronous reply subject")))
+		})
+	})
+
+	Describe("Process", func() {
+		It("Should handle replies and record stats", func() {
+			req, err := v1.NewRequest("package", "client.example.net", "choria=rip.mcollective", 120, "fb1fd0fb5a0e4a2bbb2e1e42cd5fe2c1", "mcollective")
+			Expect(err).ToNot(HaveOccurred())
+			req.SetMessage([]byte("{}"))
+
+			res := &AsyncResults{RequestID: "fb1fd0fb5a0e4a2bbb2e1e42cd5fe2c1", Expected: 3}
+			for i, status := range []int{0, 1} {
+				reply, err := v1.NewReply(req, fmt.Sprintf("node%d", i))
+				Expect(err).ToNot(HaveOccurred())
+				reply.SetMessage([]byte(fmt.Sprintf(`{"action":"status","statuscode":%d,"statusmsg":"msg","data":{}}`, status)))
+				res.Replies = append(res.Replies, reply)
+			}
+
+			var shown []string
+			stats, err := res.Process("package", "status", "ok()", func(pr protocol.Reply, reply *RPCReply) {
+				if reply != nil {
+					shown = append(shown, reply.Sender)
+				}
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shown).To(Equal([]string{"node0"}))
+			Expect(stats.RequestID).To(Equal("fb1fd0fb5a0e4a2bbb2e1e42cd5fe2c1"))
+			Expect(stats.ResponsesCount()).To(Equal(2))
+			Expect(stats.OKCount()).To(Equal(1))
+			Expect(stats.FailCount()).To(Equal(1))
+			Expect(res.Complete()).To(BeFalse())
+		})
+	})
+
+	Describe("fetchAsyncReplies", func() {
+		var (
+			srv *server.Server
+			nc  *nats.Conn
+			js  nats.JetStreamContext
+			ctx context.Context
+		)
+
+		BeforeEach(func() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+			DeferCleanup(cancel)
+
+			srv = server.New(&server.Options{
+				JetStream: true,
+				StoreDir:  GinkgoT().TempDir(),
+				Port:      -1,
+				Host:      "localhost",
+			})
+			go srv.Start()
+			Expect(srv.ReadyForConnections(10 * time.Second)).To(BeTrue())
+			DeferCleanup(srv.Shutdown)
+
+			var err error
+			nc, err = nats.Connect(srv.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(nc.Close)
+
+			js, err = nc.JetStream()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = js.AddStream(&nats.StreamConfig{Name: AsyncReplyStream, Subjects: []string{"*.reply.*.async.>"}, NoAck: true})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		caller := "choria=rip.mcollective"
+
+		collect := func(res *AsyncResults, received *[]string) func([]byte) error {
+			return func(data []byte) error {
+				if string(data) == "invalid" {
+					return errors.New("invalid")
+				}
+
+				*received = append(*received, string(data))
+				res.Replies = append(res.Replies, nil)
+
+				return nil
+			}
+		}
+
+		It("Should fail for unknown requests", func() {
+			res := &AsyncResults{}
+			err := fetchAsyncReplies(ctx, js, caller, "abc123", 0, res, collect(res, &[]string{}))
+			Expect(errors.Is(err, ErrNoAsyncResults)).To(BeTrue())
+
+			err = fetchAsyncReplies(ctx, js, caller, "abc.>", 0, res, collect(res, &[]string{}))
+			Expect(err).To(MatchError(ContainSubstring("invalid request id")))
+		})
+
+		It("Should read stored replies for the request", func() {
+			Expect(nc.Publish(AsyncReplySubject("mcollective", caller, "abc123", 3), []byte("r1"))).To(Succeed())
+			Expect(nc.Publish(AsyncReplySubject("mcollective", caller, "other", 1), []byte("other"))).To(Succeed())
+			Expect(nc.Publish(AsyncReplySubject("mcollective", "choria=other.mcollective", "abc123", 3), []byte("other caller"))).To(Succeed())
+			Expect(nc.Publish(AsyncReplySubject("mcollective", caller, "abc123", 3), []byte("invalid"))).To(Succeed())
+			Expect(nc.Flush()).To(Succeed())
+			Eventually(func() uint64 {
+				nfo, _ := js.StreamInfo(AsyncReplyStream)
+				return nfo.State.Msgs
+			}).Should(Equal(uint64(4)))
+
+			var received []string
+			res := &AsyncResults{}
+			Expect(fetchAsyncReplies(ctx, js, caller, "abc123", 0, res, collect(res, &received))).To(Succeed())
+			Expect(received).To(Equal([]string{"r1"}))
+			Expect(res.Expected).To(Equal(3))
+			Expect(res.Invalid).To(Equal(1))
+			Expect(res.Complete()).To(BeFalse())
+		})
+
+		It("Should wait for outstanding replies", func() {
+			Expect(nc.Publish(AsyncReplySubject("mcollective", caller, "abc123", 2), []byte("r1"))).To(Succeed())
+			Expect(nc.Flush()).To(Succeed())
+			Eventually(func() uint64 {
+				nfo, _ := js.StreamInfo(AsyncReplyStream)
+				return nfo.State.Msgs
+			}).Should(Equal(uint64(1)))
+
+			go func() {
+				defer GinkgoRecover()
+				time.Sleep(200 * time.Millisecond)
+				Expect(nc.Publish(AsyncReplySubject("mcollective", caller, "abc123", 2), []byte("r2"))).To(Succeed())
+			}()
+
+			var received []string
+			res := &AsyncResults{}
+			Expect(fetchAsyncReplies(ctx, js, caller, "abc123", 5*time.Second, res, collect(res, &received))).To(Succeed())
+			Expect(received).To(Equal([]string{"r1", "r2"}))
+			Expect(res.Complete()).To(BeTrue())
+
+			// a short wait with nothing outstanding returns what is there
+			received = nil
+			res = &AsyncResults{}
+			Expect(fetchAsyncReplies(ctx, js, caller, "new", 100*time.Millisecond, res, collect(res, &received))).To(Succeed())
+			Expect(received).To(BeEmpty())
+		})
+
+		It("Should count invalid replies toward completion", func() {
+			Expect(nc.Publish(AsyncReplySubject("mcollective", caller, "abc123", 2), []byte("r1"))).To(Succeed())
+			Expect(nc.Publish(AsyncReplySubject("mcollective", caller, "abc123", 2), []byte("invalid"))).To(Succeed())
+			Expect(nc.Flush()).To(Succeed())
+
+			var received []string
+			res := &AsyncResults{}
+			start := time.Now()
+			Expect(fetchAsyncReplies(ctx, js, caller, "abc123", 5*time.Second, res, collect(res, &received))).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+			Expect(received).To(Equal([]string{"r1"}))
+			Expect(res.Invalid).To(Equal(1))
+			Expect(res.Complete()).To(BeTrue())
+		})
+	})
+})
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/choria-io/go-choria/inter"
 	"github.com/expr-lang/expr/vm"
@@ -21,6 +22,7 @@ import (
 	"github.com/choria-io/go-choria/protocol"
 	"github.com/choria-io/go-choria/providers/agent/mcorpc"
 	addl "github.com/choria-io/go-choria/providers/agent/mcorpc/ddl/agent"
+	"github.com/choria-io/go-choria/server/agents"
 
 	"github.com/sirupsen/logrus"
 )
@@ -39,6 +41,8 @@ type RPC struct {
 
 	ddl *addl.DDL
 
+	journal *RequestJournal
+
 	// used for testing only
 	cl ChoriaClient
 }
@@ -204,6 +208,13 @@ func (r *RPC) Do(ctx context.Context, action string, payload any, opts ...Reques
 		return nil, err
 	}
 
+	if r.opts.Rollout != nil {
+		err = r.opts.Rollout.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid rollout policy: %s", err)
+		}
+	}
+
 	dctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -227,6 +238,14 @@ func (r *RPC) Do(ctx context.Context, action string, payload any, opts ...Reques
 		}
 	}
 
+	if r.opts.JournalFile != "" {
+		err = r.openJournal(msg.RequestID(), action, payload)
+		if err != nil {
+			return nil, fmt.Errorf("could not open journal: %s", err)
+		}
+		defer r.closeJournal()
+	}
+
 	r.opts.totalStats.Start()
 	defer r.opts.totalStats.End()
 
@@ -236,13 +255,71 @@ func (r *RPC) Do(ctx context.Context, action string, payload any, opts ...Reques
 	switch r.opts.RequestType {
 	case inter.ServiceRequestMessageType:
 		err = r.doServiceRequest(dctx, msg, cl)
+	case inter.DirectRequestMessageType:
+		if r.opts.Rollout != nil {
+			err = r.doRolloutRequest(ctx, msg, cl)
+			break
+		}
+		fallthrough
 	default:
 		err = r.doBatchedRequest(ctx, msg, cl)
 	}
 
+	// when the caller gave up before all replies were received we ask the servers to stop working on the request
+	if ctx.Err() != nil && r.opts.ProcessReplies && r.opts.RequestType != inter.ServiceRequestMessageType && !r.opts.totalStats.All() {
+		cerr := r.cancelRequest(msg)
+		if cerr != nil {
+			r.log.Warnf("Could not cancel request %s: %v", msg.RequestID(), cerr)
+		}
+	}
+
 	return &RequestOptions{totalStats: r.opts.totalStats}, err
 }
 
+// cancelRequest publishes a message asking the servers that received msg to stop processing it
+func (r *RPC) cancelRequest(msg inter.Message) error {
+	req, err := json.Marshal(&agents.CancelRequest{Agent: r.agent, Cancel: msg.RequestID()})
+	if err != nil {
+		return err
+	}
+
+	cmsg, err := r.fw.NewMessage(req, r.agent, msg.Collective(), inter.RequestMessageType, nil)
+	if err != nil {
+		return err
+	}
+
+	cmsg.SetFilter(msg.Filter())
+	cmsg.SetDiscoveredHosts(r.opts.Targets)
+	cmsg.SetProtocolVersion(msg.ProtocolVersion())
+
+	err = cmsg.SetType(msg.Type())
+	if err != nil {
+		return err
+	}
+
+	// nothing listens for replies to the cancellation, servers do not send any
+	err = cmsg.SetReplyTo(cmsg.ReplyTarget())
+	if err != nil {
+		return err
+	}
+
+	cl := r.cl
+	if cl == nil {
+		cl, err = cclient.New(r.fw, cclient.Name(fmt.Sprintf("%s_%s_cancel", r.opts.ConnectionName, msg.RequestID())))
+		if err != nil {
+			return err
+		}
+	}
+
+	// the request context is already done so a new one is needed to publish the cancellation
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r.log.Debugf("Cancelling request %s on %d nodes", msg.RequestID(), len(r.opts.Targets))
+
+	return cl.Request(ctx, cmsg, nil)
+}
+
 func (r *RPC) doBatchedRequest(ctx context.Context, msg inter.Message, cl ChoriaClient) error {
 	// the client is always batched, when batched mode is not request the size of
 	// the batch matches the size of the total targets and during setupMessage()
@@ -386,7 +463,7 @@ func (r *RPC) setupMessage(ctx context.Context, action string, payload any, opts
 	cl = r.cl
 
 	if r.cl == nil {
-		if r.opts.BatchSize == len(r.opts.Targets) || !r.opts.ProcessReplies {
+		if !r.opts.batched() || !r.opts.ProcessReplies {
 			cl, err = r.unbatchedClient()
 			if err != nil {
 				return nil, nil, err
@@ -402,6 +479,30 @@ func (r *RPC) setupMessage(ctx context.Context, action string, payload any, opts
 	return msg, cl, err
 }
 
+func (r *RPC) openJournal(id string, action string, payload any) (err error) {
+	r.journal, err = OpenJournal(r.opts.JournalFile)
+	if err != nil {
+		return err
+	}
+
+	err = r.journal.RecordRequest(id, r.agent, action, r.opts.Collective, payload, r.ddl, r.opts.Targets)
+	if err != nil {
+		r.closeJournal()
+		return err
+	}
+
+	return nil
+}
+
+func (r *RPC) closeJournal() {
+	err := r.journal.Close()
+	if err != nil {
+		r.log.Errorf("Could not close journal %s: %s", r.opts.JournalFile, err)
+	}
+
+	r.journal = nil
+}
+
 func (r *RPC) unbatchedClient() (cl ChoriaClient, err error) {
 	cl, err = cclient.New(
 		r.fw,
@@ -466,7 +567,7 @@ func (r *RPC) handlerFactory(_ context.Context, cancel context.CancelFunc, stats
 		return nil
 	}
 
-	var prog *vm.Program
+	var prog, abortProg *vm.Program
 
 	handler := func(ctx context.Context, rawmsg inter.ConnectorMessage) {
 		reply, err := r.fw.NewReplyFromTransportJSON(rawmsg.Data(), false)
@@ -491,6 +592,25 @@ func (r *RPC) handlerFactory(_ context.Context, cancel context.CancelFunc, stats
 			stats.PassedRequestInc()
 		default:
 			stats.FailedRequestInc()
+			stats.recordRolloutFailure(reply.SenderID())
+		}
+
+		if r.journal != nil {
+			err = r.journal.RecordReply(r.opts.RequestID, reply.SenderID(), rpcreply)
+			if err != nil {
+				r.log.Errorf("Could not record reply from %s in the journal: %s", reply.SenderID(), err)
+			}
+		}
+
+		if r.opts.Rollout != nil && r.opts.Rollout.AbortExpr != "" {
+			var matched bool
+			matched, abortProg, err = rpcreply.MatchExpr(r.opts.Rollout.AbortExpr, abortProg)
+			if err != nil {
+				r.log.Errorf("Rollout abort expression failed in reply from %s: %s", reply.SenderID(), err)
+			}
+			if matched || err != nil {
+				stats.recordRolloutFailure(reply.SenderID())
+			}
 		}
 
 		if r.opts.Handler != nil {
@@ -305,6 +305,28 @@ var _ = Describe("Providers/Agent/McoRPC/Client", func() {
 			Expect(stats.Agent()).To(Equal("package"))
 		})
 
+		It("Should cancel requests the caller gave up on", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			reqid := ""
+
+			gomock.InOrder(
+				cl.EXPECT().Request(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, msg inter.Message, handler client.Handler) {
+					reqid = msg.RequestID()
+					cancel()
+				}),
+				cl.EXPECT().Request(gomock.Any(), gomock.Any(), gomock.Nil()).Return(nil).Do(func(_ context.Context, msg inter.Message, handler client.Handler) {
+					Expect(msg.Type()).To(Equal(inter.DirectRequestMessageType))
+					Expect(msg.DiscoveredHosts()).To(Equal([]string{"host1", "host2"}))
+					Expect(msg.Payload()).To(MatchJSON(fmt.Sprintf(`{"agent":"package","cancel_request":%q}`, reqid)))
+				}),
+			)
+
+			_, err := rpc.Do(ctx, "test_action", request{Testing: true}, Targets([]string{"host1", "host2"}))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
 		It("Should support discovery callbacks and limits", func(ctx context.Context) {
 			cl.EXPECT().Request(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Do(func(ctx context.Context, msg inter.Message, handler client.Handler) {
 				Expect(msg.DiscoveredHosts()).To(Equal([]string{"host1"}))
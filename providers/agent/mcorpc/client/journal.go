@@ -0,0 +1,256 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/providers/agent/mcorpc"
+)
+
+const (
+	// JournalRequestKind is the kind of journal entries recording a request
+	JournalRequestKind = "request"
+
+	// JournalReplyKind is the kind of journal entries recording a reply from a node
+	JournalReplyKind = "reply"
+)
+
+// JournalEntry is a single line in a request journal
+type JournalEntry struct {
+	Kind      string    `json:"kind"`
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+
+	// set for request entries
+	Agent      string          `json:"agent,omitempty"`
+	Action     string          `json:"action,omitempty"`
+	Collective string          `json:"collective,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	DDL        json.RawMessage `json:"ddl,omitempty"`
+	Targets    []string        `json:"targets,omitempty"`
+
+	// set for reply entries
+	Sender     string            `json:"sender,omitempty"`
+	StatusCode mcorpc.StatusCode `json:"statuscode,omitempty"`
+	StatusMsg  string            `json:"statusmsg,omitempty"`
+}
+
+// RequestJournal records requests and the replies received from every node in a JSON Lines file,
+// each entry is written as soon as it is known so the journal survives the client dying mid-request
+type RequestJournal struct {
+	file *os.File
+	mu   sync.Mutex
+}
+
+// JournalState is the outcome of all requests recorded in a journal
+type JournalState struct {
+	// Agent is the agent the original request was made to
+	Agent string
+
+	// Action is the action the original request invoked
+	Action string
+
+	// Collective is the collective the original request was made in
+	Collective string
+
+	// Payload is the input sent to the action
+	Payload json.RawMessage
+
+	// DDL is the DDL of the agent used for the original request
+	DDL json.RawMessage
+
+	// Targets are the nodes targeted by the original request
+	Targets []string
+
+	// Requests are the IDs of all requests recorded in the journal
+	Requests []string
+
+	// Replies are the most recent replies received from each node, successful replies are never replaced
+	Replies map[string]*JournalEntry
+}
+
+// Journal records the request and the status of every reply in file, an existing journal will be appended to
+func Journal(file string) RequestOption {
+	return func(o *RequestOptions) {
+		o.JournalFile = file
+	}
+}
+
+// OpenJournal opens or creates the journal in file for appending
+func OpenJournal(file string) (*RequestJournal, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequestJournal{file: f}, nil
+}
+
+// RecordRequest adds a request to the journal
+func (j *RequestJournal) RecordRequest(id string, agent string, action string, collective string, payload any, ddl any, targets []string) error {
+	pj, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not encode payload: %s", err)
+	}
+
+	dj, err := json.Marshal(ddl)
+	if err != nil {
+		return fmt.Errorf("could not encode DDL: %s", err)
+	}
+
+	return j.write(&JournalEntry{
+		Kind:       JournalRequestKind,
+		Time:       time.Now().UTC(),
+		RequestID:  id,
+		Agent:      agent,
+		Action:     action,
+		Collective: collective,
+		Payload:    pj,
+		DDL:        dj,
+		Targets:    targets,
+	})
+}
+
+// RecordReply adds a reply received from sender to the journal
+func (j *RequestJournal) RecordReply(id string, sender string, reply *RPCReply) error {
+	return j.write(&JournalEntry{
+		Kind:       JournalReplyKind,
+		Time:       time.Now().UTC(),
+		RequestID:  id,
+		Sender:     sender,
+		StatusCode: reply.Statuscode,
+		StatusMsg:  reply.Statusmsg,
+	})
+}
+
+// Close closes the journal file
+func (j *RequestJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
+
+func (j *RequestJournal) write(entry *JournalEntry) error {
+	ej, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_, err = j.file.Write(append(ej, '\n'))
+
+	return err
+}
+
+// LoadJournal reads a journal and calculates the outcome of the requests it records, a partially written
+// final line as left by a client that died mid-write is ignored
+func LoadJournal(file string) (*JournalState, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	state := &JournalState{Replies: make(map[string]*JournalEntry)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	var invalid error
+
+	for scanner.Scan() {
+		line++
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		if invalid != nil {
+			return nil, invalid
+		}
+
+		entry := &JournalEntry{}
+		err = json.Unmarshal(scanner.Bytes(), entry)
+		if err != nil {
+			invalid = fmt.Errorf("invalid journal entry on line %d: %s", line, err)
+			continue
+		}
+
+		switch entry.Kind {
+		case JournalRequestKind:
+			if len(state.Requests) == 0 {
+				state.Agent = entry.Agent
+				state.Action = entry.Action
+				state.Collective = entry.Collective
+				state.Payload = entry.Payload
+				state.DDL = entry.DDL
+				state.Targets = entry.Targets
+			} else if entry.Agent != state.Agent || entry.Action != state.Action {
+				return nil, fmt.Errorf("request %s on line %d invokes %s#%s while the journal is for %s#%s", entry.RequestID, line, entry.Agent, entry.Action, state.Agent, state.Action)
+			}
+
+			state.Requests = append(state.Requests, entry.RequestID)
+
+		case JournalReplyKind:
+			// a node that succeeded in an earlier request should not be retried because a later one failed
+			prev, ok := state.Replies[entry.Sender]
+			if ok && prev.StatusCode == mcorpc.OK {
+				continue
+			}
+
+			state.Replies[entry.Sender] = entry
+
+		default:
+			return nil, fmt.Errorf("unknown journal entry kind %q on line %d", entry.Kind, line)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(state.Requests) == 0 {
+		return nil, fmt.Errorf("no requests found in journal %s", file)
+	}
+
+	return state, nil
+}
+
+// Completed are the targeted nodes that replied successfully
+func (s *JournalState) Completed() []string {
+	var nodes []string
+
+	for _, node := range s.Targets {
+		reply, ok := s.Replies[node]
+		if ok && reply.StatusCode == mcorpc.OK {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes
+}
+
+// Pending are the targeted nodes that did not reply or replied with a failure
+func (s *JournalState) Pending() []string {
+	var nodes []string
+
+	for _, node := range s.Targets {
+		reply, ok := s.Replies[node]
+		if !ok || reply.StatusCode != mcorpc.OK {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes
+}
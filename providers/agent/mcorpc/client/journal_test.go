@@ -0,0 +1,150 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/choria-io/go-choria/inter"
+	imock "github.com/choria-io/go-choria/inter/imocks"
+	"github.com/choria-io/go-choria/message"
+	"github.com/choria-io/go-choria/protocol"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc"
+	addl "github.com/choria-io/go-choria/providers/agent/mcorpc/ddl/agent"
+	"go.uber.org/mock/gomock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Journal", func() {
+	var (
+		file    string
+		targets []string
+	)
+
+	BeforeEach(func() {
+		file = filepath.Join(GinkgoT().TempDir(), "journal.jsonl")
+		targets = strings.Fields("host1 host2 host3 host4")
+	})
+
+	record := func(id string, targets []string, replies map[string]mcorpc.StatusCode) {
+		j, err := OpenJournal(file)
+		Expect(err).ToNot(HaveOccurred())
+		defer j.Close()
+
+		Expect(j.RecordRequest(id, "package", "status", "ginkgo", map[string]string{"package": "zsh"}, nil, targets)).To(Succeed())
+		for _, node := range targets {
+			code, ok := replies[node]
+			if ok {
+				Expect(j.RecordReply(id, node, &RPCReply{Statuscode: code})).To(Succeed())
+			}
+		}
+	}
+
+	It("Should find nodes that did not complete", func() {
+		record("1", targets, map[string]mcorpc.StatusCode{"host1": mcorpc.OK, "host2": mcorpc.Aborted})
+
+		state, err := LoadJournal(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.Agent).To(Equal("package"))
+		Expect(state.Action).To(Equal("status"))
+		Expect(state.Collective).To(Equal("ginkgo"))
+		Expect(state.Payload).To(MatchJSON(`{"package":"zsh"}`))
+		Expect(state.Targets).To(Equal(targets))
+		Expect(state.Completed()).To(Equal([]string{"host1"}))
+		Expect(state.Pending()).To(Equal([]string{"host2", "host3", "host4"}))
+	})
+
+	It("Should combine resumed requests", func() {
+		record("1", targets, map[string]mcorpc.StatusCode{"host1": mcorpc.OK, "host2": mcorpc.Aborted})
+		record("2", targets[1:], map[string]mcorpc.StatusCode{"host1": mcorpc.Aborted, "host2": mcorpc.OK, "host3": mcorpc.UnknownError})
+
+		state, err := LoadJournal(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.Requests).To(Equal([]string{"1", "2"}))
+		Expect(state.Targets).To(Equal(targets))
+		Expect(state.Completed()).To(Equal([]string{"host1", "host2"}))
+		Expect(state.Pending()).To(Equal([]string{"host3", "host4"}))
+	})
+
+	It("Should ignore a partially written final entry", func() {
+		record("1", targets, map[string]mcorpc.StatusCode{"host1": mcorpc.OK})
+
+		f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.WriteString(`{"kind":"reply","sen`)
+		Expect(err).ToNot(HaveOccurred())
+		f.Close()
+
+		state, err := LoadJournal(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.Pending()).To(Equal(targets[1:]))
+
+		f, err = os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.WriteString("\n{}\n")
+		Expect(err).ToNot(HaveOccurred())
+		f.Close()
+
+		_, err = LoadJournal(file)
+		Expect(err).To(MatchError(ContainSubstring("invalid journal entry on line 3")))
+	})
+
+	It("Should reject journals for different actions", func() {
+		record("1", targets, nil)
+
+		j, err := OpenJournal(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(j.RecordRequest("2", "package", "install", "ginkgo", nil, nil, targets)).To(Succeed())
+		Expect(j.Close()).To(Succeed())
+
+		_, err = LoadJournal(file)
+		Expect(err).To(MatchError("request 2 on line 2 invokes package#install while the journal is for package#status"))
+	})
+
+	It("Should journal requests", func(ctx context.Context) {
+		mockctl := gomock.NewController(GinkgoT())
+		defer mockctl.Finish()
+
+		fw, _ := imock.NewFrameworkForTests(mockctl, GinkgoWriter, imock.WithCallerID(), imock.WithDDLFiles("agent", "package", "testdata/mcollective/agent/package.json"))
+		fw.EXPECT().NewMessage(gomock.Any(), gomock.Eq("package"), gomock.Eq("ginkgo"), gomock.Eq(inter.RequestMessageType), gomock.Eq(nil)).DoAndReturn(func(payload []byte, agent string, collective string, msgType string, request inter.Message) (msg inter.Message, err error) {
+			return message.NewMessage(payload, agent, collective, msgType, request, fw)
+		}).AnyTimes()
+		fw.Configuration().LibDir = []string{"testdata"}
+		protocol.Secure = "false"
+
+		cl := NewMockChoriaClient(mockctl)
+		cl.EXPECT().Request(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		rpc, err := New(fw, "package")
+		Expect(err).ToNot(HaveOccurred())
+		rpc.cl = cl
+
+		res, err := rpc.Do(ctx, "test_action", map[string]bool{"testing": true}, Targets(targets), Journal(file))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rpc.journal).To(BeNil())
+
+		state, err := LoadJournal(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.Requests).To(Equal([]string{res.Stats().RequestID}))
+		Expect(state.Agent).To(Equal("package"))
+		Expect(state.Action).To(Equal("test_action"))
+		Expect(state.Payload).To(MatchJSON(`{"testing":true}`))
+		Expect(state.Pending()).To(Equal(targets))
+
+		ddl, err := addl.NewFromBytes(state.DDL)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ddl.Metadata.Name).To(Equal("package"))
+
+		dj, err := json.Marshal(rpc.DDL())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.DDL).To(MatchJSON(dj))
+	})
+})
@@ -41,6 +41,9 @@ type RequestOptions struct {
 	ReplyExprFilter  string
 	DiscoveryStartCB DiscoveryStartFunc
 	DiscoveryEndCB   DiscoveryEndFunc
+	Async            bool
+	Rollout          *RolloutPolicy
+	JournalFile      string
 
 	// merged of all batches
 	totalStats *Stats
@@ -91,7 +94,7 @@ func (o *RequestOptions) ConfigureMessage(msg inter.Message) (err error) {
 
 	switch o.RequestType {
 	case inter.RequestMessageType, inter.DirectRequestMessageType:
-		if o.RequestType == inter.RequestMessageType && o.BatchSize > 0 {
+		if o.RequestType == inter.RequestMessageType && (o.BatchSize > 0 || o.Rollout != nil) {
 			return fmt.Errorf("batched mode requires %s mode", inter.DirectRequestMessageType)
 		}
 
@@ -140,6 +143,15 @@ func (o *RequestOptions) ConfigureMessage(msg inter.Message) (err error) {
 
 	msg.SetProtocolVersion(o.ProtocolVersion)
 
+	if o.Async {
+		expected := len(o.Targets)
+		if o.RequestType == inter.ServiceRequestMessageType {
+			expected = 1
+		}
+
+		o.ReplyTo = AsyncReplySubject(o.Collective, msg.CallerID(), msg.RequestID(), expected)
+	}
+
 	stdtarget := msg.ReplyTarget()
 	if o.ReplyTo == "" {
 		o.ReplyTo = stdtarget
@@ -168,8 +180,11 @@ func (o *RequestOptions) ConfigureMessage(msg inter.Message) (err error) {
 	// (TTL + DiscoveryTimeout + Timeout) * batches
 	//
 	// We have to allow TTL per batch since the last batch will get it much
-	if msg.IsCachedTransport() && o.BatchSize != len(o.Targets) {
+	if msg.IsCachedTransport() && o.batched() {
 		batches := int(math.Ceil(float64(len(o.Targets)) / float64(o.BatchSize)))
+		if o.Rollout != nil {
+			batches = len(o.Rollout.Plan(len(o.Targets), o.BatchSize))
+		}
 
 		msg.SetTTL(batches * (msg.TTL() + int(o.DiscoveryTimeout.Seconds()) + int(o.Timeout.Seconds())))
 		if msg.TTL() > int((5 * time.Hour).Seconds()) {
@@ -180,6 +195,11 @@ func (o *RequestOptions) ConfigureMessage(msg inter.Message) (err error) {
 	return nil
 }
 
+// batched determines if the request will be done in more than one batch
+func (o *RequestOptions) batched() bool {
+	return o.BatchSize != len(o.Targets) || o.Rollout != nil
+}
+
 // Stats retrieves the stats for the completed request
 func (o *RequestOptions) Stats() *Stats {
 	return o.totalStats
@@ -85,6 +85,19 @@ var _ = Describe("McoRPC/Client/Options", func() {
 			Expect(o.ProcessReplies).To(BeFalse())
 		})
 
+		It("Should support asynchronous replies", func() {
+			msg, err := message.NewMessage(nil, "test", "mcollective", "request", nil, fw)
+			Expect(err).ToNot(HaveOccurred())
+
+			Targets([]string{"host1", "host2"})(o)
+			AsyncReplies()(o)
+
+			o.ConfigureMessage(msg)
+
+			Expect(msg.ReplyTo()).To(Equal(AsyncReplySubject(o.Collective, msg.CallerID(), msg.RequestID(), 2)))
+			Expect(o.ProcessReplies).To(BeFalse())
+		})
+
 		It("Should support limiting targets", func() {
 			targets := make([]string, 100)
 			for i := 0; i < 100; i++ {
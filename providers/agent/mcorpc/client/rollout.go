@@ -0,0 +1,161 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/choria-io/go-choria/inter"
+)
+
+// RolloutPolicy controls batched requests, the first batch can be a small canary batch, later batches can
+// grow in size and the rollout is aborted when too many nodes in a batch fail
+type RolloutPolicy struct {
+	// CanarySize is the size of the first batch, 0 disables the canary batch
+	CanarySize int
+
+	// GrowthFactor multiplies the batch size after every batch following the canary, values <= 1 keep the size fixed
+	GrowthFactor float64
+
+	// MaxBatchSize limits the size batches can grow to, 0 means no limit
+	MaxBatchSize int
+
+	// MaxFailureRatio aborts the rollout after a batch where the ratio of failed nodes is above it,
+	// 0 aborts on any failure while 1 never aborts
+	MaxFailureRatio float64
+
+	// AbortExpr is an expression evaluated against every reply using the same semantics as ReplyExprFilter,
+	// nodes with matching replies count as failed
+	AbortExpr string
+
+	// Confirm is called before every batch after the first, returning false stops the rollout
+	Confirm RolloutConfirmFunc
+}
+
+// RolloutConfirmFunc is called before starting a batch with the stats of the request so far and the nodes
+// in the next batch, the rollout continues only when true is returned
+type RolloutConfirmFunc func(completed *Stats, next []string) (bool, error)
+
+// Rollout performs requests in batches according to a rollout policy, the size of batches following the canary
+// batch is set using InBatches, without it all remaining nodes are contacted in one batch
+func Rollout(p *RolloutPolicy) RequestOption {
+	return func(o *RequestOptions) {
+		o.Rollout = p
+		o.Workers = 1
+	}
+}
+
+// Validate checks the policy is usable
+func (p *RolloutPolicy) Validate() error {
+	if p.CanarySize < 0 {
+		return fmt.Errorf("canary size cannot be negative")
+	}
+
+	if p.MaxBatchSize < 0 {
+		return fmt.Errorf("maximum batch size cannot be negative")
+	}
+
+	if p.MaxFailureRatio < 0 || p.MaxFailureRatio > 1 {
+		return fmt.Errorf("maximum failure ratio should be between 0 and 1")
+	}
+
+	if p.AbortExpr != "" {
+		// only compile errors matter, the empty reply might not satisfy the expression
+		_, prog, err := (&RPCReply{}).MatchExpr(p.AbortExpr, nil)
+		if prog == nil {
+			return fmt.Errorf("invalid abort expression: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// Plan calculates the sizes of batches used to reach total nodes when the batch size is size
+func (p *RolloutPolicy) Plan(total int, size int) []int {
+	var plan []int
+
+	if size <= 0 {
+		size = max(p.CanarySize, 1)
+	}
+
+	if p.CanarySize > 0 && total > 0 {
+		plan = append(plan, min(p.CanarySize, total))
+		total -= plan[0]
+	}
+
+	for total > 0 {
+		if p.MaxBatchSize > 0 {
+			size = min(size, p.MaxBatchSize)
+		}
+
+		plan = append(plan, min(size, total))
+		total -= min(size, total)
+
+		if p.GrowthFactor > 1 {
+			size = int(math.Ceil(float64(size) * p.GrowthFactor))
+		}
+	}
+
+	return plan
+}
+
+func (r *RPC) doRolloutRequest(ctx context.Context, msg inter.Message, cl ChoriaClient) error {
+	policy := r.opts.Rollout
+	remaining := r.opts.Targets
+
+	for i, size := range policy.Plan(len(r.opts.Targets), r.opts.BatchSize) {
+		nodes := remaining[:size]
+
+		if i > 0 {
+			err := InterruptableSleep(ctx, r.opts.BatchSleep)
+			if err != nil {
+				r.opts.totalStats.setUntouched(remaining, "%s", err)
+				return err
+			}
+
+			if policy.Confirm != nil {
+				ok, err := policy.Confirm(r.opts.totalStats, nodes)
+				if err != nil {
+					r.opts.totalStats.setUntouched(remaining, "%s", err)
+					return err
+				}
+
+				if !ok {
+					r.opts.totalStats.setUntouched(remaining, "rollout stopped before batch %d", i+1)
+					return nil
+				}
+			}
+		}
+
+		remaining = remaining[size:]
+
+		stats := NewStats()
+		stats.SetDiscoveredNodes(nodes)
+		msg.SetDiscoveredHosts(nodes)
+
+		r.log.Debugf("Performing rollout batch %d for %d/%d nodes", i+1, len(nodes), len(r.opts.Targets))
+
+		stats.Start()
+		err := r.request(ctx, msg, cl, stats)
+		stats.End()
+		r.opts.totalStats.Merge(stats)
+		if err != nil {
+			r.opts.totalStats.setUntouched(remaining, "%s", err)
+			return err
+		}
+
+		failed := stats.rolloutFailures()
+		ratio := float64(failed) / float64(len(nodes))
+		if ratio > policy.MaxFailureRatio && len(remaining) > 0 {
+			r.opts.totalStats.setUntouched(remaining, "rollout aborted after batch %d: %d of %d nodes failed", i+1, failed, len(nodes))
+			r.log.Warnf("Aborting rollout after batch %d with %d of %d failed nodes, %d nodes were not contacted", i+1, failed, len(nodes), len(remaining))
+			return nil
+		}
+	}
+
+	return nil
+}
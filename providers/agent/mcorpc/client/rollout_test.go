@@ -0,0 +1,122 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package client
+
+import (
+	"context"
+	"strings"
+
+	"github.com/choria-io/go-choria/client/client"
+	"github.com/choria-io/go-choria/inter"
+	imock "github.com/choria-io/go-choria/inter/imocks"
+	"github.com/choria-io/go-choria/message"
+	"github.com/choria-io/go-choria/protocol"
+	"go.uber.org/mock/gomock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rollout", func() {
+	var (
+		fw      *imock.MockFramework
+		rpc     *RPC
+		mockctl *gomock.Controller
+		cl      *MockChoriaClient
+		batches [][]string
+		targets []string
+	)
+
+	BeforeEach(func() {
+		mockctl = gomock.NewController(GinkgoT())
+		cl = NewMockChoriaClient(mockctl)
+
+		fw, _ = imock.NewFrameworkForTests(mockctl, GinkgoWriter, imock.WithCallerID(), imock.WithDDLFiles("agent", "package", "testdata/mcollective/agent/package.json"))
+		fw.EXPECT().NewMessage(gomock.Any(), gomock.Eq("package"), gomock.Eq("ginkgo"), gomock.Eq(inter.RequestMessageType), gomock.Eq(nil)).DoAndReturn(func(payload []byte, agent string, collective string, msgType string, request inter.Message) (msg inter.Message, err error) {
+			return message.NewMessage(payload, agent, collective, msgType, request, fw)
+		}).AnyTimes()
+		fw.Configuration().LibDir = []string{"testdata"}
+
+		protocol.Secure = "false"
+
+		var err error
+		rpc, err = New(fw, "package")
+		Expect(err).ToNot(HaveOccurred())
+		rpc.cl = cl
+
+		batches = nil
+		targets = strings.Fields("host1 host2 host3 host4 host5 host6")
+
+		// no node replies so every node in a batch counts as failed
+		cl.EXPECT().Request(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Do(func(_ context.Context, msg inter.Message, _ client.Handler) {
+			batches = append(batches, msg.DiscoveredHosts())
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockctl.Finish()
+	})
+
+	DescribeTable("Plan",
+		func(policy RolloutPolicy, total int, size int, expected []int) {
+			Expect(policy.Plan(total, size)).To(Equal(expected))
+		},
+		Entry("fixed batches", RolloutPolicy{}, 5, 2, []int{2, 2, 1}),
+		Entry("canary", RolloutPolicy{CanarySize: 1}, 5, 2, []int{1, 2, 2}),
+		Entry("canary without size", RolloutPolicy{CanarySize: 2}, 5, 0, []int{2, 2, 1}),
+		Entry("large canary", RolloutPolicy{CanarySize: 10}, 5, 2, []int{5}),
+		Entry("growth", RolloutPolicy{CanarySize: 1, GrowthFactor: 2}, 20, 2, []int{1, 2, 4, 8, 5}),
+		Entry("capped growth", RolloutPolicy{GrowthFactor: 3, MaxBatchSize: 5}, 20, 2, []int{2, 5, 5, 5, 3}),
+	)
+
+	It("Should validate the policy", func() {
+		Expect((&RolloutPolicy{CanarySize: -1}).Validate()).To(MatchError("canary size cannot be negative"))
+		Expect((&RolloutPolicy{MaxFailureRatio: 1.5}).Validate()).To(MatchError("maximum failure ratio should be between 0 and 1"))
+		Expect((&RolloutPolicy{AbortExpr: "code =="}).Validate()).To(MatchError(ContainSubstring("invalid abort expression")))
+		Expect((&RolloutPolicy{AbortExpr: "!ok() && data('x') > 1", MaxFailureRatio: 0.5}).Validate()).To(Succeed())
+	})
+
+	It("Should perform growing batches", func(ctx context.Context) {
+		res, err := rpc.Do(ctx, "test_action", nil, Targets(targets), InBatches(1, -1), Rollout(&RolloutPolicy{CanarySize: 1, GrowthFactor: 2, MaxFailureRatio: 1}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batches).To(Equal([][]string{{"host1"}, {"host2"}, {"host3", "host4"}, {"host5", "host6"}}))
+		Expect(res.Stats().UntouchedNodes()).To(BeEmpty())
+		Expect(res.Stats().StopReason()).To(BeEmpty())
+	})
+
+	It("Should abort when the failure ratio is crossed", func(ctx context.Context) {
+		res, err := rpc.Do(ctx, "test_action", nil, Targets(targets), InBatches(2, -1), Rollout(&RolloutPolicy{CanarySize: 1, MaxFailureRatio: 0.5}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(batches).To(Equal([][]string{{"host1"}}))
+
+		stats := res.Stats()
+		Expect(stats.UntouchedNodes()).To(Equal(targets[1:]))
+		Expect(stats.StopReason()).To(Equal("rollout aborted after batch 1: 1 of 1 nodes failed"))
+		Expect(*stats.DiscoveredNodes()).To(Equal([]string{"host1"}))
+		Expect(stats.NoResponseFrom()).To(Equal([]string{"host1"}))
+	})
+
+	It("Should stop when the operator declines the next batch", func(ctx context.Context) {
+		confirmed := 0
+		confirm := func(completed *Stats, next []string) (bool, error) {
+			confirmed++
+			Expect(completed.ResponsesCount()).To(Equal(0))
+			Expect(next).To(Equal([]string{"host3", "host4"}))
+			return false, nil
+		}
+
+		res, err := rpc.Do(ctx, "test_action", nil, Targets(targets), InBatches(2, -1), Rollout(&RolloutPolicy{MaxFailureRatio: 1, Confirm: confirm}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(confirmed).To(Equal(1))
+		Expect(batches).To(Equal([][]string{{"host1", "host2"}}))
+		Expect(res.Stats().UntouchedNodes()).To(Equal(targets[2:]))
+		Expect(res.Stats().StopReason()).To(Equal("rollout stopped before batch 2"))
+	})
+
+	It("Should require direct requests", func(ctx context.Context) {
+		_, err := rpc.Do(ctx, "test_action", nil, Targets(targets), BroadcastRequest(), Rollout(&RolloutPolicy{}))
+		Expect(err).To(MatchError(ContainSubstring("batched mode requires direct_request mode")))
+	})
+})
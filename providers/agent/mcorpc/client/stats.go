@@ -19,6 +19,10 @@ type Stats struct {
 
 	outstandingNodes   *NodeList
 	unexpectedRespones *NodeList
+	rolloutFailed      *NodeList
+
+	untouchedNodes []string
+	stopReason     string
 
 	responses int32
 	passed    int32
@@ -47,6 +51,7 @@ func NewStats() *Stats {
 		discoveredNodes:    []string{},
 		outstandingNodes:   NewNodeList(),
 		unexpectedRespones: NewNodeList(),
+		rolloutFailed:      NewNodeList(),
 		mu:                 &sync.Mutex{},
 	}
 }
@@ -159,6 +164,65 @@ func (s *Stats) RecordReceived(sender string) {
 	}
 }
 
+// UntouchedNodes are the nodes that were not contacted because a rollout stopped early
+func (s *Stats) UntouchedNodes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.untouchedNodes
+}
+
+// StopReason is the reason a rollout stopped before contacting all nodes, empty when it completed
+func (s *Stats) StopReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stopReason
+}
+
+// setUntouched records nodes that will not be contacted, they are removed from the discovered nodes
+func (s *Stats) setUntouched(nodes []string, format string, a ...any) {
+	if len(nodes) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.untouchedNodes = append([]string{}, nodes...)
+	s.stopReason = fmt.Sprintf(format, a...)
+
+	untouched := NewNodeList()
+	untouched.AddHosts(nodes...)
+
+	var discovered []string
+	for _, n := range s.discoveredNodes {
+		if untouched.Have(n) {
+			s.outstandingNodes.DeleteIfKnown(n)
+		} else {
+			discovered = append(discovered, n)
+		}
+	}
+	s.discoveredNodes = discovered
+}
+
+// recordRolloutFailure records that sender failed for the purpose of rollout failure thresholds
+func (s *Stats) recordRolloutFailure(sender string) {
+	s.rolloutFailed.AddHosts(sender)
+}
+
+// rolloutFailures counts the nodes that failed or did not respond
+func (s *Stats) rolloutFailures() int {
+	failed := s.rolloutFailed.Count()
+	for _, n := range s.NoResponseFrom() {
+		if !s.rolloutFailed.Have(n) {
+			failed++
+		}
+	}
+
+	return failed
+}
+
 // DiscoveredCount is how many nodes were discovered
 func (s *Stats) DiscoveredCount() int {
 	return len(s.discoveredNodes)
@@ -273,6 +273,7 @@ func (p *Provider) executeRequest(ctx context.Context, command string, protocol
 	}
 
 	execution := exec.CommandContext(ctx, command, reqfile.Name(), repfile.Name(), rpcRequestProtocol)
+	util.TerminateOnCancel(execution, 5*time.Second)
 	execution.Dir = os.TempDir()
 	execution.Env = []string{
 		"CHORIA_EXTERNAL_REQUEST=" + reqfile.Name(),
@@ -29,9 +29,11 @@ func New(mgr server.AgentManager) (*mcorpc.Agent, error) {
 		return mgr.Choria().Configuration().Choria.ExecutorEnabled
 	})
 
+	agent.MustRegisterAction("start", startAction)
 	agent.MustRegisterAction("status", statusAction)
 	agent.MustRegisterAction("signal", signalAction)
 	agent.MustRegisterAction("list", listAction)
+	agent.MustRegisterAction("purge", purgeAction)
 
 	return agent, nil
 }
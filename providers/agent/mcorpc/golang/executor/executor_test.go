@@ -0,0 +1,125 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/choria-io/go-choria/choria"
+	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc"
+	"github.com/choria-io/go-choria/providers/execution"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Executor/Agent")
+}
+
+var _ = Describe("Executor/Agent", func() {
+	var (
+		cfg   *config.Config
+		agent *mcorpc.Agent
+		reply *mcorpc.Reply
+		req   *mcorpc.Request
+		ctx   context.Context
+	)
+
+	BeforeEach(func() {
+		cfg = config.NewConfigForTests()
+		cfg.DisableTLS = true
+		cfg.ConfigFile = "/etc/choria/server.conf"
+		cfg.Identity = "ginkgo.example.net"
+		cfg.Choria.ExecutorSpool = GinkgoT().TempDir()
+		cfg.Choria.SubmissionSpool = GinkgoT().TempDir()
+
+		fw, err := choria.NewWithConfig(cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := logrus.New()
+		logger.SetOutput(GinkgoWriter)
+
+		agent = mcorpc.New("executor", metadata, fw, logrus.NewEntry(logger))
+		reply = &mcorpc.Reply{}
+		req = &mcorpc.Request{
+			Agent:     "executor",
+			Action:    "start",
+			CallerID:  "choria=ginkgo.mcollective",
+			RequestID: "4e5f7b8c2a1d4e6f9a0b1c2d3e4f5a6b",
+			Data:      []byte(`{"command":"/bin/sleep","args":["10"],"heartbeat":10,"track_output":true}`),
+		}
+		ctx = context.Background()
+
+		DeferCleanup(func() { startSupervisor = (*execution.Process).StartSupervisor })
+	})
+
+	Describe("startAction", func() {
+		It("Should require the spools", func() {
+			cfg.Choria.ExecutorSpool = ""
+			startAction(ctx, req, reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("Executor spool is not configured"))
+
+			cfg.Choria.ExecutorSpool = GinkgoT().TempDir()
+			cfg.Choria.SubmissionSpool = ""
+			reply = &mcorpc.Reply{}
+			startAction(ctx, req, reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("Submission spool is not configured"))
+		})
+
+		It("Should validate the request", func() {
+			req.Data = []byte(`{"args":["10"]}`)
+			startAction(ctx, req, reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("Command is required"))
+
+			req.Data = []byte(`{"command":"/bin/sleep","heartbeat":-1}`)
+			reply = &mcorpc.Reply{}
+			startAction(ctx, req, reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("Heartbeat interval can not be negative"))
+		})
+
+		It("Should create the job and start its supervisor", func() {
+			var started *execution.Process
+			startSupervisor = func(p *execution.Process, configFile string, heartbeat time.Duration, publishOutput bool, log *logrus.Entry) error {
+				Expect(configFile).To(Equal("/etc/choria/server.conf"))
+				Expect(heartbeat).To(Equal(10 * time.Second))
+				Expect(publishOutput).To(BeTrue())
+				started = p
+				return nil
+			}
+
+			startAction(ctx, req, reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.OK))
+			Expect(started).ToNot(BeNil())
+			Expect(reply.Data).To(Equal(&StartResponse{ID: started.ID}))
+
+			job, err := execution.Load(cfg.Choria.ExecutorSpool, started.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.Command).To(Equal("/bin/sleep"))
+			Expect(job.Args).To(Equal([]string{"10"}))
+			Expect(job.Caller).To(Equal("choria=ginkgo.mcollective"))
+			Expect(job.RequestID).To(Equal(req.RequestID))
+		})
+
+		It("Should fail when the supervisor could not be started", func() {
+			startSupervisor = func(p *execution.Process, configFile string, heartbeat time.Duration, publishOutput bool, log *logrus.Entry) error {
+				return errors.New("simulated failure")
+			}
+
+			startAction(ctx, req, reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("Could not start job: simulated failure"))
+		})
+	})
+})
@@ -26,6 +26,29 @@ type ListRequest struct {
 	Since     int64  `json:"since"`
 }
 
+// Query creates a job list query from the request, unset timestamps are not matched on
+func (r *ListRequest) Query() *execution.ListQuery {
+	q := &execution.ListQuery{
+		Action:    r.Action,
+		Agent:     r.Agent,
+		Caller:    r.Caller,
+		Command:   r.Command,
+		Completed: r.Completed,
+		Identity:  r.Identity,
+		RequestID: r.RequestID,
+		Running:   r.Running,
+	}
+
+	if r.Before > 0 {
+		q.Before = time.Unix(r.Before, 0)
+	}
+	if r.Since > 0 {
+		q.Since = time.Unix(r.Since, 0)
+	}
+
+	return q
+}
+
 type ListMatched struct {
 	Action        string    `json:"action"`
 	Agent         string    `json:"agent"`
@@ -54,18 +77,7 @@ func listAction(ctx context.Context, req *mcorpc.Request, reply *mcorpc.Reply, a
 
 	resp := &ListResponse{}
 
-	matched, err := execution.List(spool, &execution.ListQuery{
-		Action:    args.Action,
-		Agent:     args.Agent,
-		Before:    time.Unix(args.Before, 0),
-		Caller:    args.Caller,
-		Command:   args.Command,
-		Completed: args.Completed,
-		Identity:  args.Identity,
-		RequestID: args.RequestID,
-		Running:   args.Running,
-		Since:     time.Unix(args.Since, 0),
-	})
+	matched, err := execution.List(spool, args.Query())
 	if err != nil {
 		abort(reply, "Could not list jobs: %v", err)
 		return
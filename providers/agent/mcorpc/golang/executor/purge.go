@@ -0,0 +1,63 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package executor
+
+import (
+	"context"
+
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc"
+	"github.com/choria-io/go-choria/providers/execution"
+)
+
+type PurgeRequest struct {
+	ListRequest
+}
+
+type PurgeResponse struct {
+	Purged  []string `json:"purged"`
+	Skipped int      `json:"skipped"`
+}
+
+func purgeAction(ctx context.Context, req *mcorpc.Request, reply *mcorpc.Reply, agent *mcorpc.Agent, conn inter.ConnectorInfo) {
+	if agent.Config.Choria.ExecutorSpool == "" {
+		abort(reply, "Executor spool is not configured")
+		return
+	}
+
+	args := &PurgeRequest{}
+	if !mcorpc.ParseRequestData(args, req, reply) {
+		return
+	}
+
+	if args.Running {
+		abort(reply, "Running jobs can not be purged")
+		return
+	}
+
+	res, err := execution.PurgeWithChoria(agent.Choria, args.Query(), func(job *execution.Process) bool {
+		if !proxyAuthorize(job, req, agent) {
+			agent.Log.Warnf("Denying %s access to purge process created by %s#%s based on authorization policy for request %s", req.CallerID, job.Agent, job.Action, req.RequestID)
+			return false
+		}
+
+		return true
+	})
+	if err != nil {
+		abort(reply, "Could not list jobs: %v", err)
+		return
+	}
+
+	for _, err := range res.Errors {
+		agent.Log.Errorf("Purge failed: %v", err)
+	}
+
+	resp := &PurgeResponse{Purged: []string{}, Skipped: len(res.Skipped)}
+	for _, job := range res.Purged {
+		resp.Purged = append(resp.Purged, job.ID)
+	}
+
+	reply.Data = resp
+}
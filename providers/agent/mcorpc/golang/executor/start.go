@@ -0,0 +1,85 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc"
+	"github.com/choria-io/go-choria/providers/execution"
+)
+
+type StartRequest struct {
+	Command     string            `json:"command"`
+	Args        []string          `json:"args"`
+	Environment map[string]string `json:"environment"`
+	Heartbeat   int               `json:"heartbeat"`
+	TrackOutput bool              `json:"track_output"`
+}
+
+type StartResponse struct {
+	ID string `json:"id"`
+}
+
+// startSupervisor starts the supervisor for a process
+var startSupervisor = (*execution.Process).StartSupervisor
+
+func startAction(ctx context.Context, req *mcorpc.Request, reply *mcorpc.Reply, agent *mcorpc.Agent, conn inter.ConnectorInfo) {
+	spool := agent.Config.Choria.ExecutorSpool
+	if spool == "" {
+		abort(reply, "Executor spool is not configured")
+		return
+	}
+
+	if agent.Config.Choria.SubmissionSpool == "" {
+		abort(reply, "Submission spool is not configured")
+		return
+	}
+
+	args := &StartRequest{}
+	if !mcorpc.ParseRequestData(args, req, reply) {
+		return
+	}
+
+	if args.Command == "" {
+		abort(reply, "Command is required")
+		return
+	}
+
+	if args.Heartbeat < 0 {
+		abort(reply, "Heartbeat interval can not be negative")
+		return
+	}
+
+	id, err := jobID()
+	if err != nil {
+		abort(reply, "Could not create job ID: %v", err)
+		return
+	}
+
+	p, err := execution.New(req.CallerID, req.Agent, req.Action, req.RequestID, agent.Config.Identity, id, args.Command, args.Args, args.Environment)
+	if err != nil {
+		abort(reply, "Could not create job: %v", err)
+		return
+	}
+
+	_, err = p.CreateSpool(spool)
+	if err != nil {
+		abort(reply, "Could not create job: %v", err)
+		return
+	}
+
+	err = startSupervisor(p, agent.Config.ConfigFile, time.Duration(args.Heartbeat)*time.Second, args.TrackOutput, agent.Log)
+	if err != nil {
+		abort(reply, "Could not start job: %v", err)
+		return
+	}
+
+	agent.Log.Infof("Started job %s running %s for %s in request %s", p.ID, p.Command, req.CallerID, req.RequestID)
+
+	reply.Data = &StartResponse{ID: p.ID}
+}
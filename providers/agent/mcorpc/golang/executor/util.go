@@ -5,6 +5,8 @@
 package executor
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -34,3 +36,14 @@ func proxyAuthorize(p *execution.Process, req *mcorpc.Request, agent *mcorpc.Age
 
 	return mcorpc.AuthorizeRequest(agent.Choria, processRequest, agent.Config, agent.ServerInfoSource, agent.Log)
 }
+
+// jobID creates a random job id that fits within the DDL limits for job ids
+func jobID() (string, error) {
+	b := make([]byte, 10)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -5,8 +5,11 @@
 package mcorpc
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/choria-io/go-choria/build"
@@ -172,6 +175,58 @@ var _ = Describe("McoRPC", func() {
 			Expect(gjson.GetBytes(reply.Body, "statuscode").Int()).To(Equal(int64(1)))
 
 		})
+
+		It("Should audit the outcome of requests", func() {
+			cfg.RPCAudit = true
+			cfg.RPCAuthorization = true
+			cfg.Choria.RPCAuditLogfile = filepath.Join(GinkgoT().TempDir(), "audit.log")
+			msg.SetPayload([]byte(`{"agent":"test", "action":"test"}`))
+
+			agent.RegisterAction("test", func(ctx context.Context, req *Request, reply *Reply, agent *Agent, conn inter.ConnectorInfo) {})
+			agent.HandleMessage(ctx, msg, req, nil, outbox)
+			<-outbox
+
+			j, err := os.ReadFile(cfg.Choria.RPCAuditLogfile)
+			Expect(err).ToNot(HaveOccurred())
+			lines := bytes.Split(bytes.TrimSpace(j), []byte("\n"))
+			Expect(lines).To(HaveLen(1))
+
+			j = lines[0]
+			Expect(gjson.GetBytes(j, "request_id").String()).To(Equal("testrequest"))
+			Expect(gjson.GetBytes(j, "action").String()).To(Equal("test"))
+			Expect(gjson.GetBytes(j, "authorized").Exists()).To(BeTrue())
+			Expect(gjson.GetBytes(j, "authorized").Bool()).To(BeFalse())
+			Expect(gjson.GetBytes(j, "statuscode").Int()).To(Equal(int64(1)))
+			Expect(gjson.GetBytes(j, "statusmsg").String()).To(Equal("You are not authorized to call this agent or action"))
+			Expect(gjson.GetBytes(j, "phase").Exists()).To(BeFalse())
+		})
+
+		It("Should audit received requests when enabled", func() {
+			cfg.RPCAudit = true
+			cfg.RPCAuthorization = true
+			cfg.Choria.RPCAuditReceived = true
+			cfg.Choria.RPCAuditLogfile = filepath.Join(GinkgoT().TempDir(), "audit.log")
+			msg.SetPayload([]byte(`{"agent":"test", "action":"test"}`))
+
+			agent.RegisterAction("test", func(ctx context.Context, req *Request, reply *Reply, agent *Agent, conn inter.ConnectorInfo) {})
+			agent.HandleMessage(ctx, msg, req, nil, outbox)
+			<-outbox
+
+			j, err := os.ReadFile(cfg.Choria.RPCAuditLogfile)
+			Expect(err).ToNot(HaveOccurred())
+			lines := bytes.Split(bytes.TrimSpace(j), []byte("\n"))
+			Expect(lines).To(HaveLen(2))
+
+			// the request is recorded before authorization without an outcome
+			Expect(gjson.GetBytes(lines[0], "request_id").String()).To(Equal("testrequest"))
+			Expect(gjson.GetBytes(lines[0], "phase").String()).To(Equal("received"))
+			Expect(gjson.GetBytes(lines[0], "authorized").Exists()).To(BeFalse())
+			Expect(gjson.GetBytes(lines[0], "statuscode").Exists()).To(BeFalse())
+
+			Expect(gjson.GetBytes(lines[1], "request_id").String()).To(Equal("testrequest"))
+			Expect(gjson.GetBytes(lines[1], "phase").String()).To(Equal("completed"))
+			Expect(gjson.GetBytes(lines[1], "statuscode").Int()).To(Equal(int64(1)))
+		})
 	})
 
 	Describe("publish", func() {
@@ -122,6 +122,7 @@ func rubyAction(ctx context.Context, req *mcorpc.Request, reply *mcorpc.Reply, a
 	defer cancel()
 
 	execution := exec.CommandContext(tctx, agent.Config.Choria.RubyAgentShim, "--config", shimcfg)
+	util.TerminateOnCancel(execution, 5*time.Second)
 
 	stdin, err := execution.StdinPipe()
 	if err != nil {
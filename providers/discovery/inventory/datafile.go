@@ -83,6 +83,14 @@ type Node struct {
 	Facts       json.RawMessage `json:"facts" yaml:"facts"`
 	Classes     []string        `json:"classes" yaml:"classes"`
 	Agents      []string        `json:"agents" yaml:"agents"`
+	Machines    []MachineState  `json:"machines,omitempty" yaml:"machines,omitempty"`
+}
+
+// MachineState is the state of an autonomous agent running on a node
+type MachineState struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+	State   string `json:"state" yaml:"state"`
 }
 
 // LookupGroup finds a group by name
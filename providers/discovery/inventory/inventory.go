@@ -21,6 +21,7 @@ import (
 	"github.com/choria-io/go-choria/filter/compound"
 	"github.com/choria-io/go-choria/internal/util"
 	"github.com/choria-io/go-choria/protocol"
+	"github.com/choria-io/go-choria/providers/data/ddl"
 )
 
 type Inventory struct {
@@ -147,6 +148,11 @@ func (i *Inventory) isValidGroupLookup(f *protocol.Filter) (grouped bool, err er
 }
 
 func (i *Inventory) selectMatchingNodes(ctx context.Context, d *DataFile, collective string, f *protocol.Filter) ([]string, error) {
+	return SelectMatchingNodes(ctx, d.Nodes, collective, f, i.log)
+}
+
+// SelectMatchingNodes finds the names of nodes in a collective matching filter f, all collectives are searched when collective is empty
+func SelectMatchingNodes(ctx context.Context, nodes []Node, collective string, f *protocol.Filter, log *logrus.Entry) ([]string, error) {
 	var (
 		matched []string
 		query   string
@@ -156,13 +162,13 @@ func (i *Inventory) selectMatchingNodes(ctx context.Context, d *DataFile, collec
 
 	if len(f.CompoundFilters()) > 0 {
 		query = f.CompoundFilters()[0][0]["expr"]
-		prog, err = compound.CompileExprQuery(query, nil)
+		prog, err = compound.CompileExprQuery(query, nodeDataFuncs(Node{}))
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	for _, node := range d.Nodes {
+	for _, node := range nodes {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
@@ -195,7 +201,7 @@ func (i *Inventory) selectMatchingNodes(ctx context.Context, d *DataFile, collec
 		}
 
 		if len(f.ClassFilters()) > 0 {
-			if f.MatchClasses(node.Classes, i.log) {
+			if f.MatchClasses(node.Classes, log) {
 				passed++
 			} else {
 				continue
@@ -203,7 +209,7 @@ func (i *Inventory) selectMatchingNodes(ctx context.Context, d *DataFile, collec
 		}
 
 		if len(f.FactFilters()) > 0 {
-			if f.MatchFacts(node.Facts, i.log) {
+			if f.MatchFacts(node.Facts, log) {
 				passed++
 			} else {
 				continue
@@ -211,7 +217,7 @@ func (i *Inventory) selectMatchingNodes(ctx context.Context, d *DataFile, collec
 		}
 
 		if len(f.CompoundFilters()) > 0 {
-			b, _ := compound.MatchExprProgram(prog, node.Facts, node.Classes, node.Agents, nil, i.log)
+			b, _ := compound.MatchExprProgram(prog, node.Facts, node.Classes, node.Agents, nodeDataFuncs(node), log)
 			if b {
 				passed++
 			} else {
@@ -227,6 +233,31 @@ func (i *Inventory) selectMatchingNodes(ctx context.Context, d *DataFile, collec
 	return matched, nil
 }
 
+// nodeDataFuncs exposes the choria() data function to compound filters using the node inventory
+// so that expressions like "nats" in choria().machines that work on servers can also be used against inventories
+func nodeDataFuncs(node Node) ddl.FuncMap {
+	machines := []string{}
+	for _, m := range node.Machines {
+		machines = append(machines, m.Name)
+	}
+
+	return ddl.FuncMap{
+		"choria": ddl.FuncMapEntry{
+			Name: "choria",
+			F: func() map[string]any {
+				return map[string]any{
+					"agents":         node.Agents,
+					"agents_count":   len(node.Agents),
+					"classes":        node.Classes,
+					"classes_count":  len(node.Classes),
+					"machines":       machines,
+					"machines_count": len(machines),
+				}
+			},
+		},
+	}
+}
+
 // ReadInventory reads and validates an inventory file
 func ReadInventory(path string, noValidate bool) (*DataFile, error) {
 	var err error
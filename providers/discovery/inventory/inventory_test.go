@@ -137,5 +137,14 @@ var _ = Describe("Inventory", func() {
 			Expect(err).To(Not(HaveOccurred()))
 			Expect(nodes).To(Equal([]string{"dev1.example.net"}))
 		})
+
+		It("Should expose machines to compound filters", func() {
+			filter := protocol.NewFilter()
+			err := filter.AddCompoundFilter(`"nats" in choria().machines`)
+			Expect(err).To(Not(HaveOccurred()))
+			nodes, err := inv.Discover(context.Background(), Collective("mcollective"), Filter(filter))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(nodes).To(Equal([]string{"dev1.example.net"}))
+		})
 	})
 })
@@ -0,0 +1,70 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"time"
+
+	"github.com/choria-io/go-choria/protocol"
+)
+
+type dOpts struct {
+	filter     *protocol.Filter
+	collective string
+	do         map[string]string
+	bucket     string
+	timeout    time.Duration
+	maxAge     time.Duration
+}
+
+// DiscoverOption configures the registry discovery method
+type DiscoverOption func(o *dOpts)
+
+// Filter sets the filter to use for the discovery, else a blank one is used
+func Filter(f *protocol.Filter) DiscoverOption {
+	return func(o *dOpts) {
+		o.filter = f
+	}
+}
+
+// Collective sets the collective to discover in, else main collective is used
+func Collective(c string) DiscoverOption {
+	return func(o *dOpts) {
+		o.collective = c
+	}
+}
+
+// Timeout sets how long to wait for the registry to be read
+func Timeout(t time.Duration) DiscoverOption {
+	return func(o *dOpts) {
+		o.timeout = t
+	}
+}
+
+// Bucket sets the Key-Value bucket to read nodes from
+func Bucket(b string) DiscoverOption {
+	return func(o *dOpts) {
+		o.bucket = b
+	}
+}
+
+// MaxAge ignores nodes that did not register within the duration
+func MaxAge(d time.Duration) DiscoverOption {
+	return func(o *dOpts) {
+		o.maxAge = d
+	}
+}
+
+// DiscoveryOptions sets the key value pairs that make user supplied discovery options.
+//
+// Supported options:
+//
+//	bucket - the Key-Value bucket to read nodes from
+//	max_age - ignore nodes that did not register within this duration
+func DiscoveryOptions(opt map[string]string) DiscoverOption {
+	return func(o *dOpts) {
+		o.do = opt
+	}
+}
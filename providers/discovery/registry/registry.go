@@ -0,0 +1,182 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/protocol"
+	"github.com/choria-io/go-choria/providers/discovery/inventory"
+	"github.com/choria-io/go-choria/providers/kv"
+)
+
+// DefaultBucket is the Key-Value bucket node inventories are stored in by default
+const DefaultBucket = "CHORIA_REGISTRY"
+
+// Node is the inventory of a single node as stored in the registry
+type Node struct {
+	Identity    string          `json:"identity"`
+	Collectives []string        `json:"collectives"`
+	Facts       json.RawMessage `json:"facts"`
+	Classes     []string        `json:"classes"`
+	Agents      []string        `json:"agents"`
+	Machines    []MachineState  `json:"machines,omitempty"`
+	Version     string          `json:"version,omitempty"`
+	Updated     time.Time       `json:"updated"`
+}
+
+// MachineState is the state of an autonomous agent running on a node
+type MachineState struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	State   string `json:"state"`
+}
+
+// Registry discovers nodes using inventories stored in Choria Streams by the registry adapter
+type Registry struct {
+	fw      inter.Framework
+	timeout time.Duration
+	log     *logrus.Entry
+}
+
+// New creates a new registry discovery client
+func New(fw inter.Framework) *Registry {
+	return &Registry{
+		fw:      fw,
+		timeout: time.Second * time.Duration(fw.Configuration().DiscoveryTimeout),
+		log:     fw.Logger("registry_discovery"),
+	}
+}
+
+// Discover finds nodes in the registry matching the supplied filter
+func (r *Registry) Discover(ctx context.Context, opts ...DiscoverOption) (n []string, err error) {
+	cfg := r.fw.Configuration()
+
+	dopts := &dOpts{
+		collective: cfg.MainCollective,
+		filter:     protocol.NewFilter(),
+		bucket:     cfg.Choria.RegistryDiscoveryBucket,
+		timeout:    r.timeout,
+		do:         make(map[string]string),
+	}
+
+	for _, opt := range opts {
+		opt(dopts)
+	}
+
+	bucket, ok := dopts.do["bucket"]
+	if ok {
+		dopts.bucket = bucket
+	}
+
+	age, ok := dopts.do["max_age"]
+	if ok {
+		dopts.maxAge, err = util.ParseDuration(age)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_age: %w", err)
+		}
+	}
+
+	if dopts.bucket == "" {
+		dopts.bucket = DefaultBucket
+	}
+
+	if dopts.timeout == 0 {
+		dopts.timeout = 2 * time.Second
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, dopts.timeout)
+	defer cancel()
+
+	conn, err := r.fw.NewConnector(tctx, r.fw.MiddlewareServers, fmt.Sprintf("registry discovery %s", util.UniqueID()), r.log)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	bkt, err := kv.NewKV(conn.Nats(), dopts.bucket, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.discover(tctx, bkt, dopts)
+}
+
+func (r *Registry) discover(ctx context.Context, bkt nats.KeyValue, dopts *dOpts) ([]string, error) {
+	nodes, err := Nodes(ctx, bkt, r.log)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []inventory.Node
+	for _, node := range nodes {
+		if dopts.maxAge > 0 && time.Since(node.Updated) > dopts.maxAge {
+			r.log.Debugf("Skipping %s that last registered at %v", node.Identity, node.Updated)
+			continue
+		}
+
+		candidate := inventory.Node{
+			Name:        node.Identity,
+			Collectives: node.Collectives,
+			Facts:       node.Facts,
+			Classes:     node.Classes,
+			Agents:      node.Agents,
+		}
+
+		for _, m := range node.Machines {
+			candidate.Machines = append(candidate.Machines, inventory.MachineState{Name: m.Name, Version: m.Version, State: m.State})
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	return inventory.SelectMatchingNodes(ctx, candidates, dopts.collective, dopts.filter, r.log)
+}
+
+// Nodes reads all node inventories from a registry bucket sorted by identity, invalid entries are skipped
+func Nodes(ctx context.Context, bkt nats.KeyValue, log *logrus.Entry) ([]*Node, error) {
+	w, err := bkt.WatchAll(nats.IgnoreDeletes(), nats.Context(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+
+	var nodes []*Node
+
+	for {
+		select {
+		case entry := <-w.Updates():
+			// nil indicates all current values were received
+			if entry == nil {
+				sort.Slice(nodes, func(i, j int) bool {
+					return nodes[i].Identity < nodes[j].Identity
+				})
+
+				return nodes, nil
+			}
+
+			node := &Node{}
+			err = json.Unmarshal(entry.Value(), node)
+			if err != nil {
+				log.Warnf("Skipping invalid registry entry %s: %v", entry.Key(), err)
+				continue
+			}
+
+			nodes = append(nodes, node)
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	imock "github.com/choria-io/go-choria/inter/imocks"
+	"github.com/choria-io/go-choria/protocol"
+	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRegistry(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Providers/Discovery/Registry")
+}
+
+var _ = Describe("Registry", func() {
+	var (
+		mockctl *gomock.Controller
+		reg     *Registry
+		bkt     nats.KeyValue
+		ctx     context.Context
+	)
+
+	BeforeEach(func() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		DeferCleanup(cancel)
+
+		mockctl = gomock.NewController(GinkgoT())
+		fw, _ := imock.NewFrameworkForTests(mockctl, GinkgoWriter)
+		reg = New(fw)
+
+		srv, err := server.NewServer(&server.Options{
+			JetStream: true,
+			StoreDir:  GinkgoT().TempDir(),
+			Port:      -1,
+			Host:      "localhost",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		go srv.Start()
+		Expect(srv.ReadyForConnections(10 * time.Second)).To(BeTrue())
+		DeferCleanup(srv.Shutdown)
+
+		nc, err := nats.Connect(srv.ClientURL())
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(nc.Close)
+
+		bkt, err = kv.NewKV(nc, DefaultBucket, true, kv.WithHistory(1))
+		Expect(err).ToNot(HaveOccurred())
+
+		put := func(node *Node) {
+			j, err := json.Marshal(node)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = bkt.Put(node.Identity, j)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		put(&Node{
+			Identity:    "dev2.example.net",
+			Collectives: []string{"mcollective"},
+			Facts:       json.RawMessage(`{"country":"de","memory":1024}`),
+			Classes:     []string{"common", "database"},
+			Agents:      []string{"rpcutil", "package"},
+			Updated:     time.Now().Add(-2 * time.Hour),
+		})
+
+		put(&Node{
+			Identity:    "dev1.example.net",
+			Collectives: []string{"mcollective", "mt_collective"},
+			Facts:       json.RawMessage(`{"country":"mt","memory":2048}`),
+			Classes:     []string{"common", "webserver"},
+			Agents:      []string{"rpcutil", "service"},
+			Machines:    []MachineState{{Name: "nats", Version: "1.0.0", State: "running"}},
+			Updated:     time.Now(),
+		})
+
+		_, err = bkt.Put("invalid.example.net", []byte("invalid"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mockctl.Finish()
+	})
+
+	Describe("Nodes", func() {
+		It("Should read all valid nodes", func() {
+			nodes, err := Nodes(ctx, bkt, reg.log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(HaveLen(2))
+			Expect(nodes[0].Identity).To(Equal("dev1.example.net"))
+			Expect(nodes[0].Machines[0].State).To(Equal("running"))
+			Expect(nodes[1].Identity).To(Equal("dev2.example.net"))
+		})
+	})
+
+	Describe("discover", func() {
+		var dopts *dOpts
+
+		BeforeEach(func() {
+			dopts = &dOpts{collective: "mcollective", filter: protocol.NewFilter()}
+		})
+
+		It("Should limit nodes to the collective", func() {
+			nodes, err := reg.discover(ctx, bkt, dopts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(Equal([]string{"dev1.example.net", "dev2.example.net"}))
+
+			dopts.collective = "mt_collective"
+			nodes, err = reg.discover(ctx, bkt, dopts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(Equal([]string{"dev1.example.net"}))
+		})
+
+		It("Should support standard filters", func() {
+			dopts.filter.AddAgentFilter("package")
+			nodes, err := reg.discover(ctx, bkt, dopts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(Equal([]string{"dev2.example.net"}))
+
+			dopts.filter = protocol.NewFilter()
+			dopts.filter.AddClassFilter("webserver")
+			Expect(dopts.filter.AddFactFilter("country", "==", "mt")).To(Succeed())
+			nodes, err = reg.discover(ctx, bkt, dopts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(Equal([]string{"dev1.example.net"}))
+		})
+
+		It("Should support compound filters", func() {
+			Expect(dopts.filter.AddCompoundFilter(`with("database") && fact("memory") < 2048`)).To(Succeed())
+			nodes, err := reg.discover(ctx, bkt, dopts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(Equal([]string{"dev2.example.net"}))
+
+			dopts.filter = protocol.NewFilter()
+			Expect(dopts.filter.AddCompoundFilter(`"nats" in choria().machines`)).To(Succeed())
+			nodes, err = reg.discover(ctx, bkt, dopts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(Equal([]string{"dev1.example.net"}))
+		})
+
+		It("Should skip nodes that did not register recently", func() {
+			dopts.maxAge = time.Hour
+			nodes, err := reg.discover(ctx, bkt, dopts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodes).To(Equal([]string{"dev1.example.net"}))
+		})
+	})
+})
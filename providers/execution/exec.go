@@ -12,13 +12,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -92,6 +92,7 @@ var (
 	ErrSpoolCreationFailed    = errors.New("spool creation failed")
 	ErrProcessFailed          = errors.New("process failed")
 	ErrQueryRequired          = errors.New("query required")
+	ErrJobRunning             = errors.New("job is running")
 )
 
 func New(caller string, agent string, action string, reqID string, identity string, id string, command string, args []string, env map[string]string) (*Process, error) {
@@ -133,30 +134,16 @@ func List(spool string, q *ListQuery) ([]*Process, error) {
 		return nil, ErrQueryRequired
 	}
 
-	result := make([]*Process, 0)
-
-	err := filepath.WalkDir(spool, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if !d.Type().IsRegular() {
-			return nil
-		}
-
-		proc, err := Load(spool, d.Name())
-		if err != nil {
-			return nil
-		}
+	jobs, err := loadJobs(spool)
+	if err != nil {
+		return nil, err
+	}
 
+	result := make([]*Process, 0)
+	for _, proc := range jobs {
 		if proc.IsMatch(q) {
 			result = append(result, proc)
 		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
 	return result, nil
@@ -415,6 +402,51 @@ func (p *Process) StartSupervised(ctx context.Context, spool string, submit Subm
 	return nil
 }
 
+// supervisorExecutable is the binary that provides the exec-supervisor command
+var supervisorExecutable = os.Executable
+
+// StartSupervisor starts a detached exec-supervisor for the process, the supervisor runs in its own session
+// so it is not affected by signals sent to the process group of the caller and outlives the calling process
+func (p *Process) StartSupervisor(configFile string, heartbeat time.Duration, publishOutput bool, log *logrus.Entry) error {
+	if configFile == "" {
+		return fmt.Errorf("%w: no configuration file", ErrStartFailed)
+	}
+
+	if !p.StartTime.IsZero() {
+		return ErrAlreadyStarted
+	}
+
+	self, err := supervisorExecutable()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStartFailed, err)
+	}
+
+	args := []string{"exec-supervisor", "--config", configFile, "--process", p.ID, "--heartbeat", heartbeat.String()}
+	if publishOutput {
+		args = append(args, "--track-output")
+	}
+
+	log.Infof("Starting supervisor for process %s: %s %s", p.ID, self, strings.Join(args, " "))
+
+	cmd := exec.Command(self, args...)
+	cmd.Dir = "/"
+	detach(cmd)
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStartFailed, err)
+	}
+
+	go func() {
+		err := cmd.Wait()
+		if err != nil {
+			log.Errorf("Supervisor for process %s failed: %v", p.ID, err)
+		}
+	}()
+
+	return nil
+}
+
 // HasStarted determines if the command was started by the presence of the PID file
 func (p *Process) HasStarted() (bool, error) {
 	if p.PidFile == "" {
@@ -6,16 +6,19 @@ package execution
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	iu "github.com/choria-io/go-choria/internal/util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
 )
 
 func TestGovernor(t *testing.T) {
@@ -156,6 +159,59 @@ var _ = Describe("Execution", func() {
 		})
 	})
 
+	Describe("StartSupervisor", func() {
+		var log *logrus.Entry
+
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("not supported on windows")
+			}
+
+			logger := logrus.New()
+			logger.SetOutput(GinkgoWriter)
+			log = logrus.NewEntry(logger)
+
+			DeferCleanup(func() { supervisorExecutable = os.Executable })
+		})
+
+		It("Should require a configuration file", func() {
+			Expect(p.StartSupervisor("", time.Second, false, log)).To(MatchError(ErrStartFailed))
+		})
+
+		It("Should not start started processes", func() {
+			p.StartTime = time.Now()
+			Expect(p.StartSupervisor("/etc/choria/server.conf", time.Second, false, log)).To(MatchError(ErrAlreadyStarted))
+		})
+
+		It("Should start the supervisor in a new session", func() {
+			if _, err := os.Stat("/proc/self/stat"); err != nil {
+				Skip("requires /proc")
+			}
+
+			// records the arguments and the pid and session of the supervisor
+			script := filepath.Join(td, "supervisor")
+			Expect(os.WriteFile(script, []byte(fmt.Sprintf("#!/bin/sh\necho \"$@\" > %[1]s/args\ncut -d ' ' -f 1,6 /proc/$$/stat > %[1]s/session\n", td)), 0700)).To(Succeed())
+			supervisorExecutable = func() (string, error) { return script, nil }
+
+			Expect(p.StartSupervisor("/etc/choria/server.conf", 10*time.Second, true, log)).To(Succeed())
+
+			Eventually(func() string {
+				s, _ := os.ReadFile(filepath.Join(td, "session"))
+				return string(s)
+			}).ShouldNot(BeEmpty())
+
+			args, err := os.ReadFile(filepath.Join(td, "args"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(args)).To(Equal(fmt.Sprintf("exec-supervisor --config /etc/choria/server.conf --process %s --heartbeat 10s --track-output\n", p.ID)))
+
+			session, err := os.ReadFile(filepath.Join(td, "session"))
+			Expect(err).ToNot(HaveOccurred())
+			fields := strings.Fields(string(session))
+			Expect(fields).To(HaveLen(2))
+			Expect(fields[1]).To(Equal(fields[0]), "the supervisor should lead its own session")
+		})
+	})
+
 	Describe("IsRunning", func() {
 		It("Should correctly detect if the process is running", func() {
 			p.PidFile = filepath.Join(td, "pid")
@@ -0,0 +1,314 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/inter"
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/sirupsen/logrus"
+)
+
+// RetentionPolicy describes how long completed jobs are kept in the spool
+type RetentionPolicy struct {
+	// MaxAge is the maximum age of a completed job, 0 disables
+	MaxAge time.Duration `json:"max_age"`
+	// FailedMaxAge is the maximum age of a failed job, when 0 MaxAge applies
+	FailedMaxAge time.Duration `json:"failed_max_age"`
+	// MaxCount is the maximum amount of completed jobs to keep, 0 disables
+	MaxCount int `json:"max_count"`
+	// MaxBytes is the maximum total size of all completed jobs in the spool, 0 disables
+	MaxBytes int64 `json:"max_bytes"`
+}
+
+// RetentionPolicyFromChoria creates a retention policy based on the Choria configuration
+func RetentionPolicyFromChoria(fw inter.Framework) *RetentionPolicy {
+	cfg := fw.Configuration().Choria
+
+	return &RetentionPolicy{
+		MaxAge:       cfg.ExecutorRetentionMaxAge,
+		FailedMaxAge: cfg.ExecutorRetentionFailedMaxAge,
+		MaxCount:     cfg.ExecutorRetentionMaxCount,
+		MaxBytes:     int64(cfg.ExecutorRetentionMaxBytes),
+	}
+}
+
+// IsEnabled determines if any retention limits are set
+func (r *RetentionPolicy) IsEnabled() bool {
+	return r.MaxAge > 0 || r.FailedMaxAge > 0 || r.MaxCount > 0 || r.MaxBytes > 0
+}
+
+// HasFailed determines if a completed process failed, either by exiting non zero or by failing to run
+func (p *Process) HasFailed() bool {
+	code, err := p.ParseExitCode()
+
+	return err != nil || code != 0
+}
+
+// SpoolSize calculates the size on disk of all files related to the process
+func (p *Process) SpoolSize(spool string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(filepath.Join(spool, p.ID), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		nfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += nfo.Size()
+
+		return nil
+	})
+
+	return size, err
+}
+
+// Remove deletes the spool for a process, running processes can not be removed
+func (p *Process) Remove(spool string) error {
+	if spool == "" {
+		return ErrSpoolNotConfigured
+	}
+
+	if p.ID == "" {
+		return fmt.Errorf("%w: no id", ErrInvalidProcess)
+	}
+
+	if p.IsRunning() {
+		return ErrJobRunning
+	}
+
+	return os.RemoveAll(filepath.Join(spool, p.ID))
+}
+
+// PurgeResult is the outcome of purging jobs from the spool
+type PurgeResult struct {
+	// Purged are the jobs that were removed
+	Purged []*Process
+	// Skipped are matching jobs that were running, not allowed or could not be removed
+	Skipped []*Process
+	// Errors are the failures to remove jobs, removal continues past failures
+	Errors []error
+}
+
+// Err combines all the removal failures into one error, nil when all jobs were removed
+func (r *PurgeResult) Err() error {
+	return errors.Join(r.Errors...)
+}
+
+// Purge removes all completed jobs matching the query, running jobs and jobs that might still be starting are never
+// removed and when allow is not nil only jobs it accepts are removed. An error is only returned when the jobs could not be listed
+func Purge(spool string, q *ListQuery, allow func(*Process) bool) (*PurgeResult, error) {
+	matched, err := List(spool, q)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PurgeResult{}
+
+	for _, p := range matched {
+		if p.IsRunning() || p.isStarting() || (allow != nil && !allow(p)) {
+			result.Skipped = append(result.Skipped, p)
+			continue
+		}
+
+		err = p.Remove(spool)
+		if err != nil {
+			result.Skipped = append(result.Skipped, p)
+			result.Errors = append(result.Errors, fmt.Errorf("could not remove job %s: %w", p.ID, err))
+			continue
+		}
+
+		result.Purged = append(result.Purged, p)
+	}
+
+	return result, nil
+}
+
+// PurgeWithChoria removes all completed jobs matching the query from the configured spool
+func PurgeWithChoria(fw inter.Framework, q *ListQuery, allow func(*Process) bool) (*PurgeResult, error) {
+	return Purge(fw.Configuration().Choria.ExecutorSpool, q, allow)
+}
+
+// StartGracePeriod is how long a job may take to start, jobs that did not start by then are considered failed
+// and are subject to the retention policy
+const StartGracePeriod = 10 * time.Minute
+
+// isStarting determines if the supervisor might still be starting the job
+func (p *Process) isStarting() bool {
+	started, _ := p.HasStarted()
+
+	return !started && time.Since(p.Created) < StartGracePeriod
+}
+
+// EnforceRetention removes completed jobs that falls outside the retention policy, oldest jobs are removed first
+func EnforceRetention(spool string, policy *RetentionPolicy, log *logrus.Entry) ([]*Process, error) {
+	if spool == "" {
+		return nil, ErrSpoolNotConfigured
+	}
+
+	if policy == nil || !policy.IsEnabled() {
+		return nil, nil
+	}
+
+	jobs, err := loadJobs(spool)
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		proc   *Process
+		size   int64
+		failed bool
+	}
+
+	var completed []*candidate
+	for _, p := range jobs {
+		if p.isStarting() {
+			continue
+		}
+
+		started, _ := p.HasStarted()
+		if started && p.IsRunning() {
+			continue
+		}
+
+		size, err := p.SpoolSize(spool)
+		if err != nil {
+			log.Warnf("Could not determine spool size for job %s: %v", p.ID, err)
+		}
+
+		completed = append(completed, &candidate{proc: p, size: size, failed: p.HasFailed()})
+	}
+
+	// newest first so the count and size limits keep the most recent jobs
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].proc.Created.After(completed[j].proc.Created)
+	})
+
+	var (
+		purged []*Process
+		kept   int
+		total  int64
+	)
+
+	for _, c := range completed {
+		maxAge := policy.MaxAge
+		if c.failed && policy.FailedMaxAge > 0 {
+			maxAge = policy.FailedMaxAge
+		}
+
+		var reason string
+		switch {
+		case maxAge > 0 && time.Since(c.proc.Created) > maxAge:
+			reason = fmt.Sprintf("older than %v", maxAge)
+		case policy.MaxCount > 0 && kept >= policy.MaxCount:
+			reason = fmt.Sprintf("more than %d jobs", policy.MaxCount)
+		case policy.MaxBytes > 0 && total+c.size > policy.MaxBytes:
+			reason = fmt.Sprintf("more than %d bytes", policy.MaxBytes)
+		}
+
+		if reason == "" {
+			kept++
+			total += c.size
+			continue
+		}
+
+		log.Infof("Removing job %s created by %s: %s", c.proc.ID, c.proc.Caller, reason)
+
+		err = c.proc.Remove(spool)
+		if err != nil {
+			log.Errorf("Could not remove job %s: %v", c.proc.ID, err)
+			continue
+		}
+
+		purged = append(purged, c.proc)
+	}
+
+	return purged, nil
+}
+
+// RunReaper periodically enforces the retention policy until the context is canceled
+func RunReaper(ctx context.Context, wg *sync.WaitGroup, spool string, policy *RetentionPolicy, interval time.Duration, log *logrus.Entry) {
+	defer wg.Done()
+
+	if policy == nil || !policy.IsEnabled() {
+		log.Infof("Executor spool reaper not starting without a retention policy")
+		return
+	}
+
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+
+	reap := func() {
+		purged, err := EnforceRetention(spool, policy, log)
+		if err != nil {
+			log.Errorf("Could not enforce executor retention policy: %v", err)
+			return
+		}
+
+		if len(purged) > 0 {
+			log.Infof("Removed %d jobs from the executor spool", len(purged))
+		}
+	}
+
+	reap()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reap()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func loadJobs(spool string) ([]*Process, error) {
+	if !iu.FileIsDir(spool) {
+		return nil, ErrSpoolNotFound
+	}
+
+	entries, err := os.ReadDir(spool)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Process
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		proc, err := Load(spool, entry.Name())
+		if err != nil {
+			continue
+		}
+
+		result = append(result, proc)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package execution
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	iu "github.com/choria-io/go-choria/internal/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("Retention", func() {
+	var (
+		td  string
+		log *logrus.Entry
+	)
+
+	completedJob := func(age time.Duration, exitCode int, output string) *Process {
+		id := iu.UniqueID()
+		p, err := New("ginkgo", "agent", "action", iu.UniqueID(), "ginkgo.example.net", id, "echo", nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		p.Created = time.Now().Add(-age).UTC()
+
+		_, err = p.CreateSpool(td)
+		Expect(err).ToNot(HaveOccurred())
+
+		p.PidFile = pidPath(td, id)
+		p.StdoutFile = stdOutPath(td, id)
+		p.StderrFile = stdErrPath(td, id)
+		_, err = saveJobSpec(td, p)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.WriteFile(p.PidFile, []byte("0"), 0700)).To(Succeed())
+		Expect(os.WriteFile(p.StdoutFile, []byte(output), 0700)).To(Succeed())
+		Expect(os.WriteFile(exitPath(td, id), exitJson(exitCode, ""), 0700)).To(Succeed())
+
+		return p
+	}
+
+	unstarted := func(age time.Duration) *Process {
+		p, err := New("ginkgo", "agent", "action", iu.UniqueID(), "ginkgo.example.net", iu.UniqueID(), "echo", nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		p.Created = time.Now().Add(-age)
+		_, err = p.CreateSpool(td)
+		Expect(err).ToNot(HaveOccurred())
+		return p
+	}
+
+	BeforeEach(func() {
+		td = GinkgoT().TempDir()
+		logger := logrus.New()
+		logger.SetOutput(GinkgoWriter)
+		log = logrus.NewEntry(logger)
+	})
+
+	Describe("EnforceRetention", func() {
+		It("Should do nothing without a policy", func() {
+			completedJob(time.Hour, 0, "")
+			purged, err := EnforceRetention(td, &RetentionPolicy{}, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(purged).To(BeEmpty())
+		})
+
+		It("Should remove old jobs", func() {
+			old := completedJob(2*time.Hour, 0, "")
+			recent := completedJob(time.Minute, 0, "")
+
+			purged, err := EnforceRetention(td, &RetentionPolicy{MaxAge: time.Hour}, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(purged).To(HaveLen(1))
+			Expect(purged[0].ID).To(Equal(old.ID))
+			Expect(filepath.Join(td, old.ID)).ToNot(BeADirectory())
+			Expect(filepath.Join(td, recent.ID)).To(BeADirectory())
+		})
+
+		It("Should keep failed jobs longer", func() {
+			ok := completedJob(2*time.Hour, 0, "")
+			failed := completedJob(2*time.Hour, 1, "")
+
+			purged, err := EnforceRetention(td, &RetentionPolicy{MaxAge: time.Hour, FailedMaxAge: 24 * time.Hour}, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(purged).To(HaveLen(1))
+			Expect(purged[0].ID).To(Equal(ok.ID))
+			Expect(filepath.Join(td, failed.ID)).To(BeADirectory())
+		})
+
+		It("Should limit the number of jobs", func() {
+			oldest := completedJob(3*time.Minute, 0, "")
+			completedJob(2*time.Minute, 0, "")
+			completedJob(time.Minute, 0, "")
+
+			purged, err := EnforceRetention(td, &RetentionPolicy{MaxCount: 2}, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(purged).To(HaveLen(1))
+			Expect(purged[0].ID).To(Equal(oldest.ID))
+		})
+
+		It("Should limit the total size of jobs", func() {
+			oldest := completedJob(2*time.Minute, 0, "0123456789")
+			recent := completedJob(time.Minute, 0, "0123456789")
+
+			size, err := recent.SpoolSize(td)
+			Expect(err).ToNot(HaveOccurred())
+
+			purged, err := EnforceRetention(td, &RetentionPolicy{MaxBytes: size + 1}, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(purged).To(HaveLen(1))
+			Expect(purged[0].ID).To(Equal(oldest.ID))
+		})
+
+		It("Should treat jobs that did not start as failed once the start grace period passed", func() {
+			starting := unstarted(2 * time.Minute)
+			failed := unstarted(StartGracePeriod + 2*time.Minute)
+			expired := unstarted(2 * StartGracePeriod)
+
+			purged, err := EnforceRetention(td, &RetentionPolicy{MaxAge: time.Minute, FailedMaxAge: StartGracePeriod + 5*time.Minute}, log)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(purged).To(HaveLen(1))
+			Expect(purged[0].ID).To(Equal(expired.ID))
+			Expect(filepath.Join(td, starting.ID)).To(BeADirectory())
+			Expect(filepath.Join(td, failed.ID)).To(BeADirectory())
+		})
+	})
+
+	Describe("Purge", func() {
+		It("Should remove matching jobs", func() {
+			old := completedJob(2*time.Hour, 0, "")
+			recent := completedJob(time.Minute, 0, "")
+
+			res, err := Purge(td, &ListQuery{Before: time.Now().Add(-time.Hour)}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Err()).ToNot(HaveOccurred())
+			Expect(res.Purged).To(HaveLen(1))
+			Expect(res.Purged[0].ID).To(Equal(old.ID))
+			Expect(res.Skipped).To(BeEmpty())
+			Expect(filepath.Join(td, recent.ID)).To(BeADirectory())
+		})
+
+		It("Should only remove allowed jobs", func() {
+			denied := completedJob(2*time.Hour, 0, "")
+			allowed := completedJob(2*time.Hour, 0, "")
+
+			res, err := Purge(td, &ListQuery{Before: time.Now()}, func(p *Process) bool { return p.ID == allowed.ID })
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Purged).To(HaveLen(1))
+			Expect(res.Purged[0].ID).To(Equal(allowed.ID))
+			Expect(res.Skipped).To(HaveLen(1))
+			Expect(res.Skipped[0].ID).To(Equal(denied.ID))
+		})
+
+		It("Should continue past jobs that could not be removed", func() {
+			invalid := completedJob(2*time.Hour, 0, "")
+			valid := completedJob(2*time.Hour, 0, "")
+
+			res, err := Purge(td, &ListQuery{Before: time.Now()}, func(p *Process) bool {
+				if p.ID == invalid.ID {
+					p.ID = ""
+				}
+				return true
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Purged).To(HaveLen(1))
+			Expect(res.Purged[0].ID).To(Equal(valid.ID))
+			Expect(res.Skipped).To(HaveLen(1))
+			Expect(res.Errors).To(HaveLen(1))
+			Expect(res.Err()).To(MatchError(ErrInvalidProcess))
+		})
+
+		It("Should not remove jobs that might still be starting", func() {
+			starting := unstarted(2 * time.Minute)
+			failed := unstarted(2 * StartGracePeriod)
+
+			res, err := Purge(td, &ListQuery{Before: time.Now()}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Err()).ToNot(HaveOccurred())
+			Expect(res.Purged).To(HaveLen(1))
+			Expect(res.Purged[0].ID).To(Equal(failed.ID))
+			Expect(res.Skipped).To(HaveLen(1))
+			Expect(res.Skipped[0].ID).To(Equal(starting.ID))
+			Expect(filepath.Join(td, starting.ID)).To(BeADirectory())
+		})
+
+		It("Should require a query", func() {
+			_, err := Purge(td, nil, nil)
+			Expect(err).To(MatchError(ErrQueryRequired))
+		})
+	})
+})
@@ -0,0 +1,17 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package execution
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach places the supervisor in a new session so it does not share the process group and controlling terminal of the caller
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
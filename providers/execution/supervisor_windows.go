@@ -0,0 +1,17 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package execution
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach places the supervisor in a new process group so console signals sent to the caller do not reach it
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -25,6 +25,7 @@ type options struct {
 	maxBucketSize int64
 	replicas      int
 	direct        bool
+	denyPurge     bool
 }
 
 func WithTTL(ttl time.Duration) Option {
@@ -51,6 +52,11 @@ func WithoutDirectAccess() Option {
 	return func(o *options) { o.direct = false }
 }
 
+// WithoutPurge creates the bucket with purges and rollups denied so the history of keys can not be erased
+func WithoutPurge() Option {
+	return func(o *options) { o.denyPurge = true }
+}
+
 func DeleteKV(nc *nats.Conn, kv nats.KeyValue) error {
 	status, err := kv.Status()
 	if err != nil {
@@ -116,8 +122,9 @@ func NewKV(nc *nats.Conn, name string, create bool, opts ...Option) (nats.KeyVal
 		Storage:       api.FileStorage,
 		Discard:       api.DiscardNew,
 		Duplicates:    2 * time.Minute,
-		RollupAllowed: true,
+		RollupAllowed: !opt.denyPurge,
 		DenyDelete:    true,
+		DenyPurge:     opt.denyPurge,
 	}
 
 	if cfg.Duplicates > cfg.MaxAge {
@@ -31,6 +31,9 @@ type InventoryContent struct {
 
 const inventoryContentProtocol = "choria:registration:inventorycontent:1"
 
+// MaxInventoryContentSize is the largest decompressed inventory ParseInventoryContent will accept
+const MaxInventoryContentSize = 16 * 1024 * 1024
+
 type InventoryData struct {
 	Agents      []agents.Metadata          `json:"agents"`
 	Classes     []string                   `json:"classes"`
@@ -58,6 +61,35 @@ type InventoryMachineState struct {
 	State   string `json:"state"`
 }
 
+// ParseInventoryContent parses a registration message published by the inventory content plugin
+func ParseInventoryContent(payload []byte) (*InventoryData, error) {
+	var msg InventoryContentMessage
+	err := json.Unmarshal(payload, &msg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registration message: %w", err)
+	}
+
+	if msg.Protocol != inventoryContentProtocol {
+		return nil, fmt.Errorf("unsupported registration protocol %q", msg.Protocol)
+	}
+
+	content := msg.Content
+	if len(msg.ZContent) > 0 {
+		content, err = decompress(msg.ZContent, MaxInventoryContentSize)
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress registration content: %w", err)
+		}
+	}
+
+	data := &InventoryData{}
+	err = json.Unmarshal(content, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inventory content: %w", err)
+	}
+
+	return data, nil
+}
+
 // NewInventoryContent creates a new fully managed registration plugin instance
 func NewInventoryContent(c *config.Config, si ServerInfoSource, logger *logrus.Entry) (*InventoryContent, error) {
 	reg := &InventoryContent{si: si}
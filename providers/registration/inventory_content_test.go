@@ -0,0 +1,70 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package registration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("InventoryContent", func() {
+	Describe("ParseInventoryContent", func() {
+		var content []byte
+
+		BeforeEach(func() {
+			var err error
+			content, err = json.Marshal(&InventoryData{
+				Classes:     []string{"one", "two"},
+				Facts:       json.RawMessage(`{"country":"mt"}`),
+				Collectives: []string{"mcollective"},
+				AutoAgents:  []*InventoryMachineState{{Name: "nats", Version: "1.0.0", State: "running"}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Should parse plain content", func() {
+			j, err := json.Marshal(&InventoryContentMessage{Protocol: inventoryContentProtocol, Content: content})
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := ParseInventoryContent(j)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data.Classes).To(Equal([]string{"one", "two"}))
+			Expect(data.Collectives).To(Equal([]string{"mcollective"}))
+			Expect(data.AutoAgents[0].State).To(Equal("running"))
+		})
+
+		It("Should parse compressed content", func() {
+			zdat, err := compress(content)
+			Expect(err).ToNot(HaveOccurred())
+
+			j, err := json.Marshal(&InventoryContentMessage{Protocol: inventoryContentProtocol, ZContent: zdat})
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := ParseInventoryContent(j)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data.Facts)).To(Equal(`{"country":"mt"}`))
+		})
+
+		It("Should limit the size of compressed content", func() {
+			zdat, err := compress(bytes.Repeat([]byte(" "), MaxInventoryContentSize+1))
+			Expect(err).ToNot(HaveOccurred())
+
+			j, err := json.Marshal(&InventoryContentMessage{Protocol: inventoryContentProtocol, ZContent: zdat})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = ParseInventoryContent(j)
+			Expect(err).To(MatchError(fmt.Sprintf("could not decompress registration content: decompressed data exceeds %d bytes", MaxInventoryContentSize)))
+		})
+
+		It("Should only support inventory content messages", func() {
+			_, err := ParseInventoryContent([]byte(`{"protocol":"other"}`))
+			Expect(err).To(MatchError(`unsupported registration protocol "other"`))
+		})
+	})
+})
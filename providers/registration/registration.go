@@ -8,6 +8,8 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"fmt"
+	"io"
 
 	"github.com/choria-io/go-choria/aagent"
 	"github.com/choria-io/go-choria/build"
@@ -50,3 +52,23 @@ func compress(data []byte) ([]byte, error) {
 
 	return b.Bytes(), nil
 }
+
+// decompress gunzips data, failing when the result would be larger than limit bytes
+func decompress(data []byte, limit int64) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	res, err := io.ReadAll(io.LimitReader(gz, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(res)) > limit {
+		return nil, fmt.Errorf("decompressed data exceeds %d bytes", limit)
+	}
+
+	return res, nil
+}
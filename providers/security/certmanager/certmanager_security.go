@@ -27,6 +27,7 @@ import (
 	"github.com/choria-io/go-choria/inter"
 	"github.com/choria-io/go-choria/internal/util"
 	"github.com/choria-io/go-choria/providers/security/filesec"
+	"github.com/choria-io/go-choria/tlssetup"
 	"github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 )
@@ -57,6 +58,7 @@ type Config struct {
 	key             string
 	ca              string
 	legacyCerts     bool
+	tlsConfig       *tlssetup.Config
 }
 
 func New(opts ...Option) (*CertManagerSecurity, error) {
@@ -99,6 +101,7 @@ func (cm *CertManagerSecurity) reinit() error {
 		CA:                         cm.conf.ca,
 		PrivilegedUsers:            cm.conf.privilegedUsers,
 		BackwardCompatVerification: cm.conf.legacyCerts,
+		TLSConfig:                  cm.conf.tlsConfig,
 	}
 
 	cm.fsec, err = filesec.New(filesec.WithConfig(&fc), filesec.WithLog(cm.log))
@@ -519,6 +522,10 @@ func (cm *CertManagerSecurity) TLSConfig() (*tls.Config, error) {
 	return cm.fsec.TLSConfig()
 }
 
+func (cm *CertManagerSecurity) ReloadCredentials() (changed []string, err error) {
+	return cm.fsec.ReloadCredentials()
+}
+
 func (cm *CertManagerSecurity) SSLContext() (*http.Transport, error) {
 	return cm.fsec.SSLContext()
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/tlssetup"
 )
 
 type Option func(*CertManagerSecurity) error
@@ -28,6 +29,7 @@ func WithChoriaConfig(c *config.Config) Option {
 			altnames:        c.Choria.CertManagerSecurityAltNames,
 			identity:        c.Identity,
 			legacyCerts:     c.Choria.SecurityAllowLegacyCerts,
+			tlsConfig:       tlssetup.TLSConfig(c),
 		}
 
 		if c.OverrideCertname == "" {
@@ -23,6 +23,7 @@ import (
 
 	"github.com/choria-io/go-choria/inter"
 	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/security/denylist"
 	"github.com/choria-io/go-choria/tlssetup"
 	"github.com/choria-io/tokens"
 	"github.com/sirupsen/logrus"
@@ -35,9 +36,16 @@ var (
 )
 
 type ChoriaSecurity struct {
-	conf *Config
-	mu   *sync.Mutex
-	log  *logrus.Entry
+	conf    *Config
+	mu      *sync.Mutex
+	log     *logrus.Entry
+	keyPair *tlssetup.KeyPair
+
+	// checksums of the token and seed files to detect renewals
+	sums map[string][32]byte
+	// the last renewed token and the seed it was verified against
+	token string
+	seed  ed25519.PrivateKey
 }
 
 type Config struct {
@@ -82,6 +90,9 @@ type Config struct {
 
 	// InitiatedByServer indicates this is a server, it would require trusted signers
 	InitiatedByServer bool
+
+	// DenyList when set rejects revoked tokens
+	DenyList *denylist.List
 }
 
 func New(opts ...Option) (*ChoriaSecurity, error) {
@@ -89,7 +100,8 @@ func New(opts ...Option) (*ChoriaSecurity, error) {
 		conf: &Config{
 			SignedReplies: true,
 		},
-		mu: &sync.Mutex{},
+		mu:   &sync.Mutex{},
+		sums: make(map[string][32]byte),
 	}
 
 	for _, opt := range opts {
@@ -111,6 +123,8 @@ func New(opts ...Option) (*ChoriaSecurity, error) {
 
 	s.log.Infof("Security provider initializing")
 
+	s.recordCredentialSums()
+
 	return s, nil
 }
 
@@ -256,6 +270,11 @@ func (s *ChoriaSecurity) verifyByteSignatureByDelegation(dat []byte, sig []byte,
 			return "", "", fmt.Errorf("could not parse client token: %w", err)
 		}
 
+		err = s.conf.DenyList.CheckClientClaims(st)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: delegator %s: %v", errPermissionDenied, st.CallerID, err)
+		}
+
 		// it successfully parsed but now must be a delegator else it's not allowed to sign this data
 		if st.Permissions == nil || !st.Permissions.AuthenticationDelegator {
 			return "", "", fmt.Errorf("%w: token attempted to sign a request as delegator without required delegator permission: %s", errPermissionDenied, hex.EncodeToString(signer))
@@ -271,6 +290,11 @@ func (s *ChoriaSecurity) verifyByteSignatureByDelegation(dat []byte, sig []byte,
 			return "", "", fmt.Errorf("%w: caller token cannot be used without fleet management access: %s: %v", errPermissionDenied, string(caller), err)
 		}
 
+		err = s.conf.DenyList.CheckClientClaims(ct)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: caller %s: %v", errPermissionDenied, ct.CallerID, err)
+		}
+
 		if st.PublicKey == "" {
 			return "", "", fmt.Errorf("%w: no public key set", errPermissionDenied)
 		}
@@ -357,6 +381,11 @@ func (s *ChoriaSecurity) verifyByteSignatureByCaller(dat []byte, sig []byte, tok
 			return "", "", fmt.Errorf("%w: no public key in token", errPermissionDenied)
 		}
 
+		err = s.conf.DenyList.CheckClientClaims(t)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: %v", errPermissionDenied, err)
+		}
+
 		return t.PublicKey, t.CallerID, nil
 	}
 
@@ -384,6 +413,12 @@ func (s *ChoriaSecurity) verifyByteSignatureByCaller(dat []byte, sig []byte, tok
 				return false, ""
 			}
 
+			err = s.conf.DenyList.CheckServerClaims(t)
+			if err != nil {
+				s.log.Warnf("Rejecting server token for %s: %v", t.ChoriaIdentity, err)
+				return false, ""
+			}
+
 			pks = t.PublicKey
 			name = t.ChoriaIdentity
 		} else {
@@ -401,6 +436,12 @@ func (s *ChoriaSecurity) verifyByteSignatureByCaller(dat []byte, sig []byte, tok
 					continue
 				}
 
+				err = s.conf.DenyList.CheckServerClaims(t)
+				if err != nil {
+					s.log.Warnf("Rejecting server token for %s: %v", t.ChoriaIdentity, err)
+					return false, ""
+				}
+
 				if t.PublicKey != "" {
 					pks = t.PublicKey
 					name = t.ChoriaIdentity
@@ -493,19 +534,12 @@ func (s *ChoriaSecurity) TLSConfig() (*tls.Config, error) {
 	}
 
 	if iu.FileExist(s.conf.Key) && iu.FileExist(s.conf.Certificate) {
-		cert, err := tls.LoadX509KeyPair(s.conf.Certificate, s.conf.Key)
-		if err != nil {
-			err = fmt.Errorf("could not load certificate %s and key %s: %s", s.conf.Certificate, s.conf.Key, err)
-			return nil, err
-		}
-
-		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		kp, err := s.certificate()
 		if err != nil {
-			err = fmt.Errorf("error parsing certificate: %v", err)
 			return nil, err
 		}
 
-		tlsc.Certificates = []tls.Certificate{cert}
+		kp.Configure(tlsc)
 	}
 
 	if iu.FileExist(s.conf.CA) {
@@ -680,6 +680,7 @@ var _ = Describe("Providers/Security/Choria", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(c.Certificates).To(BeEmpty())
+			Expect(c.GetCertificate).To(BeNil())
 		})
 
 		It("Should produce a valid TLS Config", func() {
@@ -696,8 +697,17 @@ var _ = Describe("Providers/Security/Choria", func() {
 			cert, err := tls.LoadX509KeyPair(prov.conf.Certificate, prov.conf.Key)
 			Expect(err).ToNot(HaveOccurred())
 
+			// the nats server monitoring reads the certificate expiry from here
 			Expect(c.Certificates).To(HaveLen(1))
 			Expect(c.Certificates[0].Certificate).To(Equal(cert.Certificate))
+
+			active, err := c.GetCertificate(&tls.ClientHelloInfo{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active.Certificate).To(Equal(cert.Certificate))
+
+			active, err = c.GetClientCertificate(&tls.CertificateRequestInfo{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active.Certificate).To(Equal(cert.Certificate))
 		})
 
 		It("Should support disabling tls verify", func() {
@@ -710,4 +720,112 @@ var _ = Describe("Providers/Security/Choria", func() {
 		})
 	})
 
+	Describe("ReloadCredentials", func() {
+		var td string
+
+		copyFile := func(src string, dst string) {
+			b, err := os.ReadFile(src)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(dst, b, 0600)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			td = GinkgoT().TempDir()
+		})
+
+		It("Should do nothing without credentials", func() {
+			changed, err := prov.ReloadCredentials()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeEmpty())
+		})
+
+		It("Should load renewed certificates", func() {
+			prov.conf.Certificate = filepath.Join(td, "cert.pem")
+			prov.conf.Key = filepath.Join(td, "key.pem")
+			copyFile(filepath.Join("..", "testdata", "good", "certs", "rip.mcollective.pem"), prov.conf.Certificate)
+			copyFile(filepath.Join("..", "testdata", "good", "private_keys", "rip.mcollective.pem"), prov.conf.Key)
+
+			c, err := prov.TLSConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			original, err := tls.LoadX509KeyPair(prov.conf.Certificate, prov.conf.Key)
+			Expect(err).ToNot(HaveOccurred())
+
+			changed, err := prov.ReloadCredentials()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeEmpty())
+
+			// only the certificate written so far, the key pair is invalid and the old certificate remains
+			copyFile(filepath.Join("..", "testdata", "intermediate", "rip.mcollective.pem"), prov.conf.Certificate)
+			changed, err = prov.ReloadCredentials()
+			Expect(err).To(HaveOccurred())
+			Expect(changed).To(BeEmpty())
+
+			active, err := c.GetCertificate(&tls.ClientHelloInfo{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active.Certificate).To(Equal(original.Certificate))
+
+			copyFile(filepath.Join("..", "testdata", "intermediate", "rip.mcollective-key.pem"), prov.conf.Key)
+			changed, err = prov.ReloadCredentials()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(Equal([]string{"certificate"}))
+
+			renewed, err := tls.LoadX509KeyPair(prov.conf.Certificate, prov.conf.Key)
+			Expect(err).ToNot(HaveOccurred())
+
+			active, err = c.GetClientCertificate(&tls.CertificateRequestInfo{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active.Certificate).To(Equal(renewed.Certificate))
+		})
+
+		It("Should detect renewed tokens once the seed matches", func() {
+			prov.conf.TokenFile = filepath.Join(td, "server.jwt")
+			prov.conf.SeedFile = filepath.Join(td, "server.seed")
+			signerSeedFile := filepath.Join(td, "signer.seed")
+
+			_, _, err := iu.Ed25519KeyPairToFile(signerSeedFile)
+			Expect(err).ToNot(HaveOccurred())
+
+			saveToken := func(pubk ed25519.PublicKey) {
+				token, err := tokens.NewServerClaims("ginkgo.example.net", []string{"choria"}, "choria", nil, nil, pubk, "ginkgo", time.Hour)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tokens.SaveAndSignTokenWithKeyFile(token, signerSeedFile, prov.conf.TokenFile, 0600)).To(Succeed())
+			}
+
+			pubk, _, err := iu.Ed25519KeyPairToFile(prov.conf.SeedFile)
+			Expect(err).ToNot(HaveOccurred())
+			saveToken(pubk)
+			prov.recordCredentialSums()
+
+			changed, err := prov.ReloadCredentials()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeEmpty())
+
+			// new seed without a matching token yet
+			pubk, _, err = iu.Ed25519KeyPairToFile(prov.conf.SeedFile)
+			Expect(err).ToNot(HaveOccurred())
+			changed, err = prov.ReloadCredentials()
+			Expect(err).To(MatchError(ContainSubstring("does not match seed")))
+			Expect(changed).To(BeEmpty())
+
+			token, seed := prov.ReloadedToken()
+			Expect(token).To(BeEmpty())
+			Expect(seed).To(BeNil())
+
+			saveToken(pubk)
+			changed, err = prov.ReloadCredentials()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(Equal([]string{"token", "seed"}))
+
+			tb, err := os.ReadFile(prov.conf.TokenFile)
+			Expect(err).ToNot(HaveOccurred())
+			token, seed = prov.ReloadedToken()
+			Expect(token).To(Equal(string(tb)))
+			Expect(seed.Public()).To(Equal(pubk))
+
+			changed, err = prov.ReloadCredentials()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeEmpty())
+		})
+	})
 })
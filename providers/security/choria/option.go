@@ -14,6 +14,7 @@ import (
 
 	"github.com/choria-io/go-choria/config"
 	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/providers/security/denylist"
 	"github.com/choria-io/go-choria/tlssetup"
 	"github.com/sirupsen/logrus"
 )
@@ -141,6 +142,14 @@ func WithSigner(signer inter.RequestSigner) Option {
 	}
 }
 
+// WithDenyList rejects tokens found in the deny list, typically the list returned by the framework
+func WithDenyList(l *denylist.List) Option {
+	return func(s *ChoriaSecurity) error {
+		s.conf.DenyList = l
+		return nil
+	}
+}
+
 // WithConfig optionally configures the Security Provider using its native configuration format
 func WithConfig(c *Config) Option {
 	return func(s *ChoriaSecurity) error {
@@ -0,0 +1,182 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package choria
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/tlssetup"
+	"github.com/choria-io/tokens"
+)
+
+// certificate is the optional key pair shared by all TLS configurations, it is loaded on first use and reloaded by ReloadCredentials()
+func (s *ChoriaSecurity) certificate() (*tlssetup.KeyPair, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keyPair != nil && s.keyPair.CertFile() == s.conf.Certificate && s.keyPair.KeyFile() == s.conf.Key {
+		return s.keyPair, nil
+	}
+
+	kp, err := tlssetup.NewKeyPair(s.conf.Certificate, s.conf.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.keyPair = kp
+
+	return kp, nil
+}
+
+// ReloadCredentials detects renewed tokens and seeds and loads a renewed certificate and key
+//
+// Tokens and seeds are read from disk whenever they are used so this mainly reports that they changed, a token
+// is only reported once its matching seed is in place.
+func (s *ChoriaSecurity) ReloadCredentials() (changed []string, err error) {
+	var errs []error
+
+	if s.conf.TokenFile != "" && s.conf.SeedFile != "" {
+		tokenChanged, seedChanged, err := s.reloadToken()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if tokenChanged {
+			changed = append(changed, "token")
+		}
+		if seedChanged {
+			changed = append(changed, "seed")
+		}
+	}
+
+	s.mu.Lock()
+	kp := s.keyPair
+	s.mu.Unlock()
+
+	if kp != nil {
+		reloaded, err := kp.Reload()
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case reloaded:
+			s.log.Infof("Reloaded certificate %s expiring %s", kp.CertFile(), kp.Certificate().Leaf.NotAfter)
+			changed = append(changed, "certificate")
+		}
+	}
+
+	return changed, errors.Join(errs...)
+}
+
+func (s *ChoriaSecurity) reloadToken() (tokenChanged bool, seedChanged bool, err error) {
+	tb, err := os.ReadFile(s.conf.TokenFile)
+	if err != nil {
+		return false, false, fmt.Errorf("could not read token: %s", err)
+	}
+
+	sb, err := os.ReadFile(s.conf.SeedFile)
+	if err != nil {
+		return false, false, fmt.Errorf("could not read seed: %s", err)
+	}
+
+	tokenSum := sha256.Sum256(tb)
+	seedSum := sha256.Sum256(sb)
+
+	s.mu.Lock()
+	tokenChanged = tokenSum != s.sums["token"]
+	seedChanged = seedSum != s.sums["seed"]
+	s.mu.Unlock()
+
+	if !tokenChanged && !seedChanged {
+		return false, false, nil
+	}
+
+	tokenPK, exp, err := tokenPublicKey(string(tb))
+	if err != nil {
+		return false, false, fmt.Errorf("invalid token in %s: %s", s.conf.TokenFile, err)
+	}
+
+	seed, err := hex.DecodeString(string(sb))
+	if err != nil {
+		return false, false, fmt.Errorf("invalid seed in %s: %s", s.conf.SeedFile, err)
+	}
+
+	seedPK, seedPri, err := iu.Ed25519KeyPairFromSeed(seed)
+	if err != nil {
+		return false, false, fmt.Errorf("invalid seed in %s: %s", s.conf.SeedFile, err)
+	}
+
+	// during renewal the token and seed are not written at the same time, we wait for both to match
+	if !strings.EqualFold(tokenPK, hex.EncodeToString(seedPK)) {
+		return false, false, fmt.Errorf("the public key in token %s does not match seed %s", s.conf.TokenFile, s.conf.SeedFile)
+	}
+
+	s.mu.Lock()
+	s.sums["token"] = tokenSum
+	s.sums["seed"] = seedSum
+	s.token = strings.TrimSpace(string(tb))
+	s.seed = seedPri
+	s.mu.Unlock()
+
+	if tokenChanged {
+		s.log.Infof("Reloaded token %s expiring %s", s.conf.TokenFile, exp)
+	}
+
+	return tokenChanged, seedChanged, nil
+}
+
+// ReloadedToken is the most recently renewed token and the seed it was verified against, token is empty
+// until a renewal was loaded using ReloadCredentials()
+func (s *ChoriaSecurity) ReloadedToken() (token string, seed ed25519.PrivateKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.token, s.seed
+}
+
+// recordCredentialSums records the token and seed present at start so later renewals can be detected
+func (s *ChoriaSecurity) recordCredentialSums() {
+	for kind, file := range map[string]string{"token": s.conf.TokenFile, "seed": s.conf.SeedFile} {
+		if file == "" {
+			continue
+		}
+
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		s.sums[kind] = sha256.Sum256(b)
+	}
+}
+
+func tokenPublicKey(token string) (string, time.Time, error) {
+	switch tokens.TokenPurpose(token) {
+	case tokens.ClientIDPurpose:
+		claims, err := tokens.ParseClientIDTokenUnverified(token)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+
+		return claims.PublicKey, claims.ExpireTime(), nil
+
+	case tokens.ServerPurpose:
+		claims, err := tokens.ParseServerTokenUnverified(token)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+
+		return claims.PublicKey, claims.ExpireTime(), nil
+
+	default:
+		return "", time.Time{}, fmt.Errorf("unsupported token purpose")
+	}
+}
@@ -0,0 +1,373 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package denylist maintains a list of revoked JWT tokens that is distributed using a Choria Streams Key-Value bucket.
+//
+// Every entry in the bucket is signed by a trusted ed25519 key, unsigned or badly signed entries are ignored
+// so only holders of the revocation keys can deny access to valid tokens. Revocations are removed by storing a
+// signed removal in their key.
+//
+// The list is built from the full history of every key and the most recently signed entry in a key wins, so
+// replaying an older signed entry has no effect and deleting a key does not restore access as the earlier
+// values remain in its history. The bucket is created with purges denied as a purge would erase that history.
+// A key holds at most History values, write access to the bucket should therefore be limited to those managing
+// revocations as storing that many values in a key would still displace a revocation.
+//
+// Tokens are only checked when a connection is made, revoking a token does not close connections that were made
+// using it, and every token is accepted until the list was loaded.
+package denylist
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/backoff"
+	"github.com/choria-io/tokens"
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultBucket is the Key-Value bucket revocations are stored in when none is configured
+const DefaultBucket = "CHORIA_JWT_REVOCATIONS"
+
+// History is how many values the bucket keeps for every key
+const History = 5
+
+// ErrRevoked is returned when a token is in the deny list
+var ErrRevoked = errors.New("token has been revoked")
+
+// List is the set of revoked tokens, it is kept up to date by watching a Key-Value bucket
+type List struct {
+	bucket  string
+	signers []ed25519.PublicKey
+	entries map[string]*Entry
+	signed  map[string]time.Time
+	loaded  bool
+	log     *logrus.Entry
+	mu      sync.Mutex
+}
+
+// New creates a new deny list that trusts entries signed by the hex encoded ed25519 public keys in signers
+func New(bucket string, signers []string, log *logrus.Entry) (*List, error) {
+	if bucket == "" {
+		bucket = DefaultBucket
+	}
+
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("at least one revocation signer is required")
+	}
+
+	l := &List{
+		bucket:  bucket,
+		entries: make(map[string]*Entry),
+		signed:  make(map[string]time.Time),
+		log:     log.WithField("bucket", bucket),
+	}
+
+	for _, signer := range signers {
+		pk, err := hex.DecodeString(signer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ed25519 public key: %v: %v", signer, err)
+		}
+		if len(pk) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid ed25519 public key size: %v: %v", signer, len(pk))
+		}
+
+		l.signers = append(l.signers, pk)
+	}
+
+	return l, nil
+}
+
+// Bucket is the name of the Key-Value bucket holding the revocations
+func (l *List) Bucket() string {
+	return l.bucket
+}
+
+// Signers are the keys trusted to sign revocations
+func (l *List) Signers() []ed25519.PublicKey {
+	return l.signers
+}
+
+// Loaded determines if the initial revocations were read from the bucket
+func (l *List) Loaded() bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.loaded
+}
+
+// Entries are all the active revocations sorted by kind and value
+func (l *List) Entries() []*Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []*Entry
+	for _, entry := range l.entries {
+		if !entry.IsExpired() {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Kind == entries[j].Kind {
+			return entries[i].Value < entries[j].Value
+		}
+
+		return entries[i].Kind < entries[j].Kind
+	})
+
+	return entries
+}
+
+// Check checks if any of the token ID, public key or caller was revoked, empty values are not checked
+func (l *List) Check(id string, publicKey string, caller string) error {
+	if l == nil {
+		return nil
+	}
+
+	checks := []struct {
+		kind  Kind
+		value string
+	}{
+		{TokenID, id},
+		{PublicKey, strings.ToLower(publicKey)},
+		{Caller, caller},
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, check := range checks {
+		if check.value == "" {
+			continue
+		}
+
+		key, err := Key(check.kind, check.value)
+		if err != nil {
+			return err
+		}
+
+		entry, ok := l.entries[key]
+		if !ok || entry.Value != check.value || entry.IsExpired() {
+			continue
+		}
+
+		rejections.WithLabelValues(string(check.kind)).Inc()
+
+		if entry.Reason != "" {
+			return fmt.Errorf("%w: %s %s was revoked at %s: %s", ErrRevoked, check.kind, check.value, entry.Revoked, entry.Reason)
+		}
+
+		return fmt.Errorf("%w: %s %s was revoked at %s", ErrRevoked, check.kind, check.value, entry.Revoked)
+	}
+
+	return nil
+}
+
+// CheckClientClaims checks if a client token was revoked by ID, public key or caller
+func (l *List) CheckClientClaims(claims *tokens.ClientIDClaims) error {
+	if l == nil || claims == nil {
+		return nil
+	}
+
+	return l.Check(claims.ID, claims.PublicKey, claims.CallerID)
+}
+
+// CheckServerClaims checks if a server token was revoked by ID or public key
+func (l *List) CheckServerClaims(claims *tokens.ServerClaims) error {
+	if l == nil || claims == nil {
+		return nil
+	}
+
+	return l.Check(claims.ID, claims.PublicKey, "")
+}
+
+// Put verifies and adds the signed revocation data stored in key, invalid data never removes an existing revocation
+// and only entries signed after the most recent entry already seen in key are applied
+func (l *List) Put(key string, data []byte) error {
+	entry, err := ParseEntry(data, l.signers)
+	if err != nil {
+		invalidEntries.Inc()
+		return fmt.Errorf("invalid revocation in key %s: %s", key, err)
+	}
+
+	expected, err := entry.Key()
+	if err != nil {
+		invalidEntries.Inc()
+		return err
+	}
+
+	if key != expected {
+		invalidEntries.Inc()
+		return fmt.Errorf("revocation of %s %s stored in key %s, expected %s", entry.Kind, entry.Value, key, expected)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	signed := entry.Signed()
+	latest, ok := l.signed[key]
+	switch {
+	case ok && signed.Equal(latest):
+		// the same entry seen again, for example when the watch is restarted
+		return nil
+
+	case ok && signed.Before(latest):
+		invalidEntries.Inc()
+		if entry.IsRemoval() {
+			return fmt.Errorf("removal of %s %s made at %s predates the entry made at %s", entry.Kind, entry.Value, signed, latest)
+		}
+
+		return fmt.Errorf("revocation of %s %s made at %s predates the entry made at %s", entry.Kind, entry.Value, signed, latest)
+	}
+
+	l.signed[key] = signed
+
+	if entry.IsRemoval() {
+		delete(l.entries, key)
+	} else {
+		l.entries[key] = entry
+	}
+
+	entriesGauge.Set(float64(len(l.entries)))
+
+	return nil
+}
+
+// Watch keeps the list up to date with the bucket until ctx is canceled, load is called to access the
+// bucket and is retried until it succeeds. A bucket that does not exist is treated as an empty list.
+//
+// Every token is accepted until the initial revocations are loaded, the choria_security_jwt_revocations_loaded
+// gauge is 0 while this is the case.
+func (l *List) Watch(ctx context.Context, wg *sync.WaitGroup, load func(ctx context.Context) (nats.KeyValue, error)) {
+	defer wg.Done()
+
+	l.log.Warnf("JWT revocations are not enforced until the %s bucket is loaded", l.bucket)
+
+	backoff.TwentySec.For(ctx, func(try int) error {
+		store, err := load(ctx)
+		if errors.Is(err, nats.ErrBucketNotFound) {
+			// nothing has been revoked yet, we keep checking for the bucket to be created
+			l.log.Debugf("JWT revocation bucket does not exist")
+			l.synced()
+			return err
+		}
+		if err != nil {
+			l.log.Warnf("Could not access JWT revocation bucket on try %d: %s", try, err)
+			return err
+		}
+
+		err = l.watch(ctx, store)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		l.log.Warnf("Watching JWT revocations failed, retrying: %s", err)
+
+		return err
+	})
+}
+
+func (l *List) watch(ctx context.Context, store nats.KeyValue) error {
+	// the full history is read so the most recently signed entry in every key wins
+	watch, err := store.WatchAll(nats.IncludeHistory(), nats.Context(ctx))
+	if err != nil {
+		return err
+	}
+	defer watch.Stop()
+
+	for {
+		select {
+		case kve, ok := <-watch.Updates():
+			if !ok {
+				return fmt.Errorf("watcher closed")
+			}
+
+			// a nil entry marks the end of the initial values
+			if kve == nil {
+				l.synced()
+				continue
+			}
+
+			l.update(kve)
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Load reads the revocations in store once without watching it for further changes
+func (l *List) Load(ctx context.Context, store nats.KeyValue) error {
+	watch, err := store.WatchAll(nats.IncludeHistory(), nats.Context(ctx))
+	if err != nil {
+		return err
+	}
+	defer watch.Stop()
+
+	for {
+		select {
+		case kve, ok := <-watch.Updates():
+			if !ok {
+				return fmt.Errorf("watcher closed")
+			}
+
+			if kve == nil {
+				l.synced()
+				return nil
+			}
+
+			l.update(kve)
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *List) update(kve nats.KeyValueEntry) {
+	// deletes are not signed so anyone able to write to the bucket could restore access using them, the
+	// earlier values are still in the history so they are ignored
+	if kve.Operation() != nats.KeyValuePut {
+		unsignedRemovals.Inc()
+		l.log.Warnf("Ignoring unsigned removal of key %s, revocations can only be removed using signed removals", kve.Key())
+		return
+	}
+
+	err := l.Put(kve.Key(), kve.Value())
+	if err != nil {
+		l.log.Warnf("Ignoring update: %s", err)
+	}
+}
+
+func (l *List) synced() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, entry := range l.entries {
+		if entry.IsExpired() {
+			delete(l.entries, key)
+		}
+	}
+
+	if !l.loaded {
+		l.log.Infof("Loaded %d JWT revocations", len(l.entries))
+	}
+
+	l.loaded = true
+	loadedGauge.Set(1)
+	entriesGauge.Set(float64(len(l.entries)))
+}
@@ -0,0 +1,365 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package denylist_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/choria-io/go-choria/providers/security/denylist"
+	"github.com/choria-io/tokens"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+func TestDenyList(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Providers/Security/DenyList")
+}
+
+var _ = Describe("DenyList", func() {
+	var (
+		signerPub ed25519.PublicKey
+		signerPri ed25519.PrivateKey
+		list      *denylist.List
+		log       *logrus.Entry
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		signerPub, signerPri, err = iu.Ed25519KeyPair()
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := logrus.New()
+		logger.Out = io.Discard
+		log = logrus.NewEntry(logger)
+
+		list, err = denylist.New("", []string{hex.EncodeToString(signerPub)}, log)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	signed := func(kind denylist.Kind, value string, expires time.Time, key ed25519.PrivateKey) (string, []byte) {
+		entry, err := denylist.NewEntry(kind, value, "ginkgo", expires)
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := entry.Sign(key)
+		Expect(err).ToNot(HaveOccurred())
+
+		k, err := entry.Key()
+		Expect(err).ToNot(HaveOccurred())
+
+		return k, data
+	}
+
+	signedRemoval := func(kind denylist.Kind, value string, key ed25519.PrivateKey) (string, []byte) {
+		entry, err := denylist.NewRemoval(kind, value)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entry.IsRemoval()).To(BeTrue())
+
+		data, err := entry.Sign(key)
+		Expect(err).ToNot(HaveOccurred())
+
+		k, err := entry.Key()
+		Expect(err).ToNot(HaveOccurred())
+
+		return k, data
+	}
+
+	Describe("New", func() {
+		It("Should validate the signers", func() {
+			_, err := denylist.New("", nil, log)
+			Expect(err).To(MatchError("at least one revocation signer is required"))
+
+			_, err = denylist.New("", []string{"x"}, log)
+			Expect(err).To(MatchError(ContainSubstring("invalid ed25519 public key")))
+
+			_, err = denylist.New("", []string{"abcd"}, log)
+			Expect(err).To(MatchError("invalid ed25519 public key size: abcd: 2"))
+
+			Expect(list.Bucket()).To(Equal(denylist.DefaultBucket))
+		})
+	})
+
+	Describe("Entries", func() {
+		It("Should validate new entries", func() {
+			_, err := denylist.NewEntry("other", "x", "", time.Time{})
+			Expect(err).To(MatchError(`invalid revocation kind "other"`))
+
+			_, err = denylist.NewEntry(denylist.Caller, "", "", time.Time{})
+			Expect(err).To(MatchError("a value to revoke is required"))
+
+			_, err = denylist.NewEntry(denylist.PublicKey, "x", "", time.Time{})
+			Expect(err).To(MatchError(`invalid ed25519 public key "x"`))
+		})
+
+		It("Should verify signatures", func() {
+			_, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+
+			entry, err := denylist.ParseEntry(data, list.Signers())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entry.Kind).To(Equal(denylist.Caller))
+			Expect(entry.Value).To(Equal("choria=bob"))
+			Expect(entry.Reason).To(Equal("ginkgo"))
+			Expect(entry.Signer).To(Equal(hex.EncodeToString(signerPub)))
+
+			_, otherPri, err := iu.Ed25519KeyPair()
+			Expect(err).ToNot(HaveOccurred())
+			_, data = signed(denylist.Caller, "choria=bob", time.Time{}, otherPri)
+			_, err = denylist.ParseEntry(data, list.Signers())
+			Expect(err).To(MatchError(ContainSubstring("entry signed by untrusted key")))
+
+			_, err = denylist.ParseEntry([]byte(`{"entry":{"kind":"caller","value":"choria=bob"}}`), list.Signers())
+			Expect(err).To(MatchError("entry is not signed"))
+		})
+
+		It("Should detect tampered entries", func() {
+			_, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			tampered := bytes.ReplaceAll(data, []byte("choria=bob"), []byte("choria=joe"))
+
+			_, err := denylist.ParseEntry(tampered, list.Signers())
+			Expect(err).To(MatchError("signature verification failed"))
+		})
+	})
+
+	Describe("Check", func() {
+		It("Should allow everything for nil lists", func() {
+			var l *denylist.List
+			Expect(l.Check("1", "", "choria=bob")).To(Succeed())
+			Expect(l.CheckClientClaims(&tokens.ClientIDClaims{CallerID: "choria=bob"})).To(Succeed())
+			Expect(l.Loaded()).To(BeFalse())
+		})
+
+		It("Should reject revoked token ids, public keys and callers", func() {
+			pk, _, err := iu.Ed25519KeyPair()
+			Expect(err).ToNot(HaveOccurred())
+			pks := hex.EncodeToString(pk)
+
+			Expect(list.Check("1", pks, "choria=bob")).To(Succeed())
+
+			for kind, value := range map[denylist.Kind]string{denylist.TokenID: "1", denylist.PublicKey: pks, denylist.Caller: "choria=bob"} {
+				key, data := signed(kind, value, time.Time{}, signerPri)
+				Expect(list.Put(key, data)).To(Succeed())
+
+				err := list.Check("1", pks, "choria=bob")
+				Expect(errors.Is(err, denylist.ErrRevoked)).To(BeTrue())
+				Expect(err).To(MatchError(ContainSubstring("%s %s was revoked at", kind, value)))
+				Expect(err).To(MatchError(ContainSubstring(": ginkgo")))
+
+				_, removal := signedRemoval(kind, value, signerPri)
+				Expect(list.Put(key, removal)).To(Succeed())
+				Expect(list.Check("1", pks, "choria=bob")).To(Succeed())
+			}
+		})
+
+		It("Should check token claims", func() {
+			pk, _, err := iu.Ed25519KeyPair()
+			Expect(err).ToNot(HaveOccurred())
+
+			client, err := tokens.NewClientIDClaims("choria=bob", nil, "choria", nil, "", "", time.Hour, nil, pk)
+			Expect(err).ToNot(HaveOccurred())
+			srvClaims, err := tokens.NewServerClaims("ginkgo.example.net", []string{"choria"}, "choria", nil, nil, pk, "", time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			key, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			Expect(list.Put(key, data)).To(Succeed())
+			Expect(list.CheckClientClaims(client)).To(MatchError(denylist.ErrRevoked))
+			Expect(list.CheckServerClaims(srvClaims)).To(Succeed())
+
+			key, data = signed(denylist.TokenID, srvClaims.ID, time.Time{}, signerPri)
+			Expect(list.Put(key, data)).To(Succeed())
+			Expect(list.CheckServerClaims(srvClaims)).To(MatchError(denylist.ErrRevoked))
+		})
+
+		It("Should ignore expired entries", func() {
+			key, data := signed(denylist.Caller, "choria=bob", time.Now().Add(-time.Minute), signerPri)
+			Expect(list.Put(key, data)).To(Succeed())
+			Expect(list.Check("", "", "choria=bob")).To(Succeed())
+			Expect(list.Entries()).To(BeEmpty())
+		})
+	})
+
+	Describe("Put", func() {
+		It("Should remove revocations using signed removals", func() {
+			key, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			Expect(list.Put(key, data)).To(Succeed())
+
+			_, otherPri, err := iu.Ed25519KeyPair()
+			Expect(err).ToNot(HaveOccurred())
+			_, removal := signedRemoval(denylist.Caller, "choria=bob", otherPri)
+			Expect(list.Put(key, removal)).To(MatchError(ContainSubstring("untrusted key")))
+			Expect(list.Check("", "", "choria=bob")).To(MatchError(denylist.ErrRevoked))
+
+			_, removal = signedRemoval(denylist.Caller, "choria=bob", signerPri)
+			Expect(list.Put(key, removal)).To(Succeed())
+			Expect(list.Check("", "", "choria=bob")).To(Succeed())
+			Expect(list.Entries()).To(BeEmpty())
+		})
+
+		It("Should not honor removals made before the revocation", func() {
+			_, removal := signedRemoval(denylist.Caller, "choria=bob", signerPri)
+			time.Sleep(10 * time.Millisecond)
+
+			key, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			Expect(list.Put(key, data)).To(Succeed())
+
+			Expect(list.Put(key, removal)).To(MatchError(ContainSubstring("predates the entry made at")))
+			Expect(list.Check("", "", "choria=bob")).To(MatchError(denylist.ErrRevoked))
+		})
+
+		It("Should not honor revocations made before a removal", func() {
+			key, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			time.Sleep(10 * time.Millisecond)
+
+			_, removal := signedRemoval(denylist.Caller, "choria=bob", signerPri)
+			Expect(list.Put(key, removal)).To(Succeed())
+
+			Expect(list.Put(key, data)).To(MatchError(ContainSubstring("predates the entry made at")))
+			Expect(list.Check("", "", "choria=bob")).To(Succeed())
+
+			// seeing the current entry again is not an error
+			Expect(list.Put(key, removal)).To(Succeed())
+		})
+
+		It("Should not remove revocations when given invalid data", func() {
+			key, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			Expect(list.Put(key, data)).To(Succeed())
+
+			Expect(list.Put(key, []byte("{}"))).To(MatchError(ContainSubstring("invalid revocation in key")))
+			Expect(list.Check("", "", "choria=bob")).To(MatchError(denylist.ErrRevoked))
+		})
+
+		It("Should reject entries stored in the wrong key", func() {
+			_, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			other, err := denylist.Key(denylist.Caller, "choria=joe")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(list.Put(other, data)).To(MatchError(ContainSubstring("revocation of caller choria=bob stored in key")))
+			Expect(list.Check("", "", "choria=joe")).To(Succeed())
+			Expect(list.Check("", "", "choria=bob")).To(Succeed())
+		})
+	})
+
+	Describe("Watch", func() {
+		var (
+			nc *nats.Conn
+			js nats.JetStreamContext
+		)
+
+		BeforeEach(func() {
+			srv, err := server.NewServer(&server.Options{
+				JetStream: true,
+				StoreDir:  GinkgoT().TempDir(),
+				Port:      -1,
+				Host:      "localhost",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			go srv.Start()
+			Expect(srv.ReadyForConnections(10 * time.Second)).To(BeTrue())
+			DeferCleanup(srv.Shutdown)
+
+			nc, err = nats.Connect(srv.ClientURL())
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(nc.Close)
+
+			js, err = nc.JetStream()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		watch := func(l *denylist.List) {
+			ctx, cancel := context.WithCancel(context.Background())
+			wg := &sync.WaitGroup{}
+			DeferCleanup(func() {
+				cancel()
+				wg.Wait()
+			})
+
+			load := func(_ context.Context) (nats.KeyValue, error) {
+				return js.KeyValue(l.Bucket())
+			}
+
+			wg.Add(1)
+			go l.Watch(ctx, wg, load)
+		}
+
+		It("Should track the bucket", func() {
+			watch(list)
+
+			// a missing bucket means nothing is revoked
+			Eventually(list.Loaded).Should(BeTrue())
+
+			store, err := kv.NewKV(nc, list.Bucket(), true, kv.WithHistory(denylist.History), kv.WithoutPurge())
+			Expect(err).ToNot(HaveOccurred())
+
+			key, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			_, err = store.Put(key, data)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() error { return list.Check("", "", "choria=bob") }, 30*time.Second).Should(MatchError(denylist.ErrRevoked))
+
+			// unsigned deletes do not restore access and purges are denied
+			Expect(store.Delete(key)).To(Succeed())
+			Expect(store.Purge(key)).ToNot(Succeed())
+			Consistently(func() error { return list.Check("", "", "choria=bob") }, time.Second).Should(MatchError(denylist.ErrRevoked))
+
+			key, data = signedRemoval(denylist.Caller, "choria=bob", signerPri)
+			_, err = store.Put(key, data)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(func() error { return list.Check("", "", "choria=bob") }).Should(Succeed())
+		})
+
+		It("Should load revocations hidden by deletes and replayed removals when starting", func() {
+			store, err := kv.NewKV(nc, list.Bucket(), true, kv.WithHistory(denylist.History), kv.WithoutPurge())
+			Expect(err).ToNot(HaveOccurred())
+
+			key, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			_, err = store.Put(key, data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.Delete(key)).To(Succeed())
+
+			key, removal := signedRemoval(denylist.Caller, "choria=joe", signerPri)
+			_, err = store.Put(key, removal)
+			Expect(err).ToNot(HaveOccurred())
+			time.Sleep(10 * time.Millisecond)
+			_, data = signed(denylist.Caller, "choria=joe", time.Time{}, signerPri)
+			_, err = store.Put(key, data)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = store.Put(key, removal)
+			Expect(err).ToNot(HaveOccurred())
+
+			watch(list)
+			Eventually(list.Loaded).Should(BeTrue())
+
+			Expect(list.Check("", "", "choria=bob")).To(MatchError(denylist.ErrRevoked))
+			Expect(list.Check("", "", "choria=joe")).To(MatchError(denylist.ErrRevoked))
+		})
+
+		It("Should load the bucket once", func() {
+			store, err := kv.NewKV(nc, list.Bucket(), true, kv.WithHistory(denylist.History), kv.WithoutPurge())
+			Expect(err).ToNot(HaveOccurred())
+
+			key, data := signed(denylist.Caller, "choria=bob", time.Time{}, signerPri)
+			_, err = store.Put(key, data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.Delete(key)).To(Succeed())
+
+			Expect(list.Load(context.Background(), store)).To(Succeed())
+			Expect(list.Loaded()).To(BeTrue())
+			Expect(list.Check("", "", "choria=bob")).To(MatchError(denylist.ErrRevoked))
+		})
+	})
+})
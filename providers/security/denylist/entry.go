@@ -0,0 +1,219 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package denylist
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	iu "github.com/choria-io/go-choria/internal/util"
+)
+
+// Kind is the property of a token an entry revokes
+type Kind string
+
+const (
+	// TokenID revokes a single token by its unique ID, the jti claim
+	TokenID Kind = "id"
+
+	// PublicKey revokes all tokens holding a specific ed25519 public key
+	PublicKey Kind = "public_key"
+
+	// Caller revokes all client tokens issued to a caller
+	Caller Kind = "caller"
+)
+
+// Kinds are all the valid kinds of entry
+var Kinds = []Kind{TokenID, PublicKey, Caller}
+
+// Entry is a single revocation in the deny list
+type Entry struct {
+	// Kind is the token property being revoked
+	Kind Kind `json:"kind"`
+
+	// Value is the token ID, public key or caller being revoked
+	Value string `json:"value"`
+
+	// Reason is an optional human readable reason for the revocation
+	Reason string `json:"reason,omitempty"`
+
+	// Revoked is when the revocation was made
+	Revoked time.Time `json:"revoked"`
+
+	// Expires is an optional time after which the entry is ignored, typically the expiry time of the revoked token
+	Expires time.Time `json:"expires,omitempty"`
+
+	// Removed is when the revocation was removed, set when the entry restores access rather than revoking it
+	Removed time.Time `json:"removed,omitempty"`
+
+	// Signer is the hex encoded ed25519 public key that signed the entry
+	Signer string `json:"signer"`
+}
+
+type signedEntry struct {
+	Entry     json.RawMessage `json:"entry"`
+	Signature string          `json:"signature"`
+}
+
+// NewEntry creates a new unsigned entry revoking value
+func NewEntry(kind Kind, value string, reason string, expires time.Time) (*Entry, error) {
+	if !kind.IsValid() {
+		return nil, fmt.Errorf("invalid revocation kind %q", kind)
+	}
+
+	if value == "" {
+		return nil, fmt.Errorf("a value to revoke is required")
+	}
+
+	if kind == PublicKey {
+		if !iu.IsEncodedEd25519KeyString(value) {
+			return nil, fmt.Errorf("invalid ed25519 public key %q", value)
+		}
+
+		value = strings.ToLower(value)
+	}
+
+	return &Entry{
+		Kind:    kind,
+		Value:   value,
+		Reason:  reason,
+		Revoked: time.Now().UTC(),
+		Expires: expires.UTC(),
+	}, nil
+}
+
+// NewRemoval creates a new unsigned entry that removes an earlier revocation of value
+func NewRemoval(kind Kind, value string) (*Entry, error) {
+	entry, err := NewEntry(kind, value, "", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Removed = entry.Revoked
+
+	return entry, nil
+}
+
+// IsValid determines if k is a known kind
+func (k Kind) IsValid() bool {
+	for _, v := range Kinds {
+		if k == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Key is the key in the Key-Value bucket that stores a revocation of value
+func Key(kind Kind, value string) (string, error) {
+	if !kind.IsValid() {
+		return "", fmt.Errorf("invalid revocation kind %q", kind)
+	}
+
+	// values like callers can hold characters not valid in keys so we store them by hash
+	return fmt.Sprintf("%s.%x", kind, sha256.Sum256([]byte(value))), nil
+}
+
+// Key is the key in the Key-Value bucket that stores this entry
+func (e *Entry) Key() (string, error) {
+	return Key(e.Kind, e.Value)
+}
+
+// IsRemoval determines if the entry removes an earlier revocation
+func (e *Entry) IsRemoval() bool {
+	return !e.Removed.IsZero()
+}
+
+// Signed is when the entry was made, the removal time for removals and the revocation time otherwise
+func (e *Entry) Signed() time.Time {
+	if e.IsRemoval() {
+		return e.Removed
+	}
+
+	return e.Revoked
+}
+
+// IsExpired determines if the entry has an expiry time that has passed
+func (e *Entry) IsExpired() bool {
+	return !e.Expires.IsZero() && time.Now().After(e.Expires)
+}
+
+// Sign signs the entry using key producing the data to store in the bucket
+func (e *Entry) Sign(key ed25519.PrivateKey) ([]byte, error) {
+	e.Signer = hex.EncodeToString(key.Public().(ed25519.PublicKey))
+
+	ej, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := iu.Ed25519Sign(key, ej)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign entry: %s", err)
+	}
+
+	return json.Marshal(signedEntry{Entry: ej, Signature: hex.EncodeToString(sig)})
+}
+
+// ParseEntry parses and verifies signed entry data, the entry must be signed by one of signers
+func ParseEntry(data []byte, signers []ed25519.PublicKey) (*Entry, error) {
+	var signed signedEntry
+	err := json.Unmarshal(data, &signed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry: %s", err)
+	}
+
+	if len(signed.Entry) == 0 || signed.Signature == "" {
+		return nil, fmt.Errorf("entry is not signed")
+	}
+
+	var entry Entry
+	err = json.Unmarshal(signed.Entry, &entry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry: %s", err)
+	}
+
+	if !entry.Kind.IsValid() {
+		return nil, fmt.Errorf("invalid revocation kind %q", entry.Kind)
+	}
+
+	signer, err := hex.DecodeString(entry.Signer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signer: %s", err)
+	}
+
+	trusted := false
+	for _, s := range signers {
+		if bytes.Equal(s, signer) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return nil, fmt.Errorf("entry signed by untrusted key %s", entry.Signer)
+	}
+
+	sig, err := hex.DecodeString(signed.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %s", err)
+	}
+
+	ok, err := iu.Ed25519Verify(signer, signed.Entry, sig)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("signature verification failed")
+	}
+
+	return &entry, nil
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package denylist
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var entriesGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "choria_security_jwt_revocations",
+	Help: "Number of JWT revocations loaded from the revocation bucket",
+})
+
+var rejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "choria_security_jwt_revocation_rejections",
+	Help: "Number of times a revoked JWT was rejected by the kind of revocation that matched",
+}, []string{"kind"})
+
+var invalidEntries = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "choria_security_jwt_revocation_invalid",
+	Help: "Number of revocation bucket entries ignored because they were not validly signed",
+})
+
+var unsignedRemovals = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "choria_security_jwt_revocation_unsigned_removals",
+	Help: "Number of deletes or purges of revocation bucket keys that were ignored because they were not signed",
+})
+
+var loadedGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "choria_security_jwt_revocations_loaded",
+	Help: "Set to 1 once the JWT revocations were loaded, until then revoked tokens are accepted",
+})
+
+func init() {
+	prometheus.MustRegister(entriesGauge)
+	prometheus.MustRegister(rejections)
+	prometheus.MustRegister(invalidEntries)
+	prometheus.MustRegister(unsignedRemovals)
+	prometheus.MustRegister(loadedGauge)
+}
@@ -48,8 +48,14 @@ var (
 
 // FileSecurity implements SecurityProvider using files on disk
 type FileSecurity struct {
-	conf *Config
-	log  *logrus.Entry
+	conf    *Config
+	log     *logrus.Entry
+	keyPair *tlssetup.KeyPair
+
+	// caPool is the parsed CA used when checking revocation, it is parsed again when the CA file changes
+	caPool     *x509.CertPool
+	caPoolMod  time.Time
+	caPoolSize int64
 
 	mu *sync.Mutex
 }
@@ -128,6 +134,10 @@ func New(opts ...Option) (*FileSecurity, error) {
 		f.log.Infof("Enabling support for legacy SAN free certificates")
 	}
 
+	if f.conf.TLSConfig.Revocation != nil {
+		f.conf.TLSConfig.Revocation.SetLogger(f.log)
+	}
+
 	return f, nil
 }
 
@@ -293,6 +303,14 @@ func (s *FileSecurity) VerifySignatureBytes(dat []byte, sig []byte, public ...[]
 		return false, ""
 	}
 
+	if len(public[0]) > 0 {
+		err = s.CheckRevocation(cert, pubcert)
+		if err != nil {
+			s.log.Errorf("Signature verification failed: %s", err)
+			return false, ""
+		}
+	}
+
 	rsaPublicKey := cert.PublicKey.(*rsa.PublicKey)
 	hashed := s.ChecksumBytes(dat)
 
@@ -381,7 +399,13 @@ func (s *FileSecurity) VerifyCertificate(certpem []byte, name string) error {
 		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 	}
 
-	_, err = cert.Verify(opts)
+	chains, err := cert.Verify(opts)
+	if err != nil {
+		s.log.Warnf("Certificate does not pass verification as '%s': %s", name, err)
+		return err
+	}
+
+	err = s.conf.TLSConfig.Revocation.CheckChains(chains)
 	if err != nil {
 		s.log.Warnf("Certificate does not pass verification as '%s': %s", name, err)
 		return err
@@ -412,6 +436,63 @@ func (s *FileSecurity) VerifyCertificate(certpem []byte, name string) error {
 	return nil
 }
 
+// CheckRevocation verifies cert against the CA and checks the resulting chains against the configured revocation sources
+func (s *FileSecurity) CheckRevocation(cert *x509.Certificate, certpem []byte) error {
+	if s.conf.TLSConfig.Revocation == nil {
+		return nil
+	}
+
+	roots, err := s.caCertPool()
+	if err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	intermediates.AppendCertsFromPEM(certpem)
+
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.conf.TLSConfig.Revocation.CheckChains(chains)
+}
+
+// caCertPool is the CA as a certificate pool, the CA file is only read and parsed again after it changed
+func (s *FileSecurity) caCertPool() (*x509.CertPool, error) {
+	capath := s.caPath()
+
+	stat, err := os.Stat(capath)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.caPool != nil && stat.ModTime().Equal(s.caPoolMod) && stat.Size() == s.caPoolSize {
+		return s.caPool, nil
+	}
+
+	capem, err := os.ReadFile(capath)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(capem)
+
+	s.caPool = pool
+	s.caPoolMod = stat.ModTime()
+	s.caPoolSize = stat.Size()
+
+	return pool, nil
+}
+
 func findName(names []string, name string) bool {
 	for _, n := range names {
 		if n == name {
@@ -467,19 +548,12 @@ func (s *FileSecurity) TLSConfig() (*tls.Config, error) {
 	}
 
 	if s.privateKeyExists() && s.publicCertExists() {
-		cert, err := tls.LoadX509KeyPair(pub, pri)
-		if err != nil {
-			err = fmt.Errorf("could not load certificate %s and key %s: %s", pub, pri, err)
-			return nil, err
-		}
-
-		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		kp, err := s.certificate(pub, pri)
 		if err != nil {
-			err = fmt.Errorf("error parsing certificate: %v", err)
 			return nil, err
 		}
 
-		tlsc.Certificates = []tls.Certificate{cert}
+		kp.Configure(tlsc)
 	}
 
 	if s.caExists() {
@@ -495,6 +569,10 @@ func (s *FileSecurity) TLSConfig() (*tls.Config, error) {
 		tlsc.RootCAs = caCertPool
 	}
 
+	if s.conf.TLSConfig.Revocation != nil {
+		tlsc.VerifyConnection = s.conf.TLSConfig.Revocation.VerifyConnection
+	}
+
 	if s.conf.DisableTLSVerify {
 		tlsc.InsecureSkipVerify = true
 	}
@@ -502,6 +580,50 @@ func (s *FileSecurity) TLSConfig() (*tls.Config, error) {
 	return tlsc, nil
 }
 
+// certificate is the key pair shared by all TLS configurations, it is loaded on first use and reloaded by ReloadCredentials()
+func (s *FileSecurity) certificate(pub string, pri string) (*tlssetup.KeyPair, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keyPair != nil && s.keyPair.CertFile() == pub && s.keyPair.KeyFile() == pri {
+		return s.keyPair, nil
+	}
+
+	kp, err := tlssetup.NewKeyPair(pub, pri)
+	if err != nil {
+		return nil, err
+	}
+
+	s.keyPair = kp
+
+	return kp, nil
+}
+
+// ReloadCredentials loads a renewed certificate and key, TLS configurations made earlier use it for new connections
+func (s *FileSecurity) ReloadCredentials() (changed []string, err error) {
+	s.mu.Lock()
+	kp := s.keyPair
+	s.mu.Unlock()
+
+	// nothing uses the certificate yet, it will be loaded fresh when first needed
+	if kp == nil {
+		return nil, nil
+	}
+
+	reloaded, err := kp.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	if !reloaded {
+		return nil, nil
+	}
+
+	s.log.Infof("Reloaded certificate %s expiring %s", kp.CertFile(), kp.Certificate().Leaf.NotAfter)
+
+	return []string{"certificate"}, nil
+}
+
 func (s *FileSecurity) constructCustomVerifier(pool *x509.CertPool) func(cs tls.ConnectionState) error {
 	return func(cs tls.ConnectionState) error {
 		s.log.Debug("Verifying connection using legacy SAN free certificate support")
@@ -530,8 +652,12 @@ func (s *FileSecurity) constructCustomVerifier(pool *x509.CertPool) func(cs tls.
 		for _, cert := range cs.PeerCertificates[1:] {
 			opts.Intermediates.AddCert(cert)
 		}
-		_, err := cs.PeerCertificates[0].Verify(opts)
-		return err
+		chains, err := cs.PeerCertificates[0].Verify(opts)
+		if err != nil {
+			return err
+		}
+
+		return s.conf.TLSConfig.Revocation.CheckChains(chains)
 	}
 }
 
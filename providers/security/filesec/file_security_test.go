@@ -282,8 +282,13 @@ var _ = Describe("FileSecurity", func() {
 			cert, err := tls.LoadX509KeyPair(pub, pri)
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(c.Certificates).To(HaveLen(1))
-			Expect(c.Certificates[0].Certificate).To(Equal(cert.Certificate))
+			active, err := c.GetCertificate(&tls.ClientHelloInfo{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active.Certificate).To(Equal(cert.Certificate))
+
+			active, err = c.GetClientCertificate(&tls.CertificateRequestInfo{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active.Certificate).To(Equal(cert.Certificate))
 		})
 
 		It("Should support disabling tls verify", func() {
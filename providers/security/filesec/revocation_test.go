@@ -0,0 +1,211 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package filesec
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/choria-io/go-choria/tlssetup"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+var _ = Describe("Revocation", func() {
+	var (
+		td      string
+		ca      *x509.Certificate
+		caKey   *rsa.PrivateKey
+		crlFile string
+		prov    *FileSecurity
+	)
+
+	writePEM := func(file string, kind string, der []byte) {
+		Expect(os.MkdirAll(filepath.Dir(file), 0700)).To(Succeed())
+		Expect(os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600)).To(Succeed())
+	}
+
+	newKey := func() *rsa.PrivateKey {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		return key
+	}
+
+	newCA := func(name string) (*x509.Certificate, *rsa.PrivateKey) {
+		key := newKey()
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).ToNot(HaveOccurred())
+		cert, err := x509.ParseCertificate(der)
+		Expect(err).ToNot(HaveOccurred())
+
+		return cert, key
+	}
+
+	newCert := func(name string, serial int64) {
+		key := newKey()
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		writePEM(filepath.Join(td, "certs", name+".pem"), "CERTIFICATE", der)
+		writePEM(filepath.Join(td, "private_keys", name+".pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	}
+
+	writeCRL := func(issuer *x509.Certificate, key *rsa.PrivateKey, serials ...int64) {
+		template := &x509.RevocationList{
+			Number:     big.NewInt(1),
+			ThisUpdate: time.Now().Add(-time.Minute),
+			NextUpdate: time.Now().Add(time.Hour),
+		}
+
+		for _, serial := range serials {
+			template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+				SerialNumber:   big.NewInt(serial),
+				RevocationTime: time.Now().Add(-time.Minute),
+			})
+		}
+
+		der, err := x509.CreateRevocationList(rand.Reader, template, issuer, key)
+		Expect(err).ToNot(HaveOccurred())
+		writePEM(crlFile, "X509 CRL", der)
+	}
+
+	certPEM := func(name string) []byte {
+		pb, err := os.ReadFile(filepath.Join(td, "certs", name+".pem"))
+		Expect(err).ToNot(HaveOccurred())
+		return pb
+	}
+
+	BeforeEach(func() {
+		td = GinkgoT().TempDir()
+		crlFile = filepath.Join(td, "crl.pem")
+
+		ca, caKey = newCA("Ginkgo CA")
+		writePEM(filepath.Join(td, "certs", "ca.pem"), "CERTIFICATE", ca.Raw)
+		newCert("good.mcollective", 10)
+		newCert("revoked.mcollective", 11)
+		writeCRL(ca, caKey, 11)
+
+		cfg := &Config{}
+		setTLS(cfg, td, "good.mcollective", "")
+		cfg.TLSConfig = tlssetup.TLSConfig(nil)
+		cfg.TLSConfig.Revocation = tlssetup.NewRevocationChecker(crlFile, time.Hour, false, false)
+
+		l := logrus.New()
+		l.Out = io.Discard
+
+		var err error
+		prov, err = New(WithConfig(cfg), WithLog(l.WithFields(logrus.Fields{})))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Should reject revoked certificates", func() {
+		Expect(prov.VerifyCertificate(certPEM("good.mcollective"), "good.mcollective")).To(Succeed())
+		Expect(prov.VerifyCertificate(certPEM("revoked.mcollective"), "revoked.mcollective")).To(MatchError(MatchRegexp("certificate revoked.mcollective with serial 11 was revoked at")))
+
+		_, err := prov.ShouldAllowCaller("revoked.mcollective", certPEM("revoked.mcollective"))
+		Expect(err).To(MatchError(ContainSubstring("was revoked")))
+	})
+
+	It("Should reject signatures made by revoked certificates", func() {
+		for _, name := range []string{"good.mcollective", "revoked.mcollective"} {
+			signer, err := New(WithConfig(&Config{
+				Identity:    name,
+				Certificate: filepath.Join(td, "certs", name+".pem"),
+				Key:         filepath.Join(td, "private_keys", name+".pem"),
+				CA:          filepath.Join(td, "certs", "ca.pem"),
+			}), WithLog(prov.log))
+			Expect(err).ToNot(HaveOccurred())
+
+			sig, err := signer.SignBytes([]byte("too many secrets"))
+			Expect(err).ToNot(HaveOccurred())
+
+			valid, _ := prov.VerifySignatureBytes([]byte("too many secrets"), sig, certPEM(name))
+			Expect(valid).To(Equal(name == "good.mcollective"))
+		}
+	})
+
+	It("Should reject revoked TLS peers", func() {
+		tlsc, err := prov.TLSConfig()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tlsc.VerifyConnection).ToNot(BeNil())
+
+		chain := func(name string) [][]*x509.Certificate {
+			block, _ := pem.Decode(certPEM(name))
+			cert, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).ToNot(HaveOccurred())
+			return [][]*x509.Certificate{{cert, ca}}
+		}
+
+		Expect(tlsc.VerifyConnection(tls.ConnectionState{VerifiedChains: chain("good.mcollective")})).To(Succeed())
+		Expect(tlsc.VerifyConnection(tls.ConnectionState{VerifiedChains: chain("revoked.mcollective")})).To(MatchError(ContainSubstring("was revoked")))
+	})
+
+	It("Should only trust CRLs signed by the issuer", func() {
+		other, otherKey := newCA("Ginkgo CA")
+		writeCRL(other, otherKey)
+		prov.conf.TLSConfig.Revocation = tlssetup.NewRevocationChecker(crlFile, time.Hour, false, false)
+
+		Expect(prov.VerifyCertificate(certPEM("good.mcollective"), "good.mcollective")).To(MatchError("the CRL " + crlFile + " is not signed by Ginkgo CA"))
+	})
+
+	It("Should parse the CA again only once it changed", func() {
+		pool, err := prov.caCertPool()
+		Expect(err).ToNot(HaveOccurred())
+
+		cached, err := prov.caCertPool()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cached).To(BeIdenticalTo(pool))
+
+		other, _ := newCA("Other CA")
+		caFile := filepath.Join(td, "certs", "ca.pem")
+		writePEM(caFile, "CERTIFICATE", other.Raw)
+		Expect(os.Chtimes(caFile, time.Now(), time.Now().Add(time.Minute))).To(Succeed())
+
+		reloaded, err := prov.caCertPool()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reloaded).ToNot(BeIdenticalTo(pool))
+		Expect(prov.VerifyCertificate(certPEM("good.mcollective"), "good.mcollective")).To(HaveOccurred())
+	})
+
+	It("Should describe the CRL", func() {
+		info, err := prov.conf.TLSConfig.Revocation.CRL()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Source).To(Equal(crlFile))
+		Expect(info.Issuer).To(Equal("CN=Ginkgo CA"))
+		Expect(info.Number).To(Equal("1"))
+		Expect(info.Revoked).To(HaveKey("11"))
+		Expect(info.Revoked).To(HaveLen(1))
+	})
+})
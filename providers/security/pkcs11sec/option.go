@@ -7,6 +7,7 @@ package pkcs11sec
 import (
 	"github.com/choria-io/go-choria/config"
 	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/tlssetup"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,6 +22,7 @@ func WithChoriaConfig(c *config.Config) Option {
 			CAFile:           c.Choria.FileSecurityCA,
 			PKCS11DriverFile: c.Choria.PKCS11DriverFile,
 			PKCS11Slot:       uint(c.Choria.PKCS11Slot),
+			TLSConfig:        tlssetup.TLSConfig(c),
 		}
 
 		p.conf = &cfg
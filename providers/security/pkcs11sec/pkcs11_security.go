@@ -26,6 +26,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/choria-io/go-choria/providers/security/filesec"
+	"github.com/choria-io/go-choria/tlssetup"
 )
 
 // fetched from https://golang.org/src/crypto/rsa/pkcs1v15.go
@@ -99,6 +100,9 @@ type Config struct {
 
 	// RemoteSigner is the signer used to sign requests using a remote like AAA Service
 	RemoteSigner inter.RequestSigner
+
+	// TLSConfig is the shared TLS configuration state between security providers
+	TLSConfig *tlssetup.Config
 }
 
 func New(opts ...Option) (*Pkcs11Security, error) {
@@ -271,6 +275,7 @@ func (p *Pkcs11Security) reinit() error {
 		Certificate:      "unused",
 		Identity:         "unused",
 		RemoteSigner:     p.conf.RemoteSigner,
+		TLSConfig:        p.conf.TLSConfig,
 	}
 
 	p.fsec, err = filesec.New(filesec.WithConfig(&fc), filesec.WithLog(p.log))
@@ -372,6 +377,12 @@ func (p *Pkcs11Security) VerifySignatureBytes(dat []byte, sig []byte, public ...
 			p.log.Errorf("Could not parse decoded PEM data for public key: %s", err)
 			return false, ""
 		}
+
+		err = p.fsec.CheckRevocation(cert, pubcert)
+		if err != nil {
+			p.log.Errorf("Signature verification failed: %s", err)
+			return false, ""
+		}
 	} else {
 		cert = p.cert.Leaf
 	}
@@ -473,6 +484,10 @@ func (p *Pkcs11Security) TLSConfig() (*tls.Config, error) {
 		RootCAs:   caCertPool,
 	}
 
+	if p.conf.TLSConfig != nil && p.conf.TLSConfig.Revocation != nil {
+		tlsc.VerifyConnection = p.conf.TLSConfig.Revocation.VerifyConnection
+	}
+
 	if p.conf.DisableTLSVerify {
 		tlsc.InsecureSkipVerify = true
 	}
@@ -358,6 +358,11 @@ func (s *PuppetSecurity) ClientTLSConfig() (*tls.Config, error) {
 	return s.fsec.ClientTLSConfig()
 }
 
+// ReloadCredentials loads a renewed certificate and key
+func (s *PuppetSecurity) ReloadCredentials() (changed []string, err error) {
+	return s.fsec.ReloadCredentials()
+}
+
 // SSLContext creates a SSL context loaded with our certs and ca
 func (s *PuppetSecurity) SSLContext() (*http.Transport, error) {
 	return s.fsec.SSLContext()
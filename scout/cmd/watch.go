@@ -160,6 +160,9 @@ func (w *WatchCommand) handleTransition(m *nats.Msg, gui *gocui.Gui) {
 		return
 	}
 
+	if transition.IsVeto() {
+		return
+	}
 	if slices.Contains(w.ignoreMachineTransitions, transition.Machine) {
 		return
 	}
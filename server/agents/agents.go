@@ -7,8 +7,10 @@ package agents
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -74,6 +76,13 @@ type Metadata struct {
 	Description string `json:"description"`
 	Provider    string `json:"provider,omitempty"`
 	Service     bool   `json:"service,omitempty"`
+
+	// MaxConcurrency limits how many requests the agent handles concurrently, 0 is unlimited
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+	// ActionConcurrency limits how many requests individual actions handle concurrently
+	ActionConcurrency map[string]int `json:"action_concurrency,omitempty"`
+	// MaxQueue is how many requests can wait for a free slot before being rejected
+	MaxQueue int `json:"max_queue,omitempty"`
 }
 
 // Manager manages agents, handles registration, dispatches requests etc
@@ -88,6 +97,8 @@ type Manager struct {
 	denylist     []string
 	requests     chan inter.ConnectorMessage
 	servicesOnly bool
+	limiters     map[string]*concurrencyLimiter
+	inflight     map[string]*inflightRequest
 }
 
 // NewServices creates an agent manager restricted to service agents
@@ -104,6 +115,8 @@ func New(requests chan inter.ConnectorMessage, fw inter.Framework, conn inter.Co
 	return &Manager{
 		agents:     make(map[string]Agent),
 		subs:       make(map[string][]string),
+		limiters:   make(map[string]*concurrencyLimiter),
+		inflight:   make(map[string]*inflightRequest),
 		fw:         fw,
 		log:        log.WithFields(logrus.Fields{"subsystem": "agents"}),
 		mu:         &sync.Mutex{},
@@ -153,6 +166,7 @@ func (a *Manager) ReplaceAgent(name string, agent Agent) error {
 	agent.SetServerInfo(a.serverInfo)
 
 	a.agents[name] = agent
+	a.updateLimiters(agent)
 
 	return nil
 }
@@ -203,6 +217,7 @@ func (a *Manager) UnregisterAgent(name string, conn inter.AgentConnector) error
 
 	delete(a.agents, name)
 	delete(a.subs, name)
+	a.resetLimiters(name)
 
 	return nil
 }
@@ -364,15 +379,75 @@ func (a *Manager) Dispatch(ctx context.Context, wg *sync.WaitGroup, replies chan
 		return
 	}
 
-	result := make(chan *AgentReply)
+	identity := a.fw.Configuration().Identity
+
+	if id, ok := cancellationRequest(msg); ok {
+		err := a.CancelRequest(id, request.CallerID())
+		if err != nil {
+			a.log.Debugf("Could not cancel request %s for %s: %v", id, request.CallerID(), err)
+			return
+		}
+
+		a.log.Infof("Cancelled request %s for %s on request of %s", id, agent.Name(), request.CallerID())
+		cancelledCtr.WithLabelValues(identity, agent.Name()).Inc()
+
+		return
+	}
+
+	// buffered so handlers that finish after a timeout do not block forever and release their slot
+	result := make(chan *AgentReply, 1)
 
 	td := time.Duration(agent.Metadata().Timeout) * time.Second
 	a.log.Debugf("Handling message %s with timeout %s", msg.RequestID(), td)
 
-	timeout, cancel := context.WithTimeout(context.Background(), td)
-	defer cancel()
+	// requests can be cancelled by the caller while waiting for a slot and while being handled
+	rctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	inflight := a.trackRequest(msg.RequestID(), request.CallerID(), cancel)
+	defer a.untrackRequest(msg.RequestID(), inflight)
+
+	action := requestAction(msg)
+
+	// waiting for a slot is limited by the agent timeout, handling the request gets the full timeout once a slot is acquired
+	qctx, cancelQueue := context.WithTimeout(rctx, td)
+	release, err := a.acquireSlots(qctx, agent, action)
+	cancelQueue()
+
+	if err != nil && errors.Is(context.Cause(rctx), ErrRequestCancelled) {
+		replies <- &AgentReply{
+			Message: msg,
+			Request: request,
+			Body:    cancelledReply(msg.RequestID(), action),
+		}
+
+		return
+	}
+
+	if err != nil {
+		a.log.Warnf("Rejecting request %s for %s#%s from %s: %v", msg.RequestID(), agent.Name(), action, request.CallerID(), err)
+		rejectedCtr.WithLabelValues(identity, agent.Name(), action).Inc()
+
+		replies <- &AgentReply{
+			Message: msg,
+			Request: request,
+			Body:    busyReply(agent.Name(), action, err),
+		}
+
+		return
+	}
+
+	timeout, cancelTimeout := context.WithTimeout(rctx, td)
+	defer cancelTimeout()
+
+	inflightGauge.WithLabelValues(identity, agent.Name(), action).Inc()
 
-	go agent.HandleMessage(timeout, msg, request, a.conn, result)
+	go func() {
+		defer release()
+		defer inflightGauge.WithLabelValues(identity, agent.Name(), action).Dec()
+
+		agent.HandleMessage(timeout, msg, request, a.conn, result)
+	}()
 
 	select {
 	case reply := <-result:
@@ -384,6 +459,16 @@ func (a *Manager) Dispatch(ctx context.Context, wg *sync.WaitGroup, replies chan
 			Error:   fmt.Errorf("agent dispatcher for request %s exiting on interrupt", msg.RequestID()),
 		}
 	case <-timeout.Done():
+		if errors.Is(context.Cause(timeout), ErrRequestCancelled) {
+			replies <- &AgentReply{
+				Message: msg,
+				Request: request,
+				Body:    cancelledReply(msg.RequestID(), action),
+			}
+
+			return
+		}
+
 		replies <- &AgentReply{
 			Message: msg,
 			Request: request,
@@ -392,6 +477,42 @@ func (a *Manager) Dispatch(ctx context.Context, wg *sync.WaitGroup, replies chan
 	}
 }
 
+// updateLimiters applies the limits of a replacement agent to its cached concurrency limiters, the slots held by
+// in-flight requests are carried over so the new limits apply to them too, must be called with mu held
+func (a *Manager) updateLimiters(agent Agent) {
+	name := agent.Name()
+
+	for key, limiter := range a.limiters {
+		var action string
+
+		switch {
+		case key == name:
+		case strings.HasPrefix(key, name+"#"):
+			action = strings.TrimPrefix(key, name+"#")
+		default:
+			continue
+		}
+
+		limit := a.concurrencyLimit(agent, action)
+		if limiter == nil || limit < 1 {
+			// recreated on next use, unlimited agents do not track in-flight requests
+			delete(a.limiters, key)
+			continue
+		}
+
+		limiter.update(limit, a.maxQueue(agent))
+	}
+}
+
+// resetLimiters removes cached concurrency limiters for an agent so that they are recreated using current metadata
+func (a *Manager) resetLimiters(name string) {
+	for key := range a.limiters {
+		if key == name || strings.HasPrefix(key, name+"#") {
+			delete(a.limiters, key)
+		}
+	}
+}
+
 // Logger is the logger the manager prefers new agents derive from
 func (a *Manager) Logger() *logrus.Entry {
 	return a.log
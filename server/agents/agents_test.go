@@ -66,8 +66,11 @@ var _ = Describe("Server/Agents", func() {
 		}
 
 		handler = func(ctx context.Context, msg *message.Message, request protocol.Request, ci inter.ConnectorInfo, result chan *AgentReply) {
-			if bytes.Equal(msg.Payload(), []byte("sleep")) {
+			switch {
+			case bytes.Equal(msg.Payload(), []byte("sleep")):
 				time.Sleep(10 * time.Second)
+			case bytes.Equal(msg.Payload(), []byte("nap")):
+				time.Sleep(700 * time.Millisecond)
 			}
 
 			reply := &AgentReply{
@@ -292,6 +295,33 @@ var _ = Describe("Server/Agents", func() {
 			Expect(mgr.ReplaceAgent("testing", agent)).To(Succeed())
 			Expect(mgr.agents["testing"]).To(Equal(agent))
 		})
+
+		It("Should carry in-flight requests over to the new limits", func() {
+			mgr.agents["stub_agent"] = oa
+			agent.EXPECT().ShouldActivate().Return(true).Times(2)
+			agent.Metadata().MaxConcurrency = 1
+			cfg.Choria.AgentMaxQueue = 0
+
+			release, err := mgr.acquireSlots(ctx, agent, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mgr.ReplaceAgent("stub_agent", agent)).To(Succeed())
+			_, err = mgr.acquireSlots(ctx, agent, "")
+			Expect(err).To(MatchError(ErrQueueFull))
+
+			agent.Metadata().MaxConcurrency = 2
+			Expect(mgr.ReplaceAgent("stub_agent", agent)).To(Succeed())
+			other, err := mgr.acquireSlots(ctx, agent, "")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = mgr.acquireSlots(ctx, agent, "")
+			Expect(err).To(MatchError(ErrQueueFull))
+
+			release()
+			other()
+			release, err = mgr.acquireSlots(ctx, agent, "")
+			Expect(err).ToNot(HaveOccurred())
+			release()
+		})
 	})
 
 	Describe("KnownAgents", func() {
@@ -408,5 +438,200 @@ var _ = Describe("Server/Agents", func() {
 
 			Expect(reply.Error.Error()).To(MatchRegexp("exiting on 1s timeout"))
 		})
+
+		It("Should reply with an error when the agent is too busy", func() {
+			agent.Metadata().Timeout = 1
+			agent.Metadata().MaxConcurrency = 1
+			cfg.Choria.AgentMaxQueue = 0
+
+			err := mgr.RegisterAgent(ctx, "stub", agent, conn)
+			Expect(err).ToNot(HaveOccurred())
+
+			release, err := mgr.acquireSlots(ctx, agent, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			replyc := make(chan *AgentReply, 1)
+			wg.Add(1)
+			mgr.Dispatch(ctx, wg, replyc, msg, request)
+
+			reply := <-replyc
+			Expect(reply.Error).ToNot(HaveOccurred())
+			Expect(string(reply.Body)).To(ContainSubstring(`"statuscode":1`))
+			Expect(string(reply.Body)).To(ContainSubstring("request queue is full"))
+
+			release()
+
+			wg.Add(1)
+			mgr.Dispatch(ctx, wg, replyc, msg, request)
+			reply = <-replyc
+			Expect(reply.Body).To(Equal([]byte("pong hello world")))
+		})
+
+		It("Should start the timeout once a slot is acquired", func() {
+			agent.Metadata().Timeout = 1
+			agent.Metadata().MaxConcurrency = 1
+
+			err := mgr.RegisterAgent(ctx, "stub", agent, conn)
+			Expect(err).ToNot(HaveOccurred())
+
+			release, err := mgr.acquireSlots(ctx, agent, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			msg.SetPayload([]byte("nap"))
+			replyc := make(chan *AgentReply, 1)
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				mgr.Dispatch(ctx, wg, replyc, msg, request)
+			}()
+
+			// queued for 500ms and handled for 700ms, more than the 1s timeout combined
+			time.Sleep(500 * time.Millisecond)
+			release()
+
+			var reply *AgentReply
+			Eventually(replyc, 2*time.Second).Should(Receive(&reply))
+			Expect(reply.Error).ToNot(HaveOccurred())
+			Expect(reply.Body).To(Equal([]byte("pong nap")))
+		})
+
+		It("Should cancel in-flight requests on request of the caller", func() {
+			err := mgr.RegisterAgent(ctx, "stub", agent, conn)
+			Expect(err).ToNot(HaveOccurred())
+
+			msg.SetPayload([]byte("sleep"))
+			replyc := make(chan *AgentReply, 1)
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				mgr.Dispatch(ctx, wg, replyc, msg, request)
+			}()
+
+			Eventually(func() error { return mgr.CancelRequest("123", "choria=other.mcollective") }).Should(MatchError("request 123 was not made by choria=other.mcollective"))
+
+			creq, err := v1.NewRequest("stub", "example.net", "choria=rip.mcollective", 60, "456", "cone")
+			Expect(err).ToNot(HaveOccurred())
+			creq.SetMessage([]byte(`{"agent":"stub","cancel_request":"123"}`))
+			cmsg, err := message.NewMessageFromRequest(creq, "choria.reply.to", mgr.fw)
+			Expect(err).ToNot(HaveOccurred())
+
+			wg.Add(1)
+			mgr.Dispatch(ctx, wg, replyc, cmsg, creq)
+
+			reply := <-replyc
+			Expect(reply.Error).ToNot(HaveOccurred())
+			Expect(reply.Message.RequestID()).To(Equal("123"))
+			Expect(string(reply.Body)).To(ContainSubstring(`"statuscode":1`))
+			Expect(string(reply.Body)).To(ContainSubstring("Request 123 was cancelled by the caller"))
+
+			Eventually(func() error { return mgr.CancelRequest("123", "choria=rip.mcollective") }).Should(MatchError("unknown request 123"))
+		})
+	})
+
+	Describe("Concurrency", func() {
+		It("Should determine limits from metadata and configuration", func() {
+			Expect(mgr.concurrencyLimit(agent, "")).To(Equal(0))
+
+			agent.Metadata().MaxConcurrency = 2
+			agent.Metadata().ActionConcurrency = map[string]int{"install": 1}
+			Expect(mgr.concurrencyLimit(agent, "")).To(Equal(2))
+			Expect(mgr.concurrencyLimit(agent, "install")).To(Equal(1))
+			Expect(mgr.concurrencyLimit(agent, "status")).To(Equal(0))
+
+			cfg.SetOption("plugin.stub_agent.max_concurrency", "5")
+			cfg.SetOption("plugin.stub_agent.status.max_concurrency", "3")
+			Expect(mgr.concurrencyLimit(agent, "")).To(Equal(5))
+			Expect(mgr.concurrencyLimit(agent, "status")).To(Equal(3))
+		})
+
+		It("Should determine the queue size from metadata and configuration", func() {
+			Expect(mgr.maxQueue(agent)).To(Equal(100))
+
+			agent.Metadata().MaxQueue = 10
+			Expect(mgr.maxQueue(agent)).To(Equal(10))
+
+			cfg.SetOption("plugin.stub_agent.max_queue", "1")
+			Expect(mgr.maxQueue(agent)).To(Equal(1))
+		})
+
+		It("Should apply both agent and action limits", func() {
+			agent.Metadata().MaxConcurrency = 2
+			agent.Metadata().ActionConcurrency = map[string]int{"install": 1}
+
+			Expect(mgr.limitersFor(agent, "install")).To(HaveLen(2))
+			Expect(mgr.limitersFor(agent, "status")).To(HaveLen(1))
+		})
+
+		It("Should not hold agent slots while waiting for a busy action", func() {
+			agent.Metadata().MaxConcurrency = 5
+			agent.Metadata().ActionConcurrency = map[string]int{"install": 1}
+
+			release, err := mgr.acquireSlots(ctx, agent, "install")
+			Expect(err).ToNot(HaveOccurred())
+
+			qctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					_, err := mgr.acquireSlots(qctx, agent, "install")
+					Expect(err).To(MatchError(ErrQueueTimeout))
+				}()
+			}
+
+			install := mgr.limitersFor(agent, "install")[0]
+			Eventually(func() int {
+				install.mu.Lock()
+				defer install.mu.Unlock()
+				return install.waiting
+			}).Should(Equal(4))
+
+			to, tcancel := context.WithTimeout(ctx, time.Second)
+			defer tcancel()
+
+			for i := 0; i < 4; i++ {
+				other, err := mgr.acquireSlots(to, agent, "status")
+				Expect(err).ToNot(HaveOccurred())
+				defer other()
+			}
+
+			cancel()
+			wg.Wait()
+			release()
+		})
+
+		It("Should queue requests and time out waiting", func() {
+			limiter := newConcurrencyLimiter(1, 1)
+			queued := 0
+			queueCb := func(w bool) {
+				if w {
+					queued++
+				}
+			}
+
+			Expect(limiter.acquire(ctx, queueCb)).To(Succeed())
+
+			to, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			Expect(limiter.acquire(to, queueCb)).To(MatchError(ErrQueueTimeout))
+			Expect(queued).To(Equal(1))
+
+			done := make(chan error, 1)
+			go func() { done <- limiter.acquire(ctx, func(bool) {}) }()
+			Eventually(func() int {
+				limiter.mu.Lock()
+				defer limiter.mu.Unlock()
+				return limiter.waiting
+			}).Should(Equal(1))
+
+			Expect(limiter.acquire(ctx, queueCb)).To(MatchError(ErrQueueFull))
+
+			limiter.release()
+			Eventually(done).Should(Receive(BeNil()))
+		})
 	})
 })
@@ -0,0 +1,95 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/choria-io/go-choria/inter"
+)
+
+// ErrRequestCancelled is the cause set on the context of requests cancelled by their caller
+var ErrRequestCancelled = errors.New("request cancelled by the caller")
+
+// CancelRequest is the payload of a message asking servers to cancel an in-flight request
+type CancelRequest struct {
+	Agent  string `json:"agent"`
+	Cancel string `json:"cancel_request"`
+}
+
+// inflightRequest is a request currently being handled by an agent
+type inflightRequest struct {
+	caller string
+	cancel context.CancelCauseFunc
+}
+
+// trackRequest records an in-flight request so that it can later be cancelled by its caller
+func (a *Manager) trackRequest(id string, caller string, cancel context.CancelCauseFunc) *inflightRequest {
+	req := &inflightRequest{caller: caller, cancel: cancel}
+
+	a.mu.Lock()
+	a.inflight[id] = req
+	a.mu.Unlock()
+
+	return req
+}
+
+// untrackRequest removes a request from the in-flight requests once it completed
+func (a *Manager) untrackRequest(id string, req *inflightRequest) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.inflight[id] == req {
+		delete(a.inflight, id)
+	}
+}
+
+// CancelRequest cancels the in-flight request id, only the caller who made the request may cancel it
+func (a *Manager) CancelRequest(id string, caller string) error {
+	a.mu.Lock()
+	req, ok := a.inflight[id]
+	a.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("unknown request %s", id)
+	}
+
+	if req.caller != caller {
+		return fmt.Errorf("request %s was not made by %s", id, caller)
+	}
+
+	req.cancel(ErrRequestCancelled)
+
+	return nil
+}
+
+// cancellationRequest determines if msg is a request to cancel another request and returns the id of the request to cancel
+func cancellationRequest(msg inter.Message) (string, bool) {
+	req := CancelRequest{}
+
+	err := json.Unmarshal(msg.Payload(), &req)
+	if err != nil || req.Cancel == "" {
+		return "", false
+	}
+
+	return req.Cancel, true
+}
+
+// cancelledReply creates a SimpleRPC compatible reply indicating the request was aborted by its caller
+func cancelledReply(id string, action string) []byte {
+	reply := map[string]any{
+		"action":     action,
+		"statuscode": 1,
+		"statusmsg":  fmt.Sprintf("Request %s was cancelled by the caller", id),
+		"data":       map[string]any{},
+	}
+
+	j, _ := json.Marshal(reply)
+
+	return j
+}
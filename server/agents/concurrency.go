@@ -0,0 +1,264 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/choria-io/go-choria/inter"
+)
+
+var (
+	// ErrQueueFull indicates that a request could not wait for a free slot as the wait queue is full
+	ErrQueueFull = errors.New("request queue is full")
+
+	// ErrQueueTimeout indicates that a request timed out while waiting for a free slot
+	ErrQueueTimeout = errors.New("timeout waiting for a free request slot")
+)
+
+// concurrencyLimiter limits concurrent access to an agent or action with a bounded queue of waiting requests
+type concurrencyLimiter struct {
+	limit    int
+	maxQueue int
+	active   int
+	waiting  int
+	// free is closed and replaced whenever a slot might have become available
+	free chan struct{}
+	mu   sync.Mutex
+}
+
+func newConcurrencyLimiter(limit int, maxQueue int) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		limit:    limit,
+		maxQueue: maxQueue,
+		free:     make(chan struct{}),
+	}
+}
+
+// acquire waits for a free slot, the queued callback is called when the request has to wait
+func (l *concurrencyLimiter) acquire(ctx context.Context, queued func(bool)) error {
+	l.mu.Lock()
+	if l.active < l.limit {
+		l.active++
+		l.mu.Unlock()
+		return nil
+	}
+
+	if l.waiting >= l.maxQueue {
+		l.mu.Unlock()
+		return ErrQueueFull
+	}
+	l.waiting++
+	l.mu.Unlock()
+
+	queued(true)
+
+	defer func() {
+		l.mu.Lock()
+		l.waiting--
+		l.mu.Unlock()
+
+		queued(false)
+	}()
+
+	for {
+		l.mu.Lock()
+		if l.active < l.limit {
+			l.active++
+			l.mu.Unlock()
+			return nil
+		}
+		free := l.free
+		l.mu.Unlock()
+
+		select {
+		case <-free:
+		case <-ctx.Done():
+			return ErrQueueTimeout
+		}
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	l.notify()
+}
+
+// update changes the limits while keeping track of slots that are in use, requests holding slots
+// when the limit is lowered keep them until they are done
+func (l *concurrencyLimiter) update(limit int, maxQueue int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = limit
+	l.maxQueue = maxQueue
+	l.notify()
+}
+
+// notify wakes up waiting requests, must be called with mu held
+func (l *concurrencyLimiter) notify() {
+	close(l.free)
+	l.free = make(chan struct{})
+}
+
+// limiterKey is the key used to store the limiter for an agent or action
+func limiterKey(agent string, action string) string {
+	if action == "" {
+		return agent
+	}
+
+	return fmt.Sprintf("%s#%s", agent, action)
+}
+
+// concurrencyLimit determines the limit for an agent or action, configuration overrides agent metadata
+func (a *Manager) concurrencyLimit(agent Agent, action string) int {
+	md := agent.Metadata()
+	cfg := a.fw.Configuration()
+
+	key := fmt.Sprintf("plugin.%s.max_concurrency", md.Name)
+	limit := md.MaxConcurrency
+
+	if action != "" {
+		key = fmt.Sprintf("plugin.%s.%s.max_concurrency", md.Name, action)
+		limit = md.ActionConcurrency[action]
+	}
+
+	if cfg.HasOption(key) {
+		cl, err := strconv.Atoi(cfg.Option(key, "0"))
+		if err != nil {
+			a.log.Warnf("Invalid %s configuration: %v", key, err)
+		} else {
+			limit = cl
+		}
+	}
+
+	return limit
+}
+
+// maxQueue determines how many requests can wait for a free slot, configuration overrides agent metadata
+func (a *Manager) maxQueue(agent Agent) int {
+	md := agent.Metadata()
+	cfg := a.fw.Configuration()
+
+	queue := cfg.Choria.AgentMaxQueue
+	if md.MaxQueue > 0 {
+		queue = md.MaxQueue
+	}
+
+	key := fmt.Sprintf("plugin.%s.max_queue", md.Name)
+	if cfg.HasOption(key) {
+		q, err := strconv.Atoi(cfg.Option(key, "0"))
+		if err != nil {
+			a.log.Warnf("Invalid %s configuration: %v", key, err)
+		} else {
+			queue = q
+		}
+	}
+
+	return queue
+}
+
+// limitersFor retrieves the action and agent limiters that apply to a request, the action limiter comes first
+// so requests waiting for a busy action do not hold agent slots that other actions could use
+func (a *Manager) limitersFor(agent Agent, action string) []*concurrencyLimiter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var actions []string
+	if action != "" {
+		actions = append(actions, action)
+	}
+	actions = append(actions, "")
+
+	var result []*concurrencyLimiter
+
+	for _, act := range actions {
+		key := limiterKey(agent.Name(), act)
+
+		limiter, ok := a.limiters[key]
+		if !ok {
+			limit := a.concurrencyLimit(agent, act)
+			if limit > 0 {
+				limiter = newConcurrencyLimiter(limit, a.maxQueue(agent))
+			}
+
+			// nil limiters are stored to avoid repeated config lookups for unlimited agents
+			a.limiters[key] = limiter
+		}
+
+		if limiter != nil {
+			result = append(result, limiter)
+		}
+	}
+
+	return result
+}
+
+// acquireSlots acquires slots on all limiters that apply to a request in the order returned by limitersFor, the returned function releases all acquired slots
+func (a *Manager) acquireSlots(ctx context.Context, agent Agent, action string) (func(), error) {
+	var acquired []*concurrencyLimiter
+
+	release := func() {
+		for _, l := range acquired {
+			l.release()
+		}
+	}
+
+	queued := func(waiting bool) {
+		if waiting {
+			queuedGauge.WithLabelValues(a.fw.Configuration().Identity, agent.Name(), action).Inc()
+		} else {
+			queuedGauge.WithLabelValues(a.fw.Configuration().Identity, agent.Name(), action).Dec()
+		}
+	}
+
+	for _, limiter := range a.limitersFor(agent, action) {
+		err := limiter.acquire(ctx, queued)
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		acquired = append(acquired, limiter)
+	}
+
+	return release, nil
+}
+
+// busyReply creates a SimpleRPC compatible reply indicating the agent is too busy to handle the request
+func busyReply(agent string, action string, err error) []byte {
+	reply := map[string]any{
+		"action":     action,
+		"statuscode": 1,
+		"statusmsg":  fmt.Sprintf("Agent %s is too busy to handle the request: %v", agent, err),
+		"data":       map[string]any{},
+	}
+
+	j, _ := json.Marshal(reply)
+
+	return j
+}
+
+// requestAction extracts the action from a SimpleRPC request, empty when it cannot be determined
+func requestAction(msg inter.Message) string {
+	req := struct {
+		Action string `json:"action"`
+	}{}
+
+	err := json.Unmarshal(msg.Payload(), &req)
+	if err != nil {
+		return ""
+	}
+
+	return req.Action
+}
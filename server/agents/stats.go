@@ -0,0 +1,38 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agents
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	inflightGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "choria_server_agent_requests_inflight",
+		Help: "Number of requests currently being handled by an agent",
+	}, []string{"identity", "agent", "action"})
+
+	queuedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "choria_server_agent_requests_queued",
+		Help: "Number of requests waiting for a free slot due to concurrency limits",
+	}, []string{"identity", "agent", "action"})
+
+	rejectedCtr = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "choria_server_agent_requests_rejected",
+		Help: "Number of requests that were rejected due to concurrency limits",
+	}, []string{"identity", "agent", "action"})
+
+	cancelledCtr = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "choria_server_agent_requests_cancelled",
+		Help: "Number of in-flight requests that were cancelled by their caller",
+	}, []string{"identity", "agent"})
+)
+
+func init() {
+	prometheus.MustRegister(inflightGauge)
+	prometheus.MustRegister(queuedGauge)
+	prometheus.MustRegister(rejectedCtr)
+	prometheus.MustRegister(cancelledCtr)
+}
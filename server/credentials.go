@@ -0,0 +1,63 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/lifecycle"
+)
+
+// watchSecurityCredentials periodically loads renewed certificates and tokens so they are used when
+// reconnecting to the broker without restarting the server
+func (srv *Instance) watchSecurityCredentials(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	interval := srv.cfg.Choria.SecurityCredentialReload
+	if interval <= 0 {
+		srv.log.Infof("Reloading renewed security credentials is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			srv.reloadSecurityCredentials()
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (srv *Instance) reloadSecurityCredentials() {
+	changed, err := srv.fw.ReloadSecurityCredentials()
+	if err != nil {
+		srv.log.Errorf("Could not reload security credentials: %s", err)
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	srv.log.Infof("Reloaded renewed security credentials: %s", strings.Join(changed, ", "))
+
+	event, err := lifecycle.New(lifecycle.CredentialsReloaded, lifecycle.Identity(srv.cfg.Identity), lifecycle.Component(srv.eventComponent()), lifecycle.Credentials(changed...))
+	if err != nil {
+		srv.log.Errorf("Could not create new credentials reloaded event: %s", err)
+		return
+	}
+
+	err = srv.PublishEvent(event)
+	if err != nil {
+		srv.log.Errorf("Could not publish credentials reloaded event: %s", err)
+	}
+}
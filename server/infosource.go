@@ -68,6 +68,16 @@ func (srv *Instance) MachineHTTPPort() int {
 	return srv.cfg.Choria.MachinesHTTPPort
 }
 
+// MachineWebhooksFile is the file holding webhooks that receive notifications from all autonomous agents
+func (srv *Instance) MachineWebhooksFile() string {
+	return srv.cfg.Choria.MachinesWebhooks
+}
+
+// SubmissionSpool is the directory holding the Submission spool and its maximum size
+func (srv *Instance) SubmissionSpool() (string, int) {
+	return srv.cfg.Choria.SubmissionSpool, srv.cfg.Choria.SubmissionSpoolMaxSize
+}
+
 // Connector is the raw NATS connection, use with care, major vendor lock here - but needed for JetStream
 func (srv *Instance) Connector() inter.Connector {
 	return srv.connector
@@ -15,6 +15,7 @@ import (
 	"github.com/choria-io/go-choria/config"
 	"github.com/choria-io/go-choria/inter"
 	"github.com/choria-io/go-choria/providers/data"
+	"github.com/choria-io/go-choria/providers/execution"
 	"github.com/choria-io/go-choria/server/agents"
 	"github.com/choria-io/go-choria/server/discovery"
 	"github.com/choria-io/go-choria/server/registration"
@@ -126,6 +127,11 @@ func (srv *Instance) RunServiceHost(ctx context.Context, wg *sync.WaitGroup) err
 	wg.Add(1)
 	go srv.WriteServerStatus(sctx, wg)
 
+	srv.startTokenDenyList(sctx, wg)
+
+	wg.Add(1)
+	go srv.watchSecurityCredentials(sctx, wg)
+
 	srv.agents = agents.NewServices(srv.requests, srv.fw, srv.connector, srv, srv.log)
 
 	err = srv.setupAdditionalAgentProviders(sctx)
@@ -158,13 +164,21 @@ func (srv *Instance) RunServiceHost(ctx context.Context, wg *sync.WaitGroup) err
 	return nil
 }
 
-func (srv *Instance) setupExecutor() error {
+func (srv *Instance) setupExecutor(ctx context.Context, wg *sync.WaitGroup) error {
 	if !srv.cfg.Choria.ExecutorEnabled || srv.cfg.Choria.ExecutorSpool == "" {
 		srv.log.Infof("Skipping executor setup as no spool is configured")
 		return nil
 	}
 
-	return os.MkdirAll(srv.cfg.Choria.ExecutorSpool, 0700)
+	err := os.MkdirAll(srv.cfg.Choria.ExecutorSpool, 0700)
+	if err != nil {
+		return err
+	}
+
+	wg.Add(1)
+	go execution.RunReaper(ctx, wg, srv.cfg.Choria.ExecutorSpool, execution.RetentionPolicyFromChoria(srv.fw), srv.cfg.Choria.ExecutorRetentionInterval, srv.fw.Logger("executor"))
+
+	return nil
 }
 
 func (srv *Instance) SetupSubmissions(ctx context.Context, wg *sync.WaitGroup) error {
@@ -205,6 +219,11 @@ func (srv *Instance) Run(ctx context.Context, wg *sync.WaitGroup) error {
 	wg.Add(1)
 	go srv.WriteServerStatus(sctx, wg)
 
+	srv.startTokenDenyList(sctx, wg)
+
+	wg.Add(1)
+	go srv.watchSecurityCredentials(sctx, wg)
+
 	srv.agents = agents.New(srv.requests, srv.fw, srv.connector, srv, srv.log)
 	srv.registration = registration.New(srv.fw, srv, srv.connector, srv.log)
 
@@ -255,7 +274,7 @@ func (srv *Instance) Run(ctx context.Context, wg *sync.WaitGroup) error {
 		srv.log.Errorf("Submission setup failed: %s", err)
 	}
 
-	err = srv.setupExecutor()
+	err = srv.setupExecutor(sctx, wg)
 	if err != nil {
 		srv.log.Errorf("Could not setup choria executor: %s", err)
 	}
@@ -0,0 +1,28 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/nats-io/nats.go"
+)
+
+// startTokenDenyList keeps the list of revoked JWTs that requests are checked against up to date
+func (srv *Instance) startTokenDenyList(ctx context.Context, wg *sync.WaitGroup) {
+	list := srv.fw.TokenDenyList()
+	if list == nil {
+		return
+	}
+
+	srv.log.Infof("Rejecting requests from revoked JWT tokens listed in the %s bucket", list.Bucket())
+
+	wg.Add(1)
+	go list.Watch(ctx, wg, func(_ context.Context) (nats.KeyValue, error) {
+		return kv.LoadKV(srv.connector.Nats(), list.Bucket())
+	})
+}
@@ -15,6 +15,9 @@ type Config struct {
 
 	// CurvePreferences is a list of curve preferences for ECC
 	CurvePreferences []tls.CurveID
+
+	// Revocation checks certificates for revocation when a CRL or OCSP is configured
+	Revocation *RevocationChecker
 }
 
 func TLSConfig(c *config.Config) *Config {
@@ -46,5 +49,9 @@ func TLSConfig(c *config.Config) *Config {
 		}
 	}
 
+	if c.Choria.SecurityCRL != "" || c.Choria.SecurityOCSP {
+		cfg.Revocation = NewRevocationChecker(c.Choria.SecurityCRL, c.Choria.SecurityCRLRefresh, c.Choria.SecurityOCSP, c.Choria.SecurityOCSPStrict)
+	}
+
 	return cfg
 }
@@ -0,0 +1,125 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tlssetup
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// KeyPair is a certificate and private key loaded from disk that can be reloaded when the files change
+//
+// A tls.Config configured using Configure() obtains the certificate using its GetCertificate and
+// GetClientCertificate callbacks, new connections made after a reload use the renewed certificate
+// while established connections are unaffected.
+type KeyPair struct {
+	certFile string
+	keyFile  string
+
+	cert    *tls.Certificate
+	certPEM []byte
+	keyPEM  []byte
+
+	mu sync.Mutex
+}
+
+// NewKeyPair loads the certificate and key from certFile and keyFile
+func NewKeyPair(certFile string, keyFile string) (*KeyPair, error) {
+	kp := &KeyPair{certFile: certFile, keyFile: keyFile}
+
+	_, err := kp.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return kp, nil
+}
+
+// CertFile is the path to the certificate
+func (k *KeyPair) CertFile() string {
+	return k.certFile
+}
+
+// KeyFile is the path to the private key
+func (k *KeyPair) KeyFile() string {
+	return k.keyFile
+}
+
+// Reload reads the certificate and key again, changed is true when either file changed. Should the
+// new files not form a valid key pair, for example when only one was written so far, the previous
+// certificate stays active and an error is returned
+func (k *KeyPair) Reload() (changed bool, err error) {
+	certPEM, err := os.ReadFile(k.certFile)
+	if err != nil {
+		return false, fmt.Errorf("could not load certificate %s and key %s: %s", k.certFile, k.keyFile, err)
+	}
+
+	keyPEM, err := os.ReadFile(k.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("could not load certificate %s and key %s: %s", k.certFile, k.keyFile, err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.cert != nil && bytes.Equal(certPEM, k.certPEM) && bytes.Equal(keyPEM, k.keyPEM) {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		certificateReloadErrors.Inc()
+		return false, fmt.Errorf("could not load certificate %s and key %s: %s", k.certFile, k.keyFile, err)
+	}
+
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		certificateReloadErrors.Inc()
+		return false, fmt.Errorf("error parsing certificate: %v", err)
+	}
+
+	if k.cert != nil {
+		certificateReloads.Inc()
+	}
+
+	k.cert = &cert
+	k.certPEM = certPEM
+	k.keyPEM = keyPEM
+
+	return true, nil
+}
+
+// Certificate is the active certificate
+func (k *KeyPair) Certificate() *tls.Certificate {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.cert
+}
+
+// GetCertificate returns the active certificate, it is suitable for use in tls.Config
+func (k *KeyPair) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return k.Certificate(), nil
+}
+
+// GetClientCertificate returns the active certificate, it is suitable for use in tls.Config
+func (k *KeyPair) GetClientCertificate(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return k.Certificate(), nil
+}
+
+// Configure sets tlsc to obtain certificates from the key pair
+//
+// The active certificate is also set in Certificates as some consumers, like the NATS Server /varz
+// monitoring endpoint, read the certificate from there, they will report the certificate that was
+// active when tlsc was configured rather than a renewed one
+func (k *KeyPair) Configure(tlsc *tls.Config) {
+	tlsc.Certificates = []tls.Certificate{*k.Certificate()}
+	tlsc.GetCertificate = k.GetCertificate
+	tlsc.GetClientCertificate = k.GetClientCertificate
+}
@@ -0,0 +1,456 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tlssetup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ocsp"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultCRLRefreshInterval is how often a CRL is reloaded when no interval is configured
+	DefaultCRLRefreshInterval = time.Hour
+
+	// crlRetryInterval limits how often a CRL that failed to load is retried
+	crlRetryInterval = 10 * time.Second
+
+	// ocspFailureCache is how long an unreachable OCSP responder is not contacted again for the same certificate
+	ocspFailureCache = time.Minute
+
+	// ocspDefaultCache is how long OCSP responses without a next update time are cached
+	ocspDefaultCache = 5 * time.Minute
+
+	fetchTimeout = 10 * time.Second
+)
+
+// RevocationChecker checks certificates against a Certificate Revocation List and OCSP responders
+//
+// The CRL is loaded in the background when the checker is created and reloaded once the refresh interval passed
+// or the CRL reached its next update time, should reloading fail the previous CRL stays active. Checks made before
+// the first load completed wait for it, certificates are rejected when a configured CRL could never be loaded.
+//
+// OCSP responders are only consulted for certificates that list them, concurrent checks of the same certificate
+// share one query and responses are cached until their next update time. Unreachable responders or unknown
+// statuses only reject certificates in strict mode.
+type RevocationChecker struct {
+	source     string
+	refresh    time.Duration
+	ocsp       bool
+	ocspStrict bool
+	client     *http.Client
+	log        *logrus.Entry
+
+	crl            *x509.RevocationList
+	revoked        map[string]time.Time
+	loaded         time.Time
+	lastAttempt    time.Time
+	lastErr        error
+	refreshing     bool
+	trustedIssuers map[string]bool
+	ocspCache      map[string]*ocspResult
+	ocspFlight     singleflight.Group
+
+	// initialLoad is closed once the first attempt to load the CRL completed
+	initialLoad     chan struct{}
+	initialLoadOnce sync.Once
+
+	mu     sync.Mutex
+	ocspMu sync.Mutex
+}
+
+// CRLInfo describes the currently loaded CRL
+type CRLInfo struct {
+	Source     string            `json:"source"`
+	Issuer     string            `json:"issuer"`
+	Number     string            `json:"number,omitempty"`
+	ThisUpdate time.Time         `json:"this_update"`
+	NextUpdate time.Time         `json:"next_update,omitempty"`
+	Loaded     time.Time         `json:"loaded"`
+	Revoked    map[string]string `json:"revoked"`
+}
+
+type ocspResult struct {
+	err     error
+	expires time.Time
+}
+
+// NewRevocationChecker creates a checker that loads the CRL from crl, a file or http(s) URL, when it is not empty and
+// consults OCSP responders when useOCSP is true
+func NewRevocationChecker(crl string, refresh time.Duration, useOCSP bool, ocspStrict bool) *RevocationChecker {
+	if refresh <= 0 {
+		refresh = DefaultCRLRefreshInterval
+	}
+
+	r := &RevocationChecker{
+		source:         crl,
+		refresh:        refresh,
+		ocsp:           useOCSP,
+		ocspStrict:     ocspStrict,
+		client:         &http.Client{Timeout: fetchTimeout},
+		log:            logrus.NewEntry(logrus.StandardLogger()).WithField("revocation", crl),
+		trustedIssuers: make(map[string]bool),
+		ocspCache:      make(map[string]*ocspResult),
+		initialLoad:    make(chan struct{}),
+	}
+
+	if crl == "" {
+		close(r.initialLoad)
+		return r
+	}
+
+	// loading the CRL can involve a slow HTTP request, this is done without holding the lock so checks
+	// are not queued behind it
+	r.refreshing = true
+	r.lastAttempt = time.Now()
+	go r.refreshCRL()
+
+	return r
+}
+
+// SetLogger configures the logger used for background CRL refreshes and OCSP failures
+func (r *RevocationChecker) SetLogger(log *logrus.Entry) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	r.log = log.WithField("revocation", r.source)
+	r.mu.Unlock()
+}
+
+// Source is the file or URL the CRL is loaded from
+func (r *RevocationChecker) Source() string {
+	return r.source
+}
+
+// VerifyConnection checks the verified chains of a TLS connection, it is suitable for use in tls.Config
+func (r *RevocationChecker) VerifyConnection(cs tls.ConnectionState) error {
+	return r.CheckChains(cs.VerifiedChains)
+}
+
+// CheckChains checks every certificate in verified chains against its issuer, a nil checker accepts all chains
+func (r *RevocationChecker) CheckChains(chains [][]*x509.Certificate) error {
+	if r == nil {
+		return nil
+	}
+
+	for _, chain := range chains {
+		for i := 0; i < len(chain)-1; i++ {
+			err := r.Check(chain[i], chain[i+1])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Check determines if cert issued by issuer was revoked
+func (r *RevocationChecker) Check(cert *x509.Certificate, issuer *x509.Certificate) error {
+	if r == nil {
+		return nil
+	}
+
+	if r.source != "" {
+		err := r.checkCRL(cert, issuer)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.ocsp && len(cert.OCSPServer) > 0 {
+		return r.checkOCSP(cert, issuer)
+	}
+
+	return nil
+}
+
+// CRL is information about the loaded CRL, it is loaded when needed
+func (r *RevocationChecker) CRL() (*CRLInfo, error) {
+	if r == nil || r.source == "" {
+		return nil, fmt.Errorf("no CRL configured")
+	}
+
+	<-r.initialLoad
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	crl, err := r.currentCRL()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &CRLInfo{
+		Source:     r.source,
+		Issuer:     crl.Issuer.String(),
+		ThisUpdate: crl.ThisUpdate,
+		NextUpdate: crl.NextUpdate,
+		Loaded:     r.loaded,
+		Revoked:    make(map[string]string),
+	}
+
+	if crl.Number != nil {
+		info.Number = crl.Number.String()
+	}
+
+	for serial, at := range r.revoked {
+		info.Revoked[serial] = at.Format(time.RFC3339)
+	}
+
+	return info, nil
+}
+
+func (r *RevocationChecker) checkCRL(cert *x509.Certificate, issuer *x509.Certificate) error {
+	<-r.initialLoad
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	crl, err := r.currentCRL()
+	if err != nil {
+		revocationChecks.WithLabelValues("crl", "error").Inc()
+		return fmt.Errorf("could not check revocation of %s: %s", cert.Subject.CommonName, err)
+	}
+
+	// the CRL only covers certificates from its issuer
+	if !bytes.Equal(crl.RawIssuer, cert.RawIssuer) {
+		return nil
+	}
+
+	if issuer != nil {
+		id := fingerprint(issuer.Raw)
+		trusted, ok := r.trustedIssuers[id]
+		if !ok {
+			trusted = crl.CheckSignatureFrom(issuer) == nil
+			r.trustedIssuers[id] = trusted
+		}
+
+		if !trusted {
+			revocationChecks.WithLabelValues("crl", "error").Inc()
+			return fmt.Errorf("the CRL %s is not signed by %s", r.source, issuer.Subject.CommonName)
+		}
+	}
+
+	at, ok := r.revoked[cert.SerialNumber.String()]
+	if ok {
+		revocationChecks.WithLabelValues("crl", "revoked").Inc()
+		return fmt.Errorf("certificate %s with serial %s was revoked at %s", cert.Subject.CommonName, cert.SerialNumber, at.Format(time.RFC3339))
+	}
+
+	revocationChecks.WithLabelValues("crl", "good").Inc()
+
+	return nil
+}
+
+// currentCRL returns the loaded CRL and schedules a background load when it is stale or could not be loaded, must be called with mu held
+func (r *RevocationChecker) currentCRL() (*x509.RevocationList, error) {
+	stale := r.crl == nil || time.Since(r.loaded) > r.refresh || (!r.crl.NextUpdate.IsZero() && time.Now().After(r.crl.NextUpdate))
+	if stale && !r.refreshing && time.Since(r.lastAttempt) > crlRetryInterval {
+		r.refreshing = true
+		r.lastAttempt = time.Now()
+		go r.refreshCRL()
+	}
+
+	if r.crl == nil {
+		return nil, r.lastErr
+	}
+
+	return r.crl, nil
+}
+
+func (r *RevocationChecker) refreshCRL() {
+	defer r.initialLoadOnce.Do(func() { close(r.initialLoad) })
+
+	crl, err := r.fetchCRL()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refreshing = false
+
+	if err != nil {
+		crlLoadErrors.Inc()
+
+		if r.crl == nil {
+			r.lastErr = err
+			r.log.Errorf("Could not load CRL: %s", err)
+			return
+		}
+
+		r.log.Errorf("Could not refresh CRL, continuing to use the CRL loaded at %s: %s", r.loaded.Format(time.RFC3339), err)
+		return
+	}
+
+	r.lastErr = nil
+	r.setCRL(crl)
+	r.log.Infof("Loaded CRL from %s with %d revoked certificates", crl.Issuer.CommonName, len(r.revoked))
+}
+
+func (r *RevocationChecker) setCRL(crl *x509.RevocationList) {
+	revoked := make(map[string]time.Time, len(crl.RevokedCertificateEntries))
+	for _, entry := range crl.RevokedCertificateEntries {
+		revoked[entry.SerialNumber.String()] = entry.RevocationTime
+	}
+
+	r.crl = crl
+	r.revoked = revoked
+	r.loaded = time.Now()
+	r.trustedIssuers = make(map[string]bool)
+
+	crlEntries.Set(float64(len(revoked)))
+	crlNextUpdate.Set(float64(crl.NextUpdate.Unix()))
+}
+
+func (r *RevocationChecker) fetchCRL() (*x509.RevocationList, error) {
+	var body []byte
+	var err error
+
+	if strings.HasPrefix(r.source, "http://") || strings.HasPrefix(r.source, "https://") {
+		body, err = r.fetch(r.client.Get(r.source))
+	} else {
+		body, err = os.ReadFile(r.source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not load CRL %s: %s", r.source, err)
+	}
+
+	block, _ := pem.Decode(body)
+	if block != nil {
+		body = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse CRL %s: %s", r.source, err)
+	}
+
+	return crl, nil
+}
+
+func (r *RevocationChecker) fetch(resp *http.Response, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 64*1024*1024))
+}
+
+func (r *RevocationChecker) checkOCSP(cert *x509.Certificate, issuer *x509.Certificate) error {
+	if issuer == nil {
+		return nil
+	}
+
+	id := fingerprint(issuer.Raw) + cert.SerialNumber.String()
+
+	r.ocspMu.Lock()
+	cached, ok := r.ocspCache[id]
+	r.ocspMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.err
+	}
+
+	// many connections using the same certificate, like after a broker restart, share a single query
+	res, _, _ := r.ocspFlight.Do(id, func() (any, error) {
+		result := r.queryOCSP(cert, issuer)
+
+		r.ocspMu.Lock()
+		r.ocspCache[id] = result
+		for k, v := range r.ocspCache {
+			if time.Now().After(v.expires) {
+				delete(r.ocspCache, k)
+			}
+		}
+		r.ocspMu.Unlock()
+
+		return result, nil
+	})
+
+	return res.(*ocspResult).err
+}
+
+func (r *RevocationChecker) queryOCSP(cert *x509.Certificate, issuer *x509.Certificate) *ocspResult {
+	unavailable := func(err error) *ocspResult {
+		revocationChecks.WithLabelValues("ocsp", "error").Inc()
+
+		r.mu.Lock()
+		log := r.log
+		r.mu.Unlock()
+
+		if r.ocspStrict {
+			return &ocspResult{err: err, expires: time.Now().Add(ocspFailureCache)}
+		}
+
+		log.Warnf("Accepting certificate %s: %s", cert.Subject.CommonName, err)
+		return &ocspResult{expires: time.Now().Add(ocspFailureCache)}
+	}
+
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return unavailable(fmt.Errorf("could not create OCSP request for %s: %s", cert.Subject.CommonName, err))
+	}
+
+	var errs []string
+
+	for _, server := range cert.OCSPServer {
+		body, err := r.fetch(r.client.Post(server, "application/ocsp-request", bytes.NewReader(req)))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", server, err))
+			continue
+		}
+
+		resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", server, err))
+			continue
+		}
+
+		expires := resp.NextUpdate
+		if expires.IsZero() {
+			expires = time.Now().Add(ocspDefaultCache)
+		}
+
+		switch resp.Status {
+		case ocsp.Good:
+			revocationChecks.WithLabelValues("ocsp", "good").Inc()
+			return &ocspResult{expires: expires}
+
+		case ocsp.Revoked:
+			revocationChecks.WithLabelValues("ocsp", "revoked").Inc()
+			return &ocspResult{err: fmt.Errorf("certificate %s with serial %s was revoked at %s", cert.Subject.CommonName, cert.SerialNumber, resp.RevokedAt.Format(time.RFC3339)), expires: expires}
+
+		default:
+			return unavailable(fmt.Errorf("OCSP responder %s does not know certificate %s", server, cert.Subject.CommonName))
+		}
+	}
+
+	return unavailable(fmt.Errorf("could not check OCSP status of %s: %s", cert.Subject.CommonName, strings.Join(errs, ", ")))
+}
+
+func fingerprint(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
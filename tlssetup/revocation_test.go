@@ -0,0 +1,219 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tlssetup
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestTLSSetup(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TLS Setup")
+}
+
+var _ = Describe("RevocationChecker", func() {
+	var (
+		ca       *x509.Certificate
+		caKey    *rsa.PrivateKey
+		cert     *x509.Certificate
+		srv      *httptest.Server
+		status   atomic.Int64
+		requests atomic.Int64
+		delay    atomic.Int64
+		log      *logrus.Entry
+	)
+
+	newCert := func(template *x509.Certificate, parent *x509.Certificate, signer *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+
+		if parent == nil {
+			parent = template
+			signer = key
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+		Expect(err).ToNot(HaveOccurred())
+
+		c, err := x509.ParseCertificate(der)
+		Expect(err).ToNot(HaveOccurred())
+
+		return c, key
+	}
+
+	BeforeEach(func() {
+		status.Store(int64(ocsp.Good))
+		requests.Store(0)
+		delay.Store(0)
+
+		logger := logrus.New()
+		logger.SetOutput(GinkgoWriter)
+		log = logrus.NewEntry(logger)
+
+		ca, caKey = newCert(&x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "Ginkgo CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		}, nil, nil)
+
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			time.Sleep(time.Duration(delay.Load()))
+
+			body, err := io.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+
+			req, err := ocsp.ParseRequest(body)
+			Expect(err).ToNot(HaveOccurred())
+
+			resp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+				Status:       int(status.Load()),
+				SerialNumber: req.SerialNumber,
+				ThisUpdate:   time.Now().Add(-time.Minute),
+				NextUpdate:   time.Now().Add(time.Hour),
+				RevokedAt:    time.Now().Add(-time.Minute),
+			}, caKey)
+			Expect(err).ToNot(HaveOccurred())
+
+			w.Header().Set("Content-Type", "application/ocsp-response")
+			w.Write(resp)
+		}))
+		DeferCleanup(srv.Close)
+
+		cert, _ = newCert(&x509.Certificate{
+			SerialNumber: big.NewInt(10),
+			Subject:      pkix.Name{CommonName: "ginkgo.example.net"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			OCSPServer:   []string{srv.URL},
+		}, ca, caKey)
+	})
+
+	checker := func(strict bool) *RevocationChecker {
+		r := NewRevocationChecker("", time.Hour, true, strict)
+		r.SetLogger(log)
+		return r
+	}
+
+	Describe("OCSP", func() {
+		for _, strict := range []bool{false, true} {
+			Context(fmt.Sprintf("with strict mode %t", strict), func() {
+				It("Should accept good certificates", func() {
+					Expect(checker(strict).Check(cert, ca)).To(Succeed())
+				})
+
+				It("Should reject revoked certificates", func() {
+					status.Store(int64(ocsp.Revoked))
+					Expect(checker(strict).Check(cert, ca)).To(MatchError(ContainSubstring("certificate ginkgo.example.net with serial 10 was revoked at")))
+				})
+
+				It("Should handle unknown certificates", func() {
+					status.Store(int64(ocsp.Unknown))
+					err := checker(strict).Check(cert, ca)
+					if strict {
+						Expect(err).To(MatchError(ContainSubstring("does not know certificate ginkgo.example.net")))
+					} else {
+						Expect(err).ToNot(HaveOccurred())
+					}
+				})
+
+				It("Should handle unreachable responders", func() {
+					srv.Close()
+					err := checker(strict).Check(cert, ca)
+					if strict {
+						Expect(err).To(MatchError(ContainSubstring("could not check OCSP status of ginkgo.example.net")))
+					} else {
+						Expect(err).ToNot(HaveOccurred())
+					}
+				})
+			})
+		}
+
+		It("Should cache responses", func() {
+			r := checker(true)
+			Expect(r.Check(cert, ca)).To(Succeed())
+			Expect(r.Check(cert, ca)).To(Succeed())
+			Expect(requests.Load()).To(Equal(int64(1)))
+		})
+
+		It("Should share one query between concurrent checks", func() {
+			delay.Store(int64(200 * time.Millisecond))
+			r := checker(true)
+
+			wg := sync.WaitGroup{}
+			for range 20 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					Expect(r.Check(cert, ca)).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			Expect(requests.Load()).To(Equal(int64(1)))
+		})
+	})
+
+	Describe("CRL", func() {
+		It("Should wait for the CRL being loaded in the background", func() {
+			der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+				Number:                    big.NewInt(1),
+				ThisUpdate:                time.Now().Add(-time.Minute),
+				NextUpdate:                time.Now().Add(time.Hour),
+				RevokedCertificateEntries: []x509.RevocationListEntry{{SerialNumber: big.NewInt(10), RevocationTime: time.Now()}},
+			}, ca, caKey)
+			Expect(err).ToNot(HaveOccurred())
+
+			release := make(chan struct{})
+			crlSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+				w.Write(der)
+			}))
+			DeferCleanup(crlSrv.Close)
+
+			r := NewRevocationChecker(crlSrv.URL, time.Hour, false, false)
+			r.SetLogger(log)
+
+			checked := make(chan error, 1)
+			go func() { checked <- r.Check(cert, ca) }()
+			Consistently(checked, 200*time.Millisecond).ShouldNot(Receive())
+
+			close(release)
+			Eventually(checked).Should(Receive(MatchError(ContainSubstring("was revoked"))))
+
+			info, err := r.CRL()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Revoked).To(HaveKey("10"))
+		})
+
+		It("Should reject certificates when the CRL could not be loaded", func() {
+			r := NewRevocationChecker("/nonexisting/crl.pem", time.Hour, false, false)
+			r.SetLogger(log)
+			Expect(r.Check(cert, ca)).To(MatchError(ContainSubstring("could not load CRL /nonexisting/crl.pem")))
+		})
+	})
+})
@@ -0,0 +1,48 @@
+// Copyright (c) 2025, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tlssetup
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var revocationChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "choria_security_revocation_checks",
+	Help: "Number of certificate revocation checks performed by method and result",
+}, []string{"method", "result"})
+
+var crlLoadErrors = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "choria_security_crl_load_errors",
+	Help: "Number of times loading the Certificate Revocation List failed",
+})
+
+var crlEntries = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "choria_security_crl_entries",
+	Help: "Number of revoked certificates in the loaded Certificate Revocation List",
+})
+
+var crlNextUpdate = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "choria_security_crl_next_update",
+	Help: "Unix time when the loaded Certificate Revocation List should be updated",
+})
+
+var certificateReloads = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "choria_security_certificate_reloads",
+	Help: "Number of times a renewed certificate was loaded without a restart",
+})
+
+var certificateReloadErrors = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "choria_security_certificate_reload_errors",
+	Help: "Number of times loading a changed certificate and key failed",
+})
+
+func init() {
+	prometheus.MustRegister(revocationChecks)
+	prometheus.MustRegister(crlLoadErrors)
+	prometheus.MustRegister(crlEntries)
+	prometheus.MustRegister(crlNextUpdate)
+	prometheus.MustRegister(certificateReloads)
+	prometheus.MustRegister(certificateReloadErrors)
+}